@@ -10,6 +10,10 @@ import (
 	"syscall"
 	"time"
 
+	_ "github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/arquivodda"
+	_ "github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/bradesco"
+	_ "github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/itau"
+	_ "github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/openfinance"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/database"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/handlers"
@@ -52,7 +56,10 @@ func main() {
 	// Inicializa serviços
 	nfeService := services.NewNFEService(cfg, db, logger)
 	bankService := services.NewBankService(cfg, db, logger)
-	pdfService := services.NewPDFService(logger)
+	pdfService := services.NewPDFService(cfg, logger)
+	openFinanceService := services.NewOpenFinanceService(cfg, db, logger)
+	pagamentoService := services.NewPagamentoService(cfg, db, logger)
+	conciliacaoService := services.NewConciliacaoService(cfg, db, logger)
 
 	// Configura router
 	router := gin.New()
@@ -68,15 +75,79 @@ func main() {
 		{
 			nfeGroup.POST("/consultar", handlers.ConsultarNFe(nfeService))
 			nfeGroup.GET("/:chave/xml", handlers.BaixarXMLNFe(nfeService))
-			nfeGroup.GET("/:chave/pdf", handlers.GerarPDFNFe(nfeService, pdfService))
+			nfeGroup.GET("/:chave/pdf", handlers.RenderizarNFe(nfeService, pdfService, services.FormatoPDF))
+			nfeGroup.GET("/:chave/html", handlers.RenderizarNFe(nfeService, pdfService, services.FormatoHTML))
+			nfeGroup.GET("/:chave/preview.png", handlers.PreviewNFe(nfeService, pdfService, services.FormatoPNG))
+			nfeGroup.GET("/:chave/preview.jpg", handlers.PreviewNFe(nfeService, pdfService, services.FormatoJPEG))
 			nfeGroup.GET("/:chave/boletos", handlers.ConsultarBoletosNFe(nfeService, bankService))
+			nfeGroup.POST("/:chave/boletos/vincular", handlers.VincularDuplicatasNFe(nfeService, bankService))
+			nfeGroup.POST("/:chave/boletos/emitir", handlers.EmitirBoletosNFe(nfeService, bankService))
 		}
 
 		// Rotas de Boletos
 		boletosGroup := api.Group("/boletos")
 		{
 			boletosGroup.GET("/:codigo", handlers.ConsultarBoleto(bankService))
+			boletosGroup.GET("/vencimentos", handlers.RelatorioVencimentosBoletos(bankService))
+			boletosGroup.GET("/:codigo/decodificar", handlers.DecodificarBoleto())
+			boletosGroup.GET("/:codigo/valor-atualizado", handlers.ValorAtualizadoBoleto(bankService))
+			boletosGroup.GET("/:codigo/pdf", handlers.BoletoPDF(bankService, pdfService))
+			boletosGroup.POST("/:codigo/baixa", handlers.BaixarBoletoEmitido(bankService))
+			boletosGroup.PUT("/:codigo/vencimento", handlers.AlterarVencimentoBoleto(bankService))
+			boletosGroup.GET("/:codigo/pix", handlers.ConsultarPixBoleto(bankService))
+			boletosGroup.PUT("/:codigo/pix", handlers.AssociarPixBoleto(bankService))
+			boletosGroup.GET("/:codigo/pix/qrcode.png", handlers.QRCodePixBoleto(bankService))
+			boletosGroup.POST("/:codigo/pix/sincronizar", handlers.SincronizarPixBoleto(bankService))
 			boletosGroup.POST("/consultar", handlers.ConsultarMultiplosBoletos(bankService))
+			boletosGroup.POST("/retorno", handlers.ImportarRetornoBoletos(bankService))
+			boletosGroup.POST("/dda", handlers.ImportarDDABoletos(bankService))
+			boletosGroup.POST("/dda/sincronizar", handlers.SincronizarDDABoletos(bankService))
+		}
+
+		// Rotas da revisão de vínculos entre duplicatas e boletos
+		vinculosGroup := api.Group("/duplicatas/vinculos")
+		{
+			vinculosGroup.GET("", handlers.ListarVinculosDuplicatas(bankService))
+			vinculosGroup.POST("/:id/confirmar", handlers.ConfirmarVinculoDuplicata(bankService))
+			vinculosGroup.POST("/:id/rejeitar", handlers.RejeitarVinculoDuplicata(bankService))
+		}
+
+		// Rotas de pagamento de boletos de fornecedores
+		pagamentosGroup := api.Group("/pagamentos")
+		{
+			pagamentosGroup.POST("/remessa", handlers.GerarRemessaPagamento(pagamentoService))
+			pagamentosGroup.POST("/retorno", handlers.ImportarRetornoPagamento(pagamentoService))
+		}
+
+		// Rotas das notificações de pagamento enviadas pelos bancos
+		webhooksGroup := api.Group("/webhooks")
+		{
+			webhooksGroup.POST("/banco/:banco", handlers.ReceberWebhookBanco(bankService))
+			webhooksGroup.GET("/notificacoes", handlers.ListarNotificacoesWebhook(bankService))
+			webhooksGroup.POST("/notificacoes/:id/reprocessar", handlers.ReprocessarNotificacaoWebhook(bankService))
+		}
+
+		// Cobranças Pix dos boletos híbridos, consultadas na API Pix do PSP
+		api.GET("/pix/cobrancas/:txid", handlers.ConsultarCobrancaPix(bankService))
+
+		// Calendário bancário usado na prorrogação dos vencimentos
+		api.GET("/calendario/feriados", handlers.ListarFeriados(bankService))
+
+		// Rotas de conciliação dos pagamentos pelo extrato bancário
+		conciliacaoGroup := api.Group("/conciliacao")
+		{
+			conciliacaoGroup.GET("", handlers.ListarConciliacaoPendente(conciliacaoService))
+			conciliacaoGroup.POST("/extrato", handlers.ImportarExtratoConciliacao(conciliacaoService))
+		}
+
+		// Rotas de consentimentos do Open Finance
+		consentimentosGroup := api.Group("/openfinance/consentimentos")
+		{
+			consentimentosGroup.POST("", handlers.CriarConsentimento(openFinanceService))
+			consentimentosGroup.GET("", handlers.ListarConsentimentos(openFinanceService))
+			consentimentosGroup.GET("/:id", handlers.ObterConsentimento(openFinanceService))
+			consentimentosGroup.POST("/:id/autorizacao", handlers.AutorizarConsentimento(openFinanceService))
+			consentimentosGroup.DELETE("/:id", handlers.RevogarConsentimento(openFinanceService))
 		}
 
 		// Rotas de Certificados
@@ -114,9 +185,14 @@ func main() {
 		}
 	}()
 
+	// Inicia a sincronização agendada do DDA
+	ctxTarefas, cancelTarefas := context.WithCancel(context.Background())
+	go bankService.IniciarSincronizacaoDDA(ctxTarefas)
+
 	// Aguarda sinal de interrupção
 	<-quit
 	logger.Info("Desligando servidor...")
+	cancelTarefas()
 
 	// Contexto com timeout para shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -0,0 +1,171 @@
+package bancos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrLinkForaDaAPI indica um link de paginação que aponta para fora da URL base da
+// API; o token do banco não é enviado a outros endereços
+var ErrLinkForaDaAPI = errors.New("link fora da URL base da API")
+
+// ClienteAPI executa requisições JSON autenticadas por token OAuth2 na API de um banco
+type ClienteAPI struct {
+	base      string
+	cliente   *http.Client
+	tokens    *GerenciadorToken
+	tokenFixo string
+	// Cabecalhos adiciona os cabeçalhos exigidos pelo banco em cada requisição
+	Cabecalhos func(req *http.Request)
+}
+
+// NovoClienteAPI cria o cliente da API com a URL base, o cliente HTTP (com mTLS) e o
+// gerenciador de token
+func NovoClienteAPI(base string, cliente *http.Client, tokens *GerenciadorToken) *ClienteAPI {
+	return &ClienteAPI{
+		base:    strings.TrimRight(base, "/"),
+		cliente: cliente,
+		tokens:  tokens,
+	}
+}
+
+// ComToken retorna uma cópia do cliente que usa o token informado em vez do
+// gerenciador, como os tokens de acesso vinculados a um consentimento
+func (c *ClienteAPI) ComToken(token string) *ClienteAPI {
+	copia := *c
+	copia.tokens = nil
+	copia.tokenFixo = token
+	return &copia
+}
+
+// Executar envia a requisição e decodifica a resposta JSON em destino. Um 401 invalida
+// o token e a requisição é repetida uma vez; as demais respostas de erro, inclusive o
+// 404, resultam em *ErroAPI, que cada provedor traduz para o erro do seu domínio
+func (c *ClienteAPI) Executar(ctx context.Context, metodo, caminho string, query url.Values, corpo, destino interface{}) error {
+	var conteudo []byte
+	if corpo != nil {
+		var err error
+		if conteudo, err = json.Marshal(corpo); err != nil {
+			return fmt.Errorf("erro ao serializar requisição: %w", err)
+		}
+	}
+
+	for tentativa := 0; ; tentativa++ {
+		status, resposta, err := c.enviar(ctx, metodo, caminho, query, conteudo)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case status == http.StatusUnauthorized && tentativa == 0 && c.tokens != nil:
+			c.tokens.Invalidar()
+			continue
+		case status < 200 || status > 299:
+			return &ErroAPI{Status: status, Corpo: string(resposta)}
+		}
+
+		if destino == nil || len(bytes.TrimSpace(resposta)) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resposta, destino); err != nil {
+			return fmt.Errorf("resposta inválida da API: %w", err)
+		}
+		return nil
+	}
+}
+
+// enviar executa uma tentativa da requisição
+func (c *ClienteAPI) enviar(ctx context.Context, metodo, caminho string, query url.Values, conteudo []byte) (int, []byte, error) {
+	endereco, err := c.endereco(caminho)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(query) > 0 {
+		endereco += "?" + query.Encode()
+	}
+
+	var corpo io.Reader
+	if conteudo != nil {
+		corpo = bytes.NewReader(conteudo)
+	}
+	req, err := http.NewRequestWithContext(ctx, metodo, endereco, corpo)
+	if err != nil {
+		return 0, nil, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if conteudo != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return 0, nil, fmt.Errorf("erro ao obter token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.tokenFixo != "" {
+		req.Header.Set("Authorization", "Bearer "+c.tokenFixo)
+	}
+	if c.Cabecalhos != nil {
+		c.Cabecalhos(req)
+	}
+
+	resp, err := c.cliente.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("erro na requisição à API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	resposta, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("erro ao ler resposta da API: %w", err)
+	}
+	return resp.StatusCode, resposta, nil
+}
+
+// endereco monta a URL da requisição. Os links de paginação já vêm como URL
+// absoluta e só são seguidos no mesmo esquema e host da URL base
+func (c *ClienteAPI) endereco(caminho string) (string, error) {
+	if !strings.HasPrefix(caminho, "https://") && !strings.HasPrefix(caminho, "http://") {
+		return c.base + caminho, nil
+	}
+	base, err := url.Parse(c.base)
+	if err != nil {
+		return "", fmt.Errorf("URL base da API inválida: %w", err)
+	}
+	link, err := base.Parse(caminho)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrLinkForaDaAPI, caminho)
+	}
+	if link.Scheme != base.Scheme || !strings.EqualFold(link.Host, base.Host) || link.User != nil {
+		return "", fmt.Errorf("%w: %s", ErrLinkForaDaAPI, caminho)
+	}
+	return link.String(), nil
+}
+
+// ErroAPI é uma resposta de erro da API bancária
+type ErroAPI struct {
+	Status int
+	Corpo  string
+}
+
+// NaoEncontrado indica se o erro é uma resposta 404 da API
+func NaoEncontrado(err error) bool {
+	var erroAPI *ErroAPI
+	return errors.As(err, &erroAPI) && erroAPI.Status == http.StatusNotFound
+}
+
+// Error implementa a interface error
+func (e *ErroAPI) Error() string {
+	corpo := e.Corpo
+	if len(corpo) > 512 {
+		corpo = corpo[:512] + "..."
+	}
+	return fmt.Sprintf("API respondeu %d: %s", e.Status, corpo)
+}
@@ -0,0 +1,63 @@
+package bancos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutarLinkPaginacao(t *testing.T) {
+	var requisicoes int32
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requisicoes, 1)
+		assert.Equal(t, "Bearer token-fixo", r.Header.Get("Authorization"))
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer servidor.Close()
+	cliente := NovoClienteAPI(servidor.URL+"/v1", servidor.Client(), nil).ComToken("token-fixo")
+	ctx := context.Background()
+
+	// O link da próxima página no mesmo host é seguido com o token
+	var resposta struct {
+		OK bool `json:"ok"`
+	}
+	assert.NoError(t, cliente.Executar(ctx, http.MethodGet, servidor.URL+"/v1/itens?page=2", nil, nil, &resposta))
+	assert.True(t, resposta.OK)
+
+	// Links para outro host ou esquema não recebem o token
+	destino, _ := url.Parse(servidor.URL)
+	for _, link := range []string{
+		"https://atacante.example/v1/itens?page=2",
+		"https://" + destino.Host + "/v1/itens?page=2",
+		"http://usuario@" + destino.Host + "/v1/itens?page=2",
+	} {
+		err := cliente.Executar(ctx, http.MethodGet, link, nil, nil, nil)
+		assert.ErrorIs(t, err, ErrLinkForaDaAPI, link)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requisicoes))
+}
+
+func TestExecutarNaoEncontrado(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"mensagem":"recurso não encontrado"}`))
+	}))
+	defer servidor.Close()
+	cliente := NovoClienteAPI(servidor.URL, servidor.Client(), nil)
+
+	// O 404 chega ao provedor como resposta da API, sem supor que o recurso era um boleto
+	err := cliente.Executar(context.Background(), http.MethodGet, "/pix/E123", nil, nil, nil)
+	var erroAPI *ErroAPI
+	if assert.ErrorAs(t, err, &erroAPI) {
+		assert.Equal(t, http.StatusNotFound, erroAPI.Status)
+	}
+	assert.NotErrorIs(t, err, ErrBoletoNaoEncontrado)
+	assert.True(t, NaoEncontrado(err))
+	assert.False(t, NaoEncontrado(&ErroAPI{Status: http.StatusBadRequest}))
+}
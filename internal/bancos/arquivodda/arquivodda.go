@@ -0,0 +1,113 @@
+// Package arquivodda implementa o provedor de DDA que lê os arquivos CNAB 240 de
+// títulos DDA (segmento G) entregues pelos bancos em um diretório, por VAN ou SFTP
+package arquivodda
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	bancos.RegistrarFabricaDDA("arquivodda", func(cfg *config.Config, deps bancos.Dependencias) bancos.ProvedorDDA {
+		if cfg.DDA.Diretorio == "" {
+			return nil
+		}
+		return New(cfg.DDA.Diretorio, deps.Logger)
+	})
+}
+
+// Provedor lê os arquivos de títulos DDA do diretório
+type Provedor struct {
+	diretorio string
+	logger    *logrus.Logger
+}
+
+// New cria o provedor para o diretório que recebe os arquivos dos bancos
+func New(diretorio string, logger *logrus.Logger) *Provedor {
+	return &Provedor{diretorio: diretorio, logger: logger}
+}
+
+// Nome identifica o provedor
+func (p *Provedor) Nome() string { return "arquivo-dda" }
+
+// Timeout limita a leitura do diretório
+func (p *Provedor) Timeout() time.Duration { return bancos.TimeoutPadrao }
+
+// ListarDDA lê os arquivos modificados a partir de consulta.Desde cujo pagador é o
+// consultado. Arquivos fora do layout são registrados em log e ignorados
+func (p *Provedor) ListarDDA(ctx context.Context, consulta bancos.ConsultaDDA) ([]models.Boleto, error) {
+	entradas, err := os.ReadDir(p.diretorio)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler diretório do DDA: %w", err)
+	}
+
+	var boletos []models.Boleto
+	for _, entrada := range entradas {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !entrada.Type().IsRegular() {
+			continue
+		}
+		info, err := entrada.Info()
+		if err != nil || info.ModTime().Before(consulta.Desde) {
+			continue
+		}
+
+		caminho := filepath.Join(p.diretorio, entrada.Name())
+		arquivo, err := lerArquivo(caminho)
+		if err != nil {
+			p.logger.WithError(err).WithField("arquivo", caminho).Warn("Ignorando arquivo DDA inválido")
+			continue
+		}
+		if !mesmoDocumento(arquivo.Pagador, consulta.Pagador) {
+			continue
+		}
+		boletos = append(boletos, Boletos(arquivo)...)
+	}
+	return boletos, nil
+}
+
+// Boletos converte os títulos do arquivo DDA em boletos
+func Boletos(arquivo *cnab.ArquivoDDA) []models.Boleto {
+	boletos := make([]models.Boleto, len(arquivo.Titulos))
+	for i, titulo := range arquivo.Titulos {
+		boletos[i] = models.Boleto{
+			Numero:                titulo.NumeroDocumento,
+			CodigoBarras:          titulo.CodigoBarras,
+			DocumentoBeneficiario: titulo.DocumentoBeneficiario,
+			NomeBeneficiario:      titulo.NomeBeneficiario,
+			Valor:                 titulo.Valor,
+		}
+		if titulo.Vencimento != nil {
+			boletos[i].Vencimento = *titulo.Vencimento
+		}
+	}
+	return boletos
+}
+
+// lerArquivo abre e interpreta um arquivo de títulos DDA
+func lerArquivo(caminho string) (*cnab.ArquivoDDA, error) {
+	f, err := os.Open(caminho)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return cnab.ParseDDA(f)
+}
+
+// mesmoDocumento compara CNPJs/CPFs sem os zeros à esquerda do campo do header
+func mesmoDocumento(a, b string) bool {
+	return strings.TrimLeft(a, "0") == strings.TrimLeft(b, "0")
+}
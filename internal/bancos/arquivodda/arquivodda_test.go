@@ -0,0 +1,63 @@
+package arquivodda
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+)
+
+// linha monta uma linha CNAB 240 com os campos nas posições do manual
+func linha(campos map[int]string) string {
+	texto := []byte(strings.Repeat(" ", 240))
+	for posicao, valor := range campos {
+		copy(texto[posicao-1:], valor)
+	}
+	return string(texto)
+}
+
+func arquivoDDA(pagador, codigoBarras string) string {
+	return strings.Join([]string{
+		linha(map[int]string{1: "34100000", 18: "2", 19: pagador, 143: "2"}),
+		linha(map[int]string{1: "34100013", 14: "G", 18: codigoBarras, 62: "2", 63: "098765432000198",
+			78: "INDUSTRIA SAO JOAO SA", 108: "30112023", 116: "000000000025075", 148: "NF123-1"}),
+		linha(map[int]string{1: "34199999"}),
+	}, "\r\n")
+}
+
+func TestListarDDA(t *testing.T) {
+	diretorio := t.TempDir()
+	escrever := func(nome, conteudo string, modificado time.Time) {
+		caminho := filepath.Join(diretorio, nome)
+		assert.NoError(t, os.WriteFile(caminho, []byte(conteudo), 0o644))
+		assert.NoError(t, os.Chtimes(caminho, modificado, modificado))
+	}
+	agora := time.Now()
+	escrever("DDA341.RET", arquivoDDA("12345678000195", "34195955000000250751091234567800057123457000"), agora)
+	// Outro estabelecimento, arquivo antigo e arquivo fora do layout
+	escrever("DDA341_FILIAL.RET", arquivoDDA("12345678000276", "23796907800000099903381090000001234500123450"), agora)
+	escrever("DDA341_ANTIGO.RET", arquivoDDA("12345678000195", "23796907800000099903381090000001234500123450"), agora.Add(-72*time.Hour))
+	escrever("LEIAME.txt", "arquivo sem header CNAB", agora)
+	assert.NoError(t, os.Mkdir(filepath.Join(diretorio, "processados"), 0o755))
+
+	provedor := New(diretorio, logrus.New())
+	boletos, err := provedor.ListarDDA(context.Background(), bancos.ConsultaDDA{Pagador: "12345678000195", Desde: agora.Add(-24 * time.Hour)})
+	assert.NoError(t, err)
+	if assert.Len(t, boletos, 1) {
+		assert.Equal(t, "34195955000000250751091234567800057123457000", boletos[0].CodigoBarras)
+		assert.Equal(t, "98765432000198", boletos[0].DocumentoBeneficiario)
+		assert.Equal(t, "NF123-1", boletos[0].Numero)
+		assert.Equal(t, 250.75, boletos[0].Valor)
+		assert.Equal(t, "2023-11-30", boletos[0].Vencimento.Format(time.DateOnly))
+	}
+
+	_, err = New(filepath.Join(diretorio, "inexistente"), logrus.New()).ListarDDA(context.Background(), bancos.ConsultaDDA{Pagador: "12345678000195"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,282 @@
+// Package bancos define os provedores de consulta de boletos nas APIs bancárias e o
+// registro que os organiza pelo código COMPE do banco. Cada banco é implementado em
+// um subpacote que registra sua fábrica em init, como os drivers de database/sql.
+package bancos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// TimeoutPadrao é usado quando o provedor não define um timeout
+const TimeoutPadrao = 30 * time.Second
+
+// ErrBoletoNaoEncontrado indica que nenhum provedor localizou o boleto
+var ErrBoletoNaoEncontrado = errors.New("boleto não encontrado nas APIs bancárias")
+
+// Consulta reúne o que se sabe do boleto procurado
+type Consulta struct {
+	// Codigo é o código informado: número do boleto, nosso número ou código de barras
+	Codigo string
+	// CodigoBarras são os 44 dígitos, quando o código informado é um código válido
+	CodigoBarras string
+	// Banco é o código COMPE decodificado do código de barras, quando conhecido
+	Banco string
+}
+
+// Provedor consulta boletos na API de um banco ou agregador
+type Provedor interface {
+	// Nome identifica o provedor nos logs e erros
+	Nome() string
+	// Bancos lista os códigos COMPE atendidos; vazio indica um agregador que atende
+	// qualquer banco (por exemplo, Open Finance)
+	Bancos() []string
+	// Timeout limita a duração de cada consulta ao provedor
+	Timeout() time.Duration
+	// ConsultarBoleto retorna ErrBoletoNaoEncontrado quando o banco não conhece o boleto
+	ConsultarBoleto(ctx context.Context, consulta Consulta) (*models.Boleto, error)
+}
+
+// ConsultaDDA identifica os títulos apresentados no DDA (Débito Direto Autorizado)
+type ConsultaDDA struct {
+	// Pagador é o CNPJ/CPF, sem formatação, contra o qual os títulos foram registrados
+	Pagador string
+	// Desde limita a consulta aos títulos registrados ou alterados a partir da data
+	Desde time.Time
+}
+
+// ProvedorDDA lista os boletos registrados no DDA contra um pagador. É implementado
+// pelos provedores de consulta que oferecem o DDA e pelos que leem os arquivos de
+// títulos DDA entregues pelos bancos, registrados com RegistrarFabricaDDA
+type ProvedorDDA interface {
+	Nome() string
+	Timeout() time.Duration
+	// ListarDDA retorna os boletos com código de barras e beneficiário preenchidos
+	ListarDDA(ctx context.Context, consulta ConsultaDDA) ([]models.Boleto, error)
+}
+
+// Dependencias são os recursos compartilhados entregues às fábricas de provedores
+type Dependencias struct {
+	Logger *logrus.Logger
+	// DB é usado pelos provedores que mantêm estado, como os consentimentos do Open Finance
+	DB *gorm.DB
+}
+
+// Fabrica cria o provedor a partir da configuração; retorna nil quando o provedor
+// não está configurado
+type Fabrica func(cfg *config.Config, deps Dependencias) Provedor
+
+// FabricaDDA cria o provedor de DDA a partir da configuração; retorna nil quando o
+// provedor não está configurado
+type FabricaDDA func(cfg *config.Config, deps Dependencias) ProvedorDDA
+
+var (
+	fabricasMu  sync.RWMutex
+	fabricas    = make(map[string]Fabrica)
+	fabricasDDA = make(map[string]FabricaDDA)
+)
+
+// RegistrarFabrica torna um provedor disponível para NovoRegistro. Deve ser chamada
+// no init do subpacote do banco
+func RegistrarFabrica(nome string, fabrica Fabrica) {
+	fabricasMu.Lock()
+	defer fabricasMu.Unlock()
+
+	if fabrica == nil {
+		panic("bancos: fábrica nula para " + nome)
+	}
+	if _, existe := fabricas[nome]; existe {
+		panic("bancos: fábrica registrada duas vezes para " + nome)
+	}
+	fabricas[nome] = fabrica
+}
+
+// RegistrarFabricaDDA torna disponível para NovoRegistro um provedor que oferece
+// apenas o DDA. Deve ser chamada no init do subpacote do provedor
+func RegistrarFabricaDDA(nome string, fabrica FabricaDDA) {
+	fabricasMu.Lock()
+	defer fabricasMu.Unlock()
+
+	if fabrica == nil {
+		panic("bancos: fábrica de DDA nula para " + nome)
+	}
+	if _, existe := fabricasDDA[nome]; existe {
+		panic("bancos: fábrica de DDA registrada duas vezes para " + nome)
+	}
+	fabricasDDA[nome] = fabrica
+}
+
+// Registro organiza os provedores pelo código COMPE
+type Registro struct {
+	mu          sync.RWMutex
+	porBanco    map[string][]Provedor
+	agregadores []Provedor
+	dda         []ProvedorDDA
+	logger      *logrus.Logger
+}
+
+// NovoRegistro cria um registro com os provedores configurados entre as fábricas registradas
+func NovoRegistro(cfg *config.Config, deps Dependencias) *Registro {
+	r := &Registro{
+		porBanco: make(map[string][]Provedor),
+		logger:   deps.Logger,
+	}
+
+	fabricasMu.RLock()
+	nomes := make([]string, 0, len(fabricas))
+	for nome := range fabricas {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	for _, nome := range nomes {
+		if provedor := fabricas[nome](cfg, deps); provedor != nil {
+			r.Registrar(provedor)
+		}
+	}
+	nomes = nomes[:0]
+	for nome := range fabricasDDA {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	for _, nome := range nomes {
+		if provedor := fabricasDDA[nome](cfg, deps); provedor != nil {
+			r.RegistrarDDA(provedor)
+		}
+	}
+	fabricasMu.RUnlock()
+
+	return r
+}
+
+// Registrar adiciona um provedor ao registro
+func (r *Registro) Registrar(provedor Provedor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bancos := provedor.Bancos()
+	if len(bancos) == 0 {
+		r.agregadores = append(r.agregadores, provedor)
+		return
+	}
+	for _, banco := range bancos {
+		r.porBanco[banco] = append(r.porBanco[banco], provedor)
+	}
+}
+
+// Provedores retorna os provedores a consultar para o banco: os específicos do banco
+// seguidos dos agregadores. Sem banco conhecido, retorna todos
+func (r *Registro) Provedores(banco string) []Provedor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var provedores []Provedor
+	if banco != "" {
+		provedores = append(provedores, r.porBanco[banco]...)
+	} else {
+		vistos := make(map[Provedor]bool)
+		bancos := make([]string, 0, len(r.porBanco))
+		for codigo := range r.porBanco {
+			bancos = append(bancos, codigo)
+		}
+		sort.Strings(bancos)
+		for _, codigo := range bancos {
+			for _, provedor := range r.porBanco[codigo] {
+				if !vistos[provedor] {
+					vistos[provedor] = true
+					provedores = append(provedores, provedor)
+				}
+			}
+		}
+	}
+	return append(provedores, r.agregadores...)
+}
+
+// RegistrarDDA adiciona um provedor que oferece apenas o DDA
+func (r *Registro) RegistrarDDA(provedor ProvedorDDA) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dda = append(r.dda, provedor)
+}
+
+// ProvedoresDDA retorna os provedores de consulta que oferecem o DDA seguidos dos
+// registrados com RegistrarDDA
+func (r *Registro) ProvedoresDDA() []ProvedorDDA {
+	var provedores []ProvedorDDA
+	for _, provedor := range r.Provedores("") {
+		if dda, ok := provedor.(ProvedorDDA); ok {
+			provedores = append(provedores, dda)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append(provedores, r.dda...)
+}
+
+// resultadoConsulta é a resposta de um provedor na consulta concorrente
+type resultadoConsulta struct {
+	provedor Provedor
+	boleto   *models.Boleto
+	err      error
+}
+
+// Consultar consulta concorrentemente os provedores do banco, cada um limitado pelo
+// seu timeout, e retorna o primeiro boleto encontrado. Quando nenhum provedor o
+// localiza, retorna ErrBoletoNaoEncontrado; falhas de comunicação são retornadas
+// para não serem confundidas com um boleto inexistente
+func (r *Registro) Consultar(ctx context.Context, consulta Consulta) (*models.Boleto, error) {
+	provedores := r.Provedores(consulta.Banco)
+	if len(provedores) == 0 {
+		return nil, ErrBoletoNaoEncontrado
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resultados := make(chan resultadoConsulta, len(provedores))
+	for _, provedor := range provedores {
+		go func(provedor Provedor) {
+			timeout := provedor.Timeout()
+			if timeout <= 0 {
+				timeout = TimeoutPadrao
+			}
+			ctxProvedor, cancelProvedor := context.WithTimeout(ctx, timeout)
+			defer cancelProvedor()
+
+			boleto, err := provedor.ConsultarBoleto(ctxProvedor, consulta)
+			if err == nil && boleto == nil {
+				err = ErrBoletoNaoEncontrado
+			}
+			resultados <- resultadoConsulta{provedor: provedor, boleto: boleto, err: err}
+		}(provedor)
+	}
+
+	var falhas []error
+	for range provedores {
+		resultado := <-resultados
+		if resultado.err == nil {
+			return resultado.boleto, nil
+		}
+		if errors.Is(resultado.err, ErrBoletoNaoEncontrado) {
+			continue
+		}
+		r.logger.WithError(resultado.err).WithField("provedor", resultado.provedor.Nome()).Warn("Erro ao consultar provedor bancário")
+		falhas = append(falhas, fmt.Errorf("%s: %w", resultado.provedor.Nome(), resultado.err))
+	}
+
+	if len(falhas) > 0 {
+		return nil, errors.Join(falhas...)
+	}
+	return nil, ErrBoletoNaoEncontrado
+}
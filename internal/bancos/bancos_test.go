@@ -0,0 +1,99 @@
+package bancos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// provedorTeste responde após um atraso com o boleto ou o erro configurado
+type provedorTeste struct {
+	nome    string
+	bancos  []string
+	atraso  time.Duration
+	timeout time.Duration
+	boleto  *models.Boleto
+	err     error
+}
+
+func (p *provedorTeste) Nome() string           { return p.nome }
+func (p *provedorTeste) Bancos() []string       { return p.bancos }
+func (p *provedorTeste) Timeout() time.Duration { return p.timeout }
+
+func (p *provedorTeste) ConsultarBoleto(ctx context.Context, consulta Consulta) (*models.Boleto, error) {
+	select {
+	case <-time.After(p.atraso):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.boleto, nil
+}
+
+func TestRegistroProvedores(t *testing.T) {
+	registro := &Registro{porBanco: make(map[string][]Provedor), logger: logrus.New()}
+	itau := &provedorTeste{nome: "itau", bancos: []string{"341"}}
+	bradesco := &provedorTeste{nome: "bradesco", bancos: []string{"237"}}
+	agregador := &provedorTeste{nome: "openfinance"}
+	registro.Registrar(itau)
+	registro.Registrar(bradesco)
+	registro.Registrar(agregador)
+
+	assert.Equal(t, []Provedor{itau, agregador}, registro.Provedores("341"))
+	assert.Equal(t, []Provedor{agregador}, registro.Provedores("001"))
+	assert.Equal(t, []Provedor{bradesco, itau, agregador}, registro.Provedores(""))
+}
+
+func TestRegistroConsultar(t *testing.T) {
+	logger := logrus.New()
+	boleto := &models.Boleto{Banco: "341", Numero: "123"}
+
+	t.Run("primeiro provedor que encontra", func(t *testing.T) {
+		registro := &Registro{porBanco: make(map[string][]Provedor), logger: logger}
+		registro.Registrar(&provedorTeste{nome: "lento", bancos: []string{"341"}, atraso: time.Second, err: ErrBoletoNaoEncontrado})
+		registro.Registrar(&provedorTeste{nome: "itau", bancos: []string{"341"}, boleto: boleto})
+
+		inicio := time.Now()
+		encontrado, err := registro.Consultar(context.Background(), Consulta{Banco: "341"})
+		assert.NoError(t, err)
+		assert.Equal(t, boleto, encontrado)
+		assert.Less(t, time.Since(inicio), time.Second)
+	})
+
+	t.Run("timeout por provedor", func(t *testing.T) {
+		registro := &Registro{porBanco: make(map[string][]Provedor), logger: logger}
+		registro.Registrar(&provedorTeste{nome: "itau", bancos: []string{"341"}, atraso: time.Second, timeout: 20 * time.Millisecond, boleto: boleto})
+
+		_, err := registro.Consultar(context.Background(), Consulta{Banco: "341"})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NotErrorIs(t, err, ErrBoletoNaoEncontrado)
+	})
+
+	t.Run("nenhum provedor encontra", func(t *testing.T) {
+		registro := &Registro{porBanco: make(map[string][]Provedor), logger: logger}
+		registro.Registrar(&provedorTeste{nome: "itau", bancos: []string{"341"}, err: ErrBoletoNaoEncontrado})
+
+		_, err := registro.Consultar(context.Background(), Consulta{Banco: "341"})
+		assert.ErrorIs(t, err, ErrBoletoNaoEncontrado)
+
+		// Banco sem provedor não cai em outro banco
+		_, err = registro.Consultar(context.Background(), Consulta{Banco: "237"})
+		assert.ErrorIs(t, err, ErrBoletoNaoEncontrado)
+	})
+
+	t.Run("falha de comunicação", func(t *testing.T) {
+		registro := &Registro{porBanco: make(map[string][]Provedor), logger: logger}
+		registro.Registrar(&provedorTeste{nome: "itau", bancos: []string{"341"}, err: errors.New("conexão recusada")})
+
+		_, err := registro.Consultar(context.Background(), Consulta{Banco: "341"})
+		assert.ErrorContains(t, err, "itau: conexão recusada")
+	})
+}
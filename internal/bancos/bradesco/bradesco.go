@@ -0,0 +1,285 @@
+// Package bradesco implementa o provedor de consulta de boletos do Bradesco pela API
+// de Cobrança, autenticada por asserção JWT bearer
+package bradesco
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// carteiraPadrao é usada nas consultas por nosso número sem carteira informada
+const carteiraPadrao = "09"
+
+// caminhoConsulta é o recurso de consulta de títulos da API de Cobrança
+const caminhoConsulta = "/boleto/cobranca-consulta/v1/consultar"
+
+func init() {
+	bancos.RegistrarFabrica("bradesco", func(cfg *config.Config, deps bancos.Dependencias) bancos.Provedor {
+		if cfg.Bank.Bradesco.URL == "" {
+			return nil
+		}
+		provedor, err := New(cfg.Bank.Bradesco, deps.Logger)
+		if err != nil {
+			deps.Logger.WithError(err).Error("Erro ao configurar provedor do Bradesco")
+			return nil
+		}
+		return provedor
+	})
+}
+
+// Provedor consulta boletos na API de Cobrança do Bradesco
+type Provedor struct {
+	config config.BankAPIConfig
+	api    *bancos.ClienteAPI
+	logger *logrus.Logger
+}
+
+// New cria o provedor do Bradesco. O token é obtido com uma asserção JWT assinada
+// pela chave em JWTKeyPath
+func New(cfg config.BankAPIConfig, logger *logrus.Logger) (*Provedor, error) {
+	if cfg.JWTKeyPath == "" {
+		return nil, errors.New("chave de assinatura JWT do Bradesco não configurada")
+	}
+	assinador, err := bancos.NovoAssinadorJWT(cfg.JWTKeyPath, cfg.ClientID, cfg.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	cliente, err := bancos.NovoClienteHTTP(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := bancos.NovoGerenciadorToken(cfg, cliente, logger).ComAssercaoJWT(assinador.Assercao)
+	return &Provedor{
+		config: cfg,
+		api:    bancos.NovoClienteAPI(cfg.URL, cliente, tokens),
+		logger: logger,
+	}, nil
+}
+
+// Nome identifica o provedor
+func (p *Provedor) Nome() string { return "bradesco" }
+
+// Bancos retorna o código COMPE do Bradesco
+func (p *Provedor) Bancos() []string { return []string{utils.BancoBradesco} }
+
+// Timeout retorna o timeout configurado para a API
+func (p *Provedor) Timeout() time.Duration { return p.config.Timeout }
+
+// ConsultarBoleto consulta a situação do título pelo código de barras ou pelo nosso
+// número, informado como "carteira/nosso número" ou apenas o nosso número (carteira 09)
+func (p *Provedor) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	requisicao, ok := p.requisicaoConsulta(consulta)
+	if !ok {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	p.logger.WithFields(logrus.Fields{
+		"carteira":     requisicao.Produto,
+		"nosso_numero": requisicao.NossoNumero,
+	}).Info("Consultando API do Bradesco")
+
+	var resposta respostaConsulta
+	if err := p.api.Executar(ctx, http.MethodPost, caminhoConsulta, nil, requisicao, &resposta); err != nil {
+		if bancos.NaoEncontrado(err) {
+			return nil, bancos.ErrBoletoNaoEncontrado
+		}
+		return nil, err
+	}
+	if resposta.Titulo == nil {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+
+	return converter(*resposta.Titulo)
+}
+
+// requisicaoConsulta é o corpo da consulta de títulos
+type requisicaoConsulta struct {
+	CPFCNPJ     documento `json:"cpfCnpj"`
+	Produto     int       `json:"produto"`
+	Negociacao  int64     `json:"negociacao"`
+	NossoNumero int64     `json:"nossoNumero"`
+	Sequencia   int       `json:"sequencia"`
+	Status      int       `json:"status"`
+}
+
+// documento é o CNPJ/CPF do beneficiário dividido em raiz, filial e controle
+type documento struct {
+	Raiz     int64 `json:"cpfCnpj"`
+	Filial   int   `json:"filial"`
+	Controle int   `json:"controle"`
+}
+
+// requisicaoConsulta monta a consulta: pelo código de barras, agência, carteira,
+// nosso número e conta vêm do campo livre; pelo nosso número, a negociação vem da
+// configuração
+func (p *Provedor) requisicaoConsulta(consulta bancos.Consulta) (requisicaoConsulta, bool) {
+	var carteira, nossoNumero, negociacao string
+
+	if consulta.CodigoBarras != "" {
+		if consulta.CodigoBarras[0:3] != utils.BancoBradesco {
+			return requisicaoConsulta{}, false
+		}
+		// Campo livre Bradesco: agência (4), carteira (2), nosso número (11), conta (7) e zero
+		campoLivre := consulta.CodigoBarras[19:44]
+		carteira = campoLivre[4:6]
+		nossoNumero = campoLivre[6:17]
+		negociacao = campoLivre[0:4] + campoLivre[17:24]
+	} else {
+		carteira, nossoNumero = carteiraPadrao, strings.TrimSpace(consulta.Codigo)
+		if partes := strings.SplitN(nossoNumero, "/", 2); len(partes) == 2 {
+			carteira, nossoNumero = partes[0], partes[1]
+		}
+		negociacao = p.config.Beneficiario
+	}
+
+	produto, err := strconv.Atoi(carteira)
+	if err != nil || nossoNumero == "" || len(nossoNumero) > 11 {
+		return requisicaoConsulta{}, false
+	}
+	numero, err := strconv.ParseInt(nossoNumero, 10, 64)
+	if err != nil {
+		return requisicaoConsulta{}, false
+	}
+	conta, err := strconv.ParseInt(negociacao, 10, 64)
+	if err != nil {
+		return requisicaoConsulta{}, false
+	}
+
+	return requisicaoConsulta{
+		CPFCNPJ:     documentoBeneficiario(p.config.DocumentoBeneficiario),
+		Produto:     produto,
+		Negociacao:  conta,
+		NossoNumero: numero,
+	}, true
+}
+
+// documentoBeneficiario divide CNPJ (raiz 8, filial 4, controle 2) ou CPF (raiz 9,
+// controle 2) no formato da API
+func documentoBeneficiario(cpfCnpj string) documento {
+	digitos := strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, cpfCnpj)
+
+	var d documento
+	switch len(digitos) {
+	case 14:
+		d.Raiz, _ = strconv.ParseInt(digitos[0:8], 10, 64)
+		d.Filial, _ = strconv.Atoi(digitos[8:12])
+		d.Controle, _ = strconv.Atoi(digitos[12:14])
+	case 11:
+		d.Raiz, _ = strconv.ParseInt(digitos[0:9], 10, 64)
+		d.Controle, _ = strconv.Atoi(digitos[9:11])
+	}
+	return d
+}
+
+// respostaConsulta é a resposta da consulta de títulos; valores vêm em centavos e
+// datas no formato dd.mm.aaaa
+type respostaConsulta struct {
+	Status    int             `json:"status"`
+	Transacao string          `json:"transacao"`
+	Titulo    *tituloBradesco `json:"titulo"`
+}
+
+type tituloBradesco struct {
+	CodStatus   int    `json:"codStatus"`
+	Status      string `json:"status"`
+	NossoNumero string `json:"nossoNumero"`
+	Carteira    string `json:"carteira"`
+	Agencia     int    `json:"agencCred"`
+	Conta       int64  `json:"ctaCred"`
+	DataVencto  string `json:"dataVencto"`
+	ValorTitulo int64  `json:"valorTitulo"`
+	ValMulta    int64  `json:"valMulta"`
+	ValJuros    int64  `json:"valJuros"`
+	ValDesc     int64  `json:"valDesc"`
+	CodBarras   string `json:"codBarras"`
+	LinhaDig    string `json:"linhaDig"`
+	DataPagto   string `json:"dataPagto"`
+	ValPagto    int64  `json:"valPagto"`
+}
+
+// converter mapeia o título do Bradesco para o modelo
+func converter(titulo tituloBradesco) (*models.Boleto, error) {
+	boleto := &models.Boleto{
+		Banco:          utils.BancoBradesco,
+		Numero:         titulo.NossoNumero,
+		NossoNumero:    titulo.NossoNumero,
+		Carteira:       titulo.Carteira,
+		Agencia:        fmt.Sprintf("%04d", titulo.Agencia),
+		Conta:          fmt.Sprintf("%07d", titulo.Conta),
+		CodigoBarras:   titulo.CodBarras,
+		LinhaDigitavel: titulo.LinhaDig,
+		Valor:          centavos(titulo.ValorTitulo),
+		ValorMulta:     centavos(titulo.ValMulta),
+		ValorJuros:     centavos(titulo.ValJuros),
+		ValorDesconto:  centavos(titulo.ValDesc),
+		Status:         situacaoBradesco(titulo),
+	}
+
+	vencimento, err := dataBradesco(titulo.DataVencto)
+	if err != nil {
+		return nil, fmt.Errorf("data de vencimento inválida: %w", err)
+	}
+	if vencimento != nil {
+		boleto.Vencimento = *vencimento
+	}
+
+	if boleto.Status == models.StatusBoletoPago {
+		if boleto.DataPagamento, err = dataBradesco(titulo.DataPagto); err != nil {
+			return nil, fmt.Errorf("data de pagamento inválida: %w", err)
+		}
+		valorPago := centavos(titulo.ValPagto)
+		boleto.ValorPago = &valorPago
+	}
+	return boleto, nil
+}
+
+// situacaoBradesco converte a situação do título: "A VENCER/VENCIDO" (e demais em
+// aberto), pagos/liquidados e baixados
+func situacaoBradesco(titulo tituloBradesco) string {
+	status := strings.ToUpper(titulo.Status)
+	switch {
+	case strings.Contains(status, "PAGO"), strings.Contains(status, "LIQUIDADO"):
+		return models.StatusBoletoPago
+	case strings.Contains(status, "BAIXADO"), strings.Contains(status, "CANCELADO"):
+		return models.StatusBoletoBaixado
+	case status == "" && titulo.ValPagto > 0:
+		return models.StatusBoletoPago
+	default:
+		return models.StatusBoletoAberto
+	}
+}
+
+// dataBradesco interpreta datas dd.mm.aaaa; vazio ou zerado indica ausência
+func dataBradesco(data string) (*time.Time, error) {
+	data = strings.TrimSpace(data)
+	if data == "" || data == "00.00.0000" {
+		return nil, nil
+	}
+	t, err := time.Parse("02.01.2006", data)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// centavos converte um valor em centavos para reais
+func centavos(valor int64) float64 {
+	return float64(valor) / 100
+}
@@ -0,0 +1,251 @@
+package bradesco
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+const codigoBarrasBradesco = "23796907800000099903381090000001234500123450"
+
+// servidorBradesco simula o token JWT bearer e a consulta de títulos, respondendo
+// com a fixture associada ao nosso número consultado. As rotas adicionais simulam
+// os demais recursos da API
+func servidorBradesco(t *testing.T, chave *rsa.PublicKey, fixtures map[int64]string, rotas map[string]http.HandlerFunc) *httptest.Server {
+	mux := http.NewServeMux()
+	for caminho, rota := range rotas {
+		mux.HandleFunc(caminho, rota)
+	}
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+		verificarAssercao(t, chave, r.FormValue("assertion"))
+		w.Write([]byte(`{"access_token":"token-bradesco","token_type":"Bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc(caminhoConsulta, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-bradesco", r.Header.Get("Authorization"))
+
+		var requisicao requisicaoConsulta
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&requisicao))
+		assert.Equal(t, int64(12345678), requisicao.CPFCNPJ.Raiz)
+		assert.Equal(t, 1, requisicao.CPFCNPJ.Filial)
+		assert.Equal(t, 95, requisicao.CPFCNPJ.Controle)
+		assert.Equal(t, int64(33810012345), requisicao.Negociacao)
+
+		fixture, ok := fixtures[requisicao.NossoNumero]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"status":404,"mensagem":"titulo nao encontrado"}`))
+			return
+		}
+		conteudo, err := os.ReadFile(filepath.Join("testdata", fixture))
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(conteudo)
+	})
+	return httptest.NewServer(mux)
+}
+
+// verificarAssercao confere a assinatura RS256 e as declarações da asserção
+func verificarAssercao(t *testing.T, chave *rsa.PublicKey, assercao string) {
+	partes := strings.Split(assercao, ".")
+	if !assert.Len(t, partes, 3) {
+		return
+	}
+	assinatura, err := base64.RawURLEncoding.DecodeString(partes[2])
+	assert.NoError(t, err)
+	resumo := sha256.Sum256([]byte(partes[0] + "." + partes[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(chave, crypto.SHA256, resumo[:], assinatura))
+
+	conteudo, err := base64.RawURLEncoding.DecodeString(partes[1])
+	assert.NoError(t, err)
+	var declaracoes map[string]interface{}
+	assert.NoError(t, json.Unmarshal(conteudo, &declaracoes))
+	assert.Equal(t, "cliente-bradesco", declaracoes["sub"])
+	assert.True(t, strings.HasSuffix(declaracoes["aud"].(string), "/token"))
+}
+
+func novoProvedorTeste(t *testing.T, fixtures map[int64]string) (*Provedor, func()) {
+	return novoProvedorRotasTeste(t, fixtures, nil)
+}
+
+func novoProvedorRotasTeste(t *testing.T, fixtures map[int64]string, rotas map[string]http.HandlerFunc) (*Provedor, func()) {
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	caminhoChave := filepath.Join(t.TempDir(), "jwt.key")
+	assert.NoError(t, os.WriteFile(caminhoChave, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(chave)}), 0o600))
+
+	servidor := servidorBradesco(t, &chave.PublicKey, fixtures, rotas)
+	provedor, err := New(config.BankAPIConfig{
+		URL:                   servidor.URL,
+		TokenURL:              servidor.URL + "/token",
+		ClientID:              "cliente-bradesco",
+		Timeout:               5 * time.Second,
+		JWTKeyPath:            caminhoChave,
+		Beneficiario:          "33810012345",
+		DocumentoBeneficiario: "12.345.678/0001-95",
+	}, logrus.New())
+	assert.NoError(t, err)
+	return provedor, servidor.Close
+}
+
+func TestConsultarBoletoBradesco(t *testing.T) {
+	provedor, fechar := novoProvedorTeste(t, map[int64]string{12345: "titulo_aberto.json"})
+	defer fechar()
+
+	boleto, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{
+		Codigo: codigoBarrasBradesco, CodigoBarras: codigoBarrasBradesco, Banco: "237",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "237", boleto.Banco)
+	assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+	assert.Equal(t, "00000012345", boleto.NossoNumero)
+	assert.Equal(t, "09", boleto.Carteira)
+	assert.Equal(t, "3381", boleto.Agencia)
+	assert.Equal(t, "0012345", boleto.Conta)
+	assert.Equal(t, codigoBarrasBradesco, boleto.CodigoBarras)
+	assert.Equal(t, 99.90, boleto.Valor)
+	assert.Equal(t, 2.00, boleto.ValorMulta)
+	assert.Equal(t, 0.33, boleto.ValorJuros)
+	assert.Equal(t, 5.00, boleto.ValorDesconto)
+	assert.Equal(t, "2022-08-15", boleto.Vencimento.Format(time.DateOnly))
+	assert.Nil(t, boleto.DataPagamento)
+	assert.Nil(t, boleto.ValorPago)
+}
+
+func TestConsultarBoletoBradescoPorNossoNumero(t *testing.T) {
+	provedor, fechar := novoProvedorTeste(t, map[int64]string{12345: "titulo_pago.json", 54321: "titulo_baixado.json"})
+	defer fechar()
+
+	pago, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "09/00000012345"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusBoletoPago, pago.Status)
+	if assert.NotNil(t, pago.DataPagamento) && assert.NotNil(t, pago.ValorPago) {
+		assert.Equal(t, "2022-08-18", pago.DataPagamento.Format(time.DateOnly))
+		assert.Equal(t, 102.23, *pago.ValorPago)
+	}
+
+	baixado, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "19/54321"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusBoletoBaixado, baixado.Status)
+	assert.Equal(t, 1500.00, baixado.Valor)
+
+	_, err = provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "99999"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+
+	_, err = provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "BOL001"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+}
+
+func TestEmitirBoletoBradesco(t *testing.T) {
+	var registro requisicaoEmissao
+	var baixa requisicaoBaixa
+	var alteracao requisicaoAlteracao
+	provedor, fechar := novoProvedorRotasTeste(t, nil, map[string]http.HandlerFunc{
+		caminhoRegistro: func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&registro))
+			w.Write([]byte(`{"status":200,"nuTituloGerado":12345,"codBarras":"` + codigoBarrasBradesco +
+				`","linhaDig":"23793381029000000123145001234504690780000009990"}`))
+		},
+		caminhoBaixa: func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&baixa))
+			w.Write([]byte(`{"status":200}`))
+		},
+		caminhoAlteracao: func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&alteracao))
+			w.Write([]byte(`{"status":200}`))
+		},
+	})
+	defer fechar()
+
+	boleto, err := provedor.EmitirBoleto(context.Background(), bancos.SolicitacaoEmissao{
+		SeuNumero:  "123-001",
+		Valor:      99.90,
+		Emissao:    time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+		Vencimento: time.Date(2022, 8, 15, 0, 0, 0, 0, time.UTC),
+		Pagador: bancos.Pagador{Documento: "12345678909", Nome: "FULANO DE TAL", Logradouro: "Rua das Flores",
+			Numero: "100", Bairro: "Centro", Cidade: "Osasco", UF: "SP", CEP: "06010100"},
+		TipoJuros: models.JurosTaxaDiaria,
+		TaxaJuros: 0.033,
+		TipoMulta: models.EncargoValor,
+		TaxaMulta: 2,
+		Descontos: []models.DescontoBoleto{{DataLimite: time.Date(2022, 8, 10, 0, 0, 0, 0, time.UTC), Tipo: models.EncargoPercentual, Valor: 5}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "00000012345", boleto.NossoNumero)
+	assert.Equal(t, "09", boleto.Carteira)
+	assert.Equal(t, "3381", boleto.Agencia)
+	assert.Equal(t, "0012345", boleto.Conta)
+	assert.Equal(t, codigoBarrasBradesco, boleto.CodigoBarras)
+	assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+
+	assert.Equal(t, int64(33810012345), registro.Negociacao)
+	assert.Equal(t, int64(0), registro.NossoNumero)
+	assert.Equal(t, int64(9990), registro.Valor)
+	assert.Equal(t, "15.08.2022", registro.Vencimento)
+	assert.Equal(t, pagadorCPF, registro.TipoPagador)
+	assert.Equal(t, 6010, registro.CEPPagador)
+	assert.Equal(t, 100, registro.SufixoCEPPagador)
+	assert.Equal(t, int64(99000), registro.PercentualJuros)
+	assert.Equal(t, int64(200), registro.ValorMulta)
+	assert.Equal(t, faixaDesconto{DataLimite: "10.08.2022", Percentual: 500000}, registro.Desconto1)
+
+	assert.NoError(t, provedor.AlterarVencimento(context.Background(), boleto, time.Date(2022, 8, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "31.08.2022", alteracao.DataVencimento)
+	assert.Equal(t, int64(12345), alteracao.NossoNumero)
+
+	assert.NoError(t, provedor.BaixarBoleto(context.Background(), boleto))
+	assert.Equal(t, codigoBaixaSolicitada, baixa.CodigoBaixa)
+	assert.Equal(t, 9, baixa.Produto)
+	assert.Equal(t, int64(33810012345), baixa.Negociacao)
+}
+
+func TestWebhookBradesco(t *testing.T) {
+	provedor, fechar := novoProvedorTeste(t, nil)
+	defer fechar()
+	provedor.config.WebhookSecret = "segredo-webhook"
+	corpo, err := os.ReadFile(filepath.Join("testdata", "webhook_liquidacao.json"))
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("segredo-webhook"))
+	mac.Write(corpo)
+	requisicao := bancos.RequisicaoWebhook{Cabecalhos: http.Header{}, Corpo: corpo}
+	requisicao.Cabecalhos.Set("X-Bradesco-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	assert.NoError(t, provedor.AutenticarWebhook(requisicao))
+	requisicao.Corpo = []byte(strings.Replace(string(corpo), "10223", "10224", 1))
+	assert.ErrorIs(t, provedor.AutenticarWebhook(requisicao), bancos.ErrWebhookNaoAutenticado)
+
+	notificacoes, err := provedor.LerWebhook(corpo)
+	assert.NoError(t, err)
+	assert.Len(t, notificacoes, 2)
+	assert.Equal(t, "LIQ-20220818-000001", notificacoes[0].Evento)
+	assert.Equal(t, codigoBarrasBradesco, notificacoes[0].CodigoBarras)
+	assert.Equal(t, models.StatusBoletoPago, notificacoes[0].Status)
+	assert.Equal(t, 102.23, notificacoes[0].ValorPago)
+	assert.Equal(t, "2022-08-18", notificacoes[0].DataPagamento.Format("2006-01-02"))
+	assert.Equal(t, "00000012346", notificacoes[1].NossoNumero)
+	assert.Equal(t, models.StatusBoletoBaixado, notificacoes[1].Status)
+
+	_, err = provedor.LerWebhook([]byte(`{"idEvento":"1","titulos":[]}`))
+	assert.ErrorIs(t, err, bancos.ErrWebhookInvalido)
+}
@@ -0,0 +1,273 @@
+package bradesco
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Recursos de registro, baixa e alteração de títulos da API de Cobrança
+const (
+	caminhoRegistro  = "/boleto/cobranca-registro/v1/gerarBoleto"
+	caminhoBaixa     = "/boleto/cobranca-baixa/v1/baixar"
+	caminhoAlteracao = "/boleto/cobranca-altera/v1/alterar"
+)
+
+// Códigos da API: espécie duplicata mercantil, baixa por solicitação do beneficiário
+// e tipo de documento do pagador
+const (
+	especieDuplicata      = 2
+	codigoBaixaSolicitada = 57
+	pagadorCPF            = 1
+	pagadorCNPJ           = 2
+)
+
+// maxDescontos é o número de faixas de desconto aceitas no registro
+const maxDescontos = 3
+
+// EmitirBoleto registra o título na negociação configurada. Sem nosso número, o
+// Bradesco atribui o próximo da carteira
+func (p *Provedor) EmitirBoleto(ctx context.Context, solicitacao bancos.SolicitacaoEmissao) (*models.Boleto, error) {
+	requisicao, err := p.requisicaoRegistro(solicitacao)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.WithFields(logrus.Fields{
+		"carteira":     requisicao.Produto,
+		"nosso_numero": requisicao.NossoNumero,
+		"seu_numero":   solicitacao.SeuNumero,
+	}).Info("Registrando boleto na API do Bradesco")
+
+	var resposta respostaRegistro
+	if err := p.api.Executar(ctx, http.MethodPost, caminhoRegistro, nil, requisicao, &resposta); err != nil {
+		return nil, err
+	}
+	if resposta.CodBarras == "" {
+		return nil, fmt.Errorf("registro sem código de barras: %s", resposta.Mensagem)
+	}
+
+	negociacao := fmt.Sprintf("%011d", requisicao.Negociacao)
+	return &models.Boleto{
+		Banco:          utils.BancoBradesco,
+		Numero:         fmt.Sprintf("%011d", resposta.NossoNumero),
+		NossoNumero:    fmt.Sprintf("%011d", resposta.NossoNumero),
+		Carteira:       fmt.Sprintf("%02d", requisicao.Produto),
+		Agencia:        negociacao[0:4],
+		Conta:          negociacao[4:11],
+		CodigoBarras:   resposta.CodBarras,
+		LinhaDigitavel: resposta.LinhaDig,
+		Valor:          solicitacao.Valor,
+		Vencimento:     solicitacao.Vencimento,
+		Status:         models.StatusBoletoAberto,
+	}, nil
+}
+
+// BaixarBoleto solicita a baixa do título
+func (p *Provedor) BaixarBoleto(ctx context.Context, boleto *models.Boleto) error {
+	titulo, err := p.tituloEmitido(boleto)
+	if err != nil {
+		return err
+	}
+	p.logger.WithField("nosso_numero", titulo.NossoNumero).Info("Baixando título na API do Bradesco")
+	return erroTitulo(p.api.Executar(ctx, http.MethodPost, caminhoBaixa, nil,
+		requisicaoBaixa{requisicaoConsulta: titulo, CodigoBaixa: codigoBaixaSolicitada}, nil))
+}
+
+// AlterarVencimento altera a data de vencimento do título
+func (p *Provedor) AlterarVencimento(ctx context.Context, boleto *models.Boleto, vencimento time.Time) error {
+	titulo, err := p.tituloEmitido(boleto)
+	if err != nil {
+		return err
+	}
+	p.logger.WithFields(logrus.Fields{
+		"nosso_numero": titulo.NossoNumero,
+		"vencimento":   vencimento.Format(time.DateOnly),
+	}).Info("Alterando vencimento na API do Bradesco")
+	return erroTitulo(p.api.Executar(ctx, http.MethodPost, caminhoAlteracao, nil,
+		requisicaoAlteracao{requisicaoConsulta: titulo, DataVencimento: vencimento.Format("02.01.2006")}, nil))
+}
+
+// erroTitulo traduz o 404 das instruções sobre um título para ErrBoletoNaoEncontrado
+func erroTitulo(err error) error {
+	if bancos.NaoEncontrado(err) {
+		return bancos.ErrBoletoNaoEncontrado
+	}
+	return err
+}
+
+// tituloEmitido identifica o título pelo código de barras ou pela carteira e nosso número
+func (p *Provedor) tituloEmitido(boleto *models.Boleto) (requisicaoConsulta, error) {
+	codigo := boleto.NossoNumero
+	if boleto.Carteira != "" {
+		codigo = boleto.Carteira + "/" + codigo
+	}
+	titulo, ok := p.requisicaoConsulta(bancos.Consulta{Codigo: codigo, CodigoBarras: boleto.CodigoBarras})
+	if !ok {
+		return requisicaoConsulta{}, errors.New("boleto sem código de barras ou nosso número do Bradesco")
+	}
+	return titulo, nil
+}
+
+// requisicaoRegistro monta o registro do título; valores em centavos, percentuais
+// com cinco casas implícitas e datas no formato dd.mm.aaaa
+func (p *Provedor) requisicaoRegistro(solicitacao bancos.SolicitacaoEmissao) (requisicaoEmissao, error) {
+	negociacao, err := strconv.ParseInt(p.config.Beneficiario, 10, 64)
+	if err != nil || len(p.config.Beneficiario) != 11 {
+		return requisicaoEmissao{}, errors.New("negociação do Bradesco não configurada")
+	}
+	carteira := solicitacao.Carteira
+	if carteira == "" {
+		carteira = carteiraPadrao
+	}
+	produto, err := strconv.Atoi(carteira)
+	if err != nil {
+		return requisicaoEmissao{}, fmt.Errorf("carteira %q inválida", carteira)
+	}
+	var nossoNumero int64
+	if solicitacao.NossoNumero != "" {
+		if nossoNumero, err = strconv.ParseInt(solicitacao.NossoNumero, 10, 64); err != nil || len(solicitacao.NossoNumero) > 11 {
+			return requisicaoEmissao{}, fmt.Errorf("nosso número %q inválido", solicitacao.NossoNumero)
+		}
+	}
+	if len(solicitacao.Descontos) > maxDescontos {
+		return requisicaoEmissao{}, fmt.Errorf("o Bradesco aceita até %d faixas de desconto", maxDescontos)
+	}
+
+	pagador := solicitacao.Pagador
+	cep, _ := strconv.Atoi(pagador.CEP)
+	requisicao := requisicaoEmissao{
+		CPFCNPJ:     documentoBeneficiario(p.config.DocumentoBeneficiario),
+		Produto:     produto,
+		Negociacao:  negociacao,
+		NossoNumero: nossoNumero,
+		SeuNumero:   solicitacao.SeuNumero,
+		Emissao:     solicitacao.Emissao.Format("02.01.2006"),
+		Vencimento:  solicitacao.Vencimento.Format("02.01.2006"),
+		Valor:       emCentavos(solicitacao.Valor),
+		Especie:     especieDuplicata,
+
+		NomePagador:        pagador.Nome,
+		LogradouroPagador:  pagador.Logradouro,
+		NumeroPagador:      pagador.Numero,
+		ComplementoPagador: pagador.Complemento,
+		CEPPagador:         cep / 1000,
+		SufixoCEPPagador:   cep % 1000,
+		BairroPagador:      pagador.Bairro,
+		MunicipioPagador:   pagador.Cidade,
+		UFPagador:          pagador.UF,
+		TipoPagador:        pagadorCPF,
+		DocumentoPagador:   pagador.Documento,
+	}
+	if pagador.PessoaJuridica() {
+		requisicao.TipoPagador = pagadorCNPJ
+	}
+
+	// Os juros são informados em valor por dia ou em taxa mensal
+	switch solicitacao.TipoJuros {
+	case models.JurosValorDia:
+		requisicao.ValorJuros, requisicao.DiasJuros = emCentavos(solicitacao.TaxaJuros), 1
+	case models.JurosTaxaDiaria:
+		requisicao.PercentualJuros, requisicao.DiasJuros = emPercentual(solicitacao.TaxaJuros*30), 1
+	case models.JurosTaxaMensal:
+		requisicao.PercentualJuros, requisicao.DiasJuros = emPercentual(solicitacao.TaxaJuros), 1
+	}
+	switch solicitacao.TipoMulta {
+	case models.EncargoValor:
+		requisicao.ValorMulta, requisicao.DiasMulta = emCentavos(solicitacao.TaxaMulta), 1
+	case models.EncargoPercentual:
+		requisicao.PercentualMulta, requisicao.DiasMulta = emPercentual(solicitacao.TaxaMulta), 1
+	}
+	faixas := []*faixaDesconto{&requisicao.Desconto1, &requisicao.Desconto2, &requisicao.Desconto3}
+	for i, desconto := range solicitacao.Descontos {
+		faixas[i].DataLimite = desconto.DataLimite.Format("02.01.2006")
+		if desconto.Tipo == models.EncargoPercentual {
+			faixas[i].Percentual = emPercentual(desconto.Valor)
+		} else {
+			faixas[i].Valor = emCentavos(desconto.Valor)
+		}
+	}
+	return requisicao, nil
+}
+
+// requisicaoEmissao é o corpo do registro de título
+type requisicaoEmissao struct {
+	CPFCNPJ     documento `json:"cpfCnpj"`
+	Produto     int       `json:"idProduto"`
+	Negociacao  int64     `json:"nuNegociacao"`
+	NossoNumero int64     `json:"nuTitulo"`
+	SeuNumero   string    `json:"nuCliente"`
+	Emissao     string    `json:"dtEmissaoTitulo"`
+	Vencimento  string    `json:"dtVencimentoTitulo"`
+	Valor       int64     `json:"vlNominalTitulo"`
+	Especie     int       `json:"cdEspecieTitulo"`
+
+	PercentualJuros int64 `json:"percentualJuros"`
+	ValorJuros      int64 `json:"vlJuros"`
+	DiasJuros       int   `json:"qtdeDiasJuros"`
+	PercentualMulta int64 `json:"percentualMulta"`
+	ValorMulta      int64 `json:"vlMulta"`
+	DiasMulta       int   `json:"qtdeDiasMulta"`
+
+	Desconto1 faixaDesconto `json:"desconto1"`
+	Desconto2 faixaDesconto `json:"desconto2"`
+	Desconto3 faixaDesconto `json:"desconto3"`
+
+	NomePagador        string `json:"nomePagador"`
+	LogradouroPagador  string `json:"logradouroPagador"`
+	NumeroPagador      string `json:"nuLogradouroPagador"`
+	ComplementoPagador string `json:"complementoLogradouroPagador"`
+	CEPPagador         int    `json:"cepPagador"`
+	SufixoCEPPagador   int    `json:"complementoCepPagador"`
+	BairroPagador      string `json:"bairroPagador"`
+	MunicipioPagador   string `json:"municipioPagador"`
+	UFPagador          string `json:"ufPagador"`
+	TipoPagador        int    `json:"cdIndCpfcnpjPagador"`
+	DocumentoPagador   string `json:"nuCpfcnpjPagador"`
+}
+
+type faixaDesconto struct {
+	DataLimite string `json:"dataLimite,omitempty"`
+	Valor      int64  `json:"valor,omitempty"`
+	Percentual int64  `json:"percentual,omitempty"`
+}
+
+// respostaRegistro é a resposta do registro, com o nosso número atribuído
+type respostaRegistro struct {
+	Status      int    `json:"status"`
+	Mensagem    string `json:"mensagem"`
+	NossoNumero int64  `json:"nuTituloGerado"`
+	CodBarras   string `json:"codBarras"`
+	LinhaDig    string `json:"linhaDig"`
+}
+
+// requisicaoBaixa é o corpo da baixa de título
+type requisicaoBaixa struct {
+	requisicaoConsulta
+	CodigoBaixa int `json:"codigoBaixa"`
+}
+
+// requisicaoAlteracao é o corpo da alteração do vencimento
+type requisicaoAlteracao struct {
+	requisicaoConsulta
+	DataVencimento string `json:"dataVencimento"`
+}
+
+// emCentavos converte reais para centavos
+func emCentavos(valor float64) int64 {
+	return int64(math.Round(valor * 100))
+}
+
+// emPercentual converte a taxa percentual para cinco casas implícitas
+func emPercentual(taxa float64) int64 {
+	return int64(math.Round(taxa * 100000))
+}
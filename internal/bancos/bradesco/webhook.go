@@ -0,0 +1,45 @@
+package bradesco
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+)
+
+// cabecalhoAssinatura traz o HMAC-SHA256 do corpo das notificações
+const cabecalhoAssinatura = "X-Bradesco-Signature"
+
+// notificacaoBradesco é o corpo do webhook de liquidação, com os títulos no mesmo
+// formato da consulta
+type notificacaoBradesco struct {
+	IDEvento string           `json:"idEvento"`
+	Titulos  []tituloBradesco `json:"titulos"`
+}
+
+// AutenticarWebhook verifica a assinatura no cabeçalho X-Bradesco-Signature e o
+// certificado de cliente configurados
+func (p *Provedor) AutenticarWebhook(requisicao bancos.RequisicaoWebhook) error {
+	return bancos.AutenticarWebhook(requisicao, p.config, cabecalhoAssinatura)
+}
+
+// LerWebhook converte os títulos liquidados ou baixados da notificação
+func (p *Provedor) LerWebhook(corpo []byte) ([]bancos.NotificacaoPagamento, error) {
+	var notificacao notificacaoBradesco
+	if err := json.Unmarshal(corpo, &notificacao); err != nil {
+		return nil, fmt.Errorf("%w: %v", bancos.ErrWebhookInvalido, err)
+	}
+	if len(notificacao.Titulos) == 0 {
+		return nil, fmt.Errorf("%w: notificação sem títulos", bancos.ErrWebhookInvalido)
+	}
+
+	notificacoes := make([]bancos.NotificacaoPagamento, 0, len(notificacao.Titulos))
+	for _, titulo := range notificacao.Titulos {
+		boleto, err := converter(titulo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: título %s: %v", bancos.ErrWebhookInvalido, titulo.NossoNumero, err)
+		}
+		notificacoes = append(notificacoes, bancos.NotificacaoBoleto(notificacao.IDEvento, boleto))
+	}
+	return notificacoes, nil
+}
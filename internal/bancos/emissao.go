@@ -0,0 +1,80 @@
+package bancos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// ErrEmissaoNaoSuportada indica que nenhum provedor do banco registra boletos
+var ErrEmissaoNaoSuportada = errors.New("banco sem provedor de emissão de boletos")
+
+// Pagador é o sacado do boleto emitido, com o endereço exigido no registro
+type Pagador struct {
+	// Documento é o CNPJ ou CPF, sem formatação
+	Documento   string
+	Nome        string
+	Logradouro  string
+	Numero      string
+	Complemento string
+	Bairro      string
+	Cidade      string
+	UF          string
+	CEP         string
+}
+
+// PessoaJuridica informa se o pagador é identificado por CNPJ
+func (p Pagador) PessoaJuridica() bool {
+	return len(p.Documento) == 14
+}
+
+// SolicitacaoEmissao reúne os dados do título a registrar no banco
+type SolicitacaoEmissao struct {
+	// SeuNumero identifica o título na empresa e volta nos arquivos de retorno
+	SeuNumero string
+	// NossoNumero é atribuído pela empresa, sem o dígito verificador
+	NossoNumero string
+	Carteira    string
+	Valor       float64
+	Emissao     time.Time
+	Vencimento  time.Time
+	Pagador     Pagador
+
+	// Regras de juros, multa e desconto, com as constantes de models.Boleto
+	TipoJuros string
+	TaxaJuros float64
+	TipoMulta string
+	TaxaMulta float64
+	Descontos []models.DescontoBoleto
+
+	// Instrucoes são as mensagens impressas no boleto
+	Instrucoes []string
+}
+
+// ProvedorEmissao é implementado pelos provedores que registram boletos de cobrança
+// da empresa como beneficiária
+type ProvedorEmissao interface {
+	Provedor
+	// EmitirBoleto registra o título e retorna o boleto com nosso número, código de
+	// barras e linha digitável atribuídos pelo banco
+	EmitirBoleto(ctx context.Context, solicitacao SolicitacaoEmissao) (*models.Boleto, error)
+	// BaixarBoleto solicita a baixa (cancelamento) do título em aberto
+	BaixarBoleto(ctx context.Context, boleto *models.Boleto) error
+	// AlterarVencimento altera a data de vencimento do título em aberto
+	AlterarVencimento(ctx context.Context, boleto *models.Boleto, vencimento time.Time) error
+}
+
+// ProvedorEmissao retorna o primeiro provedor do banco que registra boletos
+func (r *Registro) ProvedorEmissao(banco string) (ProvedorEmissao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, provedor := range r.porBanco[banco] {
+		if emissor, ok := provedor.(ProvedorEmissao); ok {
+			return emissor, nil
+		}
+	}
+	return nil, ErrEmissaoNaoSuportada
+}
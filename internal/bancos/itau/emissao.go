@@ -0,0 +1,270 @@
+package itau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Códigos da API de Cobrança v2 para juros, multa e desconto
+var (
+	tiposJurosItau = map[string]string{
+		models.JurosTaxaMensal: "90",
+		models.JurosTaxaDiaria: "91",
+		models.JurosValorDia:   "93",
+	}
+	tiposMultaItau = map[string]string{
+		models.EncargoValor:      "01",
+		models.EncargoPercentual: "02",
+	}
+	tiposDescontoItau = map[string]string{
+		models.EncargoValor:      "01",
+		models.EncargoPercentual: "02",
+	}
+)
+
+// EmitirBoleto registra o boleto na carteira do beneficiário configurado. O Itaú
+// exige o nosso número de 8 dígitos atribuído pela empresa
+func (p *Provedor) EmitirBoleto(ctx context.Context, solicitacao bancos.SolicitacaoEmissao) (*models.Boleto, error) {
+	if p.config.Beneficiario == "" {
+		return nil, errors.New("id_beneficiario do Itaú não configurado")
+	}
+	nossoNumero := fmt.Sprintf("%08s", solicitacao.NossoNumero)
+	if len(nossoNumero) > 8 {
+		return nil, fmt.Errorf("nosso número %q excede 8 dígitos", solicitacao.NossoNumero)
+	}
+	p.logger.WithFields(logrus.Fields{
+		"id_beneficiario": p.config.Beneficiario,
+		"nosso_numero":    nossoNumero,
+		"seu_numero":      solicitacao.SeuNumero,
+	}).Info("Registrando boleto na API do Itaú")
+
+	requisicao := requisicaoEmissao{EtapaProcesso: "efetivacao"}
+	requisicao.Beneficiario.IDBeneficiario = p.config.Beneficiario
+	requisicao.DadoBoleto = dadoEmissao{
+		DescricaoInstrumento: "boleto",
+		TipoBoleto:           "a vista",
+		CodigoCarteira:       solicitacao.Carteira,
+		ValorTotal:           valorCentavosItau(solicitacao.Valor),
+		CodigoEspecie:        "01",
+		DataEmissao:          solicitacao.Emissao.Format(time.DateOnly),
+		Pagador:              pagadorEmissao(solicitacao.Pagador),
+		DadosIndividuais: []individualEmissao{{
+			NossoNumero:    nossoNumero,
+			DataVencimento: solicitacao.Vencimento.Format(time.DateOnly),
+			ValorTitulo:    valorCentavosItau(solicitacao.Valor),
+			SeuNumero:      solicitacao.SeuNumero,
+		}},
+	}
+	if codigo, ok := tiposJurosItau[solicitacao.TipoJuros]; ok {
+		juros := &encargoItau{Codigo: codigo}
+		if solicitacao.TipoJuros == models.JurosValorDia {
+			juros.Valor = valorCentavosItau(solicitacao.TaxaJuros)
+		} else {
+			juros.Percentual = percentualItau(solicitacao.TaxaJuros)
+		}
+		requisicao.DadoBoleto.Juros = juros
+	}
+	if codigo, ok := tiposMultaItau[solicitacao.TipoMulta]; ok {
+		multa := &encargoItau{Codigo: codigo}
+		if solicitacao.TipoMulta == models.EncargoValor {
+			multa.Valor = valorCentavosItau(solicitacao.TaxaMulta)
+		} else {
+			multa.Percentual = percentualItau(solicitacao.TaxaMulta)
+		}
+		requisicao.DadoBoleto.Multa = multa
+	}
+	if len(solicitacao.Descontos) > 0 {
+		// A API aceita um único tipo de desconto para todas as faixas
+		tipo := solicitacao.Descontos[0].Tipo
+		desconto := &descontoEmissao{Codigo: tiposDescontoItau[tipo]}
+		for _, faixa := range solicitacao.Descontos {
+			item := faixaDescontoItau{Data: faixa.DataLimite.Format(time.DateOnly)}
+			if tipo == models.EncargoPercentual {
+				item.Percentual = percentualItau(faixa.Valor)
+			} else {
+				item.Valor = valorCentavosItau(faixa.Valor)
+			}
+			desconto.Faixas = append(desconto.Faixas, item)
+		}
+		requisicao.DadoBoleto.Desconto = desconto
+	}
+	for _, instrucao := range solicitacao.Instrucoes {
+		requisicao.DadoBoleto.Mensagens = append(requisicao.DadoBoleto.Mensagens, mensagemItau{Mensagem: instrucao})
+	}
+
+	var resposta respostaEmissao
+	if err := p.api.Executar(ctx, http.MethodPost, "/boletos", nil, requisicao, &resposta); err != nil {
+		return nil, err
+	}
+	boleto, err := p.converter(resposta.Data, nossoNumero)
+	if err != nil {
+		return nil, err
+	}
+	if boleto.Vencimento.IsZero() {
+		boleto.Vencimento = solicitacao.Vencimento
+	}
+	return boleto, nil
+}
+
+// BaixarBoleto solicita a baixa do boleto
+func (p *Provedor) BaixarBoleto(ctx context.Context, boleto *models.Boleto) error {
+	id, err := p.idBoleto(boleto)
+	if err != nil {
+		return err
+	}
+	p.logger.WithField("id_boleto", id).Info("Baixando boleto na API do Itaú")
+	return erroBoleto(p.api.Executar(ctx, http.MethodPatch, "/boletos/"+id+"/baixa", nil, map[string]string{"codigo_baixa": "OUTROS"}, nil))
+}
+
+// AlterarVencimento altera a data de vencimento do boleto
+func (p *Provedor) AlterarVencimento(ctx context.Context, boleto *models.Boleto, vencimento time.Time) error {
+	id, err := p.idBoleto(boleto)
+	if err != nil {
+		return err
+	}
+	p.logger.WithFields(logrus.Fields{
+		"id_boleto":  id,
+		"vencimento": vencimento.Format(time.DateOnly),
+	}).Info("Alterando vencimento na API do Itaú")
+	return erroBoleto(p.api.Executar(ctx, http.MethodPatch, "/boletos/"+id+"/data_vencimento", nil,
+		map[string]string{"data_vencimento": vencimento.Format(time.DateOnly)}, nil))
+}
+
+// erroBoleto traduz o 404 das instruções sobre um boleto para ErrBoletoNaoEncontrado
+func erroBoleto(err error) error {
+	if bancos.NaoEncontrado(err) {
+		return bancos.ErrBoletoNaoEncontrado
+	}
+	return err
+}
+
+// idBoleto compõe o identificador do boleto na API: id_beneficiario (12), carteira
+// (3) e nosso número (8)
+func (p *Provedor) idBoleto(boleto *models.Boleto) (string, error) {
+	query, ok := p.parametrosConsulta(bancos.Consulta{Codigo: boleto.NossoNumero, CodigoBarras: boleto.CodigoBarras})
+	if !ok {
+		return "", errors.New("boleto sem código de barras ou nosso número do Itaú")
+	}
+	carteira := query.Get("codigo_carteira")
+	if carteira == "" {
+		carteira = boleto.Carteira
+	}
+	if len(carteira) != 3 {
+		return "", fmt.Errorf("carteira %q do boleto inválida", carteira)
+	}
+	return query.Get("id_beneficiario") + carteira + query.Get("nosso_numero"), nil
+}
+
+// requisicaoEmissao é o corpo de POST /boletos
+type requisicaoEmissao struct {
+	EtapaProcesso string `json:"etapa_processo_boleto"`
+	Beneficiario  struct {
+		IDBeneficiario string `json:"id_beneficiario"`
+	} `json:"beneficiario"`
+	DadoBoleto dadoEmissao `json:"dado_boleto"`
+}
+
+type dadoEmissao struct {
+	DescricaoInstrumento string              `json:"descricao_instrumento_cobranca"`
+	TipoBoleto           string              `json:"tipo_boleto"`
+	CodigoCarteira       string              `json:"codigo_carteira"`
+	ValorTotal           string              `json:"valor_total_titulo"`
+	CodigoEspecie        string              `json:"codigo_especie"`
+	DataEmissao          string              `json:"data_emissao"`
+	Pagador              pagadorItau         `json:"pagador"`
+	DadosIndividuais     []individualEmissao `json:"dados_individuais_boleto"`
+	Juros                *encargoItau        `json:"juros,omitempty"`
+	Multa                *encargoItau        `json:"multa,omitempty"`
+	Desconto             *descontoEmissao    `json:"desconto,omitempty"`
+	Mensagens            []mensagemItau      `json:"lista_mensagem_cobranca,omitempty"`
+}
+
+type pagadorItau struct {
+	Pessoa struct {
+		Nome       string `json:"nome_pessoa"`
+		TipoPessoa struct {
+			Codigo string `json:"codigo_tipo_pessoa"`
+			CPF    string `json:"numero_cadastro_pessoa_fisica,omitempty"`
+			CNPJ   string `json:"numero_cadastro_nacional_pessoa_juridica,omitempty"`
+		} `json:"tipo_pessoa"`
+	} `json:"pessoa"`
+	Endereco struct {
+		Logradouro string `json:"nome_logradouro"`
+		Bairro     string `json:"nome_bairro"`
+		Cidade     string `json:"nome_cidade"`
+		UF         string `json:"sigla_UF"`
+		CEP        string `json:"numero_CEP"`
+	} `json:"endereco"`
+}
+
+type individualEmissao struct {
+	NossoNumero    string `json:"numero_nosso_numero"`
+	DataVencimento string `json:"data_vencimento"`
+	ValorTitulo    string `json:"valor_titulo"`
+	SeuNumero      string `json:"texto_seu_numero,omitempty"`
+}
+
+type encargoItau struct {
+	Codigo     string `json:"codigo_tipo"`
+	Valor      string `json:"valor,omitempty"`
+	Percentual string `json:"percentual,omitempty"`
+}
+
+type descontoEmissao struct {
+	Codigo string              `json:"codigo_tipo_desconto"`
+	Faixas []faixaDescontoItau `json:"descontos"`
+}
+
+type faixaDescontoItau struct {
+	Data       string `json:"data_desconto"`
+	Valor      string `json:"valor_desconto,omitempty"`
+	Percentual string `json:"percentual_desconto,omitempty"`
+}
+
+type mensagemItau struct {
+	Mensagem string `json:"mensagem"`
+}
+
+// respostaEmissao é a resposta de POST /boletos, com o boleto registrado
+type respostaEmissao struct {
+	Data boletoItau `json:"data"`
+}
+
+// pagadorEmissao converte o pagador, com o logradouro e o número em um só campo
+func pagadorEmissao(pagador bancos.Pagador) pagadorItau {
+	var p pagadorItau
+	p.Pessoa.Nome = pagador.Nome
+	if pagador.PessoaJuridica() {
+		p.Pessoa.TipoPessoa.Codigo = "J"
+		p.Pessoa.TipoPessoa.CNPJ = pagador.Documento
+	} else {
+		p.Pessoa.TipoPessoa.Codigo = "F"
+		p.Pessoa.TipoPessoa.CPF = pagador.Documento
+	}
+	p.Endereco.Logradouro = strings.Join(strings.Fields(pagador.Logradouro+" "+pagador.Numero+" "+pagador.Complemento), " ")
+	p.Endereco.Bairro = pagador.Bairro
+	p.Endereco.Cidade = pagador.Cidade
+	p.Endereco.UF = pagador.UF
+	p.Endereco.CEP = pagador.CEP
+	return p
+}
+
+// valorCentavosItau formata o valor com 17 dígitos e duas casas implícitas
+func valorCentavosItau(valor float64) string {
+	return fmt.Sprintf("%017d", int64(math.Round(valor*100)))
+}
+
+// percentualItau formata a taxa com 12 dígitos e cinco casas implícitas
+func percentualItau(taxa float64) string {
+	return fmt.Sprintf("%012d", int64(math.Round(taxa*100000)))
+}
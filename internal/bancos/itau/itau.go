@@ -0,0 +1,322 @@
+// Package itau implementa o provedor de consulta de boletos do Itaú pela API de
+// Cobrança v2 (cash_management)
+package itau
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	bancos.RegistrarFabrica("itau", func(cfg *config.Config, deps bancos.Dependencias) bancos.Provedor {
+		if cfg.Bank.Itau.URL == "" {
+			return nil
+		}
+		provedor, err := New(cfg.Bank.Itau, deps.Logger)
+		if err != nil {
+			deps.Logger.WithError(err).Error("Erro ao configurar provedor do Itaú")
+			return nil
+		}
+		return provedor
+	})
+}
+
+// Provedor consulta boletos na API de Cobrança v2 do Itaú
+type Provedor struct {
+	config config.BankAPIConfig
+	api    *bancos.ClienteAPI
+	logger *logrus.Logger
+}
+
+// New cria o provedor do Itaú, com mTLS quando o certificado está configurado
+func New(cfg config.BankAPIConfig, logger *logrus.Logger) (*Provedor, error) {
+	cliente, err := bancos.NovoClienteHTTP(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	api := bancos.NovoClienteAPI(cfg.URL, cliente, bancos.NovoGerenciadorToken(cfg, cliente, logger))
+	api.Cabecalhos = func(req *http.Request) {
+		req.Header.Set("x-itau-apikey", cfg.ClientID)
+		req.Header.Set("x-itau-correlationID", idCorrelacao())
+	}
+
+	return &Provedor{config: cfg, api: api, logger: logger}, nil
+}
+
+// Nome identifica o provedor
+func (p *Provedor) Nome() string { return "itau" }
+
+// Bancos retorna o código COMPE do Itaú
+func (p *Provedor) Bancos() []string { return []string{utils.BancoItau} }
+
+// Timeout retorna o timeout configurado para a API
+func (p *Provedor) Timeout() time.Duration { return p.config.Timeout }
+
+// ConsultarBoleto consulta o boleto pelo código de barras ou pelo nosso número
+func (p *Provedor) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	query, ok := p.parametrosConsulta(consulta)
+	if !ok {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	p.logger.WithFields(logrus.Fields{
+		"id_beneficiario": query.Get("id_beneficiario"),
+		"nosso_numero":    query.Get("nosso_numero"),
+	}).Info("Consultando API do Itaú")
+
+	var resposta respostaBoletos
+	if err := p.api.Executar(ctx, http.MethodGet, "/boletos", query, nil, &resposta); err != nil {
+		if bancos.NaoEncontrado(err) {
+			return nil, bancos.ErrBoletoNaoEncontrado
+		}
+		return nil, err
+	}
+	if len(resposta.Data) == 0 {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+
+	return p.converter(resposta.Data[0], query.Get("nosso_numero"))
+}
+
+// parametrosConsulta monta a consulta: pelo código de barras, o beneficiário, a
+// carteira e o nosso número vêm do campo livre; pelo nosso número, o beneficiário
+// vem da configuração
+func (p *Provedor) parametrosConsulta(consulta bancos.Consulta) (url.Values, bool) {
+	query := url.Values{}
+
+	if consulta.CodigoBarras != "" {
+		if consulta.CodigoBarras[0:3] != utils.BancoItau {
+			return nil, false
+		}
+		// Campo livre Itaú: carteira (3), nosso número (8), DAC (1), agência (4),
+		// conta (5), DAC (1) e zeros (3)
+		campoLivre := consulta.CodigoBarras[19:44]
+		query.Set("id_beneficiario", campoLivre[12:16]+"00"+campoLivre[16:22])
+		query.Set("codigo_carteira", campoLivre[0:3])
+		query.Set("nosso_numero", campoLivre[3:11])
+		return query, true
+	}
+
+	nossoNumero := strings.TrimSpace(consulta.Codigo)
+	if p.config.Beneficiario == "" || nossoNumero == "" || len(nossoNumero) > 8 || strings.Trim(nossoNumero, "0123456789") != "" {
+		return nil, false
+	}
+	query.Set("id_beneficiario", p.config.Beneficiario)
+	query.Set("nosso_numero", fmt.Sprintf("%08s", nossoNumero))
+	return query, true
+}
+
+// respostaBoletos é a resposta de GET /boletos
+type respostaBoletos struct {
+	Data []boletoItau `json:"data"`
+}
+
+type boletoItau struct {
+	IDBoleto     string `json:"id_boleto"`
+	Beneficiario struct {
+		IDBeneficiario string `json:"id_beneficiario"`
+		NomeCobranca   string `json:"nome_cobranca"`
+	} `json:"beneficiario"`
+	DadoBoleto struct {
+		CodigoCarteira     string           `json:"codigo_carteira"`
+		DadosIndividuais   []individualItau `json:"dados_individuais_boleto"`
+		PagamentosCobranca []pagamentoItau  `json:"pagamentos_cobranca_boleto"`
+	} `json:"dado_boleto"`
+	// Boletos híbridos trazem o Pix copia-e-cola em dados_qrcode
+	DadosQRCode struct {
+		EMV  string `json:"emv"`
+		TxID string `json:"txid"`
+	} `json:"dados_qrcode"`
+}
+
+type individualItau struct {
+	SituacaoGeral  string `json:"situacao_geral_boleto"`
+	NossoNumero    string `json:"numero_nosso_numero"`
+	DataVencimento string `json:"data_vencimento"`
+	ValorTitulo    string `json:"valor_titulo"`
+	CodigoBarras   string `json:"codigo_barras"`
+	LinhaDigitavel string `json:"numero_linha_digitavel"`
+}
+
+type pagamentoItau struct {
+	DataPagamento string `json:"data_inclusao_pagamento"`
+	ValorPago     string `json:"valor_pago_total_cobranca"`
+}
+
+// converter mapeia o boleto do Itaú para o modelo, incluindo situação e pagamento
+func (p *Provedor) converter(dado boletoItau, nossoNumero string) (*models.Boleto, error) {
+	individuais := dado.DadoBoleto.DadosIndividuais
+	if len(individuais) == 0 {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	individual := individuais[0]
+	for _, candidato := range individuais {
+		if candidato.NossoNumero == nossoNumero {
+			individual = candidato
+			break
+		}
+	}
+
+	valor, err := valorItau(individual.ValorTitulo)
+	if err != nil {
+		return nil, fmt.Errorf("valor do título inválido: %w", err)
+	}
+
+	boleto := &models.Boleto{
+		Banco:         utils.BancoItau,
+		Numero:        individual.NossoNumero,
+		NossoNumero:   individual.NossoNumero,
+		Carteira:      dado.DadoBoleto.CodigoCarteira,
+		Valor:         valor,
+		PixCopiaECola: dado.DadosQRCode.EMV,
+		PixTxID:       dado.DadosQRCode.TxID,
+
+		NomeBeneficiario: dado.Beneficiario.NomeCobranca,
+	}
+	boleto.CodigoBarras, boleto.LinhaDigitavel = p.codigosBoleto(individual)
+	// id_beneficiario: agência (4), conta (7) e DAC (1); o campo livre usa a conta com 5 dígitos
+	if beneficiario := dado.Beneficiario.IDBeneficiario; len(beneficiario) == 12 {
+		boleto.Agencia = beneficiario[0:4]
+		boleto.Conta = beneficiario[6:11]
+	}
+	if individual.DataVencimento != "" {
+		vencimento, err := time.Parse("2006-01-02", individual.DataVencimento)
+		if err != nil {
+			return nil, fmt.Errorf("data de vencimento inválida: %w", err)
+		}
+		boleto.Vencimento = vencimento
+	}
+
+	boleto.Status = situacaoItau(individual.SituacaoGeral, len(dado.DadoBoleto.PagamentosCobranca) > 0)
+	switch boleto.Status {
+	case models.StatusBoletoPago:
+		if err := preencherPagamento(boleto, dado.DadoBoleto.PagamentosCobranca); err != nil {
+			return nil, err
+		}
+	case models.StatusBoletoAberto:
+		// Pago parcialmente: o boleto continua em aberto com o valor já pago
+		if len(dado.DadoBoleto.PagamentosCobranca) > 0 {
+			total, _, err := somarPagamentos(dado.DadoBoleto.PagamentosCobranca)
+			if err != nil {
+				return nil, err
+			}
+			boleto.ValorPago = &total
+		}
+	case models.StatusBoletoBaixado:
+	default:
+		p.logger.WithField("situacao", individual.SituacaoGeral).Warn("Situação de boleto do Itaú desconhecida")
+		boleto.Status = models.StatusBoletoAberto
+	}
+
+	return boleto, nil
+}
+
+// codigosBoleto retorna o código de barras e a linha digitável derivada dele. A linha
+// informada pelo banco só é usada sem código de barras válido, e então também valida
+func (p *Provedor) codigosBoleto(individual individualItau) (string, string) {
+	if parsed, err := utils.ParseCodigoBoleto(individual.CodigoBarras); err == nil {
+		if individual.LinhaDigitavel != "" && utils.NormalizarCodigoBoleto(individual.LinhaDigitavel) != utils.NormalizarCodigoBoleto(parsed.LinhaDigitavel) {
+			p.logger.WithField("nosso_numero", individual.NossoNumero).Warn("Linha digitável do Itaú diverge do código de barras")
+		}
+		return parsed.CodigoBarras, parsed.LinhaDigitavel
+	}
+	if parsed, err := utils.ParseCodigoBoleto(individual.LinhaDigitavel); err == nil {
+		return parsed.CodigoBarras, parsed.LinhaDigitavel
+	}
+	return individual.CodigoBarras, individual.LinhaDigitavel
+}
+
+// preencherPagamento soma os pagamentos e usa a data do último
+func preencherPagamento(boleto *models.Boleto, pagamentos []pagamentoItau) error {
+	if len(pagamentos) == 0 {
+		return nil
+	}
+	total, ultima, err := somarPagamentos(pagamentos)
+	if err != nil {
+		return err
+	}
+	boleto.ValorPago = &total
+	boleto.DataPagamento = &ultima
+	return nil
+}
+
+// somarPagamentos retorna o total pago e a data do último pagamento
+func somarPagamentos(pagamentos []pagamentoItau) (float64, time.Time, error) {
+	var total float64
+	var ultima time.Time
+	for _, pagamento := range pagamentos {
+		valor, err := valorItau(pagamento.ValorPago)
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("valor pago inválido: %w", err)
+		}
+		total += valor
+
+		data, err := time.Parse("2006-01-02", pagamento.DataPagamento)
+		if err != nil {
+			return 0, time.Time{}, fmt.Errorf("data de pagamento inválida: %w", err)
+		}
+		if data.After(ultima) {
+			ultima = data
+		}
+	}
+	return total, ultima, nil
+}
+
+// situacaoItau converte a situação geral do boleto no Itaú para o status do modelo;
+// o boleto pago parcialmente continua em aberto e situações desconhecidas são
+// retornadas como vieram
+func situacaoItau(situacao string, temPagamento bool) string {
+	switch strings.ToLower(strings.TrimSpace(situacao)) {
+	case "em aberto", "aberto", "vencida", "vencido", "a vencer", "paga parcialmente":
+		return models.StatusBoletoAberto
+	case "paga", "pago", "liquidada", "liquidado":
+		return models.StatusBoletoPago
+	case "baixada", "baixado", "cancelada", "cancelado":
+		return models.StatusBoletoBaixado
+	case "":
+		if temPagamento {
+			return models.StatusBoletoPago
+		}
+		return models.StatusBoletoAberto
+	default:
+		return situacao
+	}
+}
+
+// valorItau interpreta os valores da API: com separador decimal ("250.75") ou com
+// duas casas implícitas ("00000000000025075")
+func valorItau(valor string) (float64, error) {
+	valor = strings.TrimSpace(valor)
+	if valor == "" {
+		return 0, nil
+	}
+	if strings.ContainsAny(valor, ".,") {
+		return strconv.ParseFloat(strings.Replace(valor, ",", ".", 1), 64)
+	}
+	centavos, err := strconv.ParseInt(valor, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(centavos) / 100, nil
+}
+
+// idCorrelacao gera o identificador de correlação exigido em cada requisição
+func idCorrelacao() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
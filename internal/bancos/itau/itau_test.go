@@ -0,0 +1,279 @@
+package itau
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+const codigoBarrasItau = "34195955000000250751091234567800057123457000"
+
+// servidorItau simula o token e a consulta de boletos, respondendo com a fixture
+// associada ao nosso número consultado
+func servidorItau(t *testing.T, fixtures map[string]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token-itau","token_type":"Bearer","expires_in":300}`))
+	})
+	mux.HandleFunc("/boletos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-itau", r.Header.Get("Authorization"))
+		assert.Equal(t, "cliente-itau", r.Header.Get("x-itau-apikey"))
+		assert.NotEmpty(t, r.Header.Get("x-itau-correlationID"))
+		assert.Equal(t, "005700123457", r.URL.Query().Get("id_beneficiario"))
+
+		fixture, ok := fixtures[r.URL.Query().Get("nosso_numero")]
+		if !ok {
+			fixture = "boletos_vazio.json"
+		}
+		conteudo, err := os.ReadFile(filepath.Join("testdata", fixture))
+		assert.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(conteudo)
+	})
+	return httptest.NewServer(mux)
+}
+
+func novoProvedorTeste(t *testing.T, servidor *httptest.Server) *Provedor {
+	provedor, err := New(config.BankAPIConfig{
+		URL:          servidor.URL,
+		TokenURL:     servidor.URL + "/token",
+		ClientID:     "cliente-itau",
+		ClientSecret: "segredo",
+		Timeout:      5 * time.Second,
+		Beneficiario: "005700123457",
+	}, logrus.New())
+	assert.NoError(t, err)
+	return provedor
+}
+
+func TestConsultarBoletoItau(t *testing.T) {
+	casos := []struct {
+		nome     string
+		fixture  string
+		consulta bancos.Consulta
+		validar  func(t *testing.T, boleto *models.Boleto)
+	}{
+		{
+			nome:     "em aberto pelo código de barras",
+			fixture:  "boleto_aberto.json",
+			consulta: bancos.Consulta{Codigo: codigoBarrasItau, CodigoBarras: codigoBarrasItau, Banco: "341"},
+			validar: func(t *testing.T, boleto *models.Boleto) {
+				assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+				assert.Equal(t, "12345678", boleto.NossoNumero)
+				assert.Equal(t, codigoBarrasItau, boleto.CodigoBarras)
+				assert.Equal(t, "34191.09123 34567.800056 71234.570001 5 95500000025075", boleto.LinhaDigitavel)
+				assert.Equal(t, 250.75, boleto.Valor)
+				assert.Equal(t, "2023-11-30", boleto.Vencimento.Format(time.DateOnly))
+				assert.Equal(t, "0057", boleto.Agencia)
+				assert.Equal(t, "12345", boleto.Conta)
+				assert.Equal(t, "109", boleto.Carteira)
+				assert.Contains(t, boleto.PixCopiaECola, "qrpix.itau.com.br/cobv/")
+				assert.Equal(t, "BOL0057123450000012345678", boleto.PixTxID)
+				assert.Equal(t, "EMPRESA EXEMPLO LTDA", boleto.NomeBeneficiario)
+				assert.Nil(t, boleto.DataPagamento)
+				assert.Nil(t, boleto.ValorPago)
+			},
+		},
+		{
+			nome:     "pago pelo código de barras",
+			fixture:  "boleto_pago.json",
+			consulta: bancos.Consulta{Codigo: codigoBarrasItau, CodigoBarras: codigoBarrasItau, Banco: "341"},
+			validar: func(t *testing.T, boleto *models.Boleto) {
+				assert.Equal(t, models.StatusBoletoPago, boleto.Status)
+				assert.Equal(t, 250.75, boleto.Valor)
+				if assert.NotNil(t, boleto.DataPagamento) && assert.NotNil(t, boleto.ValorPago) {
+					assert.Equal(t, "2023-12-04", boleto.DataPagamento.Format(time.DateOnly))
+					assert.Equal(t, 253.26, *boleto.ValorPago)
+				}
+			},
+		},
+		{
+			nome:     "pago parcialmente continua em aberto",
+			fixture:  "boleto_pago_parcial.json",
+			consulta: bancos.Consulta{Codigo: codigoBarrasItau, CodigoBarras: codigoBarrasItau, Banco: "341"},
+			validar: func(t *testing.T, boleto *models.Boleto) {
+				assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+				assert.Nil(t, boleto.DataPagamento)
+				if assert.NotNil(t, boleto.ValorPago) {
+					assert.Equal(t, 100.00, *boleto.ValorPago)
+				}
+			},
+		},
+		{
+			nome:     "baixado pelo nosso número",
+			fixture:  "boleto_baixado.json",
+			consulta: bancos.Consulta{Codigo: "99"},
+			validar: func(t *testing.T, boleto *models.Boleto) {
+				assert.Equal(t, models.StatusBoletoBaixado, boleto.Status)
+				assert.Equal(t, "00000099", boleto.NossoNumero)
+				assert.Equal(t, 1500.00, boleto.Valor)
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			nossoNumero := "12345678"
+			if caso.consulta.CodigoBarras == "" {
+				nossoNumero = "00000099"
+			}
+			servidor := servidorItau(t, map[string]string{nossoNumero: caso.fixture})
+			defer servidor.Close()
+
+			boleto, err := novoProvedorTeste(t, servidor).ConsultarBoleto(context.Background(), caso.consulta)
+			assert.NoError(t, err)
+			if assert.NotNil(t, boleto) {
+				assert.Equal(t, "341", boleto.Banco)
+				caso.validar(t, boleto)
+			}
+		})
+	}
+}
+
+func TestConsultarBoletoItauNaoEncontrado(t *testing.T) {
+	servidor := servidorItau(t, nil)
+	defer servidor.Close()
+	provedor := novoProvedorTeste(t, servidor)
+
+	_, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "12345678"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+
+	// Códigos que não são nosso número nem código de barras do Itaú não chegam à API
+	_, err = provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "BOL001"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+}
+
+func TestCodigosBoletoItau(t *testing.T) {
+	provedor, err := New(config.BankAPIConfig{URL: "https://api.itau.test"}, logrus.New())
+	assert.NoError(t, err)
+	linha := "34191.09123 34567.800056 71234.570001 5 95500000025075"
+
+	// A linha com DV errado é substituída pela derivada do código de barras
+	codigo, derivada := provedor.codigosBoleto(individualItau{CodigoBarras: codigoBarrasItau, LinhaDigitavel: "34191091233456780005771234570001595500000025075"})
+	assert.Equal(t, codigoBarrasItau, codigo)
+	assert.Equal(t, linha, derivada)
+
+	// Sem código de barras, a linha válida dá origem a ele
+	codigo, derivada = provedor.codigosBoleto(individualItau{LinhaDigitavel: "34191091233456780005671234570001595500000025075"})
+	assert.Equal(t, codigoBarrasItau, codigo)
+	assert.Equal(t, linha, derivada)
+}
+
+func TestSituacaoItau(t *testing.T) {
+	assert.Equal(t, models.StatusBoletoAberto, situacaoItau("Vencida", false))
+	assert.Equal(t, models.StatusBoletoPago, situacaoItau("PAGA", true))
+	assert.Equal(t, models.StatusBoletoAberto, situacaoItau("Paga Parcialmente", true))
+	assert.Equal(t, models.StatusBoletoBaixado, situacaoItau("baixada", false))
+	assert.Equal(t, models.StatusBoletoPago, situacaoItau("", true))
+}
+
+func TestEmitirBoletoItau(t *testing.T) {
+	var requisicao requisicaoEmissao
+	var operacoes []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"token-itau","token_type":"Bearer","expires_in":300}`))
+	})
+	mux.HandleFunc("/boletos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&requisicao))
+		conteudo, err := os.ReadFile(filepath.Join("testdata", "boleto_emitido.json"))
+		assert.NoError(t, err)
+		w.Write(conteudo)
+	})
+	mux.HandleFunc("/boletos/", func(w http.ResponseWriter, r *http.Request) {
+		corpo, _ := io.ReadAll(r.Body)
+		operacoes = append(operacoes, r.Method+" "+r.URL.Path+" "+string(corpo))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	servidor := httptest.NewServer(mux)
+	defer servidor.Close()
+	provedor := novoProvedorTeste(t, servidor)
+
+	boleto, err := provedor.EmitirBoleto(context.Background(), bancos.SolicitacaoEmissao{
+		SeuNumero:   "123-001",
+		NossoNumero: "12345678",
+		Carteira:    "109",
+		Valor:       250.75,
+		Emissao:     time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC),
+		Vencimento:  time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+		Pagador: bancos.Pagador{Documento: "12345678000195", Nome: "CLIENTE EXEMPLO LTDA", Logradouro: "Rua das Flores",
+			Numero: "100", Bairro: "Centro", Cidade: "São Paulo", UF: "SP", CEP: "01001000"},
+		TipoJuros: models.JurosTaxaMensal,
+		TaxaJuros: 1,
+		TipoMulta: models.EncargoPercentual,
+		TaxaMulta: 2,
+		Descontos: []models.DescontoBoleto{{DataLimite: time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC), Tipo: models.EncargoValor, Valor: 5}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, codigoBarrasItau, boleto.CodigoBarras)
+	assert.Equal(t, "12345678", boleto.NossoNumero)
+	assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+
+	assert.Equal(t, "005700123457", requisicao.Beneficiario.IDBeneficiario)
+	assert.Equal(t, "00000000000025075", requisicao.DadoBoleto.ValorTotal)
+	assert.Equal(t, "J", requisicao.DadoBoleto.Pagador.Pessoa.TipoPessoa.Codigo)
+	assert.Equal(t, "Rua das Flores 100", requisicao.DadoBoleto.Pagador.Endereco.Logradouro)
+	assert.Equal(t, "2023-11-30", requisicao.DadoBoleto.DadosIndividuais[0].DataVencimento)
+	assert.Equal(t, "123-001", requisicao.DadoBoleto.DadosIndividuais[0].SeuNumero)
+	assert.Equal(t, &encargoItau{Codigo: "90", Percentual: "000000100000"}, requisicao.DadoBoleto.Juros)
+	assert.Equal(t, &encargoItau{Codigo: "02", Percentual: "000000200000"}, requisicao.DadoBoleto.Multa)
+	assert.Equal(t, "00000000000000500", requisicao.DadoBoleto.Desconto.Faixas[0].Valor)
+
+	assert.NoError(t, provedor.AlterarVencimento(context.Background(), boleto, time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC)))
+	assert.NoError(t, provedor.BaixarBoleto(context.Background(), boleto))
+	assert.Equal(t, []string{
+		`PATCH /boletos/00570012345710912345678/data_vencimento {"data_vencimento":"2023-12-15"}`,
+		`PATCH /boletos/00570012345710912345678/baixa {"codigo_baixa":"OUTROS"}`,
+	}, operacoes)
+}
+
+func TestWebhookItau(t *testing.T) {
+	provedor, err := New(config.BankAPIConfig{URL: "https://api.itau.test", WebhookSecret: "segredo-webhook"}, logrus.New())
+	assert.NoError(t, err)
+	corpo, err := os.ReadFile(filepath.Join("testdata", "webhook_pagamento.json"))
+	assert.NoError(t, err)
+
+	// HMAC-SHA256 do corpo em hexadecimal
+	requisicao := bancos.RequisicaoWebhook{Cabecalhos: http.Header{}, Corpo: corpo}
+	requisicao.Cabecalhos.Set("x-itau-signature", "assinatura-invalida")
+	assert.ErrorIs(t, provedor.AutenticarWebhook(requisicao), bancos.ErrWebhookNaoAutenticado)
+	requisicao.Cabecalhos.Set("x-itau-signature", assinaturaTeste(corpo, "segredo-webhook"))
+	assert.NoError(t, provedor.AutenticarWebhook(requisicao))
+
+	notificacoes, err := provedor.LerWebhook(corpo)
+	assert.NoError(t, err)
+	assert.Len(t, notificacoes, 1)
+	notificacao := notificacoes[0]
+	assert.Equal(t, "5d1f0c9a-7e21-4b8c-9f10-000000000001", notificacao.Evento)
+	assert.Equal(t, "12345678", notificacao.NossoNumero)
+	assert.Equal(t, codigoBarrasItau, notificacao.CodigoBarras)
+	assert.Equal(t, models.StatusBoletoPago, notificacao.Status)
+	assert.Equal(t, 253.26, notificacao.ValorPago)
+	assert.Equal(t, "2023-12-04", notificacao.DataPagamento.Format("2006-01-02"))
+
+	_, err = provedor.LerWebhook([]byte(`{"id_notificacao":"1","data":{}}`))
+	assert.ErrorIs(t, err, bancos.ErrWebhookInvalido)
+}
+
+func assinaturaTeste(corpo []byte, segredo string) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(corpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,37 @@
+package itau
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+)
+
+// cabecalhoAssinatura traz o HMAC-SHA256 do corpo das notificações
+const cabecalhoAssinatura = "x-itau-signature"
+
+// notificacaoItau é o corpo do webhook de notificação de boletos, com o boleto no
+// mesmo formato da consulta
+type notificacaoItau struct {
+	IDNotificacao string     `json:"id_notificacao"`
+	Data          boletoItau `json:"data"`
+}
+
+// AutenticarWebhook verifica a assinatura no cabeçalho x-itau-signature e o
+// certificado de cliente configurados
+func (p *Provedor) AutenticarWebhook(requisicao bancos.RequisicaoWebhook) error {
+	return bancos.AutenticarWebhook(requisicao, p.config, cabecalhoAssinatura)
+}
+
+// LerWebhook converte a notificação de pagamento ou baixa do boleto
+func (p *Provedor) LerWebhook(corpo []byte) ([]bancos.NotificacaoPagamento, error) {
+	var notificacao notificacaoItau
+	if err := json.Unmarshal(corpo, &notificacao); err != nil {
+		return nil, fmt.Errorf("%w: %v", bancos.ErrWebhookInvalido, err)
+	}
+	boleto, err := p.converter(notificacao.Data, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", bancos.ErrWebhookInvalido, err)
+	}
+	return []bancos.NotificacaoPagamento{bancos.NotificacaoBoleto(notificacao.IDNotificacao, boleto)}, nil
+}
@@ -0,0 +1,114 @@
+package bancos
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// validadeAssercaoJWT é a validade das asserções enviadas ao endpoint de token
+const validadeAssercaoJWT = time.Hour
+
+// AssinadorJWT gera asserções JWT (RFC 7523) assinadas com RS256 ou, no perfil
+// FAPI do Open Finance, com PS256
+type AssinadorJWT struct {
+	chave     *rsa.PrivateKey
+	clientID  string
+	audiencia string
+	algoritmo string
+	keyID     string
+	agora     func() time.Time
+}
+
+// NovoAssinadorJWT carrega a chave privada RSA (PEM, PKCS#1 ou PKCS#8) que assina as
+// asserções do client ID para o endpoint de token informado
+func NovoAssinadorJWT(caminhoChave, clientID, audiencia string) (*AssinadorJWT, error) {
+	conteudo, err := os.ReadFile(caminhoChave)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave de assinatura JWT: %w", err)
+	}
+	chave, err := chavePrivadaRSA(conteudo)
+	if err != nil {
+		return nil, err
+	}
+	return &AssinadorJWT{chave: chave, clientID: clientID, audiencia: audiencia, algoritmo: "RS256", agora: time.Now}, nil
+}
+
+// ComPS256 assina com RSASSA-PSS (PS256), exigido pelo perfil FAPI, identificando a
+// chave pelo kid publicado no diretório
+func (a *AssinadorJWT) ComPS256(keyID string) *AssinadorJWT {
+	a.algoritmo = "PS256"
+	a.keyID = keyID
+	return a
+}
+
+// Assercao gera uma nova asserção assinada
+func (a *AssinadorJWT) Assercao() (string, error) {
+	agora := a.agora()
+	cabecalho := map[string]string{"alg": a.algoritmo, "typ": "JWT"}
+	if a.keyID != "" {
+		cabecalho["kid"] = a.keyID
+	}
+	declaracoes := map[string]interface{}{
+		"aud": a.audiencia,
+		"iss": a.clientID,
+		"sub": a.clientID,
+		"iat": agora.Unix(),
+		"exp": agora.Add(validadeAssercaoJWT).Unix(),
+		"jti": strconv.FormatInt(agora.UnixNano(), 10),
+		"ver": "1.1",
+	}
+
+	partes := make([]string, 0, 3)
+	for _, parte := range []interface{}{cabecalho, declaracoes} {
+		conteudo, err := json.Marshal(parte)
+		if err != nil {
+			return "", err
+		}
+		partes = append(partes, base64.RawURLEncoding.EncodeToString(conteudo))
+	}
+
+	assinado := partes[0] + "." + partes[1]
+	resumo := sha256.Sum256([]byte(assinado))
+	var assinatura []byte
+	var err error
+	if a.algoritmo == "PS256" {
+		assinatura, err = rsa.SignPSS(rand.Reader, a.chave, crypto.SHA256, resumo[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	} else {
+		assinatura, err = rsa.SignPKCS1v15(rand.Reader, a.chave, crypto.SHA256, resumo[:])
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar asserção JWT: %w", err)
+	}
+	return assinado + "." + base64.RawURLEncoding.EncodeToString(assinatura), nil
+}
+
+// chavePrivadaRSA decodifica uma chave privada RSA em PEM
+func chavePrivadaRSA(conteudo []byte) (*rsa.PrivateKey, error) {
+	bloco, _ := pem.Decode(conteudo)
+	if bloco == nil {
+		return nil, errors.New("chave de assinatura JWT não está em formato PEM")
+	}
+	if chave, err := x509.ParsePKCS1PrivateKey(bloco.Bytes); err == nil {
+		return chave, nil
+	}
+	chave, err := x509.ParsePKCS8PrivateKey(bloco.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar chave de assinatura JWT: %w", err)
+	}
+	rsaChave, ok := chave.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("chave de assinatura JWT deve ser RSA")
+	}
+	return rsaChave, nil
+}
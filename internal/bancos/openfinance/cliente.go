@@ -0,0 +1,271 @@
+package openfinance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Recursos das APIs do Open Finance Brasil usados pelo cliente
+const (
+	caminhoConsentimentos = "/open-banking/consents/v2/consents"
+	caminhoContas         = "/open-banking/accounts/v2/accounts"
+)
+
+// PermissoesPadrao são as permissões pedidas quando o consentimento não informa
+// outras: leitura das contas e das transações
+var PermissoesPadrao = []string{"ACCOUNTS_READ", "ACCOUNTS_TRANSACTIONS_READ", "RESOURCES_READ"}
+
+// validadeConsentimentoPadrao é usada quando o pedido não informa a expiração
+const validadeConsentimentoPadrao = 365 * 24 * time.Hour
+
+// Cliente acessa as APIs de consentimento e de contas de uma instituição do Open
+// Finance no perfil FAPI: mTLS em todas as chamadas e autenticação do cliente por
+// private_key_jwt assinado com PS256
+type Cliente struct {
+	config config.BankAPIConfig
+	api    *bancos.ClienteAPI
+	tokens *bancos.GerenciadorToken
+	logger *logrus.Logger
+}
+
+// NovoCliente cria o cliente a partir da configuração do Open Finance. O token
+// client credentials (escopo "consents") é usado nas chamadas de consentimento; as
+// contas são lidas com o token do consentimento autorizado
+func NovoCliente(cfg config.BankAPIConfig, logger *logrus.Logger) (*Cliente, error) {
+	if cfg.JWTKeyPath == "" {
+		return nil, errors.New("chave de assinatura JWT do Open Finance não configurada")
+	}
+	assinador, err := bancos.NovoAssinadorJWT(cfg.JWTKeyPath, cfg.ClientID, cfg.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	cliente, err := bancos.NovoClienteHTTP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Scope == "" {
+		cfg.Scope = "consents"
+	}
+
+	tokens := bancos.NovoGerenciadorToken(cfg, cliente, logger).ComPrivateKeyJWT(assinador.ComPS256(cfg.JWTKeyID).Assercao)
+	api := bancos.NovoClienteAPI(cfg.URL, cliente, tokens)
+	api.Cabecalhos = func(req *http.Request) {
+		req.Header.Set("x-fapi-interaction-id", idInteracao())
+	}
+	return &Cliente{config: cfg, api: api, tokens: tokens, logger: logger}, nil
+}
+
+// Consentimento é o recurso de consentimento retornado pela instituição
+type Consentimento struct {
+	ConsentID          string    `json:"consentId"`
+	Status             string    `json:"status"`
+	Permissions        []string  `json:"permissions"`
+	ExpirationDateTime time.Time `json:"expirationDateTime"`
+	CreationDateTime   time.Time `json:"creationDateTime"`
+}
+
+type respostaConsentimento struct {
+	Data Consentimento `json:"data"`
+}
+
+// CriarConsentimento registra um consentimento para o CPF (e CNPJ, quando a conta é
+// de pessoa jurídica) com as permissões informadas
+func (c *Cliente) CriarConsentimento(ctx context.Context, cpf, cnpj string, permissoes []string, expiraEm time.Time) (*Consentimento, error) {
+	if len(permissoes) == 0 {
+		permissoes = PermissoesPadrao
+	}
+	if expiraEm.IsZero() {
+		expiraEm = time.Now().Add(validadeConsentimentoPadrao)
+	}
+
+	type documento struct {
+		Identification string `json:"identification"`
+		Rel            string `json:"rel"`
+	}
+	dados := map[string]interface{}{
+		"loggedUser":         map[string]documento{"document": {Identification: apenasDigitos(cpf), Rel: "CPF"}},
+		"permissions":        permissoes,
+		"expirationDateTime": expiraEm.UTC().Format(time.RFC3339),
+	}
+	if cnpj != "" {
+		dados["businessEntity"] = map[string]documento{"document": {Identification: apenasDigitos(cnpj), Rel: "CNPJ"}}
+	}
+
+	var resposta respostaConsentimento
+	if err := c.api.Executar(ctx, http.MethodPost, caminhoConsentimentos, nil, map[string]interface{}{"data": dados}, &resposta); err != nil {
+		return nil, fmt.Errorf("erro ao criar consentimento: %w", err)
+	}
+	return &resposta.Data, nil
+}
+
+// ConsultarConsentimento retorna a situação atual do consentimento
+func (c *Cliente) ConsultarConsentimento(ctx context.Context, consentID string) (*Consentimento, error) {
+	var resposta respostaConsentimento
+	if err := c.api.Executar(ctx, http.MethodGet, caminhoConsentimentos+"/"+url.PathEscape(consentID), nil, nil, &resposta); err != nil {
+		if bancos.NaoEncontrado(err) {
+			return nil, ErrConsentimentoNaoEncontrado
+		}
+		return nil, fmt.Errorf("erro ao consultar consentimento: %w", err)
+	}
+	return &resposta.Data, nil
+}
+
+// RevogarConsentimento revoga o consentimento na instituição
+func (c *Cliente) RevogarConsentimento(ctx context.Context, consentID string) error {
+	err := c.api.Executar(ctx, http.MethodDelete, caminhoConsentimentos+"/"+url.PathEscape(consentID), nil, nil, nil)
+	if bancos.NaoEncontrado(err) {
+		return ErrConsentimentoNaoEncontrado
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao revogar consentimento: %w", err)
+	}
+	return nil
+}
+
+// URLAutorizacao monta o endereço para onde o titular é enviado para aprovar o
+// consentimento; state é devolvido no redirect junto com o código de autorização
+func (c *Cliente) URLAutorizacao(consentID, state string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.config.ClientID},
+		"redirect_uri":  {c.config.RedirectURI},
+		"scope":         {"openid accounts consent:" + consentID},
+		"state":         {state},
+	}
+	return c.config.AuthURL + "?" + query.Encode()
+}
+
+// TrocarCodigo troca o código de autorização recebido no redirect pelos tokens do
+// consentimento
+func (c *Cliente) TrocarCodigo(ctx context.Context, codigo string) (*bancos.RespostaToken, error) {
+	return c.tokens.SolicitarToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {codigo},
+		"redirect_uri": {c.config.RedirectURI},
+	})
+}
+
+// RenovarToken obtém um novo token de acesso do consentimento pelo refresh token
+func (c *Cliente) RenovarToken(ctx context.Context, refreshToken string) (*bancos.RespostaToken, error) {
+	return c.tokens.SolicitarToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+// Conta é uma conta de depósito ou pagamento do titular
+type Conta struct {
+	AccountID   string `json:"accountId"`
+	BrandName   string `json:"brandName"`
+	CompanyCNPJ string `json:"companyCnpj"`
+	Type        string `json:"type"`
+	CompeCode   string `json:"compeCode"`
+	BranchCode  string `json:"branchCode"`
+	Number      string `json:"number"`
+	CheckDigit  string `json:"checkDigit"`
+}
+
+// Transacao é um lançamento da conta; o valor vem como texto com duas casas decimais
+type Transacao struct {
+	TransactionID                  string `json:"transactionId"`
+	CompletedAuthorisedPaymentType string `json:"completedAuthorisedPaymentType"`
+	CreditDebitType                string `json:"creditDebitType"`
+	TransactionName                string `json:"transactionName"`
+	Type                           string `json:"type"`
+	TransactionAmount              struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	} `json:"transactionAmount"`
+	TransactionDateTime string `json:"transactionDateTime"`
+}
+
+// Valor converte o valor da transação
+func (t Transacao) Valor() (float64, error) {
+	return strconv.ParseFloat(t.TransactionAmount.Amount, 64)
+}
+
+// Data converte a data e hora da transação
+func (t Transacao) Data() (time.Time, error) {
+	return time.Parse(time.RFC3339, t.TransactionDateTime)
+}
+
+// limitePaginas evita laços quando a instituição devolve sempre o mesmo link
+const limitePaginas = 50
+
+// ListarContas lista as contas compartilhadas pelo consentimento
+func (c *Cliente) ListarContas(ctx context.Context, tokenAcesso string) ([]Conta, error) {
+	contas, err := paginar[Conta](ctx, c.api.ComToken(tokenAcesso), caminhoContas, nil)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar contas: %w", err)
+	}
+	return contas, nil
+}
+
+// ListarTransacoes lista os lançamentos da conta entre as datas informadas
+func (c *Cliente) ListarTransacoes(ctx context.Context, tokenAcesso, contaID string, de, ate time.Time) ([]Transacao, error) {
+	query := url.Values{
+		"fromBookingDate": {de.Format(time.DateOnly)},
+		"toBookingDate":   {ate.Format(time.DateOnly)},
+	}
+	caminho := caminhoContas + "/" + url.PathEscape(contaID) + "/transactions"
+	transacoes, err := paginar[Transacao](ctx, c.api.ComToken(tokenAcesso), caminho, query)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar transações: %w", err)
+	}
+	return transacoes, nil
+}
+
+// paginar percorre a lista seguindo links.next, que já traz a query da próxima página
+func paginar[T any](ctx context.Context, api *bancos.ClienteAPI, caminho string, query url.Values) ([]T, error) {
+	var itens []T
+	for pagina := 0; caminho != ""; pagina++ {
+		if pagina == limitePaginas {
+			return nil, fmt.Errorf("mais de %d páginas em %s", limitePaginas, caminho)
+		}
+		var resposta struct {
+			Data  []T `json:"data"`
+			Links struct {
+				Next string `json:"next"`
+			} `json:"links"`
+		}
+		if err := api.Executar(ctx, http.MethodGet, caminho, query, nil, &resposta); err != nil {
+			return nil, err
+		}
+		itens = append(itens, resposta.Data...)
+		caminho, query = resposta.Links.Next, nil
+	}
+	return itens, nil
+}
+
+// idInteracao gera o x-fapi-interaction-id (UUID v4) de cada requisição
+func idInteracao() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// apenasDigitos remove a pontuação de CPF e CNPJ
+func apenasDigitos(documento string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, documento)
+}
@@ -0,0 +1,233 @@
+package openfinance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrConsentimentoNaoEncontrado indica um consentimento desconhecido
+	ErrConsentimentoNaoEncontrado = errors.New("consentimento não encontrado")
+	// ErrConsentimentoNaoAutorizado indica que o titular não aprovou o consentimento
+	ErrConsentimentoNaoAutorizado = errors.New("consentimento não autorizado pelo titular")
+)
+
+// Consentimentos mantém no banco de dados os consentimentos criados na instituição
+// e os tokens obtidos com a autorização de cada um
+type Consentimentos struct {
+	cliente *Cliente
+	db      *gorm.DB
+	logger  *logrus.Logger
+	agora   func() time.Time
+
+	// mu serializa a renovação de tokens, que podem ser rotacionados pela instituição
+	mu sync.Mutex
+}
+
+// NovoConsentimentos cria o gerenciador de consentimentos
+func NovoConsentimentos(cliente *Cliente, db *gorm.DB, logger *logrus.Logger) *Consentimentos {
+	return &Consentimentos{cliente: cliente, db: db, logger: logger, agora: time.Now}
+}
+
+// Criar registra o consentimento na instituição e retorna, em URLAutorizacao, o
+// endereço onde o titular deve aprová-lo
+func (g *Consentimentos) Criar(ctx context.Context, req models.CriarConsentimentoRequest) (*models.ConsentimentoOpenFinance, error) {
+	var expiraEm time.Time
+	if req.ExpiraEm != nil {
+		expiraEm = *req.ExpiraEm
+	}
+	recurso, err := g.cliente.CriarConsentimento(ctx, req.CPF, req.CNPJ, req.Permissoes, expiraEm)
+	if err != nil {
+		return nil, err
+	}
+
+	consentimento := &models.ConsentimentoOpenFinance{
+		ConsentID:  recurso.ConsentID,
+		Status:     recurso.Status,
+		CPF:        apenasDigitos(req.CPF),
+		CNPJ:       apenasDigitos(req.CNPJ),
+		Permissoes: strings.Join(recurso.Permissions, ","),
+	}
+	if !recurso.ExpirationDateTime.IsZero() {
+		consentimento.ExpiraEm = &recurso.ExpirationDateTime
+	}
+	if err := g.db.Create(consentimento).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar consentimento: %w", err)
+	}
+
+	g.logger.WithField("consent_id", consentimento.ConsentID).Info("Consentimento do Open Finance criado")
+	g.preencherURL(consentimento)
+	return consentimento, nil
+}
+
+// Listar retorna os consentimentos registrados
+func (g *Consentimentos) Listar() ([]models.ConsentimentoOpenFinance, error) {
+	var consentimentos []models.ConsentimentoOpenFinance
+	if err := g.db.Order("created_at DESC").Find(&consentimentos).Error; err != nil {
+		return nil, err
+	}
+	for i := range consentimentos {
+		g.preencherURL(&consentimentos[i])
+	}
+	return consentimentos, nil
+}
+
+// Obter retorna o consentimento, atualizando na instituição a situação dos que
+// ainda aguardam a autorização do titular
+func (g *Consentimentos) Obter(ctx context.Context, consentID string) (*models.ConsentimentoOpenFinance, error) {
+	consentimento, err := g.carregar(consentID)
+	if err != nil {
+		return nil, err
+	}
+	if consentimento.Status == models.ConsentimentoAguardandoAutorizacao {
+		recurso, err := g.cliente.ConsultarConsentimento(ctx, consentID)
+		if err != nil {
+			return nil, err
+		}
+		if recurso.Status != consentimento.Status {
+			consentimento.Status = recurso.Status
+			if err := g.db.Save(consentimento).Error; err != nil {
+				return nil, fmt.Errorf("erro ao salvar consentimento: %w", err)
+			}
+		}
+	}
+	g.preencherURL(consentimento)
+	return consentimento, nil
+}
+
+// Autorizar troca o código recebido no redirect pelos tokens do consentimento e
+// confirma na instituição que o titular o aprovou
+func (g *Consentimentos) Autorizar(ctx context.Context, consentID, codigo string) (*models.ConsentimentoOpenFinance, error) {
+	consentimento, err := g.carregar(consentID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := g.cliente.TrocarCodigo(ctx, codigo)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao trocar código de autorização: %w", err)
+	}
+	recurso, err := g.cliente.ConsultarConsentimento(ctx, consentID)
+	if err != nil {
+		return nil, err
+	}
+
+	consentimento.Status = recurso.Status
+	if recurso.Status == models.ConsentimentoAutorizado {
+		g.aplicarTokens(consentimento, tokens)
+	}
+	if err := g.db.Save(consentimento).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar consentimento: %w", err)
+	}
+	if recurso.Status != models.ConsentimentoAutorizado {
+		return nil, ErrConsentimentoNaoAutorizado
+	}
+
+	g.logger.WithField("consent_id", consentID).Info("Consentimento do Open Finance autorizado")
+	return consentimento, nil
+}
+
+// Revogar revoga o consentimento na instituição e o remove. Um consentimento que a
+// instituição já não conhece é removido normalmente
+func (g *Consentimentos) Revogar(ctx context.Context, consentID string) error {
+	consentimento, err := g.carregar(consentID)
+	if err != nil {
+		return err
+	}
+	if err := g.cliente.RevogarConsentimento(ctx, consentID); err != nil && !errors.Is(err, ErrConsentimentoNaoEncontrado) {
+		return err
+	}
+	if err := g.db.Delete(consentimento).Error; err != nil {
+		return fmt.Errorf("erro ao remover consentimento: %w", err)
+	}
+
+	g.logger.WithField("consent_id", consentID).Info("Consentimento do Open Finance revogado")
+	return nil
+}
+
+// Autorizados retorna os consentimentos que podem ser usados para ler as contas
+func (g *Consentimentos) Autorizados() ([]models.ConsentimentoOpenFinance, error) {
+	var consentimentos []models.ConsentimentoOpenFinance
+	if err := g.db.Where("status = ?", models.ConsentimentoAutorizado).Find(&consentimentos).Error; err != nil {
+		return nil, err
+	}
+
+	agora := g.agora()
+	validos := consentimentos[:0]
+	for _, consentimento := range consentimentos {
+		if consentimento.Autorizado(agora) {
+			validos = append(validos, consentimento)
+		}
+	}
+	return validos, nil
+}
+
+// TokenAcesso retorna o token de acesso do consentimento, renovando-o pelo refresh
+// token quando expirado
+func (g *Consentimentos) TokenAcesso(ctx context.Context, consentimento *models.ConsentimentoOpenFinance) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Outra consulta pode ter renovado o token enquanto esta aguardava
+	if err := g.db.First(consentimento, consentimento.ID).Error; err != nil {
+		return "", fmt.Errorf("erro ao carregar consentimento: %w", err)
+	}
+	if consentimento.AccessToken != "" && consentimento.TokenExpiraEm != nil && g.agora().Before(*consentimento.TokenExpiraEm) {
+		return consentimento.AccessToken, nil
+	}
+	if consentimento.RefreshToken == "" {
+		return "", ErrConsentimentoNaoAutorizado
+	}
+
+	tokens, err := g.cliente.RenovarToken(ctx, consentimento.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("erro ao renovar token do consentimento: %w", err)
+	}
+	g.aplicarTokens(consentimento, tokens)
+	if err := g.db.Save(consentimento).Error; err != nil {
+		return "", fmt.Errorf("erro ao salvar consentimento: %w", err)
+	}
+	return consentimento.AccessToken, nil
+}
+
+// aplicarTokens guarda os tokens recebidos; o refresh token só é trocado quando a
+// instituição o rotaciona
+func (g *Consentimentos) aplicarTokens(consentimento *models.ConsentimentoOpenFinance, tokens *bancos.RespostaToken) {
+	expiraEm := tokens.ExpiraEm(g.agora())
+	consentimento.AccessToken = tokens.AccessToken
+	consentimento.TokenExpiraEm = &expiraEm
+	if tokens.RefreshToken != "" {
+		consentimento.RefreshToken = tokens.RefreshToken
+	}
+}
+
+// carregar busca o consentimento pelo identificador da instituição
+func (g *Consentimentos) carregar(consentID string) (*models.ConsentimentoOpenFinance, error) {
+	var consentimento models.ConsentimentoOpenFinance
+	err := g.db.Where("consent_id = ?", consentID).First(&consentimento).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConsentimentoNaoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &consentimento, nil
+}
+
+// preencherURL informa o endereço de autorização dos consentimentos pendentes. O
+// state é o próprio consent ID, para que o redirect identifique o consentimento
+func (g *Consentimentos) preencherURL(consentimento *models.ConsentimentoOpenFinance) {
+	if consentimento.Status == models.ConsentimentoAguardandoAutorizacao && g.cliente.config.AuthURL != "" {
+		consentimento.URLAutorizacao = g.cliente.URLAutorizacao(consentimento.ConsentID, consentimento.ConsentID)
+	}
+}
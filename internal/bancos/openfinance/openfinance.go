@@ -0,0 +1,186 @@
+// Package openfinance implementa o provedor de consulta de boletos via Open Finance
+// Brasil. Com os consentimentos de leitura das nossas próprias contas, o provedor
+// confirma o pagamento de boletos de qualquer banco pelos débitos nos extratos
+package openfinance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Janela de busca dos débitos em torno do vencimento; sem vencimento, os últimos
+// noventa dias
+const (
+	janelaAntesVencimento  = 60 * 24 * time.Hour
+	janelaDepoisVencimento = 60 * 24 * time.Hour
+	janelaSemVencimento    = 90 * 24 * time.Hour
+)
+
+func init() {
+	bancos.RegistrarFabrica("openfinance", func(cfg *config.Config, deps bancos.Dependencias) bancos.Provedor {
+		if cfg.Bank.OpenBanking.URL == "" || deps.DB == nil {
+			return nil
+		}
+		cliente, err := NovoCliente(cfg.Bank.OpenBanking, deps.Logger)
+		if err != nil {
+			deps.Logger.WithError(err).Error("Erro ao configurar provedor do Open Finance")
+			return nil
+		}
+		return New(cfg.Bank.OpenBanking, NovoConsentimentos(cliente, deps.DB, deps.Logger), deps.Logger)
+	})
+}
+
+// Provedor confirma pagamentos de boletos nas contas compartilhadas pelos
+// consentimentos autorizados
+type Provedor struct {
+	config         config.BankAPIConfig
+	consentimentos *Consentimentos
+	logger         *logrus.Logger
+	agora          func() time.Time
+}
+
+// New cria o provedor do Open Finance
+func New(cfg config.BankAPIConfig, consentimentos *Consentimentos, logger *logrus.Logger) *Provedor {
+	return &Provedor{config: cfg, consentimentos: consentimentos, logger: logger, agora: time.Now}
+}
+
+// Nome identifica o provedor
+func (p *Provedor) Nome() string { return "openfinance" }
+
+// Bancos retorna vazio: o Open Finance atende qualquer banco
+func (p *Provedor) Bancos() []string { return nil }
+
+// Timeout retorna o timeout configurado para a API
+func (p *Provedor) Timeout() time.Duration { return p.config.Timeout }
+
+// ConsultarBoleto procura, nos extratos das contas consentidas, o débito que pagou o
+// boleto: um lançamento que cite o código de barras ou a linha digitável. Um débito
+// de boleto com o mesmo valor pode ser de outro título e não confirma o pagamento.
+// Boletos não pagos resultam em ErrBoletoNaoEncontrado, deixando a situação para a
+// API do banco emissor
+func (p *Provedor) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	if consulta.CodigoBarras == "" {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	codigo, err := utils.ParseCodigoBoletoEm(consulta.CodigoBarras, p.agora())
+	if err != nil {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+
+	consentimentos, err := p.consentimentos.Autorizados()
+	if err != nil {
+		return nil, err
+	}
+	if len(consentimentos) == 0 {
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	p.logger.WithFields(logrus.Fields{
+		"codigo_barras":  codigo.CodigoBarras,
+		"consentimentos": len(consentimentos),
+	}).Info("Procurando pagamento no Open Finance")
+
+	de, ate := p.janela(codigo)
+	var falhas []error
+	for i := range consentimentos {
+		transacao, err := p.procurar(ctx, &consentimentos[i], codigo, de, ate)
+		if err != nil {
+			falhas = append(falhas, err)
+			continue
+		}
+		if transacao != nil {
+			return boletoPago(codigo, *transacao)
+		}
+	}
+	if len(falhas) > 0 {
+		return nil, errors.Join(falhas...)
+	}
+	return nil, bancos.ErrBoletoNaoEncontrado
+}
+
+// janela calcula o período dos extratos consultados
+func (p *Provedor) janela(codigo *utils.CodigoBoleto) (time.Time, time.Time) {
+	agora := p.agora()
+	if codigo.Vencimento == nil {
+		return agora.Add(-janelaSemVencimento), agora
+	}
+	de := codigo.Vencimento.Add(-janelaAntesVencimento)
+	ate := codigo.Vencimento.Add(janelaDepoisVencimento)
+	if ate.After(agora) {
+		ate = agora
+	}
+	return de, ate
+}
+
+// procurar percorre as contas do consentimento em busca do débito do boleto
+func (p *Provedor) procurar(ctx context.Context, consentimento *models.ConsentimentoOpenFinance, codigo *utils.CodigoBoleto, de, ate time.Time) (*Transacao, error) {
+	if ate.Before(de) {
+		return nil, nil
+	}
+	token, err := p.consentimentos.TokenAcesso(ctx, consentimento)
+	if err != nil {
+		return nil, err
+	}
+	contas, err := p.consentimentos.cliente.ListarContas(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conta := range contas {
+		transacoes, err := p.consentimentos.cliente.ListarTransacoes(ctx, token, conta.AccountID, de, ate)
+		if err != nil {
+			return nil, err
+		}
+		for i := range transacoes {
+			if confere(transacoes[i], codigo) {
+				return &transacoes[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// confere indica se a transação é o pagamento do boleto: um débito efetivado cuja
+// descrição cite o código de barras ou a linha digitável
+func confere(transacao Transacao, codigo *utils.CodigoBoleto) bool {
+	if transacao.CreditDebitType != "DEBITO" || transacao.CompletedAuthorisedPaymentType == "TRANSACAO_FUTURA" {
+		return false
+	}
+	descricao := utils.NormalizarCodigoBoleto(transacao.TransactionName)
+	return strings.Contains(descricao, codigo.CodigoBarras) ||
+		strings.Contains(descricao, utils.NormalizarCodigoBoleto(codigo.LinhaDigitavel))
+}
+
+// boletoPago monta o boleto com os dados do código de barras e o pagamento encontrado
+func boletoPago(codigo *utils.CodigoBoleto, transacao Transacao) (*models.Boleto, error) {
+	valorPago, err := transacao.Valor()
+	if err != nil {
+		return nil, err
+	}
+	dataPagamento, err := transacao.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	boleto := &models.Boleto{
+		Banco:          codigo.Banco,
+		CodigoBarras:   codigo.CodigoBarras,
+		LinhaDigitavel: codigo.LinhaDigitavel,
+		Valor:          codigo.Valor,
+		Status:         models.StatusBoletoPago,
+		DataPagamento:  &dataPagamento,
+		ValorPago:      &valorPago,
+	}
+	if codigo.Vencimento != nil {
+		boleto.Vencimento = *codigo.Vencimento
+	}
+	return boleto, nil
+}
@@ -0,0 +1,190 @@
+package openfinance
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/openfinance/openfinancetest"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// Boleto Bradesco de R$ 99,90 com vencimento em 15/08/2022
+const (
+	codigoBarras   = "23796907800000099903381090000001234500123450"
+	linhaDigitavel = "23793.38102 90000.001231 45001.234504 6 90780000009990"
+)
+
+var dataReferencia = time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+
+func novoAmbiente(t *testing.T, contas ...openfinancetest.Conta) (*openfinancetest.Servidor, *Consentimentos, *Provedor) {
+	servidor := openfinancetest.NovoServidor(t, contas...)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, db.AutoMigrate(&models.ConsentimentoOpenFinance{}))
+
+	logger := logrus.New()
+	cliente, err := NovoCliente(servidor.Config(), logger)
+	assert.NoError(t, err)
+	consentimentos := NovoConsentimentos(cliente, db, logger)
+	provedor := New(servidor.Config(), consentimentos, logger)
+	provedor.agora = func() time.Time { return dataReferencia }
+	return servidor, consentimentos, provedor
+}
+
+// autorizado cria e autoriza um consentimento
+func autorizado(t *testing.T, servidor *openfinancetest.Servidor, consentimentos *Consentimentos) *models.ConsentimentoOpenFinance {
+	criado, err := consentimentos.Criar(context.Background(), models.CriarConsentimentoRequest{CPF: "123.456.789-09"})
+	assert.NoError(t, err)
+	consentimento, err := consentimentos.Autorizar(context.Background(), criado.ConsentID, servidor.Autorizar(criado.ConsentID))
+	assert.NoError(t, err)
+	return consentimento
+}
+
+func TestCicloConsentimento(t *testing.T) {
+	servidor, consentimentos, _ := novoAmbiente(t)
+	ctx := context.Background()
+
+	criado, err := consentimentos.Criar(ctx, models.CriarConsentimentoRequest{CPF: "123.456.789-09", CNPJ: "12.345.678/0001-95"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ConsentimentoAguardandoAutorizacao, criado.Status)
+	assert.Equal(t, "12345678909", criado.CPF)
+	assert.Equal(t, "ACCOUNTS_READ,ACCOUNTS_TRANSACTIONS_READ,RESOURCES_READ", criado.Permissoes)
+	assert.NotNil(t, criado.ExpiraEm)
+
+	autorizacao, err := url.Parse(criado.URLAutorizacao)
+	assert.NoError(t, err)
+	assert.Equal(t, "openid accounts consent:"+criado.ConsentID, autorizacao.Query().Get("scope"))
+	assert.Equal(t, criado.ConsentID, autorizacao.Query().Get("state"))
+
+	// A situação é atualizada na instituição enquanto aguarda o titular
+	codigo := servidor.Autorizar(criado.ConsentID)
+	obtido, err := consentimentos.Obter(ctx, criado.ConsentID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ConsentimentoAutorizado, obtido.Status)
+	assert.False(t, obtido.Autorizado(time.Now()), "sem tokens o consentimento ainda não pode ser usado")
+
+	autorizado, err := consentimentos.Autorizar(ctx, criado.ConsentID, codigo)
+	assert.NoError(t, err)
+	assert.True(t, autorizado.Autorizado(time.Now()))
+	assert.NotEmpty(t, autorizado.AccessToken)
+	assert.NotEmpty(t, autorizado.RefreshToken)
+	assert.Empty(t, autorizado.URLAutorizacao)
+
+	validos, err := consentimentos.Autorizados()
+	assert.NoError(t, err)
+	assert.Len(t, validos, 1)
+
+	assert.NoError(t, consentimentos.Revogar(ctx, criado.ConsentID))
+	_, err = consentimentos.Obter(ctx, criado.ConsentID)
+	assert.ErrorIs(t, err, ErrConsentimentoNaoEncontrado)
+	lista, err := consentimentos.Listar()
+	assert.NoError(t, err)
+	assert.Empty(t, lista)
+}
+
+func TestAutorizarConsentimentoRejeitado(t *testing.T) {
+	servidor, consentimentos, _ := novoAmbiente(t)
+	ctx := context.Background()
+
+	criado, err := consentimentos.Criar(ctx, models.CriarConsentimentoRequest{CPF: "12345678909"})
+	assert.NoError(t, err)
+
+	_, err = consentimentos.Autorizar(ctx, criado.ConsentID, servidor.Rejeitar(criado.ConsentID))
+	assert.ErrorIs(t, err, ErrConsentimentoNaoAutorizado)
+
+	obtido, err := consentimentos.Obter(ctx, criado.ConsentID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ConsentimentoRejeitado, obtido.Status)
+	assert.Empty(t, obtido.AccessToken)
+
+	_, err = consentimentos.Autorizar(ctx, "urn:desconhecido", "codigo")
+	assert.ErrorIs(t, err, ErrConsentimentoNaoEncontrado)
+}
+
+func TestConsultarBoletoPago(t *testing.T) {
+	dia := func(d int) time.Time { return time.Date(2022, 8, d, 10, 30, 0, 0, time.UTC) }
+	contas := []openfinancetest.Conta{
+		{ID: "conta-1", Compe: "001", Transacoes: []openfinancetest.Transacao{
+			{ID: "t1", Tipo: "PIX", Natureza: "DEBITO", Descricao: "PIX ENVIADO", Valor: 99.90, Data: dia(10)},
+			{ID: "t2", Tipo: "BOLETO", Natureza: "CREDITO", Descricao: "BOLETO RECEBIDO", Valor: 99.90, Data: dia(11)},
+			{ID: "t3", Tipo: "BOLETO", Natureza: "DEBITO", Descricao: "PAGTO BOLETO", Valor: 99.90, Data: dia(12)},
+		}},
+		{ID: "conta-2", Compe: "341", Transacoes: []openfinancetest.Transacao{
+			{ID: "t4", Tipo: "BOLETO", Natureza: "DEBITO", Descricao: "PAGTO " + linhaDigitavel, Valor: 102.23, Data: dia(18)},
+		}},
+	}
+	servidor, consentimentos, provedor := novoAmbiente(t, contas...)
+	autorizado(t, servidor, consentimentos)
+
+	// Só o lançamento que cita a linha digitável confirma o pagamento, não o de mesmo valor
+	boleto, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: codigoBarras, CodigoBarras: codigoBarras, Banco: "237"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, boleto) {
+		assert.Equal(t, models.StatusBoletoPago, boleto.Status)
+		assert.Equal(t, "237", boleto.Banco)
+		assert.Equal(t, 99.90, boleto.Valor)
+		assert.Equal(t, "2022-08-15", boleto.Vencimento.Format(time.DateOnly))
+		if assert.NotNil(t, boleto.ValorPago) && assert.NotNil(t, boleto.DataPagamento) {
+			assert.Equal(t, 102.23, *boleto.ValorPago)
+			assert.Equal(t, "2022-08-18", boleto.DataPagamento.Format(time.DateOnly))
+		}
+	}
+	// Três lançamentos na primeira conta exigem duas páginas
+	assert.Equal(t, 2, servidor.Requisicoes["/open-banking/accounts/v2/accounts/conta-1/transactions"])
+}
+
+func TestConsultarBoletoSoPorValor(t *testing.T) {
+	servidor, consentimentos, provedor := novoAmbiente(t, openfinancetest.Conta{ID: "conta-1", Transacoes: []openfinancetest.Transacao{
+		{ID: "t1", Tipo: "BOLETO", Natureza: "DEBITO", Descricao: "PAGTO BOLETO BRADESCO", Valor: 99.90, Data: time.Date(2022, 8, 15, 9, 0, 0, 0, time.UTC)},
+	}})
+	consentimento := autorizado(t, servidor, consentimentos)
+
+	// Token expirado localmente: o refresh token é usado e rotacionado
+	expirado := time.Now().Add(-time.Minute)
+	consentimento.TokenExpiraEm = &expirado
+	assert.NoError(t, consentimentos.db.Save(consentimento).Error)
+	refreshAnterior := consentimento.RefreshToken
+
+	// O débito de boleto com o mesmo valor pode ser de outro título: não confirma o pagamento
+	boleto, err := provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: codigoBarras, CodigoBarras: codigoBarras, Banco: "237"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+	assert.Nil(t, boleto)
+	assert.Equal(t, 1, servidor.Requisicoes["/open-banking/accounts/v2/accounts/conta-1/transactions"])
+
+	var atualizado models.ConsentimentoOpenFinance
+	assert.NoError(t, consentimentos.db.First(&atualizado, consentimento.ID).Error)
+	assert.NotEqual(t, refreshAnterior, atualizado.RefreshToken)
+	assert.True(t, atualizado.TokenExpiraEm.After(time.Now()))
+}
+
+func TestConsultarBoletoNaoPago(t *testing.T) {
+	servidor, consentimentos, provedor := novoAmbiente(t, openfinancetest.Conta{ID: "conta-1", Transacoes: []openfinancetest.Transacao{
+		{ID: "t1", Tipo: "BOLETO", Natureza: "DEBITO", Descricao: "PAGTO BOLETO", Valor: 150.00, Data: time.Date(2022, 8, 15, 9, 0, 0, 0, time.UTC)},
+	}})
+	consulta := bancos.Consulta{Codigo: codigoBarras, CodigoBarras: codigoBarras, Banco: "237"}
+
+	// Sem consentimentos autorizados a instituição não é consultada
+	_, err := provedor.ConsultarBoleto(context.Background(), consulta)
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+	assert.Zero(t, servidor.Requisicoes["/open-banking/accounts/v2/accounts"])
+
+	autorizado(t, servidor, consentimentos)
+	_, err = provedor.ConsultarBoleto(context.Background(), consulta)
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+
+	// Sem código de barras não há como procurar o pagamento
+	_, err = provedor.ConsultarBoleto(context.Background(), bancos.Consulta{Codigo: "BOL001"})
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+}
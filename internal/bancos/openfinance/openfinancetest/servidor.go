@@ -0,0 +1,485 @@
+// Package openfinancetest fornece um servidor local que faz o papel de uma
+// instituição do Open Finance Brasil nos testes: servidor de autorização com mTLS e
+// private_key_jwt (PS256), API de consentimentos e API de contas
+package openfinancetest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+)
+
+// ClientID é o client ID registrado no servidor
+const ClientID = "cliente-open-finance"
+
+// tamanhoPagina é o número de transações por página, pequeno para exercitar a
+// paginação por links.next
+const tamanhoPagina = 2
+
+// Conta é uma conta do titular com os seus lançamentos
+type Conta struct {
+	ID         string
+	Compe      string
+	Transacoes []Transacao
+}
+
+// Transacao é um lançamento da conta
+type Transacao struct {
+	ID        string
+	Tipo      string // BOLETO, PIX, TED...
+	Natureza  string // DEBITO ou CREDITO
+	Descricao string
+	Valor     float64
+	Data      time.Time
+}
+
+// consentimento é o estado de um consentimento no servidor
+type consentimento struct {
+	ID          string
+	Status      string
+	Permissions []string
+	Expiracao   time.Time
+	Criacao     time.Time
+}
+
+// Servidor simula a instituição. Os consentimentos começam aguardando autorização;
+// Autorizar faz o papel do titular aprovando-o no aplicativo do banco
+type Servidor struct {
+	*httptest.Server
+
+	// Requisicoes conta as requisições por caminho
+	Requisicoes map[string]int
+
+	t         testing.TB
+	chave     *rsa.PrivateKey
+	diretorio string
+
+	mu             sync.Mutex
+	contas         []Conta
+	consentimentos map[string]*consentimento
+	codigos        map[string]string // código de autorização -> consentimento
+	acessos        map[string]string // access token -> consentimento
+	refresh        map[string]string // refresh token -> consentimento
+	sequencia      int
+}
+
+// NovoServidor inicia o servidor com TLS exigindo certificado de cliente e gera as
+// credenciais do cliente (certificado, chave mTLS e chave de assinatura) no
+// diretório temporário do teste
+func NovoServidor(t testing.TB, contas ...Conta) *Servidor {
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave: %v", err)
+	}
+
+	s := &Servidor{
+		Requisicoes:    make(map[string]int),
+		t:              t,
+		chave:          chave,
+		diretorio:      t.TempDir(),
+		contas:         contas,
+		consentimentos: make(map[string]*consentimento),
+		codigos:        make(map[string]string),
+		acessos:        make(map[string]string),
+		refresh:        make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/open-banking/consents/v2/consents", s.criarConsentimento)
+	mux.HandleFunc("/open-banking/consents/v2/consents/", s.consentimento)
+	mux.HandleFunc("/open-banking/accounts/v2/accounts", s.listarContas)
+	mux.HandleFunc("/open-banking/accounts/v2/accounts/", s.listarTransacoes)
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.Requisicoes[r.URL.Path]++
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	s.Server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	s.Server.StartTLS()
+	t.Cleanup(s.Close)
+
+	s.gravarCredenciais()
+	return s
+}
+
+// Config retorna a configuração do Open Finance apontando para o servidor
+func (s *Servidor) Config() config.BankAPIConfig {
+	return config.BankAPIConfig{
+		URL:         s.URL,
+		ClientID:    ClientID,
+		Timeout:     5 * time.Second,
+		TokenURL:    s.URL + "/token",
+		CertPath:    filepath.Join(s.diretorio, "cliente.crt"),
+		KeyPath:     filepath.Join(s.diretorio, "cliente.key"),
+		CAPath:      filepath.Join(s.diretorio, "servidor.crt"),
+		JWTKeyPath:  filepath.Join(s.diretorio, "cliente.key"),
+		JWTKeyID:    "chave-teste",
+		AuthURL:     s.URL + "/authorize",
+		RedirectURI: "https://helpdanfe.local/openfinance/retorno",
+	}
+}
+
+// Autorizar simula a aprovação do consentimento pelo titular e retorna o código de
+// autorização que a instituição enviaria no redirect
+func (s *Servidor) Autorizar(consentID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.consentimentos[consentID]
+	if !ok {
+		s.t.Fatalf("consentimento %s não existe", consentID)
+	}
+	c.Status = "AUTHORISED"
+	codigo := s.novoIdentificador("codigo")
+	s.codigos[codigo] = consentID
+	return codigo
+}
+
+// Rejeitar simula a recusa do consentimento pelo titular, que ainda recebe um código
+func (s *Servidor) Rejeitar(consentID string) string {
+	codigo := s.Autorizar(consentID)
+	s.mu.Lock()
+	s.consentimentos[consentID].Status = "REJECTED"
+	s.mu.Unlock()
+	return codigo
+}
+
+// ExpirarTokens invalida os tokens de acesso emitidos, forçando o uso do refresh token
+func (s *Servidor) ExpirarTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acessos = make(map[string]string)
+}
+
+// token atende as concessões client_credentials, authorization_code e refresh_token
+func (s *Servidor) token(w http.ResponseWriter, r *http.Request) {
+	if err := s.verificarCliente(r); err != nil {
+		responderErro(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.FormValue("grant_type") {
+	case "client_credentials":
+		responderJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token": "cc-" + s.novoIdentificador("token"), "token_type": "Bearer", "expires_in": 900, "scope": r.FormValue("scope"),
+		})
+	case "authorization_code":
+		consentID, ok := s.codigos[r.FormValue("code")]
+		if !ok {
+			responderErro(w, http.StatusBadRequest, "invalid_grant", "código inválido")
+			return
+		}
+		delete(s.codigos, r.FormValue("code"))
+		s.emitirTokens(w, consentID)
+	case "refresh_token":
+		consentID, ok := s.refresh[r.FormValue("refresh_token")]
+		if !ok {
+			responderErro(w, http.StatusBadRequest, "invalid_grant", "refresh token inválido")
+			return
+		}
+		delete(s.refresh, r.FormValue("refresh_token"))
+		s.emitirTokens(w, consentID)
+	default:
+		responderErro(w, http.StatusBadRequest, "unsupported_grant_type", r.FormValue("grant_type"))
+	}
+}
+
+// emitirTokens emite tokens vinculados ao consentimento, rotacionando o refresh token
+func (s *Servidor) emitirTokens(w http.ResponseWriter, consentID string) {
+	acesso, refresh := "at-"+s.novoIdentificador("token"), "rt-"+s.novoIdentificador("token")
+	s.acessos[acesso] = consentID
+	s.refresh[refresh] = consentID
+	responderJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": acesso, "refresh_token": refresh, "token_type": "Bearer", "expires_in": 900,
+	})
+}
+
+// verificarCliente confere o certificado mTLS e a asserção private_key_jwt (PS256)
+func (s *Servidor) verificarCliente(r *http.Request) error {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return fmt.Errorf("certificado de cliente ausente")
+	}
+	if r.FormValue("client_id") != ClientID {
+		return fmt.Errorf("client_id inválido")
+	}
+	if r.FormValue("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+		return fmt.Errorf("client_assertion_type inválido")
+	}
+
+	partes := strings.Split(r.FormValue("client_assertion"), ".")
+	if len(partes) != 3 {
+		return fmt.Errorf("client_assertion malformada")
+	}
+	var cabecalho, declaracoes map[string]interface{}
+	if err := decodificarParte(partes[0], &cabecalho); err != nil {
+		return err
+	}
+	if err := decodificarParte(partes[1], &declaracoes); err != nil {
+		return err
+	}
+	if cabecalho["alg"] != "PS256" || cabecalho["kid"] != "chave-teste" {
+		return fmt.Errorf("cabeçalho da asserção inválido: %v", cabecalho)
+	}
+	if declaracoes["iss"] != ClientID || declaracoes["sub"] != ClientID || declaracoes["aud"] != s.URL+"/token" {
+		return fmt.Errorf("declarações da asserção inválidas: %v", declaracoes)
+	}
+
+	assinatura, err := base64.RawURLEncoding.DecodeString(partes[2])
+	if err != nil {
+		return err
+	}
+	resumo := sha256.Sum256([]byte(partes[0] + "." + partes[1]))
+	return rsa.VerifyPSS(&s.chave.PublicKey, crypto.SHA256, resumo[:], assinatura, nil)
+}
+
+// criarConsentimento atende POST /consents, com o token client credentials
+func (s *Servidor) criarConsentimento(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer cc-") {
+		responderErro(w, http.StatusUnauthorized, "UNAUTHORIZED", "token client credentials exigido")
+		return
+	}
+	var pedido struct {
+		Data struct {
+			Permissions        []string  `json:"permissions"`
+			ExpirationDateTime time.Time `json:"expirationDateTime"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil || len(pedido.Data.Permissions) == 0 {
+		responderErro(w, http.StatusUnprocessableEntity, "PARAMETRO_INVALIDO", "pedido inválido")
+		return
+	}
+
+	s.mu.Lock()
+	c := &consentimento{
+		ID:          "urn:helpdanfe:" + s.novoIdentificador("consent"),
+		Status:      "AWAITING_AUTHORISATION",
+		Permissions: pedido.Data.Permissions,
+		Expiracao:   pedido.Data.ExpirationDateTime,
+		Criacao:     time.Now().UTC(),
+	}
+	s.consentimentos[c.ID] = c
+	s.mu.Unlock()
+
+	responderJSON(w, http.StatusCreated, map[string]interface{}{"data": representarConsentimento(c)})
+}
+
+// consentimento atende GET e DELETE /consents/{id}
+func (s *Servidor) consentimento(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer cc-") {
+		responderErro(w, http.StatusUnauthorized, "UNAUTHORIZED", "token client credentials exigido")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/open-banking/consents/v2/consents/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.consentimentos[id]
+	if !ok {
+		responderErro(w, http.StatusNotFound, "NAO_ENCONTRADO", "consentimento não encontrado")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		responderJSON(w, http.StatusOK, map[string]interface{}{"data": representarConsentimento(c)})
+	case http.MethodDelete:
+		c.Status = "REJECTED"
+		for token, consentID := range s.acessos {
+			if consentID == id {
+				delete(s.acessos, token)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// listarContas atende GET /accounts com o token do consentimento
+func (s *Servidor) listarContas(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.autorizado(w, r); !ok {
+		return
+	}
+	dados := make([]map[string]string, 0, len(s.contas))
+	for _, conta := range s.contas {
+		dados = append(dados, map[string]string{
+			"accountId": conta.ID, "compeCode": conta.Compe, "type": "CONTA_DEPOSITO_A_VISTA",
+			"brandName": "Banco Teste", "branchCode": "0001", "number": "12345", "checkDigit": "6",
+		})
+	}
+	responderJSON(w, http.StatusOK, map[string]interface{}{"data": dados, "links": map[string]string{}})
+}
+
+// listarTransacoes atende GET /accounts/{id}/transactions, paginado
+func (s *Servidor) listarTransacoes(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.autorizado(w, r); !ok {
+		return
+	}
+	caminho := strings.TrimPrefix(r.URL.Path, "/open-banking/accounts/v2/accounts/")
+	contaID, ok := strings.CutSuffix(caminho, "/transactions")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var conta *Conta
+	for i := range s.contas {
+		if s.contas[i].ID == contaID {
+			conta = &s.contas[i]
+		}
+	}
+	if conta == nil {
+		responderErro(w, http.StatusNotFound, "NAO_ENCONTRADO", "conta não encontrada")
+		return
+	}
+
+	de, errDe := time.Parse(time.DateOnly, r.URL.Query().Get("fromBookingDate"))
+	ate, errAte := time.Parse(time.DateOnly, r.URL.Query().Get("toBookingDate"))
+	if errDe != nil || errAte != nil {
+		responderErro(w, http.StatusUnprocessableEntity, "PARAMETRO_INVALIDO", "período inválido")
+		return
+	}
+
+	var filtradas []map[string]interface{}
+	for _, transacao := range conta.Transacoes {
+		dia := transacao.Data.Truncate(24 * time.Hour)
+		if dia.Before(de) || dia.After(ate) {
+			continue
+		}
+		filtradas = append(filtradas, map[string]interface{}{
+			"transactionId":                  transacao.ID,
+			"completedAuthorisedPaymentType": "TRANSACAO_EFETIVADA",
+			"creditDebitType":                transacao.Natureza,
+			"transactionName":                transacao.Descricao,
+			"type":                           transacao.Tipo,
+			"transactionAmount":              map[string]string{"amount": fmt.Sprintf("%.2f", transacao.Valor), "currency": "BRL"},
+			"transactionDateTime":            transacao.Data.UTC().Format(time.RFC3339),
+		})
+	}
+
+	pagina := 0
+	fmt.Sscanf(r.URL.Query().Get("page"), "%d", &pagina)
+	inicio := pagina * tamanhoPagina
+	if inicio > len(filtradas) {
+		inicio = len(filtradas)
+	}
+	fim := inicio + tamanhoPagina
+	links := map[string]string{}
+	if fim < len(filtradas) {
+		query := r.URL.Query()
+		query.Set("page", fmt.Sprint(pagina+1))
+		links["next"] = s.URL + r.URL.Path + "?" + query.Encode()
+	} else {
+		fim = len(filtradas)
+	}
+	responderJSON(w, http.StatusOK, map[string]interface{}{"data": filtradas[inicio:fim], "links": links})
+}
+
+// autorizado valida o token de acesso de um consentimento autorizado
+func (s *Servidor) autorizado(w http.ResponseWriter, r *http.Request) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	consentID, ok := s.acessos[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		responderErro(w, http.StatusUnauthorized, "UNAUTHORIZED", "token inválido")
+		return "", false
+	}
+	if s.consentimentos[consentID].Status != "AUTHORISED" {
+		responderErro(w, http.StatusForbidden, "FORBIDDEN", "consentimento não autorizado")
+		return "", false
+	}
+	return consentID, true
+}
+
+// gravarCredenciais grava o certificado e a chave do cliente e o certificado do
+// servidor, que faz o papel da autoridade certificadora
+func (s *Servidor) gravarCredenciais() {
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: ClientID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificado, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &s.chave.PublicKey, s.chave)
+	if err != nil {
+		s.t.Fatalf("erro ao gerar certificado do cliente: %v", err)
+	}
+
+	arquivos := map[string]*pem.Block{
+		"cliente.crt":  {Type: "CERTIFICATE", Bytes: certificado},
+		"cliente.key":  {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(s.chave)},
+		"servidor.crt": {Type: "CERTIFICATE", Bytes: s.Certificate().Raw},
+	}
+	for nome, bloco := range arquivos {
+		if err := os.WriteFile(filepath.Join(s.diretorio, nome), pem.EncodeToMemory(bloco), 0o600); err != nil {
+			s.t.Fatalf("erro ao gravar %s: %v", nome, err)
+		}
+	}
+}
+
+// novoIdentificador gera identificadores únicos e imprevisíveis
+func (s *Servidor) novoIdentificador(prefixo string) string {
+	s.sequencia++
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", prefixo, s.sequencia, hex.EncodeToString(b))
+}
+
+func representarConsentimento(c *consentimento) map[string]interface{} {
+	return map[string]interface{}{
+		"consentId":          c.ID,
+		"status":             c.Status,
+		"permissions":        c.Permissions,
+		"expirationDateTime": c.Expiracao.UTC().Format(time.RFC3339),
+		"creationDateTime":   c.Criacao.Format(time.RFC3339),
+	}
+}
+
+func decodificarParte(parte string, destino interface{}) error {
+	conteudo, err := base64.RawURLEncoding.DecodeString(parte)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(conteudo, destino)
+}
+
+func responderJSON(w http.ResponseWriter, status int, corpo interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(corpo)
+}
+
+func responderErro(w http.ResponseWriter, status int, codigo, mensagem string) {
+	responderJSON(w, status, map[string]interface{}{
+		"errors": []map[string]string{{"code": codigo, "title": codigo, "detail": mensagem}},
+	})
+}
@@ -0,0 +1,229 @@
+// Package pix implementa o cliente da API Pix padronizada pelo Banco Central, oferecida
+// pelos PSPs recebedores: cobranças imediatas (/cob), cobranças com vencimento
+// (/cobv), usadas nos boletos híbridos, e Pix recebidos (/pix). A autenticação é o
+// OAuth2 client credentials sobre mTLS
+package pix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EscopoPadrao são os escopos de leitura pedidos quando a configuração não informa outros
+const EscopoPadrao = "cob.read cobv.read pix.read"
+
+// Situações da cobrança
+const (
+	StatusAtiva             = "ATIVA"
+	StatusConcluida         = "CONCLUIDA"
+	StatusRemovidaRecebedor = "REMOVIDA_PELO_USUARIO_RECEBEDOR"
+	StatusRemovidaPSP       = "REMOVIDA_PELO_PSP"
+)
+
+// Tipos de cobrança, que são também os recursos da API
+const (
+	TipoCob  = "cob"
+	TipoCobV = "cobv"
+)
+
+// itensPorPagina é o tamanho de página pedido na consulta de Pix recebidos
+const itensPorPagina = 100
+
+// Erros do cliente da API Pix
+var (
+	// ErrTxIDInvalido indica um txid fora do padrão: 26 a 35 caracteres alfanuméricos
+	ErrTxIDInvalido = errors.New("txid inválido: deve ter de 26 a 35 letras ou dígitos")
+	// ErrCobrancaNaoEncontrada indica um txid sem cobrança no PSP
+	ErrCobrancaNaoEncontrada = errors.New("cobrança Pix não encontrada")
+	// ErrPixNaoEncontrado indica um endToEndId sem Pix recebido
+	ErrPixNaoEncontrado = errors.New("Pix não encontrado")
+)
+
+var formatoTxID = regexp.MustCompile(`^[a-zA-Z0-9]{26,35}$`)
+
+// TxIDValido informa se o txid segue o formato das cobranças da API Pix
+func TxIDValido(txid string) bool {
+	return formatoTxID.MatchString(txid)
+}
+
+// Cobranca é a cobrança imediata ou com vencimento, com os Pix que a pagaram
+type Cobranca struct {
+	// Tipo é o recurso em que a cobrança foi encontrada: cob ou cobv
+	Tipo       string `json:"tipo"`
+	TxID       string `json:"txid"`
+	Revisao    int    `json:"revisao"`
+	Status     string `json:"status"`
+	Calendario struct {
+		Criacao time.Time `json:"criacao"`
+		// Expiracao, em segundos, nas cobranças imediatas
+		Expiracao int `json:"expiracao,omitempty"`
+		// DataDeVencimento (AAAA-MM-DD) nas cobranças com vencimento
+		DataDeVencimento string `json:"dataDeVencimento,omitempty"`
+	} `json:"calendario"`
+	Valor struct {
+		Original string `json:"original"`
+	} `json:"valor"`
+	Chave    string `json:"chave"`
+	Location string `json:"location,omitempty"`
+	Pix      []Pix  `json:"pix,omitempty"`
+}
+
+// Pix é um pagamento recebido
+type Pix struct {
+	EndToEndID  string    `json:"endToEndId"`
+	TxID        string    `json:"txid,omitempty"`
+	Valor       string    `json:"valor"`
+	Horario     time.Time `json:"horario"`
+	InfoPagador string    `json:"infoPagador,omitempty"`
+}
+
+// Pagamento soma os Pix recebidos na cobrança e retorna o horário do último
+func (c *Cobranca) Pagamento() (float64, *time.Time, error) {
+	if len(c.Pix) == 0 {
+		return 0, nil, nil
+	}
+	var total float64
+	var ultimo time.Time
+	for _, pix := range c.Pix {
+		valor, err := strconv.ParseFloat(pix.Valor, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("valor do Pix %s inválido: %w", pix.EndToEndID, err)
+		}
+		total += valor
+		if pix.Horario.After(ultimo) {
+			ultimo = pix.Horario
+		}
+	}
+	return total, &ultimo, nil
+}
+
+// ConsultaPix filtra os Pix recebidos no período
+type ConsultaPix struct {
+	Inicio time.Time
+	Fim    time.Time
+	// TxID restringe aos Pix da cobrança, quando informado
+	TxID string
+}
+
+// Cliente acessa a API Pix de um PSP recebedor
+type Cliente struct {
+	api    *bancos.ClienteAPI
+	logger *logrus.Logger
+	agora  func() time.Time
+}
+
+// NovoCliente cria o cliente com mTLS e token client credentials
+func NovoCliente(cfg config.BankAPIConfig, logger *logrus.Logger) (*Cliente, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("URL da API Pix não configurada")
+	}
+	cliente, err := bancos.NovoClienteHTTP(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Scope == "" {
+		cfg.Scope = EscopoPadrao
+	}
+
+	tokens := bancos.NovoGerenciadorToken(cfg, cliente, logger)
+	return &Cliente{
+		api:    bancos.NovoClienteAPI(cfg.URL, cliente, tokens),
+		logger: logger,
+		agora:  time.Now,
+	}, nil
+}
+
+// ConsultarCobranca procura o txid nas cobranças com vencimento e nas imediatas.
+// Quando a cobrança concluída não traz os Pix, eles são buscados em /pix
+func (c *Cliente) ConsultarCobranca(ctx context.Context, txid string) (*Cobranca, error) {
+	if !TxIDValido(txid) {
+		return nil, ErrTxIDInvalido
+	}
+	c.logger.WithField("txid", txid).Info("Consultando cobrança na API Pix")
+
+	for _, tipo := range []string{TipoCobV, TipoCob} {
+		var cobranca Cobranca
+		err := c.api.Executar(ctx, http.MethodGet, "/"+tipo+"/"+txid, nil, nil, &cobranca)
+		if bancos.NaoEncontrado(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cobranca.Tipo = tipo
+		if cobranca.Status == StatusConcluida && len(cobranca.Pix) == 0 {
+			consulta := ConsultaPix{Inicio: cobranca.Calendario.Criacao, Fim: c.agora(), TxID: txid}
+			if cobranca.Pix, err = c.ListarPix(ctx, consulta); err != nil {
+				return nil, err
+			}
+		}
+		return &cobranca, nil
+	}
+	return nil, ErrCobrancaNaoEncontrada
+}
+
+// ListarPix lista os Pix recebidos no período, percorrendo todas as páginas
+func (c *Cliente) ListarPix(ctx context.Context, consulta ConsultaPix) ([]Pix, error) {
+	query := url.Values{}
+	query.Set("inicio", consulta.Inicio.UTC().Format(time.RFC3339))
+	query.Set("fim", consulta.Fim.UTC().Format(time.RFC3339))
+	if consulta.TxID != "" {
+		query.Set("txid", consulta.TxID)
+	}
+	query.Set("paginacao.itensPorPagina", strconv.Itoa(itensPorPagina))
+
+	var recebidos []Pix
+	for pagina := 0; ; pagina++ {
+		query.Set("paginacao.paginaAtual", strconv.Itoa(pagina))
+		var resposta respostaListaPix
+		if err := c.api.Executar(ctx, http.MethodGet, "/pix", query, nil, &resposta); err != nil {
+			// Alguns PSPs respondem 404 quando o período não tem Pix
+			if bancos.NaoEncontrado(err) {
+				return recebidos, nil
+			}
+			return nil, err
+		}
+		recebidos = append(recebidos, resposta.Pix...)
+		if pagina+1 >= resposta.Parametros.Paginacao.QuantidadeDePaginas {
+			return recebidos, nil
+		}
+	}
+}
+
+// ConsultarPix consulta um Pix recebido pelo identificador fim a fim
+func (c *Cliente) ConsultarPix(ctx context.Context, endToEndID string) (*Pix, error) {
+	var pix Pix
+	err := c.api.Executar(ctx, http.MethodGet, "/pix/"+url.PathEscape(endToEndID), nil, nil, &pix)
+	if bancos.NaoEncontrado(err) {
+		return nil, ErrPixNaoEncontrado
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pix, nil
+}
+
+// respostaListaPix é a resposta de GET /pix
+type respostaListaPix struct {
+	Parametros struct {
+		Paginacao struct {
+			PaginaAtual            int `json:"paginaAtual"`
+			ItensPorPagina         int `json:"itensPorPagina"`
+			QuantidadeDePaginas    int `json:"quantidadeDePaginas"`
+			QuantidadeTotalDeItens int `json:"quantidadeTotalDeItens"`
+		} `json:"paginacao"`
+	} `json:"parametros"`
+	Pix []Pix `json:"pix"`
+}
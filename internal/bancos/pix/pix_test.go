@@ -0,0 +1,114 @@
+package pix
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix/pixtest"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+)
+
+const (
+	txidCobV = "HELPDANFE000000000000000001"
+	txidCob  = "HELPDANFE000000000000000002"
+)
+
+func novoCliente(t *testing.T) (*pixtest.Servidor, *Cliente) {
+	servidor := pixtest.NovoServidor(t)
+	cliente, err := NovoCliente(servidor.Config(), logrus.New())
+	assert.NoError(t, err)
+	return servidor, cliente
+}
+
+func TestConsultarCobranca(t *testing.T) {
+	servidor, cliente := novoCliente(t)
+	servidor.CriarCobranca(TipoCobV, txidCobV, 250.75)
+	servidor.CriarCobranca(TipoCob, txidCob, 99.90)
+	ctx := context.Background()
+
+	cobranca, err := cliente.ConsultarCobranca(ctx, txidCobV)
+	assert.NoError(t, err)
+	assert.Equal(t, TipoCobV, cobranca.Tipo)
+	assert.Equal(t, StatusAtiva, cobranca.Status)
+	assert.Equal(t, "250.75", cobranca.Valor.Original)
+	assert.NotEmpty(t, cobranca.Calendario.DataDeVencimento)
+	assert.Empty(t, cobranca.Pix)
+
+	horario := time.Date(2023, 12, 4, 14, 30, 0, 0, time.UTC)
+	endToEndID := servidor.Pagar(txidCobV, 253.26, horario)
+	cobranca, err = cliente.ConsultarCobranca(ctx, txidCobV)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusConcluida, cobranca.Status)
+	assert.Len(t, cobranca.Pix, 1)
+	assert.Equal(t, endToEndID, cobranca.Pix[0].EndToEndID)
+	valor, pagoEm, err := cobranca.Pagamento()
+	assert.NoError(t, err)
+	assert.Equal(t, 253.26, valor)
+	assert.True(t, horario.Equal(*pagoEm))
+
+	// A cobrança imediata é encontrada depois de /cobv responder 404
+	cobranca, err = cliente.ConsultarCobranca(ctx, txidCob)
+	assert.NoError(t, err)
+	assert.Equal(t, TipoCob, cobranca.Tipo)
+	assert.Equal(t, 3600, cobranca.Calendario.Expiracao)
+	assert.Equal(t, 1, servidor.Requisicoes["/cobv/"+txidCob])
+	assert.Equal(t, 1, servidor.Requisicoes["/cob/"+txidCob])
+
+	_, err = cliente.ConsultarCobranca(ctx, "HELPDANFE000000000000000099")
+	assert.ErrorIs(t, err, ErrCobrancaNaoEncontrada)
+	_, err = cliente.ConsultarCobranca(ctx, "curto")
+	assert.ErrorIs(t, err, ErrTxIDInvalido)
+
+	// O token client credentials é reaproveitado entre as consultas
+	assert.Equal(t, 1, servidor.Requisicoes["/oauth/token"])
+}
+
+func TestConsultarCobrancaSemPix(t *testing.T) {
+	servidor, cliente := novoCliente(t)
+	servidor.OmitirPixNaCobranca = true
+	servidor.CriarCobranca(TipoCob, txidCob, 100)
+	servidor.CriarCobranca(TipoCob, txidCobV, 50)
+
+	// Três Pix da cobrança, em duas páginas, e um de outra cobrança
+	horario := time.Now().UTC().Add(-30 * time.Minute).Truncate(time.Second)
+	servidor.Pagar(txidCob, 40, horario)
+	servidor.Pagar(txidCobV, 50, horario.Add(time.Minute))
+	servidor.Pagar(txidCob, 30, horario.Add(2*time.Minute))
+	ultimo := servidor.Pagar(txidCob, 30, horario.Add(3*time.Minute))
+
+	cobranca, err := cliente.ConsultarCobranca(context.Background(), txidCob)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusConcluida, cobranca.Status)
+	assert.Len(t, cobranca.Pix, 3)
+	assert.Equal(t, 2, servidor.Requisicoes["/pix"])
+	valor, pagoEm, err := cobranca.Pagamento()
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, valor)
+	assert.True(t, horario.Add(3*time.Minute).Equal(*pagoEm))
+
+	pix, err := cliente.ConsultarPix(context.Background(), ultimo)
+	assert.NoError(t, err)
+	assert.Equal(t, txidCob, pix.TxID)
+	assert.Equal(t, "30.00", pix.Valor)
+	_, err = cliente.ConsultarPix(context.Background(), "E0000000000000000000000000000000")
+	assert.ErrorIs(t, err, ErrPixNaoEncontrado)
+}
+
+func TestNovoClienteSemCertificado(t *testing.T) {
+	servidor := pixtest.NovoServidor(t)
+	servidor.CriarCobranca(TipoCob, txidCob, 10)
+	cfg := servidor.Config()
+	cfg.CertPath, cfg.KeyPath = "", ""
+
+	cliente, err := NovoCliente(cfg, logrus.New())
+	assert.NoError(t, err)
+	_, err = cliente.ConsultarCobranca(context.Background(), txidCob)
+	assert.Error(t, err, "o PSP exige o certificado de cliente")
+
+	_, err = NovoCliente(config.BankAPIConfig{}, logrus.New())
+	assert.Error(t, err)
+}
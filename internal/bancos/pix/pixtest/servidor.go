@@ -0,0 +1,415 @@
+// Package pixtest fornece um servidor local que implementa a API Pix do Banco Central
+// nos testes: token OAuth2 client credentials com mTLS e os recursos /cob, /cobv e
+// /pix, com os erros no formato problem+json da especificação
+package pixtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+)
+
+// Credenciais do cliente registrado no PSP
+const (
+	ClientID     = "cliente-pix"
+	ClientSecret = "segredo-pix"
+)
+
+// Chave é a chave Pix do recebedor nas cobranças
+const Chave = "12345678000195"
+
+// tamanhoPagina limita os Pix por página, pequeno para exercitar a paginação
+const tamanhoPagina = 2
+
+// ispb identifica o PSP pagador nos endToEndId gerados
+const ispb = "12345678"
+
+// cobranca é o estado de uma cobrança no servidor
+type cobranca struct {
+	Tipo       string
+	TxID       string
+	Revisao    int
+	Status     string
+	Criacao    time.Time
+	Vencimento time.Time
+	Valor      float64
+}
+
+// recebido é um Pix recebido, vinculado ou não a uma cobrança
+type recebido struct {
+	EndToEndID string
+	TxID       string
+	Valor      float64
+	Horario    time.Time
+}
+
+// Servidor simula o PSP recebedor. As cobranças começam ATIVA; Pagar faz o papel do
+// pagador liquidando-as
+type Servidor struct {
+	*httptest.Server
+
+	// Requisicoes conta as requisições por caminho
+	Requisicoes map[string]int
+	// OmitirPixNaCobranca responde as cobranças sem a lista de Pix, que o cliente
+	// precisa buscar em /pix
+	OmitirPixNaCobranca bool
+
+	t         testing.TB
+	diretorio string
+
+	mu        sync.Mutex
+	cobrancas map[string]*cobranca
+	recebidos []recebido
+	tokens    map[string][]string // access token -> escopos
+	sequencia int
+}
+
+// NovoServidor inicia o servidor com TLS exigindo certificado de cliente e grava as
+// credenciais do cliente no diretório temporário do teste
+func NovoServidor(t testing.TB) *Servidor {
+	s := &Servidor{
+		Requisicoes: make(map[string]int),
+		t:           t,
+		diretorio:   t.TempDir(),
+		cobrancas:   make(map[string]*cobranca),
+		tokens:      make(map[string][]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", s.token)
+	mux.HandleFunc("/cob/", s.consultarCobranca)
+	mux.HandleFunc("/cobv/", s.consultarCobranca)
+	mux.HandleFunc("/pix", s.listarPix)
+	mux.HandleFunc("/pix/", s.consultarPix)
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.Requisicoes[r.URL.Path]++
+		s.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	s.Server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	s.Server.StartTLS()
+	t.Cleanup(s.Close)
+
+	s.gravarCredenciais()
+	return s
+}
+
+// Config retorna a configuração da API Pix apontando para o servidor
+func (s *Servidor) Config() config.BankAPIConfig {
+	return config.BankAPIConfig{
+		URL:          s.URL,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		Timeout:      5 * time.Second,
+		TokenURL:     s.URL + "/oauth/token",
+		CertPath:     filepath.Join(s.diretorio, "cliente.crt"),
+		KeyPath:      filepath.Join(s.diretorio, "cliente.key"),
+		CAPath:       filepath.Join(s.diretorio, "servidor.crt"),
+	}
+}
+
+// CriarCobranca cadastra uma cobrança ATIVA do tipo informado (cob ou cobv)
+func (s *Servidor) CriarCobranca(tipo, txid string, valor float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agora := time.Now().UTC().Truncate(time.Second)
+	s.cobrancas[txid] = &cobranca{
+		Tipo:       tipo,
+		TxID:       txid,
+		Status:     "ATIVA",
+		Criacao:    agora.Add(-time.Hour),
+		Vencimento: agora.AddDate(0, 0, 5),
+		Valor:      valor,
+	}
+}
+
+// Pagar registra um Pix para a cobrança, que passa a CONCLUIDA, e retorna o
+// endToEndId gerado
+func (s *Servidor) Pagar(txid string, valor float64, horario time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cobrancas[txid]
+	if !ok {
+		s.t.Fatalf("cobrança %s não existe", txid)
+	}
+	s.sequencia++
+	endToEndID := fmt.Sprintf("E%s%s%011d", ispb, horario.UTC().Format("200601021504"), s.sequencia)
+	s.recebidos = append(s.recebidos, recebido{EndToEndID: endToEndID, TxID: txid, Valor: valor, Horario: horario.UTC()})
+	c.Status = "CONCLUIDA"
+	c.Revisao++
+	return endToEndID
+}
+
+// Remover faz o papel do recebedor cancelando a cobrança
+func (s *Servidor) Remover(txid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cobrancas[txid]
+	if !ok {
+		s.t.Fatalf("cobrança %s não existe", txid)
+	}
+	c.Status = "REMOVIDA_PELO_USUARIO_RECEBEDOR"
+	c.Revisao++
+}
+
+// token atende a concessão client_credentials com client_secret_basic sobre mTLS
+func (s *Servidor) token(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		responderErro(w, http.StatusUnauthorized, "AcessoNegado", "certificado de cliente ausente")
+		return
+	}
+	id, segredo, ok := r.BasicAuth()
+	if !ok || id != ClientID || segredo != ClientSecret {
+		responderErro(w, http.StatusUnauthorized, "AcessoNegado", "credenciais do cliente inválidas")
+		return
+	}
+	if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" {
+		responderErro(w, http.StatusBadRequest, "RequisicaoInvalida", "concessão não suportada")
+		return
+	}
+
+	s.mu.Lock()
+	s.sequencia++
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	token := fmt.Sprintf("pix-%d-%s", s.sequencia, hex.EncodeToString(b))
+	s.tokens[token] = strings.Fields(r.FormValue("scope"))
+	s.mu.Unlock()
+
+	responderJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token, "token_type": "Bearer", "expires_in": 3600, "scope": r.FormValue("scope"),
+	})
+}
+
+// consultarCobranca atende GET /cob/{txid} e GET /cobv/{txid}
+func (s *Servidor) consultarCobranca(w http.ResponseWriter, r *http.Request) {
+	tipo, txid, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !s.autorizado(w, r, tipo+".read") {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cobrancas[txid]
+	if !ok || c.Tipo != tipo {
+		responderErro(w, http.StatusNotFound, "CobrancaNaoEncontrada", "Cobrança não encontrada para o txid informado.")
+		return
+	}
+
+	calendario := map[string]interface{}{"criacao": c.Criacao.Format(time.RFC3339)}
+	if tipo == "cobv" {
+		calendario["dataDeVencimento"] = c.Vencimento.Format(time.DateOnly)
+		calendario["validadeAposVencimento"] = 30
+	} else {
+		calendario["expiracao"] = 3600
+	}
+	resposta := map[string]interface{}{
+		"txid":       c.TxID,
+		"revisao":    c.Revisao,
+		"status":     c.Status,
+		"calendario": calendario,
+		"valor":      map[string]string{"original": formatarValor(c.Valor)},
+		"chave":      Chave,
+		"location":   fmt.Sprintf("%s/v2/%s/%s", strings.TrimPrefix(s.URL, "https://"), tipo, c.TxID),
+	}
+	if !s.OmitirPixNaCobranca {
+		var pix []map[string]string
+		for _, p := range s.recebidos {
+			if p.TxID == txid {
+				pix = append(pix, representarPix(p))
+			}
+		}
+		if len(pix) > 0 {
+			resposta["pix"] = pix
+		}
+	}
+	responderJSON(w, http.StatusOK, resposta)
+}
+
+// listarPix atende GET /pix, com o período obrigatório e paginado
+func (s *Servidor) listarPix(w http.ResponseWriter, r *http.Request) {
+	if !s.autorizado(w, r, "pix.read") {
+		return
+	}
+	query := r.URL.Query()
+	inicio, errInicio := time.Parse(time.RFC3339, query.Get("inicio"))
+	fim, errFim := time.Parse(time.RFC3339, query.Get("fim"))
+	if errInicio != nil || errFim != nil || fim.Before(inicio) {
+		responderErro(w, http.StatusBadRequest, "ConsultaInvalida", "período inválido")
+		return
+	}
+	pagina, _ := strconv.Atoi(query.Get("paginacao.paginaAtual"))
+	itens, _ := strconv.Atoi(query.Get("paginacao.itensPorPagina"))
+	if itens <= 0 || itens > tamanhoPagina {
+		itens = tamanhoPagina
+	}
+
+	s.mu.Lock()
+	var filtrados []recebido
+	for _, p := range s.recebidos {
+		if p.Horario.Before(inicio) || p.Horario.After(fim) {
+			continue
+		}
+		if txid := query.Get("txid"); txid != "" && p.TxID != txid {
+			continue
+		}
+		filtrados = append(filtrados, p)
+	}
+	s.mu.Unlock()
+	sort.Slice(filtrados, func(i, j int) bool { return filtrados[i].Horario.Before(filtrados[j].Horario) })
+
+	paginas := (len(filtrados) + itens - 1) / itens
+	pix := []map[string]string{}
+	for i := pagina * itens; i < len(filtrados) && i < (pagina+1)*itens; i++ {
+		pix = append(pix, representarPix(filtrados[i]))
+	}
+	responderJSON(w, http.StatusOK, map[string]interface{}{
+		"parametros": map[string]interface{}{
+			"inicio": query.Get("inicio"),
+			"fim":    query.Get("fim"),
+			"paginacao": map[string]int{
+				"paginaAtual": pagina, "itensPorPagina": itens,
+				"quantidadeDePaginas": paginas, "quantidadeTotalDeItens": len(filtrados),
+			},
+		},
+		"pix": pix,
+	})
+}
+
+// consultarPix atende GET /pix/{e2eid}
+func (s *Servidor) consultarPix(w http.ResponseWriter, r *http.Request) {
+	if !s.autorizado(w, r, "pix.read") {
+		return
+	}
+	endToEndID := strings.TrimPrefix(r.URL.Path, "/pix/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.recebidos {
+		if p.EndToEndID == endToEndID {
+			responderJSON(w, http.StatusOK, representarPix(p))
+			return
+		}
+	}
+	responderErro(w, http.StatusNotFound, "PixNaoEncontrado", "Pix não encontrado para o e2eid informado.")
+}
+
+// autorizado valida o token de acesso e o escopo exigido pelo recurso
+func (s *Servidor) autorizado(w http.ResponseWriter, r *http.Request, escopo string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	escopos, ok := s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		responderErro(w, http.StatusUnauthorized, "AcessoNegado", "token inválido")
+		return false
+	}
+	for _, concedido := range escopos {
+		if concedido == escopo {
+			return true
+		}
+	}
+	responderErro(w, http.StatusForbidden, "AcessoNegado", "escopo "+escopo+" não concedido")
+	return false
+}
+
+// gravarCredenciais grava o certificado e a chave do cliente e o certificado do
+// servidor, que faz o papel da autoridade certificadora
+func (s *Servidor) gravarCredenciais() {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		s.t.Fatalf("erro ao gerar chave: %v", err)
+	}
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: ClientID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificado, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	if err != nil {
+		s.t.Fatalf("erro ao gerar certificado do cliente: %v", err)
+	}
+	chaveDER, err := x509.MarshalECPrivateKey(chave)
+	if err != nil {
+		s.t.Fatalf("erro ao serializar chave: %v", err)
+	}
+
+	arquivos := map[string][]byte{
+		"cliente.crt":  pemBloco("CERTIFICATE", certificado),
+		"cliente.key":  pemBloco("EC PRIVATE KEY", chaveDER),
+		"servidor.crt": pemBloco("CERTIFICATE", s.Certificate().Raw),
+	}
+	for nome, conteudo := range arquivos {
+		if err := os.WriteFile(filepath.Join(s.diretorio, nome), conteudo, 0o600); err != nil {
+			s.t.Fatalf("erro ao gravar %s: %v", nome, err)
+		}
+	}
+}
+
+func pemBloco(tipo string, conteudo []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: tipo, Bytes: conteudo})
+}
+
+func representarPix(p recebido) map[string]string {
+	return map[string]string{
+		"endToEndId": p.EndToEndID,
+		"txid":       p.TxID,
+		"valor":      formatarValor(p.Valor),
+		"horario":    p.Horario.Format(time.RFC3339),
+	}
+}
+
+// formatarValor usa o formato da especificação: ponto decimal e duas casas
+func formatarValor(valor float64) string {
+	return strconv.FormatFloat(valor, 'f', 2, 64)
+}
+
+func responderJSON(w http.ResponseWriter, status int, corpo interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(corpo)
+}
+
+// responderErro responde no formato problem+json (RFC 7807) adotado pela API Pix
+func responderErro(w http.ResponseWriter, status int, tipo, detalhe string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"type":   "https://pix.bcb.gov.br/api/v2/error/" + tipo,
+		"title":  tipo,
+		"status": status,
+		"detail": detalhe,
+	})
+}
@@ -0,0 +1,303 @@
+package bancos
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// margemRenovacaoToken é a antecedência com que o token é renovado antes de expirar
+const margemRenovacaoToken = time.Minute
+
+// ErrTokenNaoConfigurado indica que a API não tem TokenURL configurada
+var ErrTokenNaoConfigurado = errors.New("endpoint de token OAuth2 não configurado")
+
+// NovoClienteHTTP cria o cliente HTTP da API bancária, com o certificado do cliente
+// para mTLS quando CertPath e KeyPath estão configurados e as autoridades de CAPath
+func NovoClienteHTTP(cfg config.BankAPIConfig) (*http.Client, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = TimeoutPadrao
+	}
+	cliente := &http.Client{Timeout: timeout}
+
+	if cfg.CertPath == "" && cfg.KeyPath == "" && cfg.CAPath == "" {
+		return cliente, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CertPath != "" || cfg.KeyPath != "" {
+		certificado, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao carregar certificado mTLS: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificado}
+	}
+	// Autoridades próprias, como a ICP do Open Finance Brasil, que não estão no sistema
+	if cfg.CAPath != "" {
+		conteudo, err := os.ReadFile(cfg.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler autoridades certificadoras: %w", err)
+		}
+		raizes := x509.NewCertPool()
+		if !raizes.AppendCertsFromPEM(conteudo) {
+			return nil, fmt.Errorf("nenhum certificado válido em %s", cfg.CAPath)
+		}
+		tlsConfig.RootCAs = raizes
+	}
+
+	transporte := http.DefaultTransport.(*http.Transport).Clone()
+	transporte.TLSClientConfig = tlsConfig
+	cliente.Transport = transporte
+	return cliente, nil
+}
+
+// RespostaToken é a resposta do endpoint de token (RFC 6749, seção 5.1)
+type RespostaToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// ExpiraEm retorna até quando o token deve ser reutilizado, já descontada a margem
+// de renovação
+func (r *RespostaToken) ExpiraEm(agora time.Time) time.Time {
+	return agora.Add(validadeToken(r.ExpiresIn))
+}
+
+// Formas de autenticação do cliente no endpoint de token
+const (
+	// autenticacaoBasic envia client ID e secret em HTTP Basic (client_secret_basic)
+	autenticacaoBasic = iota
+	// autenticacaoJWTBearer usa a asserção JWT como concessão (RFC 7523, seção 2.1)
+	autenticacaoJWTBearer
+	// autenticacaoPrivateKeyJWT autentica o cliente com uma asserção JWT
+	// (private_key_jwt, RFC 7523 seção 2.2), exigida pelo Open Finance Brasil
+	autenticacaoPrivateKeyJWT
+)
+
+// chamadaToken é uma obtenção de token em andamento, compartilhada por todos os
+// chamadores que precisam de token enquanto ela não termina
+type chamadaToken struct {
+	pronto chan struct{}
+	token  string
+	err    error
+}
+
+// GerenciadorToken obtém e mantém em cache o token OAuth2 client credentials de uma
+// API bancária. É seguro para uso concorrente: quando o token expira, apenas uma
+// requisição de renovação é feita e os demais chamadores aguardam o resultado
+type GerenciadorToken struct {
+	config       config.BankAPIConfig
+	cliente      *http.Client
+	logger       *logrus.Logger
+	agora        func() time.Time
+	autenticacao int
+	assercao     func() (string, error)
+
+	mu          sync.Mutex
+	token       string
+	expiraEm    time.Time
+	emAndamento *chamadaToken
+}
+
+// NovoGerenciadorToken cria o gerenciador de token da API, usando o cliente HTTP
+// informado (normalmente criado por NovoClienteHTTP, com mTLS)
+func NovoGerenciadorToken(cfg config.BankAPIConfig, cliente *http.Client, logger *logrus.Logger) *GerenciadorToken {
+	return &GerenciadorToken{
+		config:  cfg,
+		cliente: cliente,
+		logger:  logger,
+		agora:   time.Now,
+	}
+}
+
+// ComAssercaoJWT troca a autenticação por client secret pelo fluxo JWT bearer
+// (RFC 7523): cada obtenção de token envia uma asserção gerada pela função informada
+func (g *GerenciadorToken) ComAssercaoJWT(assercao func() (string, error)) *GerenciadorToken {
+	g.autenticacao = autenticacaoJWTBearer
+	g.assercao = assercao
+	return g
+}
+
+// ComPrivateKeyJWT autentica o cliente com uma asserção JWT assinada
+// (private_key_jwt) em vez do client secret, mantendo a concessão client credentials
+func (g *GerenciadorToken) ComPrivateKeyJWT(assercao func() (string, error)) *GerenciadorToken {
+	g.autenticacao = autenticacaoPrivateKeyJWT
+	g.assercao = assercao
+	return g
+}
+
+// Token retorna um token válido, obtendo um novo quando o atual está a menos de um
+// minuto de expirar
+func (g *GerenciadorToken) Token(ctx context.Context) (string, error) {
+	if g.config.TokenURL == "" {
+		return "", ErrTokenNaoConfigurado
+	}
+
+	g.mu.Lock()
+	if g.token != "" && g.agora().Before(g.expiraEm) {
+		token := g.token
+		g.mu.Unlock()
+		return token, nil
+	}
+	chamada := g.emAndamento
+	if chamada == nil {
+		chamada = &chamadaToken{pronto: make(chan struct{})}
+		g.emAndamento = chamada
+		go g.renovar(chamada)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-chamada.pronto:
+		return chamada.token, chamada.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Invalidar descarta o token em cache, por exemplo após a API responder 401
+func (g *GerenciadorToken) Invalidar() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.token = ""
+	g.expiraEm = time.Time{}
+}
+
+// renovar obtém um novo token e o entrega a todos os chamadores que aguardam. A
+// requisição não usa o contexto de quem a disparou, para que o cancelamento de um
+// chamador não derrube a renovação dos demais; o limite é o timeout do cliente
+func (g *GerenciadorToken) renovar(chamada *chamadaToken) {
+	g.logger.WithFields(logrus.Fields{
+		"token_url": g.config.TokenURL,
+		"client_id": g.config.ClientID,
+	}).Info("Solicitando token OAuth2")
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if g.config.Scope != "" {
+		form.Set("scope", g.config.Scope)
+	}
+	resposta, err := g.SolicitarToken(context.Background(), form)
+
+	g.mu.Lock()
+	if err == nil {
+		g.token = resposta.AccessToken
+		g.expiraEm = resposta.ExpiraEm(g.agora())
+		chamada.token = resposta.AccessToken
+	}
+	chamada.err = err
+	g.emAndamento = nil
+	g.mu.Unlock()
+	close(chamada.pronto)
+}
+
+// SolicitarToken envia ao endpoint de token a concessão informada, autenticando o
+// cliente na forma configurada. É usado diretamente nas concessões que não são
+// mantidas em cache pelo gerenciador, como authorization_code e refresh_token
+func (g *GerenciadorToken) SolicitarToken(ctx context.Context, concessao url.Values) (*RespostaToken, error) {
+	if g.config.TokenURL == "" {
+		return nil, ErrTokenNaoConfigurado
+	}
+
+	form := url.Values{}
+	for chave, valores := range concessao {
+		form[chave] = valores
+	}
+	var assercao string
+	if g.assercao != nil {
+		var err error
+		if assercao, err = g.assercao(); err != nil {
+			return nil, fmt.Errorf("erro ao gerar asserção JWT: %w", err)
+		}
+	}
+	switch g.autenticacao {
+	case autenticacaoJWTBearer:
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assercao)
+	case autenticacaoPrivateKeyJWT:
+		form.Set("client_id", g.config.ClientID)
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", assercao)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição de token: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if g.autenticacao == autenticacaoBasic {
+		req.SetBasicAuth(url.QueryEscape(g.config.ClientID), url.QueryEscape(g.config.ClientSecret))
+	}
+
+	resp, err := g.cliente.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao solicitar token: %s", g.redigir(err.Error(), assercao, form.Get("code"), form.Get("refresh_token")))
+	}
+	defer resp.Body.Close()
+
+	corpo, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta do token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("endpoint de token respondeu %d: %s", resp.StatusCode, g.redigir(string(corpo), assercao, form.Get("code"), form.Get("refresh_token")))
+	}
+
+	var resposta RespostaToken
+	if err := json.Unmarshal(corpo, &resposta); err != nil {
+		return nil, fmt.Errorf("resposta de token inválida: %w", err)
+	}
+	if resposta.AccessToken == "" {
+		return nil, fmt.Errorf("resposta de token sem access_token")
+	}
+	if resposta.TokenType != "" && !strings.EqualFold(resposta.TokenType, "bearer") {
+		return nil, fmt.Errorf("tipo de token %q não suportado", resposta.TokenType)
+	}
+	return &resposta, nil
+}
+
+// redigir remove o client secret, a asserção JWT e os demais segredos da concessão
+// (código de autorização, refresh token) de textos que podem ir para logs ou
+// mensagens de erro, como respostas de erro que ecoam a requisição
+func (g *GerenciadorToken) redigir(texto string, segredos ...string) string {
+	credenciais := base64.StdEncoding.EncodeToString([]byte(url.QueryEscape(g.config.ClientID) + ":" + url.QueryEscape(g.config.ClientSecret)))
+	segredos = append(segredos, g.config.ClientSecret, url.QueryEscape(g.config.ClientSecret), credenciais)
+	for _, segredo := range segredos {
+		if segredo != "" {
+			texto = strings.ReplaceAll(texto, segredo, "***")
+		}
+	}
+	return texto
+}
+
+// validadeToken calcula por quanto tempo o token é reutilizado: a validade informada
+// menos a margem de renovação, ou metade da validade para tokens muito curtos
+func validadeToken(expiresIn int) time.Duration {
+	if expiresIn <= 0 {
+		return 0
+	}
+	validade := time.Duration(expiresIn) * time.Second
+	if validade <= 2*margemRenovacaoToken {
+		return validade / 2
+	}
+	return validade - margemRenovacaoToken
+}
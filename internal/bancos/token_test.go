@@ -0,0 +1,152 @@
+package bancos
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+)
+
+// servidorToken simula o endpoint de token do banco, contando as emissões
+func servidorToken(atraso time.Duration, emitidos *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "cliente" || secret != "segredo-super" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error":"invalid_client","client_secret":"%s"}`, secret)
+			return
+		}
+		time.Sleep(atraso)
+		n := atomic.AddInt32(emitidos, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":300}`, n)
+	}))
+}
+
+func TestGerenciadorTokenCache(t *testing.T) {
+	var emitidos int32
+	servidor := servidorToken(50*time.Millisecond, &emitidos)
+	defer servidor.Close()
+
+	cfg := config.BankAPIConfig{TokenURL: servidor.URL, ClientID: "cliente", ClientSecret: "segredo-super"}
+	gerenciador := NovoGerenciadorToken(cfg, servidor.Client(), logrus.New())
+	agora := time.Now()
+	gerenciador.agora = func() time.Time { return agora }
+
+	// Chamadas concorrentes compartilham uma única emissão
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := gerenciador.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&emitidos))
+
+	// Dentro da validade, o token vem do cache
+	agora = agora.Add(3 * time.Minute)
+	token, _ := gerenciador.Token(context.Background())
+	assert.Equal(t, "token-1", token)
+
+	// A menos de um minuto de expirar, é renovado
+	agora = agora.Add(time.Minute + time.Second)
+	token, _ = gerenciador.Token(context.Background())
+	assert.Equal(t, "token-2", token)
+
+	gerenciador.Invalidar()
+	token, _ = gerenciador.Token(context.Background())
+	assert.Equal(t, "token-3", token)
+}
+
+func TestGerenciadorTokenRedigeSegredo(t *testing.T) {
+	var emitidos int32
+	servidor := servidorToken(0, &emitidos)
+	defer servidor.Close()
+
+	cfg := config.BankAPIConfig{TokenURL: servidor.URL, ClientID: "outro", ClientSecret: "segredo-super"}
+	gerenciador := NovoGerenciadorToken(cfg, servidor.Client(), logrus.New())
+
+	_, err := gerenciador.Token(context.Background())
+	assert.ErrorContains(t, err, "401")
+	assert.NotContains(t, err.Error(), "segredo-super")
+	assert.NotContains(t, fmt.Sprint(cfg), "segredo-super")
+
+	_, err = NovoGerenciadorToken(config.BankAPIConfig{}, servidor.Client(), logrus.New()).Token(context.Background())
+	assert.ErrorIs(t, err, ErrTokenNaoConfigurado)
+}
+
+func TestNovoClienteHTTPMTLS(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := gerarCertificado(t, dir)
+
+	servidor := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"token-mtls","token_type":"bearer","expires_in":3600}`)
+	}))
+	servidor.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	servidor.StartTLS()
+	defer servidor.Close()
+
+	cfg := config.BankAPIConfig{TokenURL: servidor.URL, ClientID: "cliente", ClientSecret: "segredo", CertPath: certPath, KeyPath: keyPath}
+	cliente, err := NovoClienteHTTP(cfg)
+	assert.NoError(t, err)
+
+	// Confia no certificado do servidor de teste
+	raizes := x509.NewCertPool()
+	raizes.AddCert(servidor.Certificate())
+	cliente.Transport.(*http.Transport).TLSClientConfig.RootCAs = raizes
+
+	token, err := NovoGerenciadorToken(cfg, cliente, logrus.New()).Token(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "token-mtls", token)
+
+	_, err = NovoClienteHTTP(config.BankAPIConfig{CertPath: filepath.Join(dir, "inexistente.crt"), KeyPath: keyPath})
+	assert.Error(t, err)
+}
+
+// gerarCertificado grava um certificado de cliente autoassinado e sua chave
+func gerarCertificado(t *testing.T, dir string) (string, string) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	assert.NoError(t, err)
+	chaveDER, err := x509.MarshalECPrivateKey(chave)
+	assert.NoError(t, err)
+
+	certPath := filepath.Join(dir, "cliente.crt")
+	keyPath := filepath.Join(dir, "cliente.key")
+	assert.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: chaveDER}), 0o600))
+	return certPath, keyPath
+}
@@ -0,0 +1,202 @@
+package bancos
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// Erros do recebimento de webhooks
+var (
+	// ErrWebhookNaoSuportado indica que nenhum provedor do banco recebe webhooks
+	ErrWebhookNaoSuportado = errors.New("banco sem provedor de webhooks")
+	// ErrWebhookNaoConfigurado indica um provedor sem segredo HMAC nem certificados aceitos
+	ErrWebhookNaoConfigurado = errors.New("autenticação do webhook não configurada")
+	// ErrWebhookNaoAutenticado indica assinatura ou certificado de cliente inválido
+	ErrWebhookNaoAutenticado = errors.New("webhook não autenticado")
+	// ErrWebhookInvalido indica um corpo que o provedor não reconhece
+	ErrWebhookInvalido = errors.New("notificação de webhook inválida")
+)
+
+// RequisicaoWebhook é a notificação recebida, com o necessário para autenticá-la
+type RequisicaoWebhook struct {
+	Cabecalhos http.Header
+	Corpo      []byte
+	// Certificados é a cadeia apresentada pelo cliente no mTLS, da conexão ou repassada
+	// pelo proxy
+	Certificados []*x509.Certificate
+}
+
+// NotificacaoPagamento é um evento de liquidação ou baixa de um boleto emitido
+type NotificacaoPagamento struct {
+	// Evento identifica a notificação no banco, quando informado
+	Evento       string
+	NossoNumero  string
+	SeuNumero    string
+	CodigoBarras string
+	// Status com as constantes de models.Boleto
+	Status        string
+	ValorPago     float64
+	DataPagamento *time.Time
+}
+
+// NotificacaoBoleto monta o evento a partir do boleto convertido pelo provedor
+func NotificacaoBoleto(evento string, boleto *models.Boleto) NotificacaoPagamento {
+	notificacao := NotificacaoPagamento{
+		Evento:        evento,
+		NossoNumero:   boleto.NossoNumero,
+		CodigoBarras:  boleto.CodigoBarras,
+		Status:        boleto.Status,
+		DataPagamento: boleto.DataPagamento,
+	}
+	if boleto.ValorPago != nil {
+		notificacao.ValorPago = *boleto.ValorPago
+	}
+	return notificacao
+}
+
+// ProvedorWebhook é implementado pelos provedores que recebem as notificações de
+// pagamento enviadas pelo banco
+type ProvedorWebhook interface {
+	Provedor
+	// AutenticarWebhook retorna ErrWebhookNaoAutenticado quando a requisição não veio
+	// do banco
+	AutenticarWebhook(requisicao RequisicaoWebhook) error
+	// LerWebhook extrai os eventos do corpo; retorna ErrWebhookInvalido quando o corpo
+	// não é reconhecido
+	LerWebhook(corpo []byte) ([]NotificacaoPagamento, error)
+}
+
+// ProvedorWebhook retorna o primeiro provedor do banco que recebe webhooks
+func (r *Registro) ProvedorWebhook(banco string) (ProvedorWebhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, provedor := range r.porBanco[banco] {
+		if webhook, ok := provedor.(ProvedorWebhook); ok {
+			return webhook, nil
+		}
+	}
+	return nil, ErrWebhookNaoSuportado
+}
+
+// AutenticarWebhook verifica os mecanismos configurados no provedor: a assinatura
+// HMAC-SHA256 do corpo no cabeçalho informado e o certificado de cliente do mTLS,
+// pela impressão digital SHA-256. Com os dois configurados, ambos são exigidos
+func AutenticarWebhook(requisicao RequisicaoWebhook, cfg config.BankAPIConfig, cabecalhoAssinatura string) error {
+	if cfg.WebhookSecret == "" && len(cfg.WebhookCertificados) == 0 {
+		return ErrWebhookNaoConfigurado
+	}
+	if cfg.WebhookSecret != "" && !assinaturaValida(requisicao.Corpo, cfg.WebhookSecret, requisicao.Cabecalhos.Get(cabecalhoAssinatura)) {
+		return fmt.Errorf("%w: assinatura HMAC inválida", ErrWebhookNaoAutenticado)
+	}
+	if len(cfg.WebhookCertificados) > 0 && !certificadoAceito(requisicao.Certificados, cfg.WebhookCertificados) {
+		return fmt.Errorf("%w: certificado de cliente não aceito", ErrWebhookNaoAutenticado)
+	}
+	return nil
+}
+
+// assinaturaValida compara em tempo constante o HMAC-SHA256 do corpo com a assinatura
+// em hexadecimal ou base64, com ou sem o prefixo "sha256="
+func assinaturaValida(corpo []byte, segredo, assinatura string) bool {
+	assinatura = strings.TrimPrefix(strings.TrimSpace(assinatura), "sha256=")
+	if assinatura == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(corpo)
+	esperada := mac.Sum(nil)
+
+	if recebida, err := hex.DecodeString(assinatura); err == nil && hmac.Equal(recebida, esperada) {
+		return true
+	}
+	recebida, err := base64.StdEncoding.DecodeString(assinatura)
+	return err == nil && hmac.Equal(recebida, esperada)
+}
+
+// certificadoAceito verifica se o certificado do cliente tem uma das impressões
+// digitais aceitas, informadas em hexadecimal com ou sem ":"
+func certificadoAceito(certificados []*x509.Certificate, aceitos []string) bool {
+	if len(certificados) == 0 {
+		return false
+	}
+	digital := sha256.Sum256(certificados[0].Raw)
+	for _, aceito := range aceitos {
+		aceito = strings.ToLower(strings.ReplaceAll(aceito, ":", ""))
+		if hmac.Equal([]byte(aceito), []byte(hex.EncodeToString(digital[:]))) {
+			return true
+		}
+	}
+	return false
+}
+
+// verificacaoProxyAprovada é o resultado de $ssl_client_verify do nginx para o
+// certificado de cliente validado
+const verificacaoProxyAprovada = "SUCCESS"
+
+// CertificadosCliente retorna o certificado de cliente da conexão TLS ou, atrás de um
+// proxy que termina o TLS, o repassado no cabeçalho configurado. O cabeçalho só é
+// aceito em conexões vindas dos proxies confiáveis e, com o cabeçalho de verificação
+// configurado, quando o proxy informa o certificado como validado
+func CertificadosCliente(req *http.Request, cfg config.WebhookConfig) []*x509.Certificate {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return req.TLS.PeerCertificates
+	}
+	if cfg.CabecalhoCertificado == "" || !proxyConfiavel(req.RemoteAddr, cfg.ProxiesConfiaveis) {
+		return nil
+	}
+	if cfg.CabecalhoVerificacao != "" && req.Header.Get(cfg.CabecalhoVerificacao) != verificacaoProxyAprovada {
+		return nil
+	}
+	valor, err := url.QueryUnescape(req.Header.Get(cfg.CabecalhoCertificado))
+	if err != nil {
+		return nil
+	}
+	bloco, _ := pem.Decode([]byte(valor))
+	if bloco == nil {
+		return nil
+	}
+	certificado, err := x509.ParseCertificate(bloco.Bytes)
+	if err != nil {
+		return nil
+	}
+	return []*x509.Certificate{certificado}
+}
+
+// proxyConfiavel verifica se o endereço remoto da conexão é um dos proxies
+// configurados, informados como IP ou rede CIDR
+func proxyConfiavel(remoto string, proxies []string) bool {
+	host, _, err := net.SplitHostPort(remoto)
+	if err != nil {
+		host = remoto
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if _, rede, err := net.ParseCIDR(proxy); err == nil {
+			if rede.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if confiavel := net.ParseIP(proxy); confiavel != nil && confiavel.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
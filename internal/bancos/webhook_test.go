@@ -0,0 +1,77 @@
+package bancos
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+)
+
+func TestAutenticarWebhookHMAC(t *testing.T) {
+	corpo := []byte(`{"evento":"pagamento"}`)
+	mac := hmac.New(sha256.New, []byte("segredo"))
+	mac.Write(corpo)
+	assinatura := mac.Sum(nil)
+	cfg := config.BankAPIConfig{WebhookSecret: "segredo"}
+
+	for _, valor := range []string{hex.EncodeToString(assinatura), "sha256=" + hex.EncodeToString(assinatura), base64.StdEncoding.EncodeToString(assinatura)} {
+		requisicao := RequisicaoWebhook{Cabecalhos: http.Header{"X-Assinatura": {valor}}, Corpo: corpo}
+		assert.NoError(t, AutenticarWebhook(requisicao, cfg, "X-Assinatura"), valor)
+	}
+
+	alterado := RequisicaoWebhook{Cabecalhos: http.Header{"X-Assinatura": {hex.EncodeToString(assinatura)}}, Corpo: []byte(`{"evento":"baixa"}`)}
+	assert.ErrorIs(t, AutenticarWebhook(alterado, cfg, "X-Assinatura"), ErrWebhookNaoAutenticado)
+	assert.ErrorIs(t, AutenticarWebhook(RequisicaoWebhook{Corpo: corpo}, cfg, "X-Assinatura"), ErrWebhookNaoAutenticado)
+	assert.ErrorIs(t, AutenticarWebhook(RequisicaoWebhook{Corpo: corpo}, config.BankAPIConfig{}, "X-Assinatura"), ErrWebhookNaoConfigurado)
+}
+
+func TestAutenticarWebhookCertificado(t *testing.T) {
+	certPath, _ := gerarCertificado(t, t.TempDir())
+	conteudo, err := os.ReadFile(certPath)
+	assert.NoError(t, err)
+	bloco, _ := pem.Decode(conteudo)
+	certificado, err := x509.ParseCertificate(bloco.Bytes)
+	assert.NoError(t, err)
+	digital := sha256.Sum256(certificado.Raw)
+
+	// Certificado repassado pelo proxy que termina o TLS
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/banco/341", nil)
+	req.RemoteAddr = "10.0.0.5:41234"
+	req.Header.Set("X-Client-Cert", url.QueryEscape(string(conteudo)))
+	proxy := config.WebhookConfig{CabecalhoCertificado: "X-Client-Cert", ProxiesConfiaveis: []string{"127.0.0.1", "10.0.0.0/24"}}
+	certificados := CertificadosCliente(req, proxy)
+	assert.Len(t, certificados, 1)
+	assert.Nil(t, CertificadosCliente(req, config.WebhookConfig{}))
+
+	// O cabeçalho enviado diretamente por quem não é o proxy é ignorado
+	assert.Nil(t, CertificadosCliente(req, config.WebhookConfig{CabecalhoCertificado: "X-Client-Cert"}))
+	direto := req.Clone(req.Context())
+	direto.RemoteAddr = "203.0.113.9:5000"
+	assert.Nil(t, CertificadosCliente(direto, proxy))
+
+	// Com o cabeçalho de verificação, o proxy precisa ter validado o certificado
+	proxy.CabecalhoVerificacao = "X-Client-Verify"
+	assert.Nil(t, CertificadosCliente(req, proxy))
+	req.Header.Set("X-Client-Verify", "FAILED:unable to verify the first certificate")
+	assert.Nil(t, CertificadosCliente(req, proxy))
+	req.Header.Set("X-Client-Verify", "SUCCESS")
+	assert.Len(t, CertificadosCliente(req, proxy), 1)
+
+	cfg := config.BankAPIConfig{WebhookCertificados: []string{"00:11", hex.EncodeToString(digital[:])}}
+	assert.NoError(t, AutenticarWebhook(RequisicaoWebhook{Certificados: certificados}, cfg, ""))
+	assert.ErrorIs(t, AutenticarWebhook(RequisicaoWebhook{}, cfg, ""), ErrWebhookNaoAutenticado)
+
+	cfg.WebhookCertificados = []string{"AA:BB"}
+	assert.ErrorIs(t, AutenticarWebhook(RequisicaoWebhook{Certificados: certificados}, cfg, ""), ErrWebhookNaoAutenticado)
+}
@@ -0,0 +1,163 @@
+// Package calendario identifica os dias sem expediente bancário — fins de semana,
+// feriados nacionais e feriados locais configurados — para a prorrogação dos
+// vencimentos de boletos e duplicatas
+package calendario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Feriado é um dia sem expediente bancário
+type Feriado struct {
+	Data  time.Time `json:"data"`
+	Nome  string    `json:"nome"`
+	Local bool      `json:"local,omitempty"`
+}
+
+// Calendario reúne os feriados nacionais, calculados para cada ano, e os locais
+type Calendario struct {
+	// anuais são os feriados locais que se repetem, indexados por MM-DD
+	anuais map[string]string
+	// datados são os feriados locais de um único ano, indexados por AAAA-MM-DD
+	datados map[string]string
+}
+
+// Nacional retorna o calendário apenas com os feriados nacionais
+func Nacional() *Calendario {
+	return &Calendario{anuais: map[string]string{}, datados: map[string]string{}}
+}
+
+// New cria o calendário com os feriados locais informados como "MM-DD" (todo ano)
+// ou "AAAA-MM-DD", seguidos opcionalmente de "=Nome". As entradas inválidas são
+// descartadas e informadas no erro; as demais continuam valendo
+func New(locais []string) (*Calendario, error) {
+	c := Nacional()
+	var invalidos []string
+	for _, entrada := range locais {
+		data, nome, _ := strings.Cut(strings.TrimSpace(entrada), "=")
+		data, nome = strings.TrimSpace(data), strings.TrimSpace(nome)
+		if nome == "" {
+			nome = "Feriado local"
+		}
+		if _, err := time.Parse("2006-01-02", data); err == nil {
+			c.datados[data] = nome
+			continue
+		}
+		// 2000 é bissexto, para aceitar 02-29
+		if _, err := time.Parse("2006-01-02", "2000-"+data); err == nil && len(data) == 5 {
+			c.anuais[data] = nome
+			continue
+		}
+		invalidos = append(invalidos, entrada)
+	}
+	if len(invalidos) > 0 {
+		return c, fmt.Errorf("feriados locais inválidos: %s", strings.Join(invalidos, ", "))
+	}
+	return c, nil
+}
+
+// Pascoa calcula o domingo de Páscoa do ano pelo algoritmo de Meeus/Jones/Butcher
+func Pascoa(ano int) time.Time {
+	a := ano % 19
+	b, c := ano/100, ano%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	mes := (h + l - 7*m + 114) / 31
+	dia := (h+l-7*m+114)%31 + 1
+	return time.Date(ano, time.Month(mes), dia, 0, 0, 0, 0, time.UTC)
+}
+
+// nacionais calcula os feriados nacionais bancários do ano, incluindo os móveis
+// (Carnaval, Sexta-feira Santa e Corpus Christi), contados a partir da Páscoa
+func nacionais(ano int) []Feriado {
+	data := func(mes time.Month, dia int) time.Time {
+		return time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC)
+	}
+	pascoa := Pascoa(ano)
+	feriados := []Feriado{
+		{Data: data(time.January, 1), Nome: "Confraternização Universal"},
+		{Data: pascoa.AddDate(0, 0, -48), Nome: "Carnaval"},
+		{Data: pascoa.AddDate(0, 0, -47), Nome: "Carnaval"},
+		{Data: pascoa.AddDate(0, 0, -2), Nome: "Sexta-feira Santa"},
+		{Data: data(time.April, 21), Nome: "Tiradentes"},
+		{Data: data(time.May, 1), Nome: "Dia do Trabalho"},
+		{Data: pascoa.AddDate(0, 0, 60), Nome: "Corpus Christi"},
+		{Data: data(time.September, 7), Nome: "Independência do Brasil"},
+		{Data: data(time.October, 12), Nome: "Nossa Senhora Aparecida"},
+		{Data: data(time.November, 2), Nome: "Finados"},
+		{Data: data(time.November, 15), Nome: "Proclamação da República"},
+		{Data: data(time.December, 25), Nome: "Natal"},
+	}
+	// O Dia da Consciência Negra é feriado nacional desde 2024
+	if ano >= 2024 {
+		feriados = append(feriados, Feriado{Data: data(time.November, 20), Nome: "Dia Nacional de Zumbi e da Consciência Negra"})
+	}
+	return feriados
+}
+
+// Feriados lista os feriados nacionais e locais do ano, em ordem de data
+func (c *Calendario) Feriados(ano int) []Feriado {
+	feriados := nacionais(ano)
+	for mmdd, nome := range c.anuais {
+		data, _ := time.Parse("2006-01-02", fmt.Sprintf("%04d-%s", ano, mmdd))
+		if !data.IsZero() {
+			feriados = append(feriados, Feriado{Data: data, Nome: nome, Local: true})
+		}
+	}
+	for texto, nome := range c.datados {
+		if data, _ := time.Parse("2006-01-02", texto); data.Year() == ano {
+			feriados = append(feriados, Feriado{Data: data, Nome: nome, Local: true})
+		}
+	}
+	sort.SliceStable(feriados, func(i, j int) bool { return feriados[i].Data.Before(feriados[j].Data) })
+	return feriados
+}
+
+// Feriado informa o nome do feriado na data, se houver
+func (c *Calendario) Feriado(data time.Time) (string, bool) {
+	data = Dia(data)
+	if nome, ok := c.datados[data.Format("2006-01-02")]; ok {
+		return nome, true
+	}
+	if nome, ok := c.anuais[data.Format("01-02")]; ok {
+		return nome, true
+	}
+	for _, feriado := range nacionais(data.Year()) {
+		if feriado.Data.Equal(data) {
+			return feriado.Nome, true
+		}
+	}
+	return "", false
+}
+
+// DiaUtil informa se há expediente bancário na data
+func (c *Calendario) DiaUtil(data time.Time) bool {
+	if data.Weekday() == time.Saturday || data.Weekday() == time.Sunday {
+		return false
+	}
+	_, feriado := c.Feriado(data)
+	return !feriado
+}
+
+// ProximoDiaUtil retorna a data, se for dia útil, ou o dia útil seguinte. É o
+// vencimento efetivo de um título que vence em dia sem expediente
+func (c *Calendario) ProximoDiaUtil(data time.Time) time.Time {
+	data = Dia(data)
+	for !c.DiaUtil(data) {
+		data = data.AddDate(0, 0, 1)
+	}
+	return data
+}
+
+// Dia descarta o horário, mantendo o dia informado
+func Dia(data time.Time) time.Time {
+	return time.Date(data.Year(), data.Month(), data.Day(), 0, 0, 0, 0, time.UTC)
+}
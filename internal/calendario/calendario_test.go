@@ -0,0 +1,72 @@
+package calendario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dia(ano int, mes time.Month, d int) time.Time {
+	return time.Date(ano, mes, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPascoa(t *testing.T) {
+	assert.Equal(t, dia(2023, 4, 9), Pascoa(2023))
+	assert.Equal(t, dia(2024, 3, 31), Pascoa(2024))
+	assert.Equal(t, dia(2025, 4, 20), Pascoa(2025))
+	assert.Equal(t, dia(2038, 4, 25), Pascoa(2038))
+}
+
+func TestFeriadosNacionais(t *testing.T) {
+	c := Nacional()
+
+	nome, ok := c.Feriado(dia(2024, 2, 13))
+	assert.True(t, ok)
+	assert.Equal(t, "Carnaval", nome)
+	nome, _ = c.Feriado(time.Date(2024, 5, 30, 15, 0, 0, 0, time.Local))
+	assert.Equal(t, "Corpus Christi", nome)
+	nome, _ = c.Feriado(dia(2024, 3, 29))
+	assert.Equal(t, "Sexta-feira Santa", nome)
+
+	// Consciência Negra só a partir de 2024
+	_, ok = c.Feriado(dia(2023, 11, 20))
+	assert.False(t, ok)
+	_, ok = c.Feriado(dia(2024, 11, 20))
+	assert.True(t, ok)
+
+	assert.Len(t, c.Feriados(2023), 12)
+	assert.Len(t, c.Feriados(2024), 13)
+}
+
+func TestProximoDiaUtil(t *testing.T) {
+	c := Nacional()
+
+	assert.Equal(t, dia(2023, 11, 30), c.ProximoDiaUtil(time.Date(2023, 11, 30, 18, 0, 0, 0, time.UTC)))
+	// Sábado
+	assert.Equal(t, dia(2023, 12, 4), c.ProximoDiaUtil(dia(2023, 12, 2)))
+	// Sábado de Carnaval: segunda e terça não têm expediente
+	assert.Equal(t, dia(2024, 2, 14), c.ProximoDiaUtil(dia(2024, 2, 10)))
+	// Corpus Christi na quinta
+	assert.Equal(t, dia(2024, 5, 31), c.ProximoDiaUtil(dia(2024, 5, 30)))
+}
+
+func TestFeriadosLocais(t *testing.T) {
+	c, err := New([]string{"01-25=Aniversário de São Paulo", "2024-07-09", "13-01", "amanhã"})
+	assert.EqualError(t, err, "feriados locais inválidos: 13-01, amanhã")
+
+	nome, ok := c.Feriado(dia(2025, 1, 25))
+	assert.True(t, ok)
+	assert.Equal(t, "Aniversário de São Paulo", nome)
+	nome, ok = c.Feriado(dia(2024, 7, 9))
+	assert.True(t, ok)
+	assert.Equal(t, "Feriado local", nome)
+	// O feriado datado vale só no ano informado
+	assert.True(t, c.DiaUtil(dia(2025, 7, 9)))
+	assert.Equal(t, dia(2024, 7, 10), c.ProximoDiaUtil(dia(2024, 7, 9)))
+
+	feriados := c.Feriados(2024)
+	assert.Len(t, feriados, 15)
+	assert.Equal(t, dia(2024, 1, 1), feriados[0].Data)
+	assert.True(t, feriados[1].Local)
+}
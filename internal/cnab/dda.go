@@ -0,0 +1,103 @@
+package cnab
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// ArquivoDDA é o arquivo CNAB 240 dos títulos apresentados no DDA (Débito Direto
+// Autorizado) contra o pagador, com um segmento G por boleto
+type ArquivoDDA struct {
+	Banco string `json:"banco"`
+	// Pagador é o CNPJ/CPF da empresa contra a qual os títulos foram registrados
+	Pagador     string      `json:"pagador"`
+	DataGeracao *time.Time  `json:"data_geracao,omitempty"`
+	Titulos     []TituloDDA `json:"titulos"`
+}
+
+// TituloDDA é um boleto registrado contra o pagador
+type TituloDDA struct {
+	Linha                 int        `json:"linha"`
+	CodigoBarras          string     `json:"codigo_barras"`
+	DocumentoBeneficiario string     `json:"documento_beneficiario"`
+	NomeBeneficiario      string     `json:"nome_beneficiario"`
+	Vencimento            *time.Time `json:"vencimento,omitempty"`
+	Valor                 float64    `json:"valor"`
+	// NumeroDocumento é o seu número atribuído pelo beneficiário
+	NumeroDocumento string     `json:"numero_documento,omitempty"`
+	Emissao         *time.Time `json:"emissao,omitempty"`
+	Juros           float64    `json:"juros,omitempty"`
+	Desconto        float64    `json:"desconto,omitempty"`
+}
+
+// ParseDDA lê o arquivo de títulos DDA. Os segmentos H (abatimentos e instruções) e
+// os de outros serviços são ignorados
+func ParseDDA(r io.Reader) (*ArquivoDDA, error) {
+	linhas, err := lerLinhas(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(linhas) == 0 {
+		return nil, &ErroLinha{Motivo: "arquivo vazio"}
+	}
+
+	header := &linhaCNAB{linha: 1, texto: linhas[0]}
+	if len(header.texto) != Layout240 || header.texto[7] != registroHeaderArquivo240 {
+		return nil, &ErroLinha{Linha: 1, Motivo: "header de arquivo CNAB 240 ausente"}
+	}
+	arquivo := &ArquivoDDA{
+		Banco:       header.alfa(1, 3),
+		Pagador:     header.numero(19, 32),
+		DataGeracao: header.data(144, 151),
+		Titulos:     []TituloDDA{},
+	}
+	if header.err != nil {
+		return nil, header.err
+	}
+
+	for i, texto := range linhas {
+		if len(texto) != Layout240 {
+			return nil, &ErroLinha{Linha: i + 1, Motivo: "linha fora do layout de 240 posições"}
+		}
+		if texto[7] != registroDetalhe240 || texto[13] != 'G' {
+			continue
+		}
+
+		linha := &linhaCNAB{linha: i + 1, texto: texto}
+		titulo := TituloDDA{
+			Linha:                 linha.linha,
+			CodigoBarras:          linha.alfa(18, 61),
+			DocumentoBeneficiario: linha.numero(63, 77),
+			NomeBeneficiario:      linha.alfa(78, 107),
+			Vencimento:            linha.data(108, 115),
+			Valor:                 linha.valor(116, 130),
+			NumeroDocumento:       linha.alfa(148, 162),
+			Emissao:               linha.data(182, 189),
+			Juros:                 linha.valor(190, 204),
+			Desconto:              linha.valor(214, 228),
+		}
+		if linha.err != nil {
+			return nil, linha.err
+		}
+		if len(titulo.CodigoBarras) != 44 || !somenteDigitos(titulo.CodigoBarras) {
+			return nil, &ErroLinha{Linha: linha.linha, Motivo: "código de barras inválido no segmento G"}
+		}
+		titulo.DocumentoBeneficiario = documento(texto[61], titulo.DocumentoBeneficiario)
+		arquivo.Titulos = append(arquivo.Titulos, titulo)
+	}
+	arquivo.Pagador = documento(header.texto[17], arquivo.Pagador)
+	return arquivo, nil
+}
+
+// documento completa o CPF (tipo 1) ou CNPJ (tipo 2) lido sem os zeros à esquerda
+func documento(tipo byte, numero string) string {
+	tamanho := 14
+	if tipo == '1' {
+		tamanho = 11
+	}
+	if numero == "" || len(numero) >= tamanho {
+		return numero
+	}
+	return strings.Repeat("0", tamanho-len(numero)) + numero
+}
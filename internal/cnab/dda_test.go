@@ -0,0 +1,65 @@
+package cnab
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func arquivoDDA() string {
+	linhas := []string{
+		linha(240, map[int]string{1: "34100000", 18: "2", 19: "12345678000195", 143: "2", 144: "04122023"}),
+		linha(240, map[int]string{1: "34100011"}),
+		linha(240, map[int]string{1: "34100013", 9: "00001", 14: "G", 18: "34195955000000250751091234567800057123457000", 62: "2",
+			63: "098765432000198", 78: "Indústria São João S.A.", 108: "30112023", 116: "000000000025075", 148: "NF123-1",
+			182: "01112023", 190: "000000000000008", 214: "000000000000500"}),
+		linha(240, map[int]string{1: "34100013", 9: "00002", 14: "H"}),
+		linha(240, map[int]string{1: "34100013", 9: "00003", 14: "G", 18: "23796907800000099903381090000001234500123450", 62: "1",
+			63: "000012345678909", 78: "JOAO DA SILVA", 108: "15082022", 116: "000000000009990"}),
+		linha(240, map[int]string{1: "34100015"}),
+		linha(240, map[int]string{1: "34199999"}),
+	}
+	return strings.Join(linhas, "\r\n") + "\r\n"
+}
+
+func TestParseDDA(t *testing.T) {
+	arquivo, err := ParseDDA(strings.NewReader(arquivoDDA()))
+	assert.NoError(t, err)
+	assert.Equal(t, "341", arquivo.Banco)
+	assert.Equal(t, "12345678000195", arquivo.Pagador)
+	assert.Equal(t, data("2023-12-04"), *arquivo.DataGeracao)
+	if !assert.Len(t, arquivo.Titulos, 2) {
+		return
+	}
+
+	titulo := arquivo.Titulos[0]
+	assert.Equal(t, 3, titulo.Linha)
+	assert.Equal(t, "34195955000000250751091234567800057123457000", titulo.CodigoBarras)
+	assert.Equal(t, "98765432000198", titulo.DocumentoBeneficiario)
+	assert.Equal(t, "Indústria São João S.A.", titulo.NomeBeneficiario)
+	assert.Equal(t, data("2023-11-30"), *titulo.Vencimento)
+	assert.Equal(t, 250.75, titulo.Valor)
+	assert.Equal(t, "NF123-1", titulo.NumeroDocumento)
+	assert.Equal(t, data("2023-11-01"), *titulo.Emissao)
+	assert.Equal(t, 0.08, titulo.Juros)
+	assert.Equal(t, 5.0, titulo.Desconto)
+
+	// Beneficiário pessoa física
+	assert.Equal(t, "12345678909", arquivo.Titulos[1].DocumentoBeneficiario)
+}
+
+func TestParseDDAInvalido(t *testing.T) {
+	_, err := ParseDDA(strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrArquivoInvalido)
+
+	_, err = ParseDDA(strings.NewReader(linha(400, map[int]string{1: "02RETORNO"})))
+	assert.ErrorIs(t, err, ErrArquivoInvalido)
+
+	arquivo := strings.Replace(arquivoDDA(), "34195955000000250751091234567800057123457000", "3419595500000025075109123456780005712345    ", 1)
+	_, err = ParseDDA(strings.NewReader(arquivo))
+	var erroLinha *ErroLinha
+	if assert.ErrorAs(t, err, &erroLinha) {
+		assert.Equal(t, 3, erroLinha.Linha)
+	}
+}
@@ -0,0 +1,321 @@
+package cnab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Versões do layout FEBRABAN de pagamentos
+const (
+	versaoArquivoPagamento = "089"
+	versaoLotePagamento    = "040"
+)
+
+// Formas de lançamento do pagamento de títulos de cobrança
+const (
+	FormaTitulosProprioBanco = "30"
+	FormaTitulosOutrosBancos = "31"
+)
+
+// Ocorrências do retorno de pagamentos
+const (
+	OcorrenciaEfetivado = "00"
+	OcorrenciaAgendado  = "BD"
+)
+
+// ErrRemessaSemPagamentos indica uma remessa sem títulos a pagar
+var ErrRemessaSemPagamentos = errors.New("remessa sem pagamentos")
+
+// Pagador identifica a empresa e a conta debitada nos pagamentos
+type Pagador struct {
+	Banco     string
+	CNPJ      string
+	Nome      string
+	Convenio  string
+	Agencia   string
+	DVAgencia string
+	Conta     string
+	DVConta   string
+}
+
+// Pagamento é um título de cobrança a pagar, identificado no retorno pelo seu número
+type Pagamento struct {
+	SeuNumero             string
+	CodigoBarras          string
+	NomeBeneficiario      string
+	DocumentoBeneficiario string
+	Vencimento            time.Time
+	ValorTitulo           float64
+	Desconto              float64
+	Juros                 float64
+	DataPagamento         time.Time
+	ValorPagamento        float64
+}
+
+// RemessaPagamento é o arquivo CNAB 240 de pagamento de títulos (segmentos J e J-52)
+type RemessaPagamento struct {
+	Pagador Pagador
+	// Sequencial é o número sequencial do arquivo (NSA), crescente por banco
+	Sequencial int
+	Geracao    time.Time
+	Pagamentos []Pagamento
+}
+
+// Gerar monta o arquivo, com um lote para os títulos do próprio banco e outro para
+// os de outros bancos
+func (r *RemessaPagamento) Gerar() ([]byte, error) {
+	if len(r.Pagamentos) == 0 {
+		return nil, ErrRemessaSemPagamentos
+	}
+
+	var lotes [][]Pagamento
+	var proprio, outros []Pagamento
+	for _, pagamento := range r.Pagamentos {
+		if len(pagamento.CodigoBarras) != 44 || !somenteDigitos(pagamento.CodigoBarras) {
+			return nil, fmt.Errorf("código de barras inválido no pagamento %s", pagamento.SeuNumero)
+		}
+		if strings.HasPrefix(pagamento.CodigoBarras, r.Pagador.Banco) {
+			proprio = append(proprio, pagamento)
+		} else {
+			outros = append(outros, pagamento)
+		}
+	}
+	for _, lote := range [][]Pagamento{proprio, outros} {
+		if len(lote) > 0 {
+			lotes = append(lotes, lote)
+		}
+	}
+
+	linhas := []*registroRemessa{r.headerArquivo()}
+	for i, pagamentos := range lotes {
+		linhas = append(linhas, r.lote(i+1, pagamentos)...)
+	}
+	linhas = append(linhas, r.trailerArquivo(len(lotes), len(linhas)+1))
+
+	var b strings.Builder
+	for _, linha := range linhas {
+		if linha.err != nil {
+			return nil, linha.err
+		}
+		b.WriteString(linha.String())
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// conta grava a identificação da empresa e da conta, comum aos headers
+func (r *RemessaPagamento) conta(registro *registroRemessa) *registroRemessa {
+	p := r.Pagador
+	return registro.
+		numero(18, 18, "2").
+		numero(19, 32, p.CNPJ).
+		alfa(33, 52, p.Convenio).
+		numero(53, 57, p.Agencia).
+		alfa(58, 58, p.DVAgencia).
+		numero(59, 70, p.Conta).
+		alfa(71, 71, p.DVConta).
+		alfa(73, 102, p.Nome)
+}
+
+func (r *RemessaPagamento) headerArquivo() *registroRemessa {
+	registro := novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		numero(4, 7, "0000").
+		numero(8, 8, "0")
+	return r.conta(registro).
+		numero(143, 143, "1").
+		data(144, 151, r.Geracao).
+		numero(152, 157, r.Geracao.Format("150405")).
+		inteiro(158, 163, r.Sequencial).
+		numero(164, 166, versaoArquivoPagamento).
+		numero(167, 171, "01600")
+}
+
+// lote monta o header, os segmentos J e J-52 de cada título e o trailer do lote
+func (r *RemessaPagamento) lote(numero int, pagamentos []Pagamento) []*registroRemessa {
+	forma := FormaTitulosOutrosBancos
+	if strings.HasPrefix(pagamentos[0].CodigoBarras, r.Pagador.Banco) {
+		forma = FormaTitulosProprioBanco
+	}
+
+	header := novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		inteiro(4, 7, numero).
+		numero(8, 8, "1").
+		alfa(9, 9, "C").
+		numero(10, 11, "20").
+		numero(12, 13, forma).
+		numero(14, 16, versaoLotePagamento)
+	linhas := []*registroRemessa{r.conta(header)}
+
+	var total float64
+	for _, pagamento := range pagamentos {
+		sequencia := len(linhas)
+		linhas = append(linhas, r.segmentoJ(numero, sequencia, pagamento), r.segmentoJ52(numero, sequencia+1, pagamento))
+		total += pagamento.ValorPagamento
+	}
+
+	trailer := novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		inteiro(4, 7, numero).
+		numero(8, 8, "5").
+		inteiro(18, 23, len(linhas)+1).
+		valor(24, 41, total).
+		numero(42, 59, "0")
+	return append(linhas, trailer)
+}
+
+// segmentoJ identifica o título pelo código de barras e informa o pagamento
+func (r *RemessaPagamento) segmentoJ(lote, sequencia int, p Pagamento) *registroRemessa {
+	return novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		inteiro(4, 7, lote).
+		numero(8, 8, "3").
+		inteiro(9, 13, sequencia).
+		alfa(14, 14, "J").
+		numero(15, 15, "0").
+		numero(16, 17, "00").
+		numero(18, 61, p.CodigoBarras).
+		alfa(62, 91, p.NomeBeneficiario).
+		data(92, 99, p.Vencimento).
+		valor(100, 114, p.ValorTitulo).
+		valor(115, 129, p.Desconto).
+		valor(130, 144, p.Juros).
+		data(145, 152, p.DataPagamento).
+		valor(153, 167, p.ValorPagamento).
+		numero(168, 182, "0").
+		alfa(183, 202, p.SeuNumero).
+		numero(223, 224, "09")
+}
+
+// segmentoJ52 traz o pagador e o beneficiário, obrigatórios nos títulos registrados
+func (r *RemessaPagamento) segmentoJ52(lote, sequencia int, p Pagamento) *registroRemessa {
+	documento := p.DocumentoBeneficiario
+	tipo := "2"
+	if len(documento) == 11 {
+		tipo = "1"
+	}
+	return novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		inteiro(4, 7, lote).
+		numero(8, 8, "3").
+		inteiro(9, 13, sequencia).
+		alfa(14, 14, "J").
+		numero(15, 15, "0").
+		numero(16, 17, "00").
+		numero(18, 19, "52").
+		numero(20, 20, "2").
+		numero(21, 35, r.Pagador.CNPJ).
+		alfa(36, 75, r.Pagador.Nome).
+		numero(76, 76, tipo).
+		numero(77, 91, documento).
+		alfa(92, 131, p.NomeBeneficiario).
+		numero(132, 132, "0").
+		numero(133, 147, "0")
+}
+
+func (r *RemessaPagamento) trailerArquivo(lotes, registros int) *registroRemessa {
+	return novoRegistro(240).
+		numero(1, 3, r.Pagador.Banco).
+		numero(4, 7, "9999").
+		numero(8, 8, "9").
+		inteiro(18, 23, lotes).
+		inteiro(24, 29, registros).
+		numero(30, 35, "0")
+}
+
+// RetornoPagamento é o retorno de uma remessa de pagamento de títulos
+type RetornoPagamento struct {
+	Banco       string              `json:"banco"`
+	Sequencial  string              `json:"sequencial"`
+	DataGeracao *time.Time          `json:"data_geracao,omitempty"`
+	Registros   []RegistroPagamento `json:"registros"`
+}
+
+// RegistroPagamento é a situação de um título do segmento J no retorno
+type RegistroPagamento struct {
+	Linha          int        `json:"linha"`
+	SeuNumero      string     `json:"seu_numero"`
+	CodigoBarras   string     `json:"codigo_barras"`
+	DataPagamento  *time.Time `json:"data_pagamento,omitempty"`
+	ValorPagamento float64    `json:"valor_pagamento"`
+	// Ocorrencias traz até cinco códigos; 00 indica pagamento efetivado
+	Ocorrencias []string `json:"ocorrencias"`
+}
+
+// Efetivado informa se o banco realizou o pagamento
+func (r *RegistroPagamento) Efetivado() bool {
+	return r.possui(OcorrenciaEfetivado)
+}
+
+// Agendado informa se o pagamento foi aceito para a data informada
+func (r *RegistroPagamento) Agendado() bool {
+	return r.possui(OcorrenciaAgendado)
+}
+
+func (r *RegistroPagamento) possui(codigo string) bool {
+	for _, ocorrencia := range r.Ocorrencias {
+		if ocorrencia == codigo {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRetornoPagamento lê o retorno CNAB 240 de pagamento de títulos. Os segmentos
+// J-52 e os de outros serviços são ignorados
+func ParseRetornoPagamento(r io.Reader) (*RetornoPagamento, error) {
+	linhas, err := lerLinhas(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(linhas) == 0 {
+		return nil, &ErroLinha{Motivo: "arquivo vazio"}
+	}
+
+	header := &linhaCNAB{linha: 1, texto: linhas[0]}
+	if len(header.texto) != Layout240 || header.texto[7] != registroHeaderArquivo240 {
+		return nil, &ErroLinha{Linha: 1, Motivo: "header de arquivo CNAB 240 ausente"}
+	}
+	if header.texto[142] != '2' {
+		return nil, &ErroLinha{Linha: 1, Motivo: "arquivo de remessa, não de retorno"}
+	}
+	retorno := &RetornoPagamento{
+		Banco:       header.alfa(1, 3),
+		Sequencial:  header.numero(158, 163),
+		DataGeracao: header.data(144, 151),
+	}
+	if header.err != nil {
+		return nil, header.err
+	}
+
+	for i, texto := range linhas {
+		if len(texto) != Layout240 {
+			return nil, &ErroLinha{Linha: i + 1, Motivo: "linha fora do layout de 240 posições"}
+		}
+		if texto[7] != registroDetalhe240 || texto[13] != 'J' || texto[17:19] == "52" {
+			continue
+		}
+
+		linha := &linhaCNAB{linha: i + 1, texto: texto}
+		registro := RegistroPagamento{
+			Linha:          linha.linha,
+			SeuNumero:      linha.alfa(183, 202),
+			CodigoBarras:   linha.alfa(18, 61),
+			DataPagamento:  linha.data(145, 152),
+			ValorPagamento: linha.valor(153, 167),
+		}
+		ocorrencias := linha.alfa(231, 240)
+		for j := 0; j+2 <= len(ocorrencias); j += 2 {
+			registro.Ocorrencias = append(registro.Ocorrencias, ocorrencias[j:j+2])
+		}
+		if linha.err != nil {
+			return nil, linha.err
+		}
+		retorno.Registros = append(retorno.Registros, registro)
+	}
+	return retorno, nil
+}
@@ -0,0 +1,142 @@
+package cnab
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func remessaTeste() *RemessaPagamento {
+	return &RemessaPagamento{
+		Pagador: Pagador{
+			Banco:     "341",
+			CNPJ:      "12345678000195",
+			Nome:      "Comércio de Peças Ltda",
+			Agencia:   "0057",
+			DVAgencia: "",
+			Conta:     "12345",
+			DVConta:   "7",
+		},
+		Sequencial: 12,
+		Geracao:    time.Date(2023, 11, 20, 14, 30, 5, 0, time.UTC),
+		Pagamentos: []Pagamento{
+			{
+				SeuNumero:             "1",
+				CodigoBarras:          "23796907800000099903381090000001234500123450",
+				NomeBeneficiario:      "Indústria São João S.A.",
+				DocumentoBeneficiario: "98765432000198",
+				Vencimento:            data("2022-08-15"),
+				ValorTitulo:           99.90,
+				DataPagamento:         data("2023-11-21"),
+				ValorPagamento:        99.90,
+			},
+			{
+				SeuNumero:             "2",
+				CodigoBarras:          "34195955000000250751091234567800057123457000",
+				NomeBeneficiario:      "Empresa Exemplo Ltda",
+				DocumentoBeneficiario: "12345678909",
+				Vencimento:            data("2023-11-30"),
+				ValorTitulo:           250.75,
+				Juros:                 2.51,
+				DataPagamento:         data("2023-11-30"),
+				ValorPagamento:        253.26,
+			},
+		},
+	}
+}
+
+func TestGerarRemessaPagamento(t *testing.T) {
+	arquivo, err := remessaTeste().Gerar()
+	assert.NoError(t, err)
+
+	linhas := strings.Split(strings.TrimSuffix(string(arquivo), "\r\n"), "\r\n")
+	// Header, dois lotes com header, J, J-52 e trailer, e trailer do arquivo
+	if !assert.Len(t, linhas, 10) {
+		return
+	}
+	for _, linha := range linhas {
+		assert.Len(t, linha, 240)
+	}
+
+	header := linhas[0]
+	assert.Equal(t, "34100000", header[0:8])
+	assert.Equal(t, "212345678000195", header[17:32])
+	assert.Equal(t, "00057 0000000123457 ", header[52:72])
+	assert.Equal(t, "COMERCIO DE PECAS LTDA", strings.TrimSpace(header[72:102]))
+	assert.Equal(t, "120112023143005000012089", header[142:166])
+
+	// O título do próprio banco vai no primeiro lote, com forma de lançamento 30
+	assert.Equal(t, "34100011C2030040", linhas[1][0:16])
+	j := linhas[2]
+	assert.Equal(t, "3410001300001J000", j[0:17])
+	assert.Equal(t, "34195955000000250751091234567800057123457000", j[17:61])
+	assert.Equal(t, "EMPRESA EXEMPLO LTDA", strings.TrimSpace(j[61:91]))
+	assert.Equal(t, "30112023", j[91:99])
+	assert.Equal(t, "000000000025075", j[99:114])
+	assert.Equal(t, "000000000000251", j[129:144])
+	assert.Equal(t, "30112023000000000025326", j[144:167])
+	assert.Equal(t, "2", strings.TrimSpace(j[182:202]))
+
+	j52 := linhas[3]
+	assert.Equal(t, "3410001300002J00052", j52[0:19])
+	assert.Equal(t, "2012345678000195", j52[19:35])
+	assert.Equal(t, "1000012345678909", j52[75:91])
+	assert.Equal(t, "EMPRESA EXEMPLO LTDA", strings.TrimSpace(j52[91:131]))
+	assert.Equal(t, "34100015         000004000000000000025326", linhas[4][0:41])
+
+	assert.Equal(t, "34100021C2031040", linhas[5][0:16])
+	assert.Equal(t, "34199999         000002000010", linhas[9][0:29])
+}
+
+func TestGerarRemessaPagamentoInvalida(t *testing.T) {
+	remessa := remessaTeste()
+	remessa.Pagamentos = nil
+	_, err := remessa.Gerar()
+	assert.ErrorIs(t, err, ErrRemessaSemPagamentos)
+
+	remessa = remessaTeste()
+	remessa.Pagamentos[0].CodigoBarras = "2379"
+	_, err = remessa.Gerar()
+	assert.Error(t, err)
+
+	remessa = remessaTeste()
+	remessa.Pagador.Agencia = "123456"
+	_, err = remessa.Gerar()
+	assert.Error(t, err)
+}
+
+func TestParseRetornoPagamento(t *testing.T) {
+	arquivo, err := remessaTeste().Gerar()
+	assert.NoError(t, err)
+
+	// O banco devolve a remessa com o código de retorno e as ocorrências de cada título
+	linhas := strings.Split(strings.TrimSuffix(string(arquivo), "\r\n"), "\r\n")
+	linhas[0] = linhas[0][:142] + "2" + linhas[0][143:]
+	linhas[2] = linhas[2][:230] + "00        "
+	linhas[6] = linhas[6][:230] + "AMBD      "
+
+	retorno, err := ParseRetornoPagamento(strings.NewReader(strings.Join(linhas, "\r\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, "341", retorno.Banco)
+	assert.Equal(t, "12", retorno.Sequencial)
+	if assert.Len(t, retorno.Registros, 2) {
+		pago := retorno.Registros[0]
+		assert.Equal(t, 3, pago.Linha)
+		assert.Equal(t, "2", pago.SeuNumero)
+		assert.Equal(t, "34195955000000250751091234567800057123457000", pago.CodigoBarras)
+		assert.Equal(t, 253.26, pago.ValorPagamento)
+		assert.Equal(t, data("2023-11-30"), *pago.DataPagamento)
+		assert.True(t, pago.Efetivado())
+
+		agendado := retorno.Registros[1]
+		assert.Equal(t, []string{"AM", "BD"}, agendado.Ocorrencias)
+		assert.False(t, agendado.Efetivado())
+		assert.True(t, agendado.Agendado())
+	}
+
+	// A própria remessa não é aceita como retorno
+	_, err = ParseRetornoPagamento(strings.NewReader(string(arquivo)))
+	assert.ErrorIs(t, err, ErrArquivoInvalido)
+}
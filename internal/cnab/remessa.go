@@ -0,0 +1,83 @@
+package cnab
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// registroRemessa monta uma linha de remessa pelas posições do manual (base 1,
+// inclusivas), começando em brancos. O primeiro campo que não cabe no tamanho é
+// guardado em err
+type registroRemessa struct {
+	texto []byte
+	err   error
+}
+
+func novoRegistro(tamanho int) *registroRemessa {
+	return &registroRemessa{texto: []byte(strings.Repeat(" ", tamanho))}
+}
+
+// alfa grava o texto em maiúsculas, sem acentos, alinhado à esquerda e truncado
+func (r *registroRemessa) alfa(inicio, fim int, texto string) *registroRemessa {
+	texto = textoCNAB(texto)
+	if tamanho := fim - inicio + 1; len(texto) > tamanho {
+		texto = texto[:tamanho]
+	}
+	copy(r.texto[inicio-1:fim], texto)
+	return r
+}
+
+// numero grava os dígitos alinhados à direita com zeros à esquerda
+func (r *registroRemessa) numero(inicio, fim int, numero string) *registroRemessa {
+	tamanho := fim - inicio + 1
+	if len(numero) > tamanho || !somenteDigitos(numero) {
+		if r.err == nil {
+			r.err = fmt.Errorf("campo %q inválido para as posições %d a %d", numero, inicio, fim)
+		}
+		return r
+	}
+	copy(r.texto[inicio-1:fim], strings.Repeat("0", tamanho-len(numero))+numero)
+	return r
+}
+
+// inteiro grava um número inteiro com zeros à esquerda
+func (r *registroRemessa) inteiro(inicio, fim int, valor int) *registroRemessa {
+	return r.numero(inicio, fim, strconv.Itoa(valor))
+}
+
+// valor grava um valor com duas casas decimais implícitas
+func (r *registroRemessa) valor(inicio, fim int, valor float64) *registroRemessa {
+	return r.numero(inicio, fim, strconv.FormatInt(int64(math.Round(valor*100)), 10))
+}
+
+// data grava a data no formato DDMMAAAA
+func (r *registroRemessa) data(inicio, fim int, data time.Time) *registroRemessa {
+	return r.numero(inicio, fim, data.Format("02012006"))
+}
+
+// String retorna a linha montada
+func (r *registroRemessa) String() string {
+	return string(r.texto)
+}
+
+// textoCNAB converte o texto para os caracteres aceitos pelos bancos: letras
+// maiúsculas sem acento, dígitos e pontuação ASCII
+func textoCNAB(texto string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(texto) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r < 32 || r > 126:
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,222 @@
+// Package cnab lê e gera os arquivos de intercâmbio bancário no padrão CNAB
+// (FEBRABAN): retornos de cobrança nos layouts de 240 e 400 posições, remessas e
+// retornos de pagamento de títulos e arquivos de títulos DDA no layout de 240
+package cnab
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts de arquivo CNAB, identificados pelo tamanho das linhas
+const (
+	Layout240 = 240
+	Layout400 = 400
+)
+
+// ErrArquivoInvalido indica um arquivo que não segue o layout CNAB
+var ErrArquivoInvalido = errors.New("arquivo CNAB inválido")
+
+// ErroLinha descreve o problema encontrado em uma linha do arquivo
+type ErroLinha struct {
+	Linha  int
+	Motivo string
+}
+
+func (e *ErroLinha) Error() string {
+	if e.Linha == 0 {
+		return fmt.Sprintf("%s: %s", ErrArquivoInvalido, e.Motivo)
+	}
+	return fmt.Sprintf("%s: linha %d: %s", ErrArquivoInvalido, e.Linha, e.Motivo)
+}
+
+// Is faz os erros de linha corresponderem a ErrArquivoInvalido
+func (e *ErroLinha) Is(target error) bool {
+	return target == ErrArquivoInvalido
+}
+
+// Retorno é o arquivo de retorno de cobrança enviado pelo banco
+type Retorno struct {
+	Layout      int        `json:"layout"`
+	Banco       string     `json:"banco"`
+	DataGeracao *time.Time `json:"data_geracao,omitempty"`
+	Registros   []Registro `json:"registros"`
+}
+
+// Registro é a movimentação de um título no retorno. No CNAB 240 reúne os
+// segmentos T e U; no CNAB 400, o registro de detalhe
+type Registro struct {
+	// Linha do arquivo onde o registro começa
+	Linha       int    `json:"linha"`
+	NossoNumero string `json:"nosso_numero"`
+	// SeuNumero é o número do documento atribuído pelo beneficiário
+	SeuNumero  string `json:"seu_numero,omitempty"`
+	Carteira   string `json:"carteira,omitempty"`
+	Ocorrencia string `json:"ocorrencia"`
+
+	Vencimento  *time.Time `json:"vencimento,omitempty"`
+	ValorTitulo float64    `json:"valor_titulo"`
+	ValorPago   float64    `json:"valor_pago"`
+	Juros       float64    `json:"juros,omitempty"`
+	Desconto    float64    `json:"desconto,omitempty"`
+	Abatimento  float64    `json:"abatimento,omitempty"`
+
+	DataOcorrencia *time.Time `json:"data_ocorrencia,omitempty"`
+	DataCredito    *time.Time `json:"data_credito,omitempty"`
+
+	// Classificação da ocorrência conforme a tabela do banco
+	liquidacao bool
+	baixa      bool
+}
+
+// Liquidado informa se a ocorrência é um pagamento do título
+func (r *Registro) Liquidado() bool {
+	return r.liquidacao
+}
+
+// Baixado informa se a ocorrência é uma baixa sem pagamento
+func (r *Registro) Baixado() bool {
+	return r.baixa
+}
+
+// DataPagamento é a data da ocorrência ou, na falta dela, a do crédito
+func (r *Registro) DataPagamento() *time.Time {
+	if r.DataOcorrencia != nil {
+		return r.DataOcorrencia
+	}
+	return r.DataCredito
+}
+
+// ocorrencias classifica os códigos de ocorrência de um banco
+type ocorrencias struct {
+	liquidacao []string
+	baixa      []string
+}
+
+// classificar marca o registro como liquidação ou baixa
+func (o ocorrencias) classificar(r *Registro) {
+	for _, codigo := range o.liquidacao {
+		if r.Ocorrencia == codigo {
+			r.liquidacao = true
+		}
+	}
+	for _, codigo := range o.baixa {
+		if r.Ocorrencia == codigo {
+			r.baixa = true
+		}
+	}
+}
+
+// ParseRetorno lê um arquivo de retorno de cobrança, identificando o layout pelo
+// tamanho das linhas
+func ParseRetorno(r io.Reader) (*Retorno, error) {
+	linhas, err := lerLinhas(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(linhas) == 0 {
+		return nil, &ErroLinha{Motivo: "arquivo vazio"}
+	}
+
+	switch len(linhas[0]) {
+	case Layout240:
+		return parseRetorno240(linhas)
+	case Layout400:
+		return parseRetorno400(linhas)
+	default:
+		return nil, &ErroLinha{Linha: 1, Motivo: fmt.Sprintf("linha com %d posições; esperado 240 ou 400", len(linhas[0]))}
+	}
+}
+
+// lerLinhas separa as linhas do arquivo, aceitando CRLF e linhas em branco no final
+func lerLinhas(r io.Reader) ([]string, error) {
+	var linhas []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024), 1024*1024)
+	for scanner.Scan() {
+		linhas = append(linhas, strings.TrimRight(scanner.Text(), "\r\x1a"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo CNAB: %w", err)
+	}
+	for len(linhas) > 0 && strings.TrimSpace(linhas[len(linhas)-1]) == "" {
+		linhas = linhas[:len(linhas)-1]
+	}
+	return linhas, nil
+}
+
+// linhaCNAB lê os campos de uma linha pelas posições do manual (base 1, inclusivas)
+type linhaCNAB struct {
+	linha int
+	texto string
+	err   error
+}
+
+// alfa retorna o campo sem os espaços à direita e à esquerda
+func (l *linhaCNAB) alfa(inicio, fim int) string {
+	return strings.TrimSpace(l.texto[inicio-1 : fim])
+}
+
+// numero retorna o campo numérico sem os zeros à esquerda
+func (l *linhaCNAB) numero(inicio, fim int) string {
+	campo := l.alfa(inicio, fim)
+	if !somenteDigitos(campo) {
+		l.falhar(inicio, fim, "campo numérico inválido")
+		return ""
+	}
+	return strings.TrimLeft(campo, "0")
+}
+
+// valor lê um valor com duas casas decimais implícitas
+func (l *linhaCNAB) valor(inicio, fim int) float64 {
+	campo := l.alfa(inicio, fim)
+	if campo == "" {
+		return 0
+	}
+	centavos, err := strconv.ParseInt(campo, 10, 64)
+	if err != nil || !somenteDigitos(campo) {
+		l.falhar(inicio, fim, "valor inválido")
+		return 0
+	}
+	return float64(centavos) / 100
+}
+
+// data lê uma data DDMMAAAA ou DDMMAA; zeros ou brancos resultam em nil
+func (l *linhaCNAB) data(inicio, fim int) *time.Time {
+	campo := l.alfa(inicio, fim)
+	if strings.Trim(campo, "0") == "" {
+		return nil
+	}
+	formato := "02012006"
+	if fim-inicio+1 == 6 {
+		formato = "020106"
+	}
+	data, err := time.Parse(formato, campo)
+	if err != nil {
+		l.falhar(inicio, fim, "data inválida")
+		return nil
+	}
+	return &data
+}
+
+// falhar registra o primeiro campo inválido da linha
+func (l *linhaCNAB) falhar(inicio, fim int, motivo string) {
+	if l.err == nil {
+		l.err = &ErroLinha{Linha: l.linha, Motivo: fmt.Sprintf("%s nas posições %d a %d", motivo, inicio, fim)}
+	}
+}
+
+// somenteDigitos informa se o texto contém apenas dígitos
+func somenteDigitos(texto string) bool {
+	for _, r := range texto {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
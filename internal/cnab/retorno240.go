@@ -0,0 +1,103 @@
+package cnab
+
+// Tipos de registro do CNAB 240 (posição 8)
+const (
+	registroHeaderArquivo240 = '0'
+	registroDetalhe240       = '3'
+)
+
+// ocorrencias240 segue a tabela FEBRABAN de movimentos de retorno, comum aos bancos:
+// 06 liquidação, 17 liquidação após baixa ou de título não registrado e 09 baixa
+var ocorrencias240 = ocorrencias{
+	liquidacao: []string{"06", "17"},
+	baixa:      []string{"09"},
+}
+
+// nossoNumero240 é a posição do nosso número dentro da identificação do título
+// (posições 38 a 57 do segmento T) em cada banco; os demais usam o campo inteiro
+var nossoNumero240 = map[string][2]int{
+	"001": {38, 54}, // Banco do Brasil: 17 dígitos alinhados à esquerda
+	"033": {41, 53}, // Santander: 13 dígitos, com DV
+	"104": {40, 56}, // Caixa (SIGCB): modalidade e 15 dígitos
+	"237": {46, 56}, // Bradesco: carteira, zeros e 11 dígitos, sem o DV
+	"341": {41, 48}, // Itaú: carteira e 8 dígitos, sem o DAC
+	"756": {38, 47}, // Sicoob: 9 dígitos com DV
+}
+
+// parseRetorno240 lê um retorno CNAB 240, reunindo cada segmento T ao segmento U
+// que o acompanha
+func parseRetorno240(linhas []string) (*Retorno, error) {
+	header := &linhaCNAB{linha: 1, texto: linhas[0]}
+	if header.texto[7] != registroHeaderArquivo240 {
+		return nil, &ErroLinha{Linha: 1, Motivo: "header de arquivo ausente"}
+	}
+	if header.texto[142] != '2' {
+		return nil, &ErroLinha{Linha: 1, Motivo: "arquivo de remessa, não de retorno"}
+	}
+	retorno := &Retorno{
+		Layout:      Layout240,
+		Banco:       header.alfa(1, 3),
+		DataGeracao: header.data(144, 151),
+	}
+	if header.err != nil {
+		return nil, header.err
+	}
+
+	var atual *Registro
+	for i, texto := range linhas {
+		if len(texto) != Layout240 {
+			return nil, &ErroLinha{Linha: i + 1, Motivo: "linha fora do layout de 240 posições"}
+		}
+		if texto[7] != registroDetalhe240 {
+			continue
+		}
+
+		linha := &linhaCNAB{linha: i + 1, texto: texto}
+		switch texto[13] {
+		case 'T':
+			retorno.Registros = append(retorno.Registros, lerSegmentoT(linha, retorno.Banco))
+			atual = &retorno.Registros[len(retorno.Registros)-1]
+		case 'U':
+			if atual == nil {
+				return nil, &ErroLinha{Linha: i + 1, Motivo: "segmento U sem o segmento T correspondente"}
+			}
+			lerSegmentoU(linha, atual)
+			atual = nil
+		}
+		if linha.err != nil {
+			return nil, linha.err
+		}
+	}
+
+	for i := range retorno.Registros {
+		ocorrencias240.classificar(&retorno.Registros[i])
+	}
+	return retorno, nil
+}
+
+// lerSegmentoT lê a identificação do título, o vencimento e o valor
+func lerSegmentoT(linha *linhaCNAB, banco string) Registro {
+	posicao, ok := nossoNumero240[banco]
+	if !ok {
+		posicao = [2]int{38, 57}
+	}
+	return Registro{
+		Linha:       linha.linha,
+		NossoNumero: linha.numero(posicao[0], posicao[1]),
+		SeuNumero:   linha.alfa(59, 73),
+		Carteira:    linha.alfa(58, 58),
+		Ocorrencia:  linha.alfa(16, 17),
+		Vencimento:  linha.data(74, 81),
+		ValorTitulo: linha.valor(82, 96),
+	}
+}
+
+// lerSegmentoU lê os valores pagos e as datas da ocorrência e do crédito
+func lerSegmentoU(linha *linhaCNAB, registro *Registro) {
+	registro.Juros = linha.valor(18, 32)
+	registro.Desconto = linha.valor(33, 47)
+	registro.Abatimento = linha.valor(48, 62)
+	registro.ValorPago = linha.valor(78, 92)
+	registro.DataOcorrencia = linha.data(138, 145)
+	registro.DataCredito = linha.data(146, 153)
+}
@@ -0,0 +1,97 @@
+package cnab
+
+// layout400 reúne o que varia entre os bancos no retorno CNAB 400: a posição do
+// nosso número, a da carteira e a tabela de ocorrências. Os demais campos seguem as
+// posições comuns aos grandes bancos
+type layout400 struct {
+	nossoNumero [2]int
+	carteira    [2]int
+	ocorrencias ocorrencias
+}
+
+// layouts400 associa cada banco suportado ao seu layout de retorno
+var layouts400 = map[string]layout400{
+	// Banco do Brasil (CBR643, convênio de 7 dígitos): nosso número de 17 dígitos
+	"001": {
+		nossoNumero: [2]int{64, 80},
+		carteira:    [2]int{107, 108},
+		ocorrencias: ocorrencias{liquidacao: []string{"05", "06", "07", "08", "15"}, baixa: []string{"09", "10"}},
+	},
+	// Santander: nosso número de 8 dígitos com DV
+	"033": {
+		nossoNumero: [2]int{63, 70},
+		carteira:    [2]int{108, 108},
+		ocorrencias: ocorrencias{liquidacao: []string{"06", "07", "08", "17"}, baixa: []string{"09", "10"}},
+	},
+	// Caixa (SIGCB): modalidade e nosso número de 15 dígitos
+	"104": {
+		nossoNumero: [2]int{57, 73},
+		carteira:    [2]int{107, 108},
+		ocorrencias: ocorrencias{liquidacao: []string{"21", "22"}, baixa: []string{"02", "23"}},
+	},
+	// Bradesco: nosso número de 11 dígitos, sem o DV da posição 82
+	"237": {
+		nossoNumero: [2]int{71, 81},
+		carteira:    [2]int{108, 108},
+		ocorrencias: ocorrencias{liquidacao: []string{"06", "15", "17"}, baixa: []string{"09", "10"}},
+	},
+	// Itaú: nosso número de 8 dígitos e carteira de 3
+	"341": {
+		nossoNumero: [2]int{63, 70},
+		carteira:    [2]int{83, 85},
+		ocorrencias: ocorrencias{liquidacao: []string{"06", "07", "08", "10"}, baixa: []string{"09"}},
+	},
+}
+
+// parseRetorno400 lê um retorno CNAB 400 de um dos bancos suportados
+func parseRetorno400(linhas []string) (*Retorno, error) {
+	header := &linhaCNAB{linha: 1, texto: linhas[0]}
+	if header.alfa(1, 2) != "02" {
+		return nil, &ErroLinha{Linha: 1, Motivo: "header de arquivo de retorno ausente"}
+	}
+	retorno := &Retorno{
+		Layout:      Layout400,
+		Banco:       header.alfa(77, 79),
+		DataGeracao: header.data(95, 100),
+	}
+	if header.err != nil {
+		return nil, header.err
+	}
+	layout, ok := layouts400[retorno.Banco]
+	if !ok {
+		return nil, &ErroLinha{Linha: 1, Motivo: "layout CNAB 400 não suportado para o banco " + retorno.Banco}
+	}
+
+	for i, texto := range linhas {
+		if len(texto) != Layout400 {
+			return nil, &ErroLinha{Linha: i + 1, Motivo: "linha fora do layout de 400 posições"}
+		}
+		// Detalhe: tipo 1 ou, no CBR643 do Banco do Brasil, tipo 7
+		if texto[0] != '1' && texto[0] != '7' {
+			continue
+		}
+
+		linha := &linhaCNAB{linha: i + 1, texto: texto}
+		registro := Registro{
+			Linha:          linha.linha,
+			NossoNumero:    linha.numero(layout.nossoNumero[0], layout.nossoNumero[1]),
+			SeuNumero:      linha.alfa(117, 126),
+			Carteira:       linha.alfa(layout.carteira[0], layout.carteira[1]),
+			Ocorrencia:     linha.alfa(109, 110),
+			DataOcorrencia: linha.data(111, 116),
+			Vencimento:     linha.data(147, 152),
+			ValorTitulo:    linha.valor(153, 165),
+			Abatimento:     linha.valor(228, 240),
+			Desconto:       linha.valor(241, 253),
+			ValorPago:      linha.valor(254, 266),
+			Juros:          linha.valor(267, 279),
+			DataCredito:    linha.data(296, 301),
+		}
+		if linha.err != nil {
+			return nil, linha.err
+		}
+		layout.ocorrencias.classificar(&registro)
+		retorno.Registros = append(retorno.Registros, registro)
+	}
+	return retorno, nil
+}
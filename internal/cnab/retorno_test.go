@@ -0,0 +1,129 @@
+package cnab
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// linha monta uma linha CNAB com os campos nas posições do manual (base 1)
+func linha(tamanho int, campos map[int]string) string {
+	texto := []byte(strings.Repeat(" ", tamanho))
+	for posicao, valor := range campos {
+		copy(texto[posicao-1:], valor)
+	}
+	return string(texto)
+}
+
+// retornoItau240 tem uma liquidação com juros e uma entrada confirmada
+func retornoItau240() string {
+	linhas := []string{
+		linha(240, map[int]string{1: "34100000", 143: "2", 144: "05122023"}),
+		linha(240, map[int]string{1: "34100011"}),
+		linha(240, map[int]string{1: "34100013", 9: "00001", 14: "T", 16: "06", 38: "109123456780", 58: "1", 59: "NF123-1", 74: "30112023", 82: "000000000025075"}),
+		linha(240, map[int]string{1: "34100013", 9: "00002", 14: "U", 16: "06", 18: "000000000000251", 78: "000000000025326", 138: "04122023", 146: "05122023"}),
+		linha(240, map[int]string{1: "34100013", 9: "00003", 14: "T", 16: "02", 38: "109876543210", 74: "15012024", 82: "000000000010000"}),
+		linha(240, map[int]string{1: "34100013", 9: "00004", 14: "U", 16: "02", 138: "04122023"}),
+		linha(240, map[int]string{1: "34100015"}),
+		linha(240, map[int]string{1: "34199999"}),
+	}
+	return strings.Join(linhas, "\r\n") + "\r\n"
+}
+
+// retornoBradesco400 tem uma liquidação e uma baixa
+func retornoBradesco400() string {
+	linhas := []string{
+		linha(400, map[int]string{1: "02RETORNO", 77: "237BRADESCO", 95: "051223"}),
+		linha(400, map[int]string{1: "1", 71: "00000012345P", 108: "9", 109: "06", 111: "041223", 117: "NF123-1", 147: "301123", 153: "0000000025075", 254: "0000000025326", 267: "0000000000251", 296: "051223"}),
+		linha(400, map[int]string{1: "1", 71: "000005432100", 109: "09", 111: "041223", 147: "301123", 153: "0000000010000"}),
+		linha(400, map[int]string{1: "9"}),
+	}
+	return strings.Join(linhas, "\n")
+}
+
+func data(texto string) time.Time {
+	d, _ := time.Parse(time.DateOnly, texto)
+	return d
+}
+
+func TestParseRetorno240(t *testing.T) {
+	retorno, err := ParseRetorno(strings.NewReader(retornoItau240()))
+	assert.NoError(t, err)
+	assert.Equal(t, Layout240, retorno.Layout)
+	assert.Equal(t, "341", retorno.Banco)
+	assert.Equal(t, data("2023-12-05"), *retorno.DataGeracao)
+
+	if assert.Len(t, retorno.Registros, 2) {
+		pago := retorno.Registros[0]
+		assert.Equal(t, 3, pago.Linha)
+		assert.Equal(t, "12345678", pago.NossoNumero)
+		assert.Equal(t, "NF123-1", pago.SeuNumero)
+		assert.Equal(t, "06", pago.Ocorrencia)
+		assert.Equal(t, data("2023-11-30"), *pago.Vencimento)
+		assert.Equal(t, 250.75, pago.ValorTitulo)
+		assert.Equal(t, 253.26, pago.ValorPago)
+		assert.Equal(t, 2.51, pago.Juros)
+		assert.Equal(t, data("2023-12-04"), *pago.DataPagamento())
+		assert.Equal(t, data("2023-12-05"), *pago.DataCredito)
+		assert.True(t, pago.Liquidado())
+
+		entrada := retorno.Registros[1]
+		assert.Equal(t, "87654321", entrada.NossoNumero)
+		assert.False(t, entrada.Liquidado())
+		assert.False(t, entrada.Baixado())
+	}
+}
+
+func TestParseRetorno400(t *testing.T) {
+	retorno, err := ParseRetorno(strings.NewReader(retornoBradesco400()))
+	assert.NoError(t, err)
+	assert.Equal(t, Layout400, retorno.Layout)
+	assert.Equal(t, "237", retorno.Banco)
+
+	if assert.Len(t, retorno.Registros, 2) {
+		pago := retorno.Registros[0]
+		assert.Equal(t, "12345", pago.NossoNumero)
+		assert.Equal(t, "9", pago.Carteira)
+		assert.Equal(t, 253.26, pago.ValorPago)
+		assert.Equal(t, 2.51, pago.Juros)
+		assert.Equal(t, data("2023-12-04"), *pago.DataOcorrencia)
+		assert.True(t, pago.Liquidado())
+
+		baixado := retorno.Registros[1]
+		assert.Equal(t, "543210", baixado.NossoNumero)
+		assert.Nil(t, baixado.DataCredito)
+		assert.True(t, baixado.Baixado())
+	}
+}
+
+func TestParseRetornoInvalido(t *testing.T) {
+	remessa := strings.Replace(retornoItau240(),
+		linha(240, map[int]string{1: "34100000", 143: "2", 144: "05122023"}),
+		linha(240, map[int]string{1: "34100000", 143: "1", 144: "05122023"}), 1)
+	valorInvalido := strings.Replace(retornoBradesco400(), "0000000025326", "00000000253X6", 1)
+	bancoDesconhecido := strings.Replace(retornoBradesco400(), "237BRADESCO", "999OUTRO   ", 1)
+
+	casos := map[string]struct {
+		arquivo string
+		linha   int
+	}{
+		"vazio":                {arquivo: "\n\n"},
+		"tamanho desconhecido": {arquivo: "0" + strings.Repeat(" ", 149), linha: 1},
+		"linha truncada":       {arquivo: retornoItau240() + "3410001", linha: 9},
+		"remessa":              {arquivo: remessa, linha: 1},
+		"valor inválido":       {arquivo: valorInvalido, linha: 2},
+		"banco sem layout":     {arquivo: bancoDesconhecido, linha: 1},
+	}
+	for nome, caso := range casos {
+		t.Run(nome, func(t *testing.T) {
+			_, err := ParseRetorno(strings.NewReader(caso.arquivo))
+			assert.ErrorIs(t, err, ErrArquivoInvalido)
+			var erroLinha *ErroLinha
+			if assert.ErrorAs(t, err, &erroLinha) {
+				assert.Equal(t, caso.linha, erroLinha.Linha)
+			}
+		})
+	}
+}
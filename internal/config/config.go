@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +16,11 @@ type Config struct {
 	Bank     BankConfig
 	Log      LogConfig
 	Cache    CacheConfig
+	DANFE    DANFEConfig
+	Pagamentos PagamentosConfig
+	DDA      DDAConfig
+	Calendario CalendarioConfig
+	Cobranca CobrancaConfig
 }
 
 // ServerConfig representa as configurações do servidor
@@ -48,6 +54,35 @@ type BankConfig struct {
 	Itau        BankAPIConfig
 	Bradesco    BankAPIConfig
 	OpenBanking BankAPIConfig
+	// Pix é a API Pix padronizada pelo Banco Central no PSP recebedor das cobranças
+	// Pix dos boletos híbridos
+	Pix         BankAPIConfig
+	Lote        LoteConfig
+	Webhook     WebhookConfig
+}
+
+// WebhookConfig representa o recebimento das notificações de pagamento enviadas pelos bancos
+type WebhookConfig struct {
+	// CabecalhoCertificado é o cabeçalho em que o proxy que termina o TLS repassa o
+	// certificado do cliente em PEM escapado para URL (ex.: $ssl_client_escaped_cert
+	// do nginx); vazio aceita apenas o certificado da própria conexão
+	CabecalhoCertificado string
+	// ProxiesConfiaveis são os endereços IP ou redes CIDR dos proxies de que o
+	// cabeçalho do certificado é aceito; sem eles, o cabeçalho é ignorado
+	ProxiesConfiaveis []string
+	// CabecalhoVerificacao é o cabeçalho com o resultado da verificação do certificado
+	// pelo proxy (ex.: $ssl_client_verify do nginx), que deve ser SUCCESS quando informado
+	CabecalhoVerificacao string
+}
+
+// LoteConfig representa os limites da consulta de boletos em lote
+type LoteConfig struct {
+	// Concorrencia é o número de códigos consultados simultaneamente
+	Concorrencia int
+	// Prazo é o tempo total do lote; os códigos não consultados até lá resultam em erro
+	Prazo time.Duration
+	// MaxCodigos é o número máximo de códigos por requisição
+	MaxCodigos int
 }
 
 // BankAPIConfig representa as configurações de uma API bancária
@@ -56,6 +91,102 @@ type BankAPIConfig struct {
 	ClientID    string
 	ClientSecret string
 	Timeout     time.Duration
+
+	// Fluxo OAuth2 client credentials; TokenURL vazio desativa a autenticação
+	TokenURL string
+	Scope    string
+	// Certificado e chave do cliente para mTLS, exigido por Itaú, Bradesco e Open
+	// Finance; CAPath acrescenta autoridades certificadoras dos servidores
+	CertPath string
+	KeyPath  string
+	CAPath   string
+	// Beneficiario identifica a conta de cobrança nas consultas por nosso número
+	Beneficiario string
+	// DocumentoBeneficiario é o CNPJ/CPF do beneficiário, exigido pelo Bradesco
+	DocumentoBeneficiario string
+	// JWTKeyPath é a chave privada RSA que assina a asserção JWT bearer (Bradesco) ou
+	// o private_key_jwt (Open Finance); JWTKeyID é o kid publicado no diretório
+	JWTKeyPath string
+	JWTKeyID   string
+	// AuthURL e RedirectURI configuram a autorização do consentimento (Open Finance)
+	AuthURL     string
+	RedirectURI string
+	// Autenticação dos webhooks de notificação: segredo do HMAC-SHA256 do corpo e
+	// impressões digitais SHA-256 dos certificados de cliente aceitos no mTLS
+	WebhookSecret       string
+	WebhookCertificados []string
+}
+
+// String omite o client secret para que a configuração possa ser registrada em log
+func (c BankAPIConfig) String() string {
+	secret := ""
+	if c.ClientSecret != "" {
+		secret = "***"
+	}
+	webhookSecret := ""
+	if c.WebhookSecret != "" {
+		webhookSecret = "***"
+	}
+	return fmt.Sprintf("{URL:%s ClientID:%s ClientSecret:%s Timeout:%s TokenURL:%s Scope:%s CertPath:%s KeyPath:%s CAPath:%s Beneficiario:%s DocumentoBeneficiario:%s JWTKeyPath:%s JWTKeyID:%s AuthURL:%s RedirectURI:%s WebhookSecret:%s WebhookCertificados:%v}",
+		c.URL, c.ClientID, secret, c.Timeout, c.TokenURL, c.Scope, c.CertPath, c.KeyPath, c.CAPath, c.Beneficiario, c.DocumentoBeneficiario,
+		c.JWTKeyPath, c.JWTKeyID, c.AuthURL, c.RedirectURI, webhookSecret, c.WebhookCertificados)
+}
+
+// PagamentosConfig representa a empresa pagadora e as contas debitadas nas remessas
+// de pagamento de boletos, uma por banco
+type PagamentosConfig struct {
+	CNPJ    string
+	Empresa string
+	// Contas indexadas pelo código COMPE do banco
+	Contas map[string]ContaPagamento
+}
+
+// ContaPagamento representa a conta da empresa em um banco pagador
+type ContaPagamento struct {
+	Convenio  string
+	Agencia   string
+	DVAgencia string
+	Conta     string
+	DVConta   string
+}
+
+// DDAConfig representa a captura dos boletos registrados no DDA (Débito Direto
+// Autorizado) contra os CNPJs da empresa
+type DDAConfig struct {
+	// CNPJs são os pagadores consultados nos provedores, um por estabelecimento
+	CNPJs []string
+	// Intervalo entre as consultas agendadas; zero desativa o agendamento
+	Intervalo time.Duration
+	// Janela é o período anterior à consulta em que os títulos são buscados
+	Janela time.Duration
+	// Diretorio recebe os arquivos CNAB 240 de títulos DDA entregues pelos bancos
+	// (VAN ou SFTP), lidos a cada sincronização; vazio desativa a leitura
+	Diretorio string
+}
+
+// CobrancaConfig representa a emissão de boletos para as duplicatas das NF-e emitidas
+// pela empresa
+type CobrancaConfig struct {
+	// Banco é o código COMPE do banco em que os boletos são registrados; vazio
+	// desativa a emissão
+	Banco    string
+	Carteira string
+	// Regras de juros e multa enviadas no registro, com os tipos de models.Boleto e
+	// taxas percentuais
+	TipoJuros string
+	TaxaJuros float64
+	TipoMulta string
+	TaxaMulta float64
+	// Instrucoes são as mensagens impressas em todos os boletos
+	Instrucoes []string
+}
+
+// CalendarioConfig representa o calendário bancário usado na prorrogação dos
+// vencimentos em dias sem expediente
+type CalendarioConfig struct {
+	// FeriadosLocais são os feriados municipais e estaduais, como "MM-DD=Nome" (todo
+	// ano) ou "AAAA-MM-DD=Nome"; os nacionais são calculados
+	FeriadosLocais []string
 }
 
 // LogConfig representa as configurações de log
@@ -70,6 +201,12 @@ type CacheConfig struct {
 	RedisURL string
 }
 
+// DANFEConfig representa as configurações de geração do DANFE
+type DANFEConfig struct {
+	TemplatesDir   string
+	TemplatePadrao string
+}
+
 // Load carrega as configurações das variáveis de ambiente
 func Load() (*Config, error) {
 	config := &Config{
@@ -99,18 +236,68 @@ func Load() (*Config, error) {
 				ClientID:     getEnv("ITAÚ_CLIENT_ID", ""),
 				ClientSecret: getEnv("ITAÚ_CLIENT_SECRET", ""),
 				Timeout:      getEnvDuration("ITAÚ_TIMEOUT", 30*time.Second),
+				TokenURL:     getEnv("ITAÚ_TOKEN_URL", ""),
+				Scope:        getEnv("ITAÚ_SCOPE", ""),
+				CertPath:     getEnv("ITAÚ_CERT_PATH", ""),
+				KeyPath:      getEnv("ITAÚ_KEY_PATH", ""),
+				Beneficiario: getEnv("ITAÚ_ID_BENEFICIARIO", ""),
+
+				WebhookSecret:       getEnv("ITAÚ_WEBHOOK_SECRET", ""),
+				WebhookCertificados: getEnvLista("ITAÚ_WEBHOOK_CERT_SHA256"),
 			},
 			Bradesco: BankAPIConfig{
 				URL:          getEnv("BRADESCO_API_URL", ""),
 				ClientID:     getEnv("BRADESCO_CLIENT_ID", ""),
 				ClientSecret: getEnv("BRADESCO_CLIENT_SECRET", ""),
 				Timeout:      getEnvDuration("BRADESCO_TIMEOUT", 30*time.Second),
+				TokenURL:     getEnv("BRADESCO_TOKEN_URL", ""),
+				Scope:        getEnv("BRADESCO_SCOPE", ""),
+				CertPath:     getEnv("BRADESCO_CERT_PATH", ""),
+				KeyPath:      getEnv("BRADESCO_KEY_PATH", ""),
+				Beneficiario: getEnv("BRADESCO_NEGOCIACAO", ""),
+				JWTKeyPath:   getEnv("BRADESCO_JWT_KEY_PATH", ""),
+
+				DocumentoBeneficiario: getEnv("BRADESCO_CNPJ_BENEFICIARIO", ""),
+				WebhookSecret:         getEnv("BRADESCO_WEBHOOK_SECRET", ""),
+				WebhookCertificados:   getEnvLista("BRADESCO_WEBHOOK_CERT_SHA256"),
 			},
 			OpenBanking: BankAPIConfig{
 				URL:          getEnv("OPEN_BANKING_URL", ""),
 				ClientID:     getEnv("OPEN_BANKING_CLIENT_ID", ""),
 				ClientSecret: getEnv("OPEN_BANKING_CLIENT_SECRET", ""),
 				Timeout:      getEnvDuration("OPEN_BANKING_TIMEOUT", 30*time.Second),
+				TokenURL:     getEnv("OPEN_BANKING_TOKEN_URL", ""),
+				Scope:        getEnv("OPEN_BANKING_SCOPE", ""),
+				CertPath:     getEnv("OPEN_BANKING_CERT_PATH", ""),
+				KeyPath:      getEnv("OPEN_BANKING_KEY_PATH", ""),
+				CAPath:       getEnv("OPEN_BANKING_CA_PATH", ""),
+				JWTKeyPath:   getEnv("OPEN_BANKING_JWT_KEY_PATH", ""),
+				JWTKeyID:     getEnv("OPEN_BANKING_JWT_KID", ""),
+				AuthURL:      getEnv("OPEN_BANKING_AUTH_URL", ""),
+				RedirectURI:  getEnv("OPEN_BANKING_REDIRECT_URI", ""),
+
+				DocumentoBeneficiario: getEnv("OPEN_BANKING_CNPJ", ""),
+			},
+			Pix: BankAPIConfig{
+				URL:          getEnv("PIX_API_URL", ""),
+				ClientID:     getEnv("PIX_CLIENT_ID", ""),
+				ClientSecret: getEnv("PIX_CLIENT_SECRET", ""),
+				Timeout:      getEnvDuration("PIX_TIMEOUT", 30*time.Second),
+				TokenURL:     getEnv("PIX_TOKEN_URL", ""),
+				Scope:        getEnv("PIX_SCOPE", ""),
+				CertPath:     getEnv("PIX_CERT_PATH", ""),
+				KeyPath:      getEnv("PIX_KEY_PATH", ""),
+				CAPath:       getEnv("PIX_CA_PATH", ""),
+			},
+			Lote: LoteConfig{
+				Concorrencia: getEnvInt("BOLETOS_LOTE_CONCORRENCIA", 16),
+				Prazo:        getEnvDuration("BOLETOS_LOTE_PRAZO", 2*time.Minute),
+				MaxCodigos:   getEnvInt("BOLETOS_LOTE_MAXIMO", 5000),
+			},
+			Webhook: WebhookConfig{
+				CabecalhoCertificado: getEnv("WEBHOOK_CABECALHO_CERTIFICADO", ""),
+				ProxiesConfiaveis:    getEnvLista("WEBHOOK_PROXIES_CONFIAVEIS"),
+				CabecalhoVerificacao: getEnv("WEBHOOK_CABECALHO_VERIFICACAO", ""),
 			},
 		},
 		Log: LogConfig{
@@ -121,6 +308,33 @@ func Load() (*Config, error) {
 			TTL:      getEnvDuration("CACHE_TTL", time.Hour),
 			RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
 		},
+		DANFE: DANFEConfig{
+			TemplatesDir:   getEnv("DANFE_TEMPLATES_DIR", "./templates/danfe"),
+			TemplatePadrao: getEnv("DANFE_TEMPLATE_PADRAO", ""),
+		},
+		Pagamentos: PagamentosConfig{
+			CNPJ:    getEnv("PAGAMENTOS_CNPJ", ""),
+			Empresa: getEnv("PAGAMENTOS_EMPRESA", ""),
+			Contas:  getEnvContasPagamento("PAGAMENTOS_BANCOS"),
+		},
+		DDA: DDAConfig{
+			CNPJs:     getEnvLista("DDA_CNPJS"),
+			Intervalo: getEnvDuration("DDA_INTERVALO", 0),
+			Janela:    getEnvDuration("DDA_JANELA", 30*24*time.Hour),
+			Diretorio: getEnv("DDA_DIRETORIO", ""),
+		},
+		Calendario: CalendarioConfig{
+			FeriadosLocais: getEnvLista("FERIADOS_LOCAIS"),
+		},
+		Cobranca: CobrancaConfig{
+			Banco:      getEnv("COBRANCA_BANCO", ""),
+			Carteira:   getEnv("COBRANCA_CARTEIRA", ""),
+			TipoJuros:  getEnv("COBRANCA_JUROS_TIPO", ""),
+			TaxaJuros:  getEnvFloat("COBRANCA_JUROS_TAXA", 0),
+			TipoMulta:  getEnv("COBRANCA_MULTA_TIPO", ""),
+			TaxaMulta:  getEnvFloat("COBRANCA_MULTA_TAXA", 0),
+			Instrucoes: getEnvLista("COBRANCA_INSTRUCOES"),
+		},
 	}
 
 	return config, nil
@@ -154,6 +368,51 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvFloat obtém uma variável de ambiente como número decimal ou retorna um valor padrão
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvLista obtém uma variável de ambiente com valores separados por vírgula
+func getEnvLista(key string) []string {
+	var valores []string
+	for _, valor := range strings.Split(getEnv(key, ""), ",") {
+		if valor = strings.TrimSpace(valor); valor != "" {
+			valores = append(valores, valor)
+		}
+	}
+	return valores
+}
+
+// getEnvContasPagamento lê as contas dos bancos listados na variável (ex.: "341,237"),
+// cada uma em PAGAMENTOS_<banco>_CONVENIO, _AGENCIA e _CONTA; agência e conta
+// aceitam o DV após o hífen
+func getEnvContasPagamento(key string) map[string]ContaPagamento {
+	contas := make(map[string]ContaPagamento)
+	for _, banco := range strings.Split(getEnv(key, ""), ",") {
+		banco = strings.TrimSpace(banco)
+		if banco == "" {
+			continue
+		}
+		prefixo := "PAGAMENTOS_" + banco + "_"
+		agencia, dvAgencia, _ := strings.Cut(getEnv(prefixo+"AGENCIA", ""), "-")
+		conta, dvConta, _ := strings.Cut(getEnv(prefixo+"CONTA", ""), "-")
+		contas[banco] = ContaPagamento{
+			Convenio:  getEnv(prefixo+"CONVENIO", ""),
+			Agencia:   agencia,
+			DVAgencia: dvAgencia,
+			Conta:     conta,
+			DVConta:   dvConta,
+		}
+	}
+	return contas
+}
+
 // GetDatabaseDSN retorna a string de conexão do banco de dados
 func (c *DatabaseConfig) GetDatabaseDSN() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
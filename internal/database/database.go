@@ -39,6 +39,13 @@ func Migrate(db *gorm.DB) error {
 		&models.NFe{},
 		&models.Duplicata{},
 		&models.Boleto{},
+		&models.DescontoBoleto{},
+		&models.ConsentimentoOpenFinance{},
+		&models.RemessaPagamento{},
+		&models.VinculoDuplicata{},
+		&models.LancamentoExtrato{},
+		&models.SequenciaNossoNumero{},
+		&models.NotificacaoWebhook{},
 	)
 }
 
@@ -3,17 +3,45 @@ package dto
 import "time"
 
 type BoletoDTO struct {
-	ID             uint       `json:"id"`
-	DuplicataID    *uint      `json:"duplicata_id,omitempty"`
-	Banco          string     `json:"banco"`
-	Numero         string     `json:"numero"`
-	CodigoBarras   string     `json:"codigo_barras"`
-	LinhaDigitavel string     `json:"linha_digitavel"`
-	Valor          float64    `json:"valor"`
-	Vencimento     time.Time  `json:"vencimento"`
-	Status         string     `json:"status"`
-	DataPagamento  *time.Time `json:"data_pagamento,omitempty"`
-	ValorPago      *float64   `json:"valor_pago,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID                    uint                `json:"id"`
+	DuplicataID           *uint               `json:"duplicata_id,omitempty"`
+	Tipo                  string              `json:"tipo"`
+	Banco                 string              `json:"banco"`
+	Numero                string              `json:"numero"`
+	CodigoBarras          string              `json:"codigo_barras"`
+	LinhaDigitavel        string              `json:"linha_digitavel"`
+	Segmento              string              `json:"segmento,omitempty"`
+	Convenio              string              `json:"convenio,omitempty"`
+	NossoNumero           string              `json:"nosso_numero,omitempty"`
+	Agencia               string              `json:"agencia,omitempty"`
+	Conta                 string              `json:"conta,omitempty"`
+	Carteira              string              `json:"carteira,omitempty"`
+	DocumentoBeneficiario string              `json:"documento_beneficiario,omitempty"`
+	NomeBeneficiario      string              `json:"nome_beneficiario,omitempty"`
+	ValorJuros            float64             `json:"valor_juros,omitempty"`
+	ValorMulta            float64             `json:"valor_multa,omitempty"`
+	ValorDesconto         float64             `json:"valor_desconto,omitempty"`
+	TipoJuros             string              `json:"tipo_juros,omitempty"`
+	TaxaJuros             float64             `json:"taxa_juros,omitempty"`
+	TipoMulta             string              `json:"tipo_multa,omitempty"`
+	TaxaMulta             float64             `json:"taxa_multa,omitempty"`
+	ValorAbatimento       float64             `json:"valor_abatimento,omitempty"`
+	Descontos             []DescontoBoletoDTO `json:"descontos,omitempty"`
+	PixCopiaECola         string              `json:"pix_copia_e_cola,omitempty"`
+	PixTxID               string              `json:"pix_txid,omitempty"`
+	RemessaPagamentoID    *uint               `json:"remessa_pagamento_id,omitempty"`
+	Emitido               bool                `json:"emitido,omitempty"`
+	Valor                 float64             `json:"valor"`
+	Vencimento            time.Time           `json:"vencimento"`
+	Status                string              `json:"status"`
+	DataPagamento         *time.Time          `json:"data_pagamento,omitempty"`
+	ValorPago             *float64            `json:"valor_pago,omitempty"`
+	CreatedAt             time.Time           `json:"created_at"`
+	UpdatedAt             time.Time           `json:"updated_at"`
+}
+
+type DescontoBoletoDTO struct {
+	DataLimite time.Time `json:"data_limite"`
+	Tipo       string    `json:"tipo"`
+	Valor      float64   `json:"valor"`
 }
@@ -0,0 +1,138 @@
+package extrato
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Colunas do CSV, reconhecidas pelo início do nome normalizado do cabeçalho
+var colunasCSV = map[string][]string{
+	"data":          {"data", "dt"},
+	"valor":         {"valor", "montante"},
+	"descricao":     {"descricao", "historico", "lancamento", "memo", "detalhe"},
+	"documento":     {"documento", "n documento", "no documento", "numero documento", "doc"},
+	"tipo":          {"tipo", "d c", "natureza"},
+	"identificador": {"identificador", "id"},
+}
+
+// parseCSV lê o CSV separado por ponto e vírgula, vírgula ou tabulação. As linhas
+// anteriores ao cabeçalho (dados da conta) e as de saldo são ignoradas
+func parseCSV(texto string) (*Extrato, error) {
+	leitor := csv.NewReader(strings.NewReader(texto))
+	leitor.Comma = separadorCSV(texto)
+	leitor.FieldsPerRecord = -1
+	leitor.LazyQuotes = true
+	registros, err := leitor.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExtratoInvalido, err)
+	}
+
+	inicio, colunas := cabecalhoCSV(registros)
+	if colunas == nil {
+		return nil, fmt.Errorf("%w: cabeçalho com as colunas de data e valor não encontrado", ErrExtratoInvalido)
+	}
+
+	extrato := &Extrato{Formato: FormatoCSV, Transacoes: []Transacao{}}
+	gerados := make(identificadores)
+	for i, registro := range registros[inicio+1:] {
+		campo := func(nome string) string {
+			if indice, ok := colunas[nome]; ok && indice < len(registro) {
+				return strings.TrimSpace(registro[indice])
+			}
+			return ""
+		}
+		descricao := campo("descricao")
+		if campo("data") == "" || strings.HasPrefix(strings.ToUpper(descricao), "SALDO") {
+			continue
+		}
+
+		linha := inicio + i + 2
+		data, err := parseData(campo("data"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: %v", ErrExtratoInvalido, linha, err)
+		}
+		valor, err := parseValor(campo("valor"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: linha %d: valor inválido: %q", ErrExtratoInvalido, linha, campo("valor"))
+		}
+		switch tipo := strings.ToUpper(campo("tipo")); {
+		case strings.HasPrefix(tipo, "D"):
+			valor = -math.Abs(valor)
+		case strings.HasPrefix(tipo, "C"):
+			valor = math.Abs(valor)
+		}
+
+		transacao := Transacao{
+			Identificador: campo("identificador"),
+			Data:          data,
+			Valor:         valor,
+			Descricao:     descricao,
+			Documento:     campo("documento"),
+		}
+		if transacao.Identificador == "" {
+			transacao.Identificador = gerados.gerar(&transacao)
+		}
+		extrato.Transacoes = append(extrato.Transacoes, transacao)
+	}
+	return extrato, nil
+}
+
+// separadorCSV escolhe o separador mais frequente nas primeiras linhas
+func separadorCSV(texto string) rune {
+	inicio := strings.Join(strings.SplitN(strings.TrimSpace(texto), "\n", 6), "\n")
+	separador, maior := ';', strings.Count(inicio, ";")
+	for _, candidato := range []rune{',', '\t'} {
+		if quantidade := strings.Count(inicio, string(candidato)); quantidade > maior {
+			separador, maior = candidato, quantidade
+		}
+	}
+	return separador
+}
+
+// cabecalhoCSV localiza a linha de cabeçalho e o índice de cada coluna conhecida
+func cabecalhoCSV(registros [][]string) (int, map[string]int) {
+	for i, registro := range registros {
+		colunas := make(map[string]int)
+		for indice, nome := range registro {
+			nome = nomeColuna(nome)
+			for coluna, prefixos := range colunasCSV {
+				if _, existe := colunas[coluna]; existe {
+					continue
+				}
+				for _, prefixo := range prefixos {
+					if nome == prefixo || strings.HasPrefix(nome, prefixo+" ") || (len(prefixo) > 3 && strings.HasPrefix(nome, prefixo)) {
+						colunas[coluna] = indice
+						break
+					}
+				}
+			}
+		}
+		_, temData := colunas["data"]
+		_, temValor := colunas["valor"]
+		if temData && temValor {
+			return i, colunas
+		}
+	}
+	return 0, nil
+}
+
+// nomeColuna normaliza o nome da coluna: minúsculas, sem acentos e com os símbolos
+// trocados por espaços
+func nomeColuna(nome string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(nome)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
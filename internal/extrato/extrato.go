@@ -0,0 +1,123 @@
+// Package extrato lê os extratos de conta corrente exportados pelos bancos, em OFX
+// (1.x SGML e 2.x XML) ou CSV, para a conciliação dos pagamentos de boletos
+package extrato
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Formatos de extrato aceitos
+const (
+	FormatoOFX = "OFX"
+	FormatoCSV = "CSV"
+)
+
+// tamanhoMaximo limita a leitura do extrato
+const tamanhoMaximo = 10 << 20
+
+// ErrExtratoInvalido indica um arquivo que não é um extrato OFX ou CSV reconhecido
+var ErrExtratoInvalido = errors.New("extrato inválido")
+
+// Extrato é o extrato de uma conta corrente
+type Extrato struct {
+	Formato string `json:"formato"`
+	// Banco é o código COMPE informado no OFX; vazio no CSV
+	Banco      string      `json:"banco,omitempty"`
+	Conta      string      `json:"conta,omitempty"`
+	Transacoes []Transacao `json:"transacoes"`
+}
+
+// Transacao é um lançamento do extrato. Débitos têm valor negativo
+type Transacao struct {
+	// Identificador é o FITID do OFX; no CSV, é montado a partir dos campos
+	Identificador string    `json:"identificador"`
+	Data          time.Time `json:"data"`
+	Valor         float64   `json:"valor"`
+	Descricao     string    `json:"descricao"`
+	Documento     string    `json:"documento,omitempty"`
+}
+
+// Debito informa se o lançamento é uma saída da conta
+func (t *Transacao) Debito() bool {
+	return t.Valor < 0
+}
+
+// Parse lê o extrato, identificando o formato pelo conteúdo
+func Parse(r io.Reader) (*Extrato, error) {
+	dados, err := io.ReadAll(io.LimitReader(r, tamanhoMaximo))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler extrato: %w", err)
+	}
+	texto := decodificar(dados)
+	if strings.TrimSpace(texto) == "" {
+		return nil, fmt.Errorf("%w: arquivo vazio", ErrExtratoInvalido)
+	}
+
+	if strings.Contains(strings.ToUpper(texto), "<OFX>") {
+		return parseOFX(texto)
+	}
+	return parseCSV(texto)
+}
+
+// decodificar converte para UTF-8 os extratos em Windows-1252, comuns nos OFX 1.x
+func decodificar(dados []byte) string {
+	dados = bytes.TrimPrefix(dados, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(dados) {
+		return string(dados)
+	}
+	convertido, err := charmap.Windows1252.NewDecoder().Bytes(dados)
+	if err != nil {
+		return string(dados)
+	}
+	return string(convertido)
+}
+
+// identificadores gera os identificadores dos lançamentos que não trazem um do banco.
+// Lançamentos iguais no mesmo arquivo são diferenciados pela ocorrência, para que a
+// reimportação de um período já lido não os repita
+type identificadores map[string]int
+
+func (ids identificadores) gerar(t *Transacao) string {
+	chave := fmt.Sprintf("%s|%.2f|%s|%s", t.Data.Format("20060102"), t.Valor, t.Descricao, t.Documento)
+	ids[chave]++
+	soma := sha1.Sum([]byte(fmt.Sprintf("%s|%d", chave, ids[chave])))
+	return hex.EncodeToString(soma[:10])
+}
+
+// parseValor lê valores com ponto ou vírgula decimal e separador de milhar
+func parseValor(texto string) (float64, error) {
+	texto = strings.ReplaceAll(strings.TrimSpace(texto), " ", "")
+	texto = strings.TrimPrefix(texto, "R$")
+	if strings.Contains(texto, ",") {
+		texto = strings.ReplaceAll(texto, ".", "")
+		texto = strings.ReplaceAll(texto, ",", ".")
+	}
+	return strconv.ParseFloat(texto, 64)
+}
+
+// parseData lê datas AAAAMMDD (com horário e fuso do OFX), DD/MM/AAAA ou AAAA-MM-DD
+func parseData(texto string) (time.Time, error) {
+	texto = strings.TrimSpace(texto)
+	for _, formato := range []string{"02/01/2006", "2006-01-02", "02-01-2006", "02/01/06"} {
+		if data, err := time.Parse(formato, texto); err == nil {
+			return data, nil
+		}
+	}
+	if len(texto) >= 8 {
+		if data, err := time.Parse("20060102", texto[:8]); err == nil {
+			return data, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida: %q", texto)
+}
@@ -0,0 +1,131 @@
+package extrato
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func data(texto string) time.Time {
+	d, _ := time.Parse("2006-01-02", texto)
+	return d
+}
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+ENCODING:USASCII
+CHARSET:1252
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20231205</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>BRL
+<BANKACCTFROM><BANKID>0341<ACCTID>00057123457<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST><DTSTART>20231101<DTEND>20231130
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231130120000[-3:BRT]<TRNAMT>-253.26<FITID>20231130001<CHECKNUM>000123<MEMO>PAGTO BOLETO 34191.09123 EMPRESA EXEMPLO</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20231130<TRNAMT>1500,00<FITID>20231130002<NAME>TED RECEBIDA<MEMO>CLIENTE &amp; CIA</STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFXSGML(t *testing.T) {
+	// Arquivos OFX 1.x costumam vir em Windows-1252
+	arquivo := strings.Replace(ofxSGML, "EMPRESA EXEMPLO", "S\xc3O JO\xc3O", 1)
+
+	extrato, err := Parse(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatoOFX, extrato.Formato)
+	assert.Equal(t, "341", extrato.Banco)
+	assert.Equal(t, "00057123457", extrato.Conta)
+	if !assert.Len(t, extrato.Transacoes, 2) {
+		return
+	}
+
+	debito := extrato.Transacoes[0]
+	assert.Equal(t, "20231130001", debito.Identificador)
+	assert.Equal(t, data("2023-11-30"), debito.Data)
+	assert.Equal(t, -253.26, debito.Valor)
+	assert.Equal(t, "PAGTO BOLETO 34191.09123 SÃO JOÃO", debito.Descricao)
+	assert.Equal(t, "000123", debito.Documento)
+	assert.True(t, debito.Debito())
+
+	credito := extrato.Transacoes[1]
+	assert.Equal(t, 1500.0, credito.Valor)
+	assert.Equal(t, "TED RECEBIDA CLIENTE & CIA", credito.Descricao)
+	assert.False(t, credito.Debito())
+}
+
+func TestParseOFXXML(t *testing.T) {
+	arquivo := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+  <BANKMSGSRSV1><STMTTRNRS><STMTRS>
+    <BANKACCTFROM><BANKID>237</BANKID><ACCTID>12345-6</ACCTID></BANKACCTFROM>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>PAYMENT</TRNTYPE>
+        <DTPOSTED>20231201</DTPOSTED>
+        <TRNAMT>-99.90</TRNAMT>
+        <MEMO>PAGAMENTO DE TITULO</MEMO>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>`
+
+	extrato, err := Parse(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, "237", extrato.Banco)
+	if assert.Len(t, extrato.Transacoes, 1) {
+		transacao := extrato.Transacoes[0]
+		assert.Equal(t, data("2023-12-01"), transacao.Data)
+		assert.Equal(t, -99.90, transacao.Valor)
+		assert.Equal(t, "PAGAMENTO DE TITULO", transacao.Descricao)
+		// Sem FITID, o identificador é estável entre importações
+		assert.Len(t, transacao.Identificador, 20)
+		novamente, _ := Parse(strings.NewReader(arquivo))
+		assert.Equal(t, transacao.Identificador, novamente.Transacoes[0].Identificador)
+	}
+
+	_, err = Parse(strings.NewReader(strings.Replace(arquivo, "<TRNAMT>-99.90", "<TRNAMT>abc", 1)))
+	assert.ErrorIs(t, err, ErrExtratoInvalido)
+}
+
+func TestParseCSV(t *testing.T) {
+	arquivo := strings.Join([]string{
+		"Extrato de conta corrente;Agência 0057;Conta 12345-7",
+		"",
+		"Data Lançamento;Histórico;Nº Documento;Valor (R$);Saldo (R$)",
+		"01/11/2023;SALDO ANTERIOR;;;1.000,00",
+		"30/11/2023;PAGTO TITULO 23796907800000099903;000123;-1.099,90;-99,90",
+		"30/11/2023;PAGTO TITULO;;-50,00;-149,90",
+		"30/11/2023;PAGTO TITULO;;-50,00;-199,90",
+		"01/12/2023;PIX RECEBIDO;;300,00;100,10",
+	}, "\r\n")
+
+	extrato, err := Parse(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatoCSV, extrato.Formato)
+	if !assert.Len(t, extrato.Transacoes, 4) {
+		return
+	}
+	transacao := extrato.Transacoes[0]
+	assert.Equal(t, data("2023-11-30"), transacao.Data)
+	assert.Equal(t, -1099.90, transacao.Valor)
+	assert.Equal(t, "PAGTO TITULO 23796907800000099903", transacao.Descricao)
+	assert.Equal(t, "000123", transacao.Documento)
+	// Lançamentos iguais recebem identificadores distintos
+	assert.NotEqual(t, extrato.Transacoes[1].Identificador, extrato.Transacoes[2].Identificador)
+	assert.Equal(t, 300.0, extrato.Transacoes[3].Valor)
+
+	// Coluna de tipo com o valor sem sinal
+	extrato, err = Parse(strings.NewReader("data,descricao,valor,tipo\n2023-11-30,BOLETO,99.90,D\n"))
+	assert.NoError(t, err)
+	if assert.Len(t, extrato.Transacoes, 1) {
+		assert.Equal(t, -99.90, extrato.Transacoes[0].Valor)
+	}
+
+	_, err = Parse(strings.NewReader("nome;conta\nfulano;123\n"))
+	assert.ErrorIs(t, err, ErrExtratoInvalido)
+}
@@ -0,0 +1,99 @@
+package extrato
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// parseOFX lê o OFX percorrendo as tags: no 1.x (SGML) os elementos com valor não
+// são fechados e no 2.x (XML) são, por isso apenas os agregados STMTTRN delimitam as
+// transações e os fechamentos dos demais elementos são ignorados
+func parseOFX(texto string) (*Extrato, error) {
+	corpo := texto[strings.Index(strings.ToUpper(texto), "<OFX>"):]
+	extrato := &Extrato{Formato: FormatoOFX, Transacoes: []Transacao{}}
+
+	var campos map[string]string
+	gerados := make(identificadores)
+	for {
+		abre := strings.IndexByte(corpo, '<')
+		if abre < 0 {
+			break
+		}
+		fecha := strings.IndexByte(corpo[abre:], '>')
+		if fecha < 0 {
+			return nil, fmt.Errorf("%w: tag OFX não fechada", ErrExtratoInvalido)
+		}
+		tag := strings.ToUpper(strings.TrimSpace(corpo[abre+1 : abre+fecha]))
+		corpo = corpo[abre+fecha+1:]
+		proxima := strings.IndexByte(corpo, '<')
+		if proxima < 0 {
+			proxima = len(corpo)
+		}
+		valor := html.UnescapeString(strings.TrimSpace(corpo[:proxima]))
+
+		switch {
+		case tag == "STMTTRN":
+			campos = make(map[string]string)
+		case tag == "/STMTTRN":
+			if campos == nil {
+				return nil, fmt.Errorf("%w: STMTTRN fechado sem abertura", ErrExtratoInvalido)
+			}
+			transacao, err := transacaoOFX(campos, len(extrato.Transacoes)+1, gerados)
+			if err != nil {
+				return nil, err
+			}
+			extrato.Transacoes = append(extrato.Transacoes, *transacao)
+			campos = nil
+		case valor == "" || strings.HasPrefix(tag, "/"):
+		case campos != nil:
+			campos[tag] = valor
+		case tag == "BANKID":
+			extrato.Banco = codigoBanco(valor)
+		case tag == "ACCTID":
+			extrato.Conta = valor
+		}
+	}
+	return extrato, nil
+}
+
+// transacaoOFX monta a transação a partir dos elementos do STMTTRN
+func transacaoOFX(campos map[string]string, ordem int, gerados identificadores) (*Transacao, error) {
+	data, err := parseData(campos["DTPOSTED"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: transação %d: %v", ErrExtratoInvalido, ordem, err)
+	}
+	valor, err := parseValor(campos["TRNAMT"])
+	if err != nil {
+		return nil, fmt.Errorf("%w: transação %d: valor inválido: %q", ErrExtratoInvalido, ordem, campos["TRNAMT"])
+	}
+
+	descricao := campos["MEMO"]
+	if nome := campos["NAME"]; nome != "" && !strings.Contains(descricao, nome) {
+		descricao = strings.TrimSpace(nome + " " + descricao)
+	}
+	documento := campos["CHECKNUM"]
+	if documento == "" {
+		documento = campos["REFNUM"]
+	}
+	transacao := &Transacao{
+		Identificador: campos["FITID"],
+		Data:          data,
+		Valor:         valor,
+		Descricao:     descricao,
+		Documento:     documento,
+	}
+	if transacao.Identificador == "" {
+		transacao.Identificador = gerados.gerar(transacao)
+	}
+	return transacao, nil
+}
+
+// codigoBanco reduz o BANKID, que alguns bancos informam com quatro dígitos, ao
+// código COMPE
+func codigoBanco(bankID string) string {
+	if len(bankID) > 3 {
+		return bankID[len(bankID)-3:]
+	}
+	return bankID
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +33,21 @@ func ConsultarBoleto(bankService *services.BankService) gin.HandlerFunc {
 
 		// Consulta boleto
 		boleto, err := bankService.ConsultarBoleto(codigo)
+		if errors.Is(err, utils.ErrCodigoBoletoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Código de boleto inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, bancos.ErrBoletoNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Boleto não encontrado",
+			})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -50,6 +73,144 @@ func ConsultarBoleto(bankService *services.BankService) gin.HandlerFunc {
 	}
 }
 
+// ValorAtualizadoBoleto handler para calcular o valor devido do boleto na data
+// informada em "data" (AAAA-MM-DD), ou no dia corrente, com desconto ou encargos
+func ValorAtualizadoBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, ok := dataReferencia(c)
+		if !ok {
+			return
+		}
+
+		valor, err := bankService.ValorAtualizado(c.Param("codigo"), data)
+		if errors.Is(err, utils.ErrCodigoBoletoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Código de boleto inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, bancos.ErrBoletoNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Boleto não encontrado",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao calcular valor atualizado do boleto",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Valor atualizado calculado com sucesso",
+			"data":    valor,
+		})
+	}
+}
+
+// BoletoPDF handler para imprimir o boleto com a ficha de compensação
+func BoletoPDF(bankService *services.BankService, pdfService *services.PDFService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		boleto, nfe, err := bankService.ConsultarBoletoImpressao(c.Param("codigo"))
+		if errors.Is(err, utils.ErrCodigoBoletoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Código de boleto inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, bancos.ErrBoletoNaoEncontrado) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Boleto não encontrado",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao consultar boleto",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		documento, err := pdfService.GerarBoleto(boleto, nfe)
+		if errors.Is(err, services.ErrBoletoNaoImprimivel) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"message": "Boleto não pode ser impresso",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar PDF do boleto",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", "inline; filename=boleto_"+boleto.CodigoBarras+".pdf")
+		c.Data(http.StatusOK, "application/pdf", documento)
+	}
+}
+
+// RelatorioVencimentosBoletos handler para agrupar os boletos em aberto por faixa de
+// atraso na data informada em "data" (AAAA-MM-DD), ou no dia corrente
+func RelatorioVencimentosBoletos(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, ok := dataReferencia(c)
+		if !ok {
+			return
+		}
+
+		relatorio, err := bankService.RelatorioVencimentos(data)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar relatório de vencimentos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Relatório de vencimentos gerado com sucesso",
+			"data":    relatorio,
+		})
+	}
+}
+
+// dataReferencia lê a data do parâmetro "data" (AAAA-MM-DD), com o dia corrente como
+// padrão, respondendo 400 quando inválida
+func dataReferencia(c *gin.Context) (time.Time, bool) {
+	texto := c.Query("data")
+	if texto == "" {
+		return time.Now(), true
+	}
+	data, err := time.Parse("2006-01-02", texto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data inválida, use o formato AAAA-MM-DD",
+		})
+		return time.Time{}, false
+	}
+	return data, true
+}
+
 // ConsultarMultiplosBoletos handler para consultar múltiplos boletos
 func ConsultarMultiplosBoletos(bankService *services.BankService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -72,8 +233,15 @@ func ConsultarMultiplosBoletos(bankService *services.BankService) gin.HandlerFun
 			return
 		}
 
-		// Consulta múltiplos boletos
-		boletos, err := bankService.ConsultarMultiplosBoletos(req.Codigos)
+		// Cada código tem o seu resultado; códigos inválidos não impedem a consulta dos demais
+		lote, err := bankService.ConsultarMultiplosBoletos(c.Request.Context(), req.Codigos)
+		if errors.Is(err, services.ErrLoteExcedido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("No máximo %d códigos de boleto por consulta", bankService.MaxCodigosLote()),
+			})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -86,7 +254,31 @@ func ConsultarMultiplosBoletos(bankService *services.BankService) gin.HandlerFun
 		c.JSON(http.StatusOK, models.ConsultaBoletosResponse{
 			Success: true,
 			Message: "Boletos consultados com sucesso",
-			Data:    boletos,
+			Data:    lote,
+		})
+	}
+}
+
+// DecodificarBoleto handler para validar um código de barras ou linha digitável e
+// extrair banco, valor e vencimento, com a conversão entre os formatos
+func DecodificarBoleto() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		codigo := c.Param("codigo")
+
+		parsed, err := utils.ParseCodigoBoleto(codigo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Código de boleto inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Código de boleto válido",
+			"data":    parsed,
 		})
 	}
 }
@@ -166,3 +358,325 @@ func GerarCodigoBarras(bankService *services.BankService) gin.HandlerFunc {
 		})
 	}
 }
+
+// AssociarPixBoleto handler para gravar o Pix copia-e-cola de um boleto híbrido
+func AssociarPixBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AssociarPixRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Dados inválidos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		boleto, err := bankService.AssociarPix(c.Param("codigo"), req.PixCopiaECola, req.TxID)
+		if err != nil {
+			responderErroPix(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Pix associado ao boleto com sucesso",
+			"data":    boleto,
+		})
+	}
+}
+
+// ConsultarPixBoleto handler para obter o Pix copia-e-cola de um boleto e os dados
+// do BR Code decodificado
+func ConsultarPixBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		boleto, brcode, err := bankService.ConsultarPix(c.Param("codigo"))
+		if err != nil {
+			responderErroPix(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Pix do boleto consultado com sucesso",
+			"data": gin.H{
+				"pix_copia_e_cola": boleto.PixCopiaECola,
+				"dinamico":         brcode.Dinamico(),
+				"brcode":           brcode,
+			},
+		})
+	}
+}
+
+// QRCodePixBoleto handler para gerar a imagem PNG do QR Code Pix de um boleto
+func QRCodePixBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		escala, err := strconv.Atoi(c.DefaultQuery("escala", "8"))
+		if err != nil || escala < 1 || escala > 32 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Escala inválida",
+			})
+			return
+		}
+
+		boleto, _, err := bankService.ConsultarPix(c.Param("codigo"))
+		if err != nil {
+			responderErroPix(c, err)
+			return
+		}
+
+		qr, err := utils.GerarQRCode(boleto.PixCopiaECola)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar QR Code",
+				"error":   err.Error(),
+			})
+			return
+		}
+		imagem, err := qr.PNG(escala)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar QR Code",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", "inline; filename=pix_"+boleto.CodigoBarras+".png")
+		c.Data(http.StatusOK, "image/png", imagem)
+	}
+}
+
+// SincronizarPixBoleto handler para consultar na API Pix a cobrança do boleto e
+// registrar o pagamento quando ela estiver concluída
+func SincronizarPixBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		boleto, cobranca, err := bankService.SincronizarPixBoleto(c.Request.Context(), c.Param("codigo"))
+		if err != nil {
+			responderErroPix(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Cobrança Pix do boleto consultada com sucesso",
+			"data": gin.H{
+				"boleto":   boleto,
+				"cobranca": cobranca,
+			},
+		})
+	}
+}
+
+// ConsultarCobrancaPix handler para consultar uma cobrança Pix pelo txid, registrando
+// o pagamento no boleto vinculado a ela
+func ConsultarCobrancaPix(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cobranca, boleto, err := bankService.ConsultarCobrancaPix(c.Request.Context(), c.Param("txid"))
+		if err != nil {
+			responderErroPix(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Cobrança Pix consultada com sucesso",
+			"data": gin.H{
+				"cobranca": cobranca,
+				"boleto":   boleto,
+			},
+		})
+	}
+}
+
+// responderErroPix traduz os erros das operações de Pix do boleto em respostas HTTP
+func responderErroPix(c *gin.Context, err error) {
+	var erroAPI *bancos.ErroAPI
+	switch {
+	case errors.Is(err, utils.ErrBRCodeInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Pix copia-e-cola inválido",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, utils.ErrCodigoBoletoInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Código de boleto inválido",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, bancos.ErrBoletoNaoEncontrado):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Boleto não encontrado",
+		})
+	case errors.Is(err, services.ErrBoletoSemPix):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Boleto sem Pix associado",
+		})
+	case errors.Is(err, pix.ErrTxIDInvalido):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "txid inválido",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, pix.ErrCobrancaNaoEncontrada):
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "Cobrança Pix não encontrada",
+		})
+	case errors.Is(err, services.ErrBoletoSemTxID):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": "Boleto sem txid da cobrança Pix",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrPixNaoConfigurado):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Integração com a API Pix não configurada",
+		})
+	case errors.As(err, &erroAPI):
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"message": "Erro na API Pix do PSP",
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Erro ao consultar Pix do boleto",
+			"error":   err.Error(),
+		})
+	}
+}
+
+// tamanhoMaximoRetorno limita o arquivo de retorno CNAB enviado
+const tamanhoMaximoRetorno = 10 << 20
+
+// ImportarRetornoBoletos handler para importar um arquivo de retorno CNAB 240 ou 400,
+// enviado no campo multipart "arquivo"
+func ImportarRetornoBoletos(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		arquivo, ok := abrirArquivoRetorno(c)
+		if !ok {
+			return
+		}
+		defer arquivo.Close()
+
+		relatorio, err := bankService.ImportarRetorno(arquivo)
+		responderRetorno(c, relatorio, err)
+	}
+}
+
+// ImportarDDABoletos handler para importar o arquivo CNAB 240 de títulos DDA,
+// enviado no campo multipart "arquivo"
+func ImportarDDABoletos(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		arquivo, ok := abrirArquivoRetorno(c)
+		if !ok {
+			return
+		}
+		defer arquivo.Close()
+
+		relatorio, err := bankService.ImportarDDA(arquivo)
+		if errors.Is(err, cnab.ErrArquivoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Arquivo DDA inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		responderDDA(c, relatorio, err)
+	}
+}
+
+// SincronizarDDABoletos handler para consultar imediatamente o DDA nos provedores
+func SincronizarDDABoletos(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		relatorio, err := bankService.SincronizarDDA(c.Request.Context())
+		if errors.Is(err, services.ErrDDANaoConfigurado) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"message": "DDA não configurado",
+				"error":   err.Error(),
+			})
+			return
+		}
+		responderDDA(c, relatorio, err)
+	}
+}
+
+// responderDDA responde com o relatório da captura de boletos do DDA
+func responderDDA(c *gin.Context, relatorio *models.RelatorioDDA, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Erro ao capturar boletos do DDA",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Boletos do DDA capturados com sucesso",
+		"data":    relatorio,
+	})
+}
+
+// abrirArquivoRetorno abre o arquivo CNAB enviado no campo multipart "arquivo",
+// respondendo ao cliente quando ele está ausente
+func abrirArquivoRetorno(c *gin.Context) (multipart.File, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, tamanhoMaximoRetorno)
+	cabecalho, err := c.FormFile("arquivo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Arquivo de retorno é obrigatório",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	arquivo, err := cabecalho.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Erro ao ler arquivo de retorno",
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+	return arquivo, true
+}
+
+// responderRetorno responde com o relatório da importação de um retorno CNAB
+func responderRetorno(c *gin.Context, relatorio *models.RelatorioRetorno, err error) {
+	if errors.Is(err, cnab.ErrArquivoInvalido) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Arquivo de retorno inválido",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Erro ao importar arquivo de retorno",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Arquivo de retorno importado com sucesso",
+		"data":    relatorio,
+	})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListarFeriados handler para listar os feriados bancários, nacionais e locais, do
+// ano informado em "ano", ou do ano corrente
+func ListarFeriados(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ano, err := strconv.Atoi(c.DefaultQuery("ano", strconv.Itoa(time.Now().Year())))
+		if err != nil || ano < 1900 || ano > 2199 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Ano inválido",
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Feriados listados com sucesso",
+			"data":    bankService.Feriados(ano),
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/extrato"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportarExtratoConciliacao handler para importar o extrato bancário OFX ou CSV,
+// enviado no campo multipart "arquivo", e conciliar os pagamentos de boletos
+func ImportarExtratoConciliacao(conciliacaoService *services.ConciliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		arquivo, ok := abrirArquivoRetorno(c)
+		if !ok {
+			return
+		}
+		defer arquivo.Close()
+
+		relatorio, err := conciliacaoService.ImportarExtrato(arquivo)
+		if errors.Is(err, extrato.ErrExtratoInvalido) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Extrato inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao importar extrato",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Extrato importado com sucesso",
+			"data":    relatorio,
+		})
+	}
+}
+
+// ListarConciliacaoPendente handler para listar os débitos do extrato ainda sem
+// boleto conciliado
+func ListarConciliacaoPendente(conciliacaoService *services.ConciliacaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lancamentos, err := conciliacaoService.ListarPendentes()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao listar lançamentos pendentes",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Lançamentos pendentes listados com sucesso",
+			"data":    lancamentos,
+		})
+	}
+}
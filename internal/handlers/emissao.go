@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmitirBoletosNFe handler para registrar no banco de cobrança os boletos das
+// duplicatas de uma NF-e emitida pela empresa
+func EmitirBoletosNFe(nfeService *services.NFEService, bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chave := c.Param("chave")
+		if len(chave) != 44 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Chave de acesso deve ter 44 dígitos",
+			})
+			return
+		}
+
+		nfe, err := nfeService.ConsultarNFe(chave)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao consultar NFe",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		emissao, err := bankService.EmitirBoletosNFe(c.Request.Context(), nfe.ID)
+		if err != nil {
+			responderErroEmissao(c, err, "Erro ao emitir boletos")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Boletos emitidos com sucesso",
+			"data":    emissao,
+		})
+	}
+}
+
+// BaixarBoletoEmitido handler para solicitar ao banco a baixa de um boleto emitido
+func BaixarBoletoEmitido(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		boleto, err := bankService.BaixarBoletoEmitido(c.Request.Context(), c.Param("codigo"))
+		if err != nil {
+			responderErroEmissao(c, err, "Erro ao baixar boleto")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Boleto baixado com sucesso",
+			"data":    boleto,
+		})
+	}
+}
+
+// alteracaoVencimentoRequest é o corpo da alteração de vencimento, com a data no
+// formato AAAA-MM-DD
+type alteracaoVencimentoRequest struct {
+	Vencimento string `json:"vencimento" binding:"required"`
+}
+
+// AlterarVencimentoBoleto handler para alterar no banco o vencimento de um boleto emitido
+func AlterarVencimentoBoleto(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req alteracaoVencimentoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Dados inválidos",
+				"error":   err.Error(),
+			})
+			return
+		}
+		vencimento, err := time.Parse(time.DateOnly, req.Vencimento)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Vencimento inválido, use o formato AAAA-MM-DD",
+			})
+			return
+		}
+
+		boleto, err := bankService.AlterarVencimentoBoleto(c.Request.Context(), c.Param("codigo"), vencimento)
+		if err != nil {
+			responderErroEmissao(c, err, "Erro ao alterar vencimento do boleto")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Vencimento alterado com sucesso",
+			"data":    boleto,
+		})
+	}
+}
+
+// responderErroEmissao converte os erros da emissão de boletos no status HTTP adequado
+func responderErroEmissao(c *gin.Context, err error, mensagem string) {
+	status := http.StatusInternalServerError
+	var erroAPI *bancos.ErroAPI
+	switch {
+	case errors.Is(err, services.ErrCobrancaNaoConfigurada), errors.Is(err, bancos.ErrEmissaoNaoSuportada):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, utils.ErrCodigoBoletoInvalido):
+		status = http.StatusBadRequest
+	case errors.Is(err, bancos.ErrBoletoNaoEncontrado):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrBoletoNaoAberto):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrNFeSemPagador), errors.Is(err, services.ErrBoletoNaoEmitido):
+		status = http.StatusUnprocessableEntity
+	case errors.As(err, &erroAPI):
+		status = http.StatusBadGateway
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": mensagem,
+		"error":   err.Error(),
+	})
+}
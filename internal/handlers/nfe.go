@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
@@ -149,8 +151,8 @@ func BaixarXMLNFe(nfeService *services.NFEService) gin.HandlerFunc {
 	}
 }
 
-// GerarPDFNFe handler para gerar PDF da NFe
-func GerarPDFNFe(nfeService *services.NFEService, pdfService *services.PDFService) gin.HandlerFunc {
+// RenderizarNFe handler para gerar o DANFE no formato do renderizador informado
+func RenderizarNFe(nfeService *services.NFEService, pdfService *services.PDFService, formato string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		chave := c.Param("chave")
 		
@@ -162,6 +164,16 @@ func GerarPDFNFe(nfeService *services.NFEService, pdfService *services.PDFServic
 			return
 		}
 
+		renderer, err := pdfService.Renderer(formato)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Formato de documento não suportado",
+				"error":   err.Error(),
+			})
+			return
+		}
+
 		// Obtém NFe
 		nfe, err := nfeService.ConsultarNFe(chave)
 		if err != nil {
@@ -173,20 +185,115 @@ func GerarPDFNFe(nfeService *services.NFEService, pdfService *services.PDFServic
 			return
 		}
 
-		// Gera PDF
-		pdf, err := pdfService.GerarDANFE(nfe)
+		// Gera o documento com o template solicitado (ou o padrão do emitente)
+		documento, err := pdfService.RenderizarDANFE(formato, nfe, c.Query("template"))
+		if errors.Is(err, services.ErrTemplateNaoEncontrado) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Template de DANFE inválido",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar documento",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		disposicao := "attachment"
+		if renderer.Inline() {
+			disposicao = "inline"
+		}
+		c.Header("Content-Disposition", disposicao+"; filename=danfe_"+chave+"."+formato)
+		c.Data(http.StatusOK, renderer.ContentType(), documento)
+	}
+}
+
+// PreviewNFe handler para gerar a prévia em imagem de uma página do DANFE
+func PreviewNFe(nfeService *services.NFEService, pdfService *services.PDFService, formato string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chave := c.Param("chave")
+		
+		if len(chave) != 44 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Chave de acesso deve ter 44 dígitos",
+			})
+			return
+		}
+
+		pagina, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || pagina < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Página inválida",
+			})
+			return
+		}
+
+		dpi, err := strconv.ParseFloat(c.DefaultQuery("dpi", strconv.Itoa(int(services.DPIPadrao))), 64)
+		// ParseFloat aceita "NaN", que passaria por comparações diretas com os limites
+		if err != nil || !(dpi >= services.DPIMinimo && dpi <= services.DPIMaximo) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "DPI inválido",
+				"error":   services.ErrDPIInvalido.Error(),
+			})
+			return
+		}
+
+		renderer, err := pdfService.Renderer(formato)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Formato de imagem não suportado",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		// Obtém NFe
+		nfe, err := nfeService.ConsultarNFe(chave)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
-				"message": "Erro ao gerar PDF",
+				"message": "Erro ao consultar NFe",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		imagem, err := pdfService.GerarPreviewDANFE(formato, nfe, c.Query("template"), pagina, dpi)
+		switch {
+		case errors.Is(err, services.ErrTemplateNaoEncontrado):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Template de DANFE inválido",
+				"error":   err.Error(),
+			})
+			return
+		case errors.Is(err, services.ErrPaginaInexistente):
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"message": "Página não encontrada",
+				"error":   err.Error(),
+			})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar prévia",
 				"error":   err.Error(),
 			})
 			return
 		}
 
-		c.Header("Content-Type", "application/pdf")
-		c.Header("Content-Disposition", "attachment; filename=danfe_"+chave+".pdf")
-		c.Data(http.StatusOK, "application/pdf", pdf)
+		c.Header("Content-Disposition", "inline; filename=danfe_"+chave+"_p"+strconv.Itoa(pagina)+"."+formato)
+		c.Data(http.StatusOK, renderer.ContentType(), imagem)
 	}
 }
 
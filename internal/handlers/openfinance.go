@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/openfinance"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CriarConsentimento handler para criar um consentimento do Open Finance; a resposta
+// traz a URL onde o titular aprova o acesso às contas
+func CriarConsentimento(openFinanceService *services.OpenFinanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CriarConsentimentoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Dados inválidos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		consentimento, err := openFinanceService.CriarConsentimento(req)
+		if err != nil {
+			responderErroOpenFinance(c, err, "Erro ao criar consentimento")
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"success": true,
+			"message": "Consentimento criado; aguardando autorização do titular",
+			"data":    consentimento,
+		})
+	}
+}
+
+// ListarConsentimentos handler para listar os consentimentos do Open Finance
+func ListarConsentimentos(openFinanceService *services.OpenFinanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		consentimentos, err := openFinanceService.ListarConsentimentos()
+		if err != nil {
+			responderErroOpenFinance(c, err, "Erro ao listar consentimentos")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Consentimentos listados com sucesso",
+			"data":    consentimentos,
+		})
+	}
+}
+
+// ObterConsentimento handler para consultar um consentimento e sua situação
+func ObterConsentimento(openFinanceService *services.OpenFinanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		consentimento, err := openFinanceService.ObterConsentimento(c.Param("id"))
+		if err != nil {
+			responderErroOpenFinance(c, err, "Erro ao consultar consentimento")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Consentimento consultado com sucesso",
+			"data":    consentimento,
+		})
+	}
+}
+
+// AutorizarConsentimento handler que conclui a autorização com o código de
+// autorização recebido no redirect da instituição
+func AutorizarConsentimento(openFinanceService *services.OpenFinanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AutorizarConsentimentoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Dados inválidos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		consentimento, err := openFinanceService.AutorizarConsentimento(c.Param("id"), req)
+		if err != nil {
+			responderErroOpenFinance(c, err, "Erro ao autorizar consentimento")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Consentimento autorizado com sucesso",
+			"data":    consentimento,
+		})
+	}
+}
+
+// RevogarConsentimento handler para revogar um consentimento
+func RevogarConsentimento(openFinanceService *services.OpenFinanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := openFinanceService.RevogarConsentimento(c.Param("id")); err != nil {
+			responderErroOpenFinance(c, err, "Erro ao revogar consentimento")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Consentimento revogado com sucesso",
+		})
+	}
+}
+
+// responderErroOpenFinance converte os erros do Open Finance no status HTTP adequado
+func responderErroOpenFinance(c *gin.Context, err error, mensagem string) {
+	status := http.StatusInternalServerError
+	var erroAPI *bancos.ErroAPI
+	switch {
+	case errors.Is(err, services.ErrOpenFinanceNaoConfigurado):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, openfinance.ErrConsentimentoNaoEncontrado):
+		status = http.StatusNotFound
+	case errors.Is(err, openfinance.ErrConsentimentoNaoAutorizado):
+		status = http.StatusConflict
+	case errors.As(err, &erroAPI):
+		status = http.StatusBadGateway
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": mensagem,
+		"error":   err.Error(),
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GerarRemessaPagamento handler para gerar o arquivo CNAB 240 de pagamento dos
+// boletos selecionados
+func GerarRemessaPagamento(pagamentoService *services.PagamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.GerarRemessaPagamentoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Dados inválidos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		remessa, arquivo, err := pagamentoService.GerarRemessa(req)
+		var erroBoletos *services.ErroBoletosRemessa
+		switch {
+		case errors.As(err, &erroBoletos):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success":   false,
+				"message":   "Boletos não podem ser incluídos na remessa",
+				"invalidos": erroBoletos.Motivos,
+			})
+			return
+		case errors.Is(err, services.ErrBancoPagadorNaoConfigurado):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Banco pagador não configurado",
+				"error":   err.Error(),
+			})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao gerar remessa de pagamento",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=PAG%s%06d.REM", remessa.Banco, remessa.Sequencial))
+		c.Header("X-Remessa-ID", strconv.FormatUint(uint64(remessa.ID), 10))
+		c.Data(http.StatusOK, "text/plain; charset=us-ascii", arquivo)
+	}
+}
+
+// ImportarRetornoPagamento handler para importar o retorno CNAB 240 de uma remessa
+// de pagamento, enviado no campo multipart "arquivo"
+func ImportarRetornoPagamento(pagamentoService *services.PagamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		arquivo, ok := abrirArquivoRetorno(c)
+		if !ok {
+			return
+		}
+		defer arquivo.Close()
+
+		relatorio, err := pagamentoService.ImportarRetorno(arquivo)
+		responderRetorno(c, relatorio, err)
+	}
+}
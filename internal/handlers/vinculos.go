@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VincularDuplicatasNFe handler para vincular as duplicatas de uma NFe aos boletos
+// conhecidos, retornando os vínculos automáticos e os enviados para revisão
+func VincularDuplicatasNFe(nfeService *services.NFEService, bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		chave := c.Param("chave")
+		if len(chave) != 44 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Chave de acesso deve ter 44 dígitos",
+			})
+			return
+		}
+
+		nfe, err := nfeService.ConsultarNFe(chave)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao consultar NFe",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		vinculos, err := bankService.VincularDuplicatas(nfe.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao vincular duplicatas",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if vinculos == nil {
+			vinculos = []models.VinculoDuplicata{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Duplicatas vinculadas com sucesso",
+			"data":    vinculos,
+		})
+	}
+}
+
+// ListarVinculosDuplicatas handler para listar os vínculos por situação; sem o
+// parâmetro status, lista a fila de revisão
+func ListarVinculosDuplicatas(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := strings.ToUpper(c.DefaultQuery("status", models.VinculoPendente))
+		switch status {
+		case models.VinculoAutomatico, models.VinculoPendente, models.VinculoConfirmado, models.VinculoRejeitado:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Situação de vínculo inválida",
+			})
+			return
+		}
+
+		vinculos, err := bankService.ListarVinculos(status)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao listar vínculos",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Vínculos listados com sucesso",
+			"data":    vinculos,
+		})
+	}
+}
+
+// ConfirmarVinculoDuplicata handler para confirmar um vínculo da fila de revisão
+func ConfirmarVinculoDuplicata(bankService *services.BankService) gin.HandlerFunc {
+	return revisarVinculo(bankService.ConfirmarVinculo, "Vínculo confirmado com sucesso")
+}
+
+// RejeitarVinculoDuplicata handler para rejeitar um vínculo da fila de revisão
+func RejeitarVinculoDuplicata(bankService *services.BankService) gin.HandlerFunc {
+	return revisarVinculo(bankService.RejeitarVinculo, "Vínculo rejeitado com sucesso")
+}
+
+func revisarVinculo(revisar func(id uint) (*models.VinculoDuplicata, error), mensagem string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Identificador de vínculo inválido",
+			})
+			return
+		}
+
+		vinculo, err := revisar(uint(id))
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, services.ErrVinculoNaoEncontrado):
+				status = http.StatusNotFound
+			case errors.Is(err, services.ErrVinculoRevisado):
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"success": false,
+				"message": "Erro ao revisar vínculo",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": mensagem,
+			"data":    vinculo,
+		})
+	}
+}
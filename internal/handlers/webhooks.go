@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReceberWebhookBanco handler para receber as notificações de pagamento enviadas pelo
+// banco, identificado pelo código COMPE, autenticadas por HMAC ou mTLS
+func ReceberWebhookBanco(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notificacao, err := bankService.ReceberWebhook(c.Param("banco"), c.Request)
+		if err != nil {
+			responderErroWebhook(c, err, "Erro ao receber notificação")
+			return
+		}
+		// O corpo não reconhecido fica gravado, mas o banco é informado da recusa
+		if notificacao.Status == models.WebhookInvalido {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Notificação inválida",
+				"error":   notificacao.Erro,
+				"data":    notificacao,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Notificação recebida com sucesso",
+			"data":    notificacao,
+		})
+	}
+}
+
+// ListarNotificacoesWebhook handler para listar as notificações recebidas, filtradas
+// pelo parâmetro status quando informado
+func ListarNotificacoesWebhook(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := strings.ToUpper(c.Query("status"))
+		switch status {
+		case "", models.WebhookProcessado, models.WebhookNaoConciliado, models.WebhookInvalido:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Situação de notificação inválida",
+			})
+			return
+		}
+
+		notificacoes, err := bankService.ListarNotificacoesWebhook(status)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "Erro ao listar notificações",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Notificações listadas com sucesso",
+			"data":    notificacoes,
+		})
+	}
+}
+
+// ReprocessarNotificacaoWebhook handler para aplicar novamente uma notificação gravada
+func ReprocessarNotificacaoWebhook(bankService *services.BankService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "Identificador de notificação inválido",
+			})
+			return
+		}
+
+		notificacao, err := bankService.ReprocessarWebhook(uint(id))
+		if err != nil {
+			responderErroWebhook(c, err, "Erro ao reprocessar notificação")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Notificação reprocessada com sucesso",
+			"data":    notificacao,
+		})
+	}
+}
+
+// responderErroWebhook converte os erros do recebimento de webhooks no status HTTP adequado
+func responderErroWebhook(c *gin.Context, err error, mensagem string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, bancos.ErrWebhookNaoSuportado), errors.Is(err, services.ErrNotificacaoNaoEncontrada):
+		status = http.StatusNotFound
+	case errors.Is(err, bancos.ErrWebhookNaoConfigurado):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, bancos.ErrWebhookNaoAutenticado):
+		status = http.StatusUnauthorized
+	case errors.Is(err, bancos.ErrWebhookInvalido):
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": mensagem,
+		"error":   err.Error(),
+	})
+}
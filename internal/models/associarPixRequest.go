@@ -0,0 +1,8 @@
+package models
+
+// AssociarPixRequest informa o Pix copia-e-cola de um boleto híbrido
+type AssociarPixRequest struct {
+	PixCopiaECola string `json:"pix_copia_e_cola" binding:"required"`
+	// TxID da cobrança (cob/cobv) no PSP, que o BR Code dinâmico não informa
+	TxID string `json:"txid"`
+}
@@ -8,38 +8,139 @@ import (
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/dto"
 )
 
+// Situações do boleto
+const (
+	StatusBoletoAberto  = "ABERTO"
+	StatusBoletoPago    = "PAGO"
+	StatusBoletoBaixado = "BAIXADO"
+	// StatusBoletoPendente identifica o boleto emitido com o nosso número reservado
+	// cujo registro no banco ainda não foi confirmado
+	StatusBoletoPendente = "PENDENTE_REGISTRO"
+)
+
+// Formas de cálculo dos juros de mora, sempre simples
+const (
+	JurosValorDia   = "VALOR_DIA"
+	JurosTaxaDiaria = "TAXA_DIARIA"
+	JurosTaxaMensal = "TAXA_MENSAL"
+)
+
+// Formas de cálculo da multa e dos descontos
+const (
+	EncargoValor      = "VALOR"
+	EncargoPercentual = "PERCENTUAL"
+)
+
 type Boleto struct {
-	ID              uint           `json:"id" gorm:"primaryKey"`
-	NFeID           uint           `json:"nfe_id"`
-	DuplicataID     *uint          `json:"duplicata_id"`
-	Banco           string         `json:"banco"`
-	Numero          string         `json:"numero"`
-	CodigoBarras    string         `json:"codigo_barras"`
-	LinhaDigitavel  string         `json:"linha_digitavel"`
-	Valor           float64        `json:"valor"`
-	Vencimento      time.Time      `json:"vencimento"`
-	Status          string         `json:"status"`
-	DataPagamento   *time.Time     `json:"data_pagamento"`
-	ValorPago       *float64       `json:"valor_pago"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID          uint  `json:"id" gorm:"primaryKey"`
+	NFeID       uint  `json:"nfe_id"`
+	DuplicataID *uint `json:"duplicata_id"`
+	// Tipo distingue boletos bancários (BANCARIO) de arrecadação/convênio (ARRECADACAO)
+	Tipo           string `json:"tipo" gorm:"default:BANCARIO"`
+	Banco          string `json:"banco"`
+	Numero         string `json:"numero"`
+	CodigoBarras   string `json:"codigo_barras"`
+	LinhaDigitavel string `json:"linha_digitavel"`
+
+	// Dados da arrecadação: segmento e identificação da empresa/órgão conveniado
+	Segmento string `json:"segmento,omitempty"`
+	Convenio string `json:"convenio,omitempty"`
+
+	// Dados do título no banco, usados na composição do campo livre
+	NossoNumero        string `json:"nosso_numero"`
+	Agencia            string `json:"agencia"`
+	Conta              string `json:"conta"`
+	Carteira           string `json:"carteira"`
+	CodigoBeneficiario string `json:"codigo_beneficiario"`
+	Posto              string `json:"posto"`
+
+	// Beneficiário (cedente) do boleto, comparado ao emitente da NF-e
+	DocumentoBeneficiario string `json:"documento_beneficiario,omitempty"`
+	NomeBeneficiario      string `json:"nome_beneficiario,omitempty"`
+
+	// Encargos e abatimento informados pelo banco
+	ValorJuros    float64 `json:"valor_juros"`
+	ValorMulta    float64 `json:"valor_multa"`
+	ValorDesconto float64 `json:"valor_desconto"`
+
+	// Regras de cobrança após o vencimento e de desconto por antecipação, usadas no
+	// cálculo do valor atualizado. As taxas são percentuais
+	TipoJuros       string           `json:"tipo_juros,omitempty"`
+	TaxaJuros       float64          `json:"taxa_juros,omitempty"`
+	TipoMulta       string           `json:"tipo_multa,omitempty"`
+	TaxaMulta       float64          `json:"taxa_multa,omitempty"`
+	ValorAbatimento float64          `json:"valor_abatimento,omitempty"`
+	Descontos       []DescontoBoleto `json:"descontos,omitempty" gorm:"foreignKey:BoletoID"`
+
+	// Pix copia-e-cola (BR Code) dos boletos híbridos, pagáveis também por Pix
+	PixCopiaECola string `json:"pix_copia_e_cola,omitempty"`
+	// PixTxID é o txid da cobrança (cob/cobv) do Pix no PSP recebedor, consultada na
+	// API Pix para confirmar o pagamento
+	PixTxID string `json:"pix_txid,omitempty" gorm:"index"`
+
+	// Remessa de pagamento em que o boleto aguarda o retorno do banco
+	RemessaPagamentoID *uint `json:"remessa_pagamento_id,omitempty"`
+
+	// Emitido indica um boleto de cobrança registrado pela empresa como beneficiária,
+	// para uma duplicata das NF-e que emitiu
+	Emitido bool `json:"emitido,omitempty"`
+
+	Valor         float64        `json:"valor"`
+	Vencimento    time.Time      `json:"vencimento"`
+	Status        string         `json:"status"`
+	DataPagamento *time.Time     `json:"data_pagamento"`
+	ValorPago     *float64       `json:"valor_pago"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// PossuiRegrasEncargos informa se o valor devido do boleto varia com a data de pagamento
+func (b *Boleto) PossuiRegrasEncargos() bool {
+	return b.TipoJuros != "" || b.TipoMulta != "" || b.ValorAbatimento > 0 || len(b.Descontos) > 0
 }
 
 func (b *Boleto) ToDTO() dto.BoletoDTO {
+	descontos := make([]dto.DescontoBoletoDTO, len(b.Descontos))
+	for i, desconto := range b.Descontos {
+		descontos[i] = desconto.ToDTO()
+	}
+
 	return dto.BoletoDTO{
-		ID:             b.ID,
-		DuplicataID:    b.DuplicataID,
-		Banco:          b.Banco,
-		Numero:         b.Numero,
-		CodigoBarras:   b.CodigoBarras,
-		LinhaDigitavel: b.LinhaDigitavel,
-		Valor:          b.Valor,
-		Vencimento:     b.Vencimento,
-		Status:         b.Status,
-		DataPagamento:  b.DataPagamento,
-		ValorPago:      b.ValorPago,
-		CreatedAt:      b.CreatedAt,
-		UpdatedAt:      b.UpdatedAt,
+		ID:                    b.ID,
+		DuplicataID:           b.DuplicataID,
+		Tipo:                  b.Tipo,
+		Banco:                 b.Banco,
+		Numero:                b.Numero,
+		CodigoBarras:          b.CodigoBarras,
+		LinhaDigitavel:        b.LinhaDigitavel,
+		Segmento:              b.Segmento,
+		Convenio:              b.Convenio,
+		NossoNumero:           b.NossoNumero,
+		Agencia:               b.Agencia,
+		Conta:                 b.Conta,
+		Carteira:              b.Carteira,
+		DocumentoBeneficiario: b.DocumentoBeneficiario,
+		NomeBeneficiario:      b.NomeBeneficiario,
+		ValorJuros:            b.ValorJuros,
+		ValorMulta:            b.ValorMulta,
+		ValorDesconto:         b.ValorDesconto,
+		TipoJuros:             b.TipoJuros,
+		TaxaJuros:             b.TaxaJuros,
+		TipoMulta:             b.TipoMulta,
+		TaxaMulta:             b.TaxaMulta,
+		ValorAbatimento:       b.ValorAbatimento,
+		Descontos:             descontos,
+		PixCopiaECola:         b.PixCopiaECola,
+		PixTxID:               b.PixTxID,
+		RemessaPagamentoID:    b.RemessaPagamentoID,
+		Emitido:               b.Emitido,
+		Valor:                 b.Valor,
+		Vencimento:            b.Vencimento,
+		Status:                b.Status,
+		DataPagamento:         b.DataPagamento,
+		ValorPago:             b.ValorPago,
+		CreatedAt:             b.CreatedAt,
+		UpdatedAt:             b.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Situações do consentimento no Open Finance Brasil
+const (
+	ConsentimentoAguardandoAutorizacao = "AWAITING_AUTHORISATION"
+	ConsentimentoAutorizado            = "AUTHORISED"
+	ConsentimentoRejeitado             = "REJECTED"
+)
+
+// ConsentimentoOpenFinance é um consentimento de leitura de contas no Open Finance,
+// com os tokens obtidos após a autorização do titular
+type ConsentimentoOpenFinance struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	ConsentID      string     `json:"consent_id" gorm:"uniqueIndex"`
+	Status         string     `json:"status"`
+	CPF            string     `json:"cpf"`
+	CNPJ           string     `json:"cnpj,omitempty"`
+	Permissoes     string     `json:"permissoes"`
+	ExpiraEm       *time.Time `json:"expira_em"`
+	URLAutorizacao string     `json:"url_autorizacao,omitempty" gorm:"-"`
+
+	// Tokens do consentimento autorizado; nunca são expostos pela API
+	AccessToken   string     `json:"-"`
+	RefreshToken  string     `json:"-"`
+	TokenExpiraEm *time.Time `json:"-"`
+
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// Autorizado informa se o consentimento pode ser usado para ler as contas na data
+// informada: aprovado pelo titular, com tokens e dentro da validade
+func (c *ConsentimentoOpenFinance) Autorizado(agora time.Time) bool {
+	if c.Status != ConsentimentoAutorizado || (c.AccessToken == "" && c.RefreshToken == "") {
+		return false
+	}
+	return c.ExpiraEm == nil || agora.Before(*c.ExpiraEm)
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// CriarConsentimentoRequest é o pedido de criação de um consentimento do Open Finance
+type CriarConsentimentoRequest struct {
+	CPF        string     `json:"cpf" binding:"required"`
+	CNPJ       string     `json:"cnpj"`
+	Permissoes []string   `json:"permissoes"`
+	ExpiraEm   *time.Time `json:"expira_em"`
+}
+
+// AutorizarConsentimentoRequest traz o código de autorização recebido no redirect
+type AutorizarConsentimentoRequest struct {
+	Code string `json:"code" binding:"required"`
+}
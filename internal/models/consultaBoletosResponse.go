@@ -1,8 +1,35 @@
 package models
 
+// Situações de cada código na consulta em lote
+const (
+	ConsultaEncontrado    = "found"
+	ConsultaNaoEncontrado = "not_found"
+	ConsultaInvalido      = "invalid"
+	ConsultaErro          = "error"
+)
+
+// ResultadoConsultaBoleto é o resultado de um código da consulta em lote, na mesma
+// posição em que foi enviado
+type ResultadoConsultaBoleto struct {
+	Codigo string  `json:"codigo"`
+	Status string  `json:"status"`
+	Motivo string  `json:"motivo,omitempty"`
+	Boleto *Boleto `json:"boleto,omitempty"`
+}
+
+// ConsultaBoletosLote reúne os resultados da consulta em lote e a contagem por situação
+type ConsultaBoletosLote struct {
+	Total          int                       `json:"total"`
+	Encontrados    int                       `json:"encontrados"`
+	NaoEncontrados int                       `json:"nao_encontrados"`
+	Invalidos      int                       `json:"invalidos"`
+	Erros          int                       `json:"erros"`
+	Resultados     []ResultadoConsultaBoleto `json:"resultados"`
+}
+
 type ConsultaBoletosResponse struct {
-	Success bool     `json:"success"`
-	Message string   `json:"message"`
-	Data    []Boleto `json:"data,omitempty"`
-	Error   string   `json:"error,omitempty"`
-}
\ No newline at end of file
+	Success bool                 `json:"success"`
+	Message string               `json:"message"`
+	Data    *ConsultaBoletosLote `json:"data,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/dto"
+)
+
+// DescontoBoleto é uma faixa de desconto por antecipação: pago até a data limite, o
+// boleto tem o desconto em valor ou percentual. Vale a faixa de menor data limite
+// ainda não ultrapassada
+type DescontoBoleto struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	BoletoID   uint      `json:"boleto_id" gorm:"index"`
+	DataLimite time.Time `json:"data_limite"`
+	Tipo       string    `json:"tipo"`
+	Valor      float64   `json:"valor"`
+}
+
+func (d *DescontoBoleto) ToDTO() dto.DescontoBoletoDTO {
+	return dto.DescontoBoletoDTO{
+		DataLimite: d.DataLimite,
+		Tipo:       d.Tipo,
+		Valor:      d.Valor,
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// EmissaoBoletos resume o registro dos boletos das duplicatas de uma NF-e
+type EmissaoBoletos struct {
+	NFeID uint   `json:"nfe_id"`
+	Banco string `json:"banco"`
+	// Emitidos são os boletos registrados nesta solicitação
+	Emitidos []Boleto `json:"emitidos"`
+	// JaEmitidos são as duplicatas que já tinham boleto emitido e não baixado
+	JaEmitidos int `json:"ja_emitidos"`
+	// Falhas são as duplicatas recusadas pelo banco; as demais continuam sendo emitidas
+	Falhas []FalhaEmissao `json:"falhas,omitempty"`
+}
+
+// FalhaEmissao identifica a duplicata cujo boleto não foi registrado
+type FalhaEmissao struct {
+	DuplicataID uint   `json:"duplicata_id"`
+	Numero      string `json:"numero"`
+	Erro        string `json:"erro"`
+}
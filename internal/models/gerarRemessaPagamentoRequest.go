@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// GerarRemessaPagamentoRequest seleciona os boletos pagos pela conta do banco
+// informado. Sem data de pagamento, cada boleto é pago no vencimento ou, se vencido,
+// no dia da geração
+type GerarRemessaPagamentoRequest struct {
+	Banco         string     `json:"banco" binding:"required,len=3"`
+	BoletoIDs     []uint     `json:"boleto_ids" binding:"required,min=1"`
+	DataPagamento *time.Time `json:"data_pagamento"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Situações dos lançamentos de extrato na conciliação
+const (
+	LancamentoConciliado = "CONCILIADO"
+	LancamentoPendente   = "PENDENTE"
+)
+
+// LancamentoExtrato é um débito do extrato bancário importado para conciliar os
+// pagamentos de boletos. O identificador do banco evita a reimportação do lançamento
+type LancamentoExtrato struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Banco         string    `json:"banco" gorm:"uniqueIndex:idx_lancamento_extrato"`
+	Conta         string    `json:"conta" gorm:"uniqueIndex:idx_lancamento_extrato"`
+	Identificador string    `json:"identificador" gorm:"uniqueIndex:idx_lancamento_extrato"`
+	Data          time.Time `json:"data"`
+	// Valor debitado, sem sinal
+	Valor     float64 `json:"valor"`
+	Descricao string  `json:"descricao"`
+	Documento string  `json:"documento,omitempty"`
+	BoletoID  *uint   `json:"boleto_id,omitempty" gorm:"index"`
+	Status    string  `json:"status" gorm:"index"`
+	// Motivo explica por que o lançamento continua pendente
+	Motivo    string    `json:"motivo,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Situações das notificações de webhook recebidas dos bancos
+const (
+	WebhookProcessado    = "PROCESSADO"
+	WebhookNaoConciliado = "NAO_CONCILIADO"
+	WebhookInvalido      = "INVALIDO"
+)
+
+// NotificacaoWebhook guarda o corpo de uma notificação autenticada enviada pelo banco,
+// para auditoria e reprocessamento. O hash do corpo identifica as reentregas
+type NotificacaoWebhook struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Banco string `json:"banco" gorm:"uniqueIndex:idx_notificacao_webhook"`
+	Hash  string `json:"hash" gorm:"uniqueIndex:idx_notificacao_webhook"`
+	// Payload é o corpo recebido, sem alteração
+	Payload string `json:"payload" gorm:"type:text"`
+	Status  string `json:"status" gorm:"index"`
+	// Eventos é o número de boletos notificados; Atualizados, os que mudaram de situação
+	Eventos     int `json:"eventos"`
+	Atualizados int `json:"atualizados"`
+	// Erro explica o corpo inválido ou lista os eventos sem boleto correspondente
+	Erro         string     `json:"erro,omitempty"`
+	ProcessadoEm *time.Time `json:"processado_em,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Duplicada indica, na resposta, uma reentrega já recebida
+	Duplicada bool `json:"duplicada,omitempty" gorm:"-"`
+}
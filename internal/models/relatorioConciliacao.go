@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RelatorioConciliacao resume a importação de um extrato bancário
+type RelatorioConciliacao struct {
+	Formato    string `json:"formato"`
+	Banco      string `json:"banco,omitempty"`
+	Conta      string `json:"conta,omitempty"`
+	Transacoes int    `json:"transacoes"`
+	// Creditos são ignorados: apenas os débitos pagam boletos
+	Creditos int `json:"creditos"`
+	// Repetidos são débitos já importados em outro extrato
+	Repetidos   int `json:"repetidos"`
+	Conciliados int `json:"conciliados"`
+	// Pendentes são todos os lançamentos ainda sem boleto, inclusive de extratos anteriores
+	Pendentes int                 `json:"pendentes"`
+	Boletos   []ConciliacaoBoleto `json:"boletos"`
+}
+
+// ConciliacaoBoleto é o boleto dado como pago por um lançamento do extrato
+type ConciliacaoBoleto struct {
+	LancamentoID uint      `json:"lancamento_id"`
+	BoletoID     uint      `json:"boleto_id"`
+	Data         time.Time `json:"data"`
+	Valor        float64   `json:"valor"`
+	// Criterio indica o que identificou o boleto: valor, data, código de barras, beneficiário
+	Criterio string `json:"criterio"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// RelatorioDDA resume a captura dos boletos do DDA, por arquivo ou pelos provedores
+type RelatorioDDA struct {
+	// Origem é "arquivo" ou o nome do provedor consultado
+	Origem  []string `json:"origem"`
+	Titulos int      `json:"titulos"`
+	Novos   int      `json:"novos"`
+	// Atualizados são boletos já conhecidos, identificados pelo código de barras,
+	// que receberam os dados do beneficiário
+	Atualizados int `json:"atualizados"`
+	// Ignorados são títulos repetidos ou sem código de barras de boleto bancário
+	Ignorados int `json:"ignorados"`
+	// Vinculos criados entre os boletos capturados e as duplicatas das NF-e
+	Vinculos int `json:"vinculos"`
+	// Falhas de comunicação com os provedores; os demais continuam sendo consultados
+	Falhas []string `json:"falhas,omitempty"`
+}
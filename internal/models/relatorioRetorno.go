@@ -0,0 +1,32 @@
+package models
+
+// RelatorioRetorno resume a importação de um arquivo de retorno CNAB
+type RelatorioRetorno struct {
+	Layout      int    `json:"layout"`
+	Banco       string `json:"banco"`
+	Registros   int    `json:"registros"`
+	Atualizados int    `json:"atualizados"`
+	// Conciliados sem alteração: ocorrências que não mudam a situação do boleto
+	SemAlteracao   int                     `json:"sem_alteracao"`
+	Boletos        []BoletoConciliado      `json:"boletos"`
+	NaoConciliados []RegistroNaoConciliado `json:"nao_conciliados"`
+}
+
+// BoletoConciliado é um boleto encontrado para um registro do retorno
+type BoletoConciliado struct {
+	Linha       int    `json:"linha"`
+	BoletoID    uint   `json:"boleto_id"`
+	NossoNumero string `json:"nosso_numero"`
+	Ocorrencia  string `json:"ocorrencia"`
+	Status      string `json:"status"`
+}
+
+// RegistroNaoConciliado é um registro do retorno sem boleto correspondente
+type RegistroNaoConciliado struct {
+	Linha       int     `json:"linha"`
+	NossoNumero string  `json:"nosso_numero"`
+	SeuNumero   string  `json:"seu_numero,omitempty"`
+	Ocorrencia  string  `json:"ocorrencia"`
+	ValorPago   float64 `json:"valor_pago"`
+	Motivo      string  `json:"motivo"`
+}
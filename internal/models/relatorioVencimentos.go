@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// RelatorioVencimentos agrupa os boletos em aberto por dias de atraso na data de
+// referência, com o valor nominal e o valor atualizado de cada faixa
+type RelatorioVencimentos struct {
+	Data            time.Time         `json:"data"`
+	Quantidade      int               `json:"quantidade"`
+	Valor           float64           `json:"valor"`
+	ValorAtualizado float64           `json:"valor_atualizado"`
+	Faixas          []FaixaVencimento `json:"faixas"`
+}
+
+// FaixaVencimento é uma faixa de atraso do relatório. DiasMaximo zero indica faixa
+// sem limite superior; a faixa "a vencer" tem os dois limites zerados
+type FaixaVencimento struct {
+	Faixa           string  `json:"faixa"`
+	DiasMinimo      int     `json:"dias_minimo"`
+	DiasMaximo      int     `json:"dias_maximo,omitempty"`
+	Quantidade      int     `json:"quantidade"`
+	Valor           float64 `json:"valor"`
+	ValorAtualizado float64 `json:"valor_atualizado"`
+	BoletoIDs       []uint  `json:"boleto_ids"`
+}
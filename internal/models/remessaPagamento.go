@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// RemessaPagamento registra um arquivo CNAB 240 de pagamento de boletos enviado ao
+// banco; o sequencial (NSA) é crescente por banco
+type RemessaPagamento struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Banco      string    `json:"banco" gorm:"uniqueIndex:idx_remessa_banco_sequencial"`
+	Sequencial int       `json:"sequencial" gorm:"uniqueIndex:idx_remessa_banco_sequencial"`
+	Quantidade int       `json:"quantidade"`
+	ValorTotal float64   `json:"valor_total"`
+	Boletos    []Boleto  `json:"boletos,omitempty" gorm:"foreignKey:RemessaPagamentoID"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// SequenciaNossoNumero guarda o último nosso número atribuído pela empresa aos
+// boletos emitidos em cada banco e carteira
+type SequenciaNossoNumero struct {
+	Banco    string `json:"banco" gorm:"primaryKey"`
+	Carteira string `json:"carteira" gorm:"primaryKey"`
+	Ultimo   int64  `json:"ultimo"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ValorAtualizado é o valor devido do boleto em uma data, com o desconto ou os
+// encargos do atraso
+type ValorAtualizado struct {
+	BoletoID     uint      `json:"boleto_id"`
+	CodigoBarras string    `json:"codigo_barras"`
+	Data         time.Time `json:"data"`
+	Vencimento   time.Time `json:"vencimento"`
+	// VencimentoUtil é o vencimento prorrogado para o dia útil seguinte, quando cai
+	// em fim de semana ou feriado bancário
+	VencimentoUtil time.Time `json:"vencimento_util"`
+	DiasAtraso     int       `json:"dias_atraso"`
+
+	Valor      float64 `json:"valor"`
+	Abatimento float64 `json:"abatimento"`
+	Desconto   float64 `json:"desconto"`
+	Juros      float64 `json:"juros"`
+	Multa      float64 `json:"multa"`
+	Total      float64 `json:"total"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Situações do vínculo entre duplicata e boleto
+const (
+	VinculoAutomatico = "AUTOMATICO"
+	VinculoPendente   = "PENDENTE"
+	VinculoConfirmado = "CONFIRMADO"
+	VinculoRejeitado  = "REJEITADO"
+)
+
+// VinculoDuplicata é a correspondência encontrada entre uma duplicata da NF-e e um
+// boleto conhecido. Vínculos de alta confiança são gravados no boleto; os demais
+// aguardam revisão na fila de pendentes
+type VinculoDuplicata struct {
+	ID          uint    `json:"id" gorm:"primaryKey"`
+	NFeID       uint    `json:"nfe_id" gorm:"index"`
+	DuplicataID uint    `json:"duplicata_id" gorm:"index"`
+	BoletoID    uint    `json:"boleto_id" gorm:"index"`
+	Confianca   float64 `json:"confianca"`
+	// Motivos explica a pontuação: valor, vencimento, beneficiário e número
+	Motivos   string    `json:"motivos"`
+	Status    string    `json:"status" gorm:"index"`
+	Duplicata Duplicata `json:"duplicata" gorm:"foreignKey:DuplicataID"`
+	Boleto    Boleto    `json:"boleto" gorm:"foreignKey:BoletoID"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
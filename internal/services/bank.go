@@ -1,94 +1,134 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"time"
+	"strings"
 
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+// ErrBoletoSemPix indica um boleto sem Pix copia-e-cola associado
+var ErrBoletoSemPix = errors.New("boleto sem Pix associado")
+
 // BankService representa o serviço bancário
 type BankService struct {
-	config *config.Config
-	db     *gorm.DB
-	logger *logrus.Logger
+	config     *config.Config
+	db         *gorm.DB
+	logger     *logrus.Logger
+	provedores *bancos.Registro
+	calendario *calendario.Calendario
+	// pix consulta as cobranças Pix dos boletos híbridos; nil sem PIX_API_URL
+	pix *pix.Cliente
 }
 
 // NewBankService cria uma nova instância do serviço bancário
 func NewBankService(cfg *config.Config, db *gorm.DB, logger *logrus.Logger) *BankService {
 	return &BankService{
-		config: cfg,
-		db:     db,
-		logger: logger,
+		config:     cfg,
+		db:         db,
+		logger:     logger,
+		provedores: bancos.NovoRegistro(cfg, bancos.Dependencias{Logger: logger, DB: db}),
+		calendario: novoCalendario(cfg, logger),
+		pix:        novoClientePix(cfg, logger),
 	}
 }
 
 // ConsultarBoleto consulta um boleto específico
 func (s *BankService) ConsultarBoleto(codigo string) (*models.Boleto, error) {
+	return s.consultarBoleto(context.Background(), codigo)
+}
+
+// ConsultarBoletoImpressao consulta o boleto e a NF-e vinculada a ele, quando houver,
+// com os dados de emitente e destinatário usados na impressão
+func (s *BankService) ConsultarBoletoImpressao(codigo string) (*models.Boleto, *models.NFe, error) {
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if boleto.NFeID == 0 {
+		return boleto, nil, nil
+	}
+
+	var nfe models.NFe
+	err = s.db.Select("id", "numero", "data_emissao", "emitente_cnpj", "emitente_nome", "destinatario_cnpj", "destinatario_nome").
+		First(&nfe, boleto.NFeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return boleto, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao consultar NFe no banco: %w", err)
+	}
+	return boleto, &nfe, nil
+}
+
+// consultarBoleto consulta o boleto na base e, se não estiver nela, nos provedores
+// bancários, que são interrompidos com o contexto
+func (s *BankService) consultarBoleto(ctx context.Context, codigo string) (*models.Boleto, error) {
 	s.logger.WithField("codigo", codigo).Info("Consultando boleto")
 
+	// Códigos de barras e linhas digitáveis são validados e normalizados para os 44 dígitos
+	codigo = strings.TrimSpace(codigo)
+	var parsed *utils.CodigoBoleto
+	if utils.PareceCodigoBoleto(codigo) {
+		var err error
+		parsed, err = utils.ParseCodigoBoleto(codigo)
+		if err != nil {
+			return nil, err
+		}
+		codigo = parsed.CodigoBarras
+	}
+
 	// Verifica se existe no banco
 	var boleto models.Boleto
-	if err := s.db.Where("numero = ? OR codigo_barras = ?", codigo, codigo).First(&boleto).Error; err == nil {
+	if err := s.db.WithContext(ctx).Preload("Descontos").Where("numero = ? OR codigo_barras = ?", codigo, codigo).First(&boleto).Error; err == nil {
 		return &boleto, nil
 	}
 
-	// Consulta nas APIs bancárias
-	boleto, err := s.consultarAPIsBancarias(codigo)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao consultar APIs bancárias: %w", err)
+	// Boletos de arrecadação não são registrados em banco emissor: todos os dados
+	// estão no próprio código
+	if parsed != nil && parsed.Tipo == utils.TipoCodigoArrecadacao {
+		boleto = boletoArrecadacao(parsed)
+	} else {
+		// Consulta nas APIs bancárias
+		var err error
+		boleto, err = s.consultarAPIsBancarias(ctx, codigo, parsed)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao consultar APIs bancárias: %w", err)
+		}
 	}
 
-	// Salva no banco
+	// Salva no banco e vincula o novo boleto às duplicatas das NF-e do beneficiário
+	s.normalizarBoleto(&boleto)
 	if err := s.db.Create(&boleto).Error; err != nil {
 		s.logger.WithError(err).Error("Erro ao salvar boleto no banco")
-	}
-
-	return &boleto, nil
-}
-
-// ConsultarMultiplosBoletos consulta múltiplos boletos
-func (s *BankService) ConsultarMultiplosBoletos(codigos []string) ([]models.Boleto, error) {
-	s.logger.WithField("codigos", codigos).Info("Consultando múltiplos boletos")
-
-	var boletos []models.Boleto
-
-	for _, codigo := range codigos {
-		boleto, err := s.ConsultarBoleto(codigo)
-		if err != nil {
-			s.logger.WithError(err).WithField("codigo", codigo).Error("Erro ao consultar boleto")
-			continue
+	} else if documento := apenasDigitos(boleto.DocumentoBeneficiario); documento != "" {
+		if _, err := s.vincularBeneficiarios([]string{documento}); err != nil {
+			s.logger.WithError(err).Warn("Erro ao vincular duplicatas ao boleto consultado")
 		}
-		boletos = append(boletos, *boleto)
 	}
 
-	return boletos, nil
+	return &boleto, nil
 }
 
-// ConsultarBoletosPorNFe consulta boletos vinculados a uma NFe
+// ConsultarBoletosPorNFe consulta boletos vinculados a uma NFe. Os vínculos são
+// gravados na captura dos boletos e em VincularDuplicatas, nunca na consulta
 func (s *BankService) ConsultarBoletosPorNFe(nfeID uint) ([]models.Boleto, error) {
 	s.logger.WithField("nfe_id", nfeID).Info("Consultando boletos por NFe")
 
 	var boletos []models.Boleto
-	if err := s.db.Where("nfe_id = ?", nfeID).Find(&boletos).Error; err != nil {
+	if err := s.db.Where("n_fe_id = ?", nfeID).Find(&boletos).Error; err != nil {
 		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
 	}
-
-	// Se não encontrou boletos, tenta localizar pelas duplicatas
-	if len(boletos) == 0 {
-		var localizados []models.Boleto
-		localizados, localizarErr := s.localizarBoletosPorDuplicatas(nfeID)
-		if localizarErr != nil {
-			s.logger.WithError(localizarErr).Error("Erro ao localizar boletos por duplicatas")
-		} else {
-			boletos = localizados
-		}
-	}
-
 	return boletos, nil
 }
 
@@ -104,6 +144,52 @@ func (s *BankService) ConsultarBoletosPorDuplicata(duplicataID uint) ([]models.B
 	return boletos, nil
 }
 
+// AssociarPix valida e grava o Pix copia-e-cola de um boleto híbrido e, quando
+// informado, o txid da cobrança no PSP. O BR Code não serve de fonte para o txid: o
+// dinâmico traz "***" e o campo não comporta os 26 a 35 caracteres das cobranças
+func (s *BankService) AssociarPix(codigo, copiaECola, txid string) (*models.Boleto, error) {
+	copiaECola = strings.TrimSpace(copiaECola)
+	if _, err := utils.ParseBRCode(copiaECola); err != nil {
+		return nil, err
+	}
+	txid = strings.TrimSpace(txid)
+	if txid != "" && !pix.TxIDValido(txid) {
+		return nil, pix.ErrTxIDInvalido
+	}
+
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithField("codigo_barras", boleto.CodigoBarras).Info("Associando Pix ao boleto")
+
+	boleto.PixCopiaECola = copiaECola
+	if txid != "" {
+		boleto.PixTxID = txid
+	}
+	if err := s.db.Save(boleto).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	return boleto, nil
+}
+
+// ConsultarPix retorna o boleto e o BR Code decodificado do seu Pix copia-e-cola
+func (s *BankService) ConsultarPix(codigo string) (*models.Boleto, *utils.BRCode, error) {
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if boleto.PixCopiaECola == "" {
+		return nil, nil, ErrBoletoSemPix
+	}
+
+	brcode, err := utils.ParseBRCode(boleto.PixCopiaECola)
+	if err != nil {
+		return nil, nil, err
+	}
+	return boleto, brcode, nil
+}
+
 // GerarCodigoBarras gera código de barras para um boleto
 func (s *BankService) GerarCodigoBarras(codigo string) (string, error) {
 	s.logger.WithField("codigo", codigo).Info("Gerando código de barras")
@@ -117,6 +203,9 @@ func (s *BankService) GerarCodigoBarras(codigo string) (string, error) {
 	if boleto.CodigoBarras != "" {
 		return boleto.CodigoBarras, nil
 	}
+	if boleto.Tipo == utils.TipoCodigoArrecadacao {
+		return "", fmt.Errorf("boleto de arrecadação sem código de barras não pode ser gerado")
+	}
 
 	// Gera código de barras baseado no padrão FEBRABAN
 	codigoBarras, err := s.gerarCodigoBarrasFEBRABAN(boleto)
@@ -124,8 +213,14 @@ func (s *BankService) GerarCodigoBarras(codigo string) (string, error) {
 		return "", fmt.Errorf("erro ao gerar código de barras: %w", err)
 	}
 
+	linhaDigitavel, err := utils.LinhaDigitavel(codigoBarras)
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar linha digitável: %w", err)
+	}
+
 	// Atualiza o boleto no banco
 	boleto.CodigoBarras = codigoBarras
+	boleto.LinhaDigitavel = linhaDigitavel
 	if err := s.db.Save(boleto).Error; err != nil {
 		s.logger.WithError(err).Error("Erro ao atualizar código de barras no banco")
 	}
@@ -133,134 +228,105 @@ func (s *BankService) GerarCodigoBarras(codigo string) (string, error) {
 	return codigoBarras, nil
 }
 
-// consultarAPIsBancarias consulta as APIs bancárias para localizar boletos
-func (s *BankService) consultarAPIsBancarias(codigo string) (models.Boleto, error) {
-	s.logger.Info("Consultando APIs bancárias")
-
-	// Tenta consultar em diferentes bancos
-	boletos := []models.Boleto{}
-
-	// Consulta Itaú
-	if s.config.Bank.Itau.URL != "" {
-		if boleto, err := s.consultarItau(codigo); err == nil {
-			boletos = append(boletos, boleto)
-		}
+// consultarAPIsBancarias consulta os provedores bancários, direcionando a consulta
+// ao banco decodificado do código de barras quando ele é conhecido
+func (s *BankService) consultarAPIsBancarias(ctx context.Context, codigo string, parsed *utils.CodigoBoleto) (models.Boleto, error) {
+	consulta := bancos.Consulta{Codigo: codigo}
+	if parsed != nil {
+		consulta.CodigoBarras = parsed.CodigoBarras
+		consulta.Banco = parsed.Banco
 	}
+	s.logger.WithField("banco", consulta.Banco).Info("Consultando APIs bancárias")
 
-	// Consulta Bradesco
-	if s.config.Bank.Bradesco.URL != "" {
-		if boleto, err := s.consultarBradesco(codigo); err == nil {
-			boletos = append(boletos, boleto)
-		}
+	boleto, err := s.provedores.Consultar(ctx, consulta)
+	if err != nil {
+		return models.Boleto{}, err
 	}
+	if boleto.Numero == "" {
+		boleto.Numero = codigo
+	}
+	return *boleto, nil
+}
 
-	// Consulta Open Banking
-	if s.config.Bank.OpenBanking.URL != "" {
-		if boleto, err := s.consultarOpenBanking(codigo); err == nil {
-			boletos = append(boletos, boleto)
+// normalizarBoleto grava o código de barras com 44 dígitos e a linha digitável
+// formatada, a partir de qualquer um dos dois que seja válido
+func (s *BankService) normalizarBoleto(boleto *models.Boleto) {
+	s.normalizarPix(boleto)
+	for _, codigo := range []string{boleto.CodigoBarras, boleto.LinhaDigitavel} {
+		if codigo == "" {
+			continue
+		}
+		parsed, err := utils.ParseCodigoBoleto(codigo)
+		if err != nil {
+			s.logger.WithError(err).WithField("codigo", codigo).Warn("Código de boleto inválido")
+			continue
+		}
+		boleto.Tipo = parsed.Tipo
+		boleto.CodigoBarras = parsed.CodigoBarras
+		boleto.LinhaDigitavel = parsed.LinhaDigitavel
+		if parsed.Tipo == utils.TipoCodigoArrecadacao {
+			boleto.Segmento = parsed.Segmento
+			boleto.Convenio = parsed.Convenio
+			return
 		}
+		if boleto.Banco == "" {
+			boleto.Banco = parsed.Banco
+		}
+		if boleto.Vencimento.IsZero() && parsed.Vencimento != nil {
+			boleto.Vencimento = *parsed.Vencimento
+		}
+		return
 	}
-
-	// Retorna o primeiro boleto encontrado
-	if len(boletos) > 0 {
-		return boletos[0], nil
+	if boleto.Tipo == "" {
+		boleto.Tipo = utils.TipoCodigoBancario
 	}
-
-	// Mock de boleto para demonstração
-	return models.Boleto{
-		Numero:         codigo,
-		Banco:          "001",
-		CodigoBarras:   "00193373700000001000500940144816060680935031",
-		LinhaDigitavel: "00190.00009 04441.601448 60606.809350 3 37370000000100",
-		Valor:          1000.00,
-		Vencimento:     time.Now().AddDate(0, 1, 0),
-		Status:         "ABERTO",
-	}, nil
 }
 
-// consultarItau consulta a API do Itaú
-func (s *BankService) consultarItau(codigo string) (models.Boleto, error) {
-	s.logger.Info("Consultando API do Itaú")
-
-	// Implementação da consulta à API do Itaú
-	// Esta é uma implementação simplificada
-
-	return models.Boleto{
-		Numero:         codigo,
-		Banco:          "341",
-		CodigoBarras:   "34191790010104351004791020150008787870026300",
-		LinhaDigitavel: "34191.79001 01043.510047 91020.150008 8 787870026300",
-		Valor:          1000.00,
-		Vencimento:     time.Now().AddDate(0, 1, 0),
-		Status:         "ABERTO",
-	}, nil
-}
-
-// consultarBradesco consulta a API do Bradesco
-func (s *BankService) consultarBradesco(codigo string) (models.Boleto, error) {
-	s.logger.Info("Consultando API do Bradesco")
-
-	// Implementação da consulta à API do Bradesco
-	// Esta é uma implementação simplificada
-
-	return models.Boleto{
-		Numero:         codigo,
-		Banco:          "237",
-		CodigoBarras:   "23793381286000782713695000063305975820000126000",
-		LinhaDigitavel: "23793.38128 60007.827136 95000.063305 9 75820000126000",
-		Valor:          1000.00,
-		Vencimento:     time.Now().AddDate(0, 1, 0),
-		Status:         "ABERTO",
-	}, nil
+// normalizarPix descarta o Pix copia-e-cola inválido, para que o boleto continue
+// utilizável pelo código de barras
+func (s *BankService) normalizarPix(boleto *models.Boleto) {
+	boleto.PixCopiaECola = strings.TrimSpace(boleto.PixCopiaECola)
+	if boleto.PixCopiaECola == "" {
+		return
+	}
+	if _, err := utils.ParseBRCode(boleto.PixCopiaECola); err != nil {
+		s.logger.WithError(err).WithField("codigo_barras", boleto.CodigoBarras).Warn("Pix copia-e-cola do boleto inválido")
+		boleto.PixCopiaECola = ""
+	}
 }
 
-// consultarOpenBanking consulta a API do Open Banking
-func (s *BankService) consultarOpenBanking(codigo string) (models.Boleto, error) {
-	s.logger.Info("Consultando API do Open Banking")
-
-	// Implementação da consulta à API do Open Banking
-	// Esta é uma implementação simplificada
-
+// boletoArrecadacao monta um boleto de arrecadação/convênio a partir do código decodificado
+func boletoArrecadacao(parsed *utils.CodigoBoleto) models.Boleto {
 	return models.Boleto{
-		Numero:         codigo,
-		Banco:          "001",
-		CodigoBarras:   "00193373700000001000500940144816060680935031",
-		LinhaDigitavel: "00190.00009 04441.601448 60606.809350 3 37370000000100",
-		Valor:          1000.00,
-		Vencimento:     time.Now().AddDate(0, 1, 0),
+		Tipo:           utils.TipoCodigoArrecadacao,
+		Numero:         parsed.CodigoBarras,
+		CodigoBarras:   parsed.CodigoBarras,
+		LinhaDigitavel: parsed.LinhaDigitavel,
+		Segmento:       parsed.Segmento,
+		Convenio:       parsed.Convenio,
+		Valor:          parsed.Valor,
 		Status:         "ABERTO",
-	}, nil
-}
-
-// localizarBoletosPorDuplicatas localiza boletos baseado nas duplicatas da NFe
-func (s *BankService) localizarBoletosPorDuplicatas(nfeID uint) ([]models.Boleto, error) {
-	s.logger.WithField("nfe_id", nfeID).Info("Localizando boletos por duplicatas")
-
-	var duplicatas []models.Duplicata
-	if err := s.db.Where("nfe_id = ?", nfeID).Find(&duplicatas).Error; err != nil {
-		return nil, fmt.Errorf("erro ao consultar duplicatas: %w", err)
 	}
-
-	var boletos []models.Boleto
-	for _, duplicata := range duplicatas {
-		// Tenta localizar boletos baseado no número da duplicata
-		codigo := fmt.Sprintf("DUP%s", duplicata.Numero)
-		if boleto, err := s.ConsultarBoleto(codigo); err == nil {
-			boleto.DuplicataID = &duplicata.ID
-			boletos = append(boletos, *boleto)
-		}
-	}
-
-	return boletos, nil
 }
 
-// gerarCodigoBarrasFEBRABAN gera código de barras no padrão FEBRABAN
+// gerarCodigoBarrasFEBRABAN gera código de barras no padrão FEBRABAN, com o
+// campo livre composto conforme o layout do banco emissor
 func (s *BankService) gerarCodigoBarrasFEBRABAN(boleto *models.Boleto) (string, error) {
-	s.logger.Info("Gerando código de barras FEBRABAN")
-
-	// Implementação simplificada do padrão FEBRABAN
-	// Na prática, você precisaria implementar o algoritmo completo
+	s.logger.WithField("banco", boleto.Banco).Info("Gerando código de barras FEBRABAN")
+
+	dados := utils.DadosBoleto{
+		Banco:              boleto.Banco,
+		Valor:              boleto.Valor,
+		Agencia:            boleto.Agencia,
+		Conta:              boleto.Conta,
+		Carteira:           boleto.Carteira,
+		NossoNumero:        boleto.NossoNumero,
+		CodigoBeneficiario: boleto.CodigoBeneficiario,
+		Posto:              boleto.Posto,
+	}
+	if !boleto.Vencimento.IsZero() {
+		dados.Vencimento = &boleto.Vencimento
+	}
 
-	// Exemplo de código de barras do Banco do Brasil
-	return "00193373700000001000500940144816060680935031", nil
+	return utils.MontarCodigoBarras(dados)
 }
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+)
+
+func TestConsultarBoletoArrecadacao(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	boleto, err := service.ConsultarBoleto("82600000001-6 23450058202-3 41030000000-8 00012345678-2")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.TipoCodigoArrecadacao, boleto.Tipo)
+	assert.Equal(t, "82600000001234500582024103000000000012345678", boleto.CodigoBarras)
+	assert.Equal(t, utils.SegmentoSaneamento, boleto.Segmento)
+	assert.Equal(t, "0058", boleto.Convenio)
+	assert.Equal(t, 123.45, boleto.Valor)
+	assert.Empty(t, boleto.Banco)
+
+	// A segunda consulta, pelo código de barras, encontra o documento gravado
+	gravado, err := service.ConsultarBoleto(boleto.CodigoBarras)
+	assert.NoError(t, err)
+	assert.Equal(t, boleto.ID, gravado.ID)
+
+	codigoBarras, err := service.GerarCodigoBarras(boleto.LinhaDigitavel)
+	assert.NoError(t, err)
+	assert.Equal(t, boleto.CodigoBarras, codigoBarras)
+}
+
+func TestConsultarBoletoNaoEncontrado(t *testing.T) {
+	service := NewBankService(setupTestConfig(), setupTestDB(), logrus.New())
+
+	_, err := service.ConsultarBoleto("BOL001")
+	assert.ErrorIs(t, err, bancos.ErrBoletoNaoEncontrado)
+}
+
+func TestAssociarPix(t *testing.T) {
+	service := NewBankService(setupTestConfig(), setupTestDB(), logrus.New())
+	codigo := "82600000001-6 23450058202-3 41030000000-8 00012345678-2"
+
+	_, _, err := service.ConsultarPix(codigo)
+	assert.ErrorIs(t, err, ErrBoletoSemPix)
+
+	_, err = service.AssociarPix(codigo, "000201010211", "")
+	assert.ErrorIs(t, err, utils.ErrBRCodeInvalido)
+
+	pix, err := (&utils.BRCode{Chave: "pix@exemplo.com.br", Valor: 123.45, NomeRecebedor: "SANEAMENTO", CidadeRecebedor: "CAMPINAS"}).Payload()
+	assert.NoError(t, err)
+	boleto, err := service.AssociarPix(codigo, " "+pix+"\n", "")
+	assert.NoError(t, err)
+	assert.Equal(t, pix, boleto.PixCopiaECola)
+
+	gravado, brcode, err := service.ConsultarPix(boleto.CodigoBarras)
+	assert.NoError(t, err)
+	assert.Equal(t, boleto.ID, gravado.ID)
+	assert.Equal(t, "pix@exemplo.com.br", brcode.Chave)
+	assert.Equal(t, 123.45, brcode.Valor)
+	assert.False(t, brcode.Dinamico())
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxItensCache limita a quantidade de documentos mantidos em memória
+const maxItensCache = 512
+
+// itemCache representa um documento renderizado e sua validade
+type itemCache struct {
+	dados  []byte
+	expira time.Time
+}
+
+// documentoCache guarda documentos renderizados em memória por um tempo limitado
+type documentoCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	itens map[string]itemCache
+}
+
+// newDocumentoCache cria um cache com o TTL informado; TTL zero desativa o cache
+func newDocumentoCache(ttl time.Duration) *documentoCache {
+	return &documentoCache{
+		ttl:   ttl,
+		itens: make(map[string]itemCache),
+	}
+}
+
+// Obter retorna o documento em cache, se ainda válido
+func (c *documentoCache) Obter(chave string) ([]byte, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.itens[chave]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expira) {
+		delete(c.itens, chave)
+		return nil, false
+	}
+	return item.dados, true
+}
+
+// Salvar guarda um documento, descartando itens expirados quando o cache está cheio
+func (c *documentoCache) Salvar(chave string, dados []byte) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	agora := time.Now()
+	if len(c.itens) >= maxItensCache {
+		c.descartar(agora)
+	}
+	c.itens[chave] = itemCache{dados: dados, expira: agora.Add(c.ttl)}
+}
+
+// descartar remove os itens expirados e, se necessário, o que expira primeiro
+func (c *documentoCache) descartar(agora time.Time) {
+	var maisAntigo string
+	var expiraAntes time.Time
+	for chave, item := range c.itens {
+		if agora.After(item.expira) {
+			delete(c.itens, chave)
+			continue
+		}
+		if maisAntigo == "" || item.expira.Before(expiraAntes) {
+			maisAntigo, expiraAntes = chave, item.expira
+		}
+	}
+	if len(c.itens) >= maxItensCache && maisAntigo != "" {
+		delete(c.itens, maisAntigo)
+	}
+}
+
+// chaveCacheDANFE identifica uma renderização do DANFE; inclui o conteúdo do template,
+// o arquivo do logo e a data de atualização da NFe para que alterações invalidem o cache
+func chaveCacheDANFE(formato, chaveAcesso string, atualizadoEm time.Time, tpl *DANFETemplate, partes ...interface{}) string {
+	conteudo, _ := json.Marshal(tpl)
+	hash := sha256.Sum256(conteudo)
+	return fmt.Sprintf("%s|%s|%d|%s|%s|%v", formato, chaveAcesso, atualizadoEm.UnixNano(), hex.EncodeToString(hash[:8]), versaoLogo(tpl), partes)
+}
+
+// versaoLogo identifica o logo pelo caminho resolvido, data de modificação e tamanho,
+// para que a troca da imagem com o mesmo nome invalide o cache
+func versaoLogo(tpl *DANFETemplate) string {
+	if tpl == nil {
+		return ""
+	}
+	caminho := tpl.CaminhoLogo()
+	if caminho == "" {
+		return ""
+	}
+	info, err := os.Stat(caminho)
+	if err != nil {
+		return caminho
+	}
+	return fmt.Sprintf("%s@%d/%d", caminho, info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/extrato"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Janela de datas aceita entre o débito do extrato e o vencimento do boleto
+const (
+	antecedenciaConciliacao = 30 * 24 * time.Hour
+	atrasoConciliacao       = 5 * 24 * time.Hour
+)
+
+// ConciliacaoService confirma os pagamentos de boletos pelos débitos dos extratos
+// bancários, sem depender das APIs dos bancos
+type ConciliacaoService struct {
+	config *config.Config
+	db     *gorm.DB
+	logger *logrus.Logger
+}
+
+// NewConciliacaoService cria uma nova instância do serviço de conciliação
+func NewConciliacaoService(cfg *config.Config, db *gorm.DB, logger *logrus.Logger) *ConciliacaoService {
+	return &ConciliacaoService{
+		config: cfg,
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ImportarExtrato grava os débitos do extrato OFX ou CSV e concilia com os boletos em
+// aberto os novos lançamentos e os que continuavam pendentes de extratos anteriores
+func (s *ConciliacaoService) ImportarExtrato(arquivo io.Reader) (*models.RelatorioConciliacao, error) {
+	lido, err := extrato.Parse(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"formato":    lido.Formato,
+		"banco":      lido.Banco,
+		"transacoes": len(lido.Transacoes),
+	}).Info("Importando extrato bancário")
+
+	relatorio := &models.RelatorioConciliacao{
+		Formato:    lido.Formato,
+		Banco:      lido.Banco,
+		Conta:      lido.Conta,
+		Transacoes: len(lido.Transacoes),
+		Boletos:    []models.ConciliacaoBoleto{},
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, transacao := range lido.Transacoes {
+			if !transacao.Debito() {
+				relatorio.Creditos++
+				continue
+			}
+			lancamento := models.LancamentoExtrato{
+				Banco:         lido.Banco,
+				Conta:         lido.Conta,
+				Identificador: transacao.Identificador,
+				Data:          transacao.Data,
+				Valor:         math.Abs(transacao.Valor),
+				Descricao:     transacao.Descricao,
+				Documento:     transacao.Documento,
+				Status:        models.LancamentoPendente,
+			}
+			resultado := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&lancamento)
+			if resultado.Error != nil {
+				return fmt.Errorf("erro ao salvar lançamento no banco: %w", resultado.Error)
+			}
+			if resultado.RowsAffected == 0 {
+				relatorio.Repetidos++
+			}
+		}
+
+		var pendentes []models.LancamentoExtrato
+		if err := tx.Where("status = ?", models.LancamentoPendente).Order("data, id").Find(&pendentes).Error; err != nil {
+			return fmt.Errorf("erro ao consultar lançamentos no banco: %w", err)
+		}
+		for i := range pendentes {
+			conciliacao, err := s.conciliar(tx, &pendentes[i])
+			if err != nil {
+				return err
+			}
+			if conciliacao == nil {
+				relatorio.Pendentes++
+				continue
+			}
+			relatorio.Conciliados++
+			relatorio.Boletos = append(relatorio.Boletos, *conciliacao)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relatorio, nil
+}
+
+// conciliar procura o boleto pago pelo lançamento e, se for único, marca o boleto
+// como pago. Sem boleto, grava no lançamento o motivo da pendência
+func (s *ConciliacaoService) conciliar(tx *gorm.DB, lancamento *models.LancamentoExtrato) (*models.ConciliacaoBoleto, error) {
+	// O débito pode ser o valor do título ou o valor com desconto e encargos. Os
+	// boletos emitidos pela empresa são a receber e nunca saem da conta
+	var candidatos []models.Boleto
+	if err := tx.Where("status = ? AND emitido = ?", models.StatusBoletoAberto, false).
+		Where("(ABS(valor - ?) < 0.005 OR ABS(valor - valor_desconto + valor_juros + valor_multa - ?) < 0.005)", lancamento.Valor, lancamento.Valor).
+		Order("vencimento, id").Find(&candidatos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+	}
+
+	boleto, criterio, motivo := escolherBoleto(lancamento, candidatos)
+	if boleto == nil {
+		lancamento.Motivo = motivo
+		if err := tx.Save(lancamento).Error; err != nil {
+			return nil, fmt.Errorf("erro ao salvar lançamento no banco: %w", err)
+		}
+		return nil, nil
+	}
+
+	data := lancamento.Data
+	valor := lancamento.Valor
+	boleto.Status = models.StatusBoletoPago
+	boleto.DataPagamento = &data
+	boleto.ValorPago = &valor
+	boleto.RemessaPagamentoID = nil
+	if err := tx.Save(boleto).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	lancamento.BoletoID = &boleto.ID
+	lancamento.Status = models.LancamentoConciliado
+	lancamento.Motivo = ""
+	if err := tx.Save(lancamento).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar lançamento no banco: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"lancamento_id": lancamento.ID,
+		"boleto_id":     boleto.ID,
+		"criterio":      criterio,
+	}).Info("Pagamento de boleto conciliado pelo extrato")
+	return &models.ConciliacaoBoleto{
+		LancamentoID: lancamento.ID,
+		BoletoID:     boleto.ID,
+		Data:         lancamento.Data,
+		Valor:        lancamento.Valor,
+		Criterio:     criterio,
+	}, nil
+}
+
+// escolherBoleto desempata os boletos com o valor do lançamento: primeiro pelos trechos
+// do código de barras citados no histórico, depois pela janela de datas e, por fim,
+// pelo nome do beneficiário. Só um candidato único é conciliado
+func escolherBoleto(lancamento *models.LancamentoExtrato, candidatos []models.Boleto) (*models.Boleto, string, string) {
+	if len(candidatos) == 0 {
+		return nil, "", "nenhum boleto em aberto com o valor do lançamento"
+	}
+
+	fragmentos := fragmentosNumericos(lancamento.Descricao + " " + lancamento.Documento)
+	var porCodigo, naJanela []*models.Boleto
+	for i := range candidatos {
+		boleto := &candidatos[i]
+		if citaBoleto(fragmentos, boleto) {
+			porCodigo = append(porCodigo, boleto)
+		}
+		if boleto.Vencimento.IsZero() ||
+			(!lancamento.Data.Before(boleto.Vencimento.Add(-antecedenciaConciliacao)) && !lancamento.Data.After(boleto.Vencimento.Add(atrasoConciliacao))) {
+			naJanela = append(naJanela, boleto)
+		}
+	}
+
+	if len(porCodigo) == 1 {
+		return porCodigo[0], "valor e código de barras", ""
+	}
+	switch len(naJanela) {
+	case 0:
+		return nil, "", "nenhum boleto em aberto com o valor do lançamento vence próximo à data do débito"
+	case 1:
+		return naJanela[0], "valor e data", ""
+	}
+
+	// Vence o beneficiário com mais palavras do nome citadas no histórico
+	var porNome *models.Boleto
+	maior, empate := 0, false
+	historico := strings.Fields(strings.ToUpper(textoASCII(lancamento.Descricao)))
+	for _, boleto := range naJanela {
+		citadas := palavrasCitadas(historico, boleto.NomeBeneficiario)
+		switch {
+		case citadas > maior:
+			porNome, maior, empate = boleto, citadas, false
+		case citadas == maior && citadas > 0:
+			empate = true
+		}
+	}
+	if porNome != nil && !empate {
+		return porNome, "valor, data e beneficiário", ""
+	}
+	return nil, "", fmt.Sprintf("%d boletos em aberto com o mesmo valor e vencimento próximo", len(naJanela))
+}
+
+// fragmentosNumericos extrai do histórico as sequências de pelo menos cinco dígitos,
+// unindo os blocos da linha digitável separados por ponto
+func fragmentosNumericos(texto string) []string {
+	var fragmentos []string
+	for _, campo := range strings.FieldsFunc(strings.ReplaceAll(texto, ".", ""), func(r rune) bool {
+		return r < '0' || r > '9'
+	}) {
+		if len(campo) >= 5 {
+			fragmentos = append(fragmentos, campo)
+		}
+	}
+	return fragmentos
+}
+
+// citaBoleto informa se algum fragmento faz parte do código de barras, da linha
+// digitável ou do nosso número do boleto
+func citaBoleto(fragmentos []string, boleto *models.Boleto) bool {
+	linha := boleto.LinhaDigitavel
+	if linha == "" && boleto.CodigoBarras != "" {
+		// Boletos gravados só com o código de barras são citados pela linha digitável
+		linha, _ = utils.ParaLinhaDigitavel(boleto.CodigoBarras)
+	}
+	codigos := []string{boleto.CodigoBarras, apenasDigitos(linha)}
+	if nossoNumero := strings.TrimLeft(apenasDigitos(boleto.NossoNumero), "0"); len(nossoNumero) >= 5 {
+		codigos = append(codigos, nossoNumero)
+	}
+	for _, fragmento := range fragmentos {
+		for _, codigo := range codigos {
+			if codigo != "" && (strings.Contains(codigo, fragmento) || (len(codigo) >= 5 && strings.Contains(fragmento, codigo))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Palavras do nome do beneficiário que não o distinguem de outras empresas
+var palavrasGenericas = map[string]bool{"LTDA": true, "EIRELI": true, "S/A": true, "CIA": true, "EPP": true}
+
+// palavrasCitadas conta as palavras significativas do nome do beneficiário presentes
+// no histórico do lançamento
+func palavrasCitadas(historico []string, nome string) int {
+	citadas := 0
+	for _, palavra := range strings.Fields(strings.ToUpper(textoASCII(nome))) {
+		if len(palavra) < 3 || palavrasGenericas[palavra] {
+			continue
+		}
+		for _, termo := range historico {
+			if termo == palavra {
+				citadas++
+				break
+			}
+		}
+	}
+	return citadas
+}
+
+// ListarPendentes lista os débitos do extrato ainda sem boleto conciliado
+func (s *ConciliacaoService) ListarPendentes() ([]models.LancamentoExtrato, error) {
+	var lancamentos []models.LancamentoExtrato
+	if err := s.db.Where("status = ?", models.LancamentoPendente).Order("data, id").Find(&lancamentos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar lançamentos no banco: %w", err)
+	}
+	return lancamentos, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/extrato"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// ofxConciliacao monta um extrato OFX 1.x com as transações informadas
+func ofxConciliacao(transacoes ...string) string {
+	return "OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n\n<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS>" +
+		"<BANKACCTFROM><BANKID>0341<ACCTID>12345-7</BANKACCTFROM><BANKTRANLIST>\n" +
+		strings.Join(transacoes, "\n") +
+		"\n</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>"
+}
+
+func TestImportarExtrato(t *testing.T) {
+	db := setupTestDB()
+	service := NewConciliacaoService(setupTestConfig(), db, logrus.New())
+
+	vencimento := time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC)
+	porCodigo := models.Boleto{Banco: "341", Numero: "BOL-1", CodigoBarras: "34195955000000250751091234567800057123457000",
+		Valor: 250.75, Vencimento: vencimento, Status: models.StatusBoletoAberto}
+	porData := models.Boleto{Banco: "237", Numero: "BOL-2", CodigoBarras: "23796907800000099903381090000001234500123450",
+		Valor: 99.90, Vencimento: vencimento, Status: models.StatusBoletoAberto}
+	// Dois boletos com o mesmo valor e vencimento só se distinguem pelo beneficiário
+	norte := models.Boleto{Banco: "001", Numero: "BOL-3", Valor: 10, Vencimento: vencimento,
+		NomeBeneficiario: "Distribuidora Norte Ltda", Status: models.StatusBoletoAberto}
+	sul := models.Boleto{Banco: "001", Numero: "BOL-4", Valor: 10, Vencimento: vencimento,
+		NomeBeneficiario: "Distribuidora Sul Ltda", Status: models.StatusBoletoAberto}
+	// O boleto emitido pela empresa é a receber e não concorre com o do fornecedor
+	emitido := models.Boleto{Banco: "341", Numero: "BOL-5", Valor: 99.90, Vencimento: vencimento,
+		Emitido: true, Status: models.StatusBoletoAberto}
+	for _, boleto := range []*models.Boleto{&porCodigo, &porData, &norte, &sul, &emitido} {
+		assert.NoError(t, db.Create(boleto).Error)
+	}
+
+	arquivo := ofxConciliacao(
+		// Valor com juros, o código de barras identifica o boleto
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231201<TRNAMT>-250.75<FITID>1<MEMO>PAGTO TITULO 34191.09123 34567.800056</STMTTRN>",
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231128<TRNAMT>-99.90<FITID>2<MEMO>PAGAMENTO DE TITULO</STMTTRN>",
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231129<TRNAMT>-10.00<FITID>3<MEMO>PAGTO TITULO</STMTTRN>",
+		"<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20231129<TRNAMT>500.00<FITID>4<MEMO>PIX RECEBIDO</STMTTRN>",
+	)
+	relatorio, err := service.ImportarExtrato(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, extrato.FormatoOFX, relatorio.Formato)
+	assert.Equal(t, "341", relatorio.Banco)
+	assert.Equal(t, 4, relatorio.Transacoes)
+	assert.Equal(t, 1, relatorio.Creditos)
+	assert.Equal(t, 2, relatorio.Conciliados)
+	assert.Equal(t, 1, relatorio.Pendentes)
+	if assert.Len(t, relatorio.Boletos, 2) {
+		assert.Equal(t, porData.ID, relatorio.Boletos[0].BoletoID)
+		assert.Equal(t, "valor e data", relatorio.Boletos[0].Criterio)
+		assert.Equal(t, porCodigo.ID, relatorio.Boletos[1].BoletoID)
+		assert.Equal(t, "valor e código de barras", relatorio.Boletos[1].Criterio)
+	}
+
+	assert.NoError(t, db.First(&porCodigo, porCodigo.ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, porCodigo.Status)
+	if assert.NotNil(t, porCodigo.DataPagamento) && assert.NotNil(t, porCodigo.ValorPago) {
+		assert.True(t, porCodigo.DataPagamento.Equal(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, 250.75, *porCodigo.ValorPago)
+	}
+	assert.NoError(t, db.First(&emitido, emitido.ID).Error)
+	assert.Equal(t, models.StatusBoletoAberto, emitido.Status)
+
+	pendentes, err := service.ListarPendentes()
+	assert.NoError(t, err)
+	if assert.Len(t, pendentes, 1) {
+		assert.Equal(t, "3", pendentes[0].Identificador)
+		assert.Contains(t, pendentes[0].Motivo, "2 boletos")
+	}
+
+	// O extrato seguinte repete o débito pendente e traz o histórico completo
+	arquivo = ofxConciliacao(
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231129<TRNAMT>-10.00<FITID>3<MEMO>PAGTO TITULO</STMTTRN>",
+		"<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20231130<TRNAMT>-10.00<FITID>5<MEMO>PAGTO DISTRIBUIDORA SUL</STMTTRN>",
+	)
+	relatorio, err = service.ImportarExtrato(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relatorio.Repetidos)
+	assert.Equal(t, 1, relatorio.Conciliados)
+	if assert.Len(t, relatorio.Boletos, 1) {
+		assert.Equal(t, sul.ID, relatorio.Boletos[0].BoletoID)
+		assert.Equal(t, "valor, data e beneficiário", relatorio.Boletos[0].Criterio)
+	}
+
+	// Com o boleto do sul pago, o débito pendente fica com o único candidato restante
+	relatorio, err = service.ImportarExtrato(strings.NewReader(ofxConciliacao()))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, relatorio.Conciliados)
+	pendentes, err = service.ListarPendentes()
+	assert.NoError(t, err)
+	assert.Empty(t, pendentes)
+}
+
+func TestImportarExtratoInvalido(t *testing.T) {
+	service := NewConciliacaoService(setupTestConfig(), setupTestDB(), logrus.New())
+
+	_, err := service.ImportarExtrato(strings.NewReader("nome;conta\nfulano;123\n"))
+	assert.ErrorIs(t, err, extrato.ErrExtratoInvalido)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/arquivodda"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrigemArquivoDDA identifica no relatório os títulos importados por arquivo
+const OrigemArquivoDDA = "arquivo"
+
+// ErrDDANaoConfigurado indica que não há CNPJ pagador ou provedor com DDA
+var ErrDDANaoConfigurado = errors.New("DDA não configurado")
+
+// ImportarDDA captura os boletos do arquivo CNAB 240 de títulos DDA exportado pelo
+// banco e vincula os novos boletos às duplicatas das NF-e
+func (s *BankService) ImportarDDA(arquivo io.Reader) (*models.RelatorioDDA, error) {
+	dda, err := cnab.ParseDDA(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"banco":   dda.Banco,
+		"pagador": dda.Pagador,
+		"titulos": len(dda.Titulos),
+	}).Info("Importando arquivo DDA")
+
+	relatorio := &models.RelatorioDDA{Origem: []string{OrigemArquivoDDA}}
+	if err := s.ingerirDDA(arquivodda.Boletos(dda), relatorio); err != nil {
+		return nil, err
+	}
+	return relatorio, nil
+}
+
+// SincronizarDDA consulta, em cada provedor com DDA, os boletos registrados contra os
+// CNPJs configurados. A falha de um provedor não interrompe os demais
+func (s *BankService) SincronizarDDA(ctx context.Context) (*models.RelatorioDDA, error) {
+	provedores := s.provedores.ProvedoresDDA()
+	if len(provedores) == 0 || len(s.config.DDA.CNPJs) == 0 {
+		return nil, ErrDDANaoConfigurado
+	}
+	s.logger.WithField("provedores", len(provedores)).Info("Sincronizando boletos do DDA")
+
+	relatorio := &models.RelatorioDDA{Origem: []string{}}
+	desde := time.Now().Add(-s.config.DDA.Janela)
+	var boletos []models.Boleto
+	for _, provedor := range provedores {
+		relatorio.Origem = append(relatorio.Origem, provedor.Nome())
+		for _, cnpj := range s.config.DDA.CNPJs {
+			timeout := provedor.Timeout()
+			if timeout <= 0 {
+				timeout = bancos.TimeoutPadrao
+			}
+			ctxProvedor, cancel := context.WithTimeout(ctx, timeout)
+			encontrados, err := provedor.ListarDDA(ctxProvedor, bancos.ConsultaDDA{Pagador: apenasDigitos(cnpj), Desde: desde})
+			cancel()
+			if err != nil {
+				s.logger.WithError(err).WithFields(logrus.Fields{
+					"provedor": provedor.Nome(),
+					"pagador":  cnpj,
+				}).Warn("Erro ao consultar DDA")
+				relatorio.Falhas = append(relatorio.Falhas, fmt.Sprintf("%s (%s): %v", provedor.Nome(), cnpj, err))
+				continue
+			}
+			boletos = append(boletos, encontrados...)
+		}
+	}
+
+	if err := s.ingerirDDA(boletos, relatorio); err != nil {
+		return nil, err
+	}
+	return relatorio, nil
+}
+
+// IniciarSincronizacaoDDA executa SincronizarDDA no intervalo configurado até o
+// contexto ser cancelado. Sem intervalo, CNPJ ou provedor com DDA, retorna em seguida
+func (s *BankService) IniciarSincronizacaoDDA(ctx context.Context) {
+	intervalo := s.config.DDA.Intervalo
+	if intervalo <= 0 {
+		return
+	}
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		relatorio, err := s.SincronizarDDA(ctx)
+		switch {
+		case errors.Is(err, ErrDDANaoConfigurado):
+			s.logger.Warn("Sincronização do DDA agendada sem CNPJ ou provedor com DDA")
+			return
+		case err != nil:
+			s.logger.WithError(err).Error("Erro ao sincronizar boletos do DDA")
+		default:
+			s.logger.WithFields(logrus.Fields{
+				"titulos":  relatorio.Titulos,
+				"novos":    relatorio.Novos,
+				"vinculos": relatorio.Vinculos,
+			}).Info("Boletos do DDA sincronizados")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ingerirDDA grava os boletos capturados, sem repetir os já conhecidos pelo código de
+// barras, e vincula as duplicatas das NF-e emitidas pelos beneficiários
+func (s *BankService) ingerirDDA(boletos []models.Boleto, relatorio *models.RelatorioDDA) error {
+	relatorio.Titulos += len(boletos)
+	vistos := make(map[string]bool)
+	beneficiarios := make(map[string]bool)
+
+	for i := range boletos {
+		boleto := &boletos[i]
+		parsed, err := utils.ParseCodigoBoleto(boleto.CodigoBarras)
+		if err != nil || parsed.Tipo != utils.TipoCodigoBancario || vistos[parsed.CodigoBarras] {
+			relatorio.Ignorados++
+			continue
+		}
+		s.normalizarBoleto(boleto)
+		vistos[boleto.CodigoBarras] = true
+		boleto.DocumentoBeneficiario = apenasDigitos(boleto.DocumentoBeneficiario)
+		if boleto.DocumentoBeneficiario != "" {
+			beneficiarios[boleto.DocumentoBeneficiario] = true
+		}
+
+		var existente models.Boleto
+		err = s.db.Where("codigo_barras = ?", boleto.CodigoBarras).First(&existente).Error
+		switch {
+		case err == nil:
+			if !completarBoletoDDA(&existente, boleto) {
+				relatorio.Ignorados++
+				continue
+			}
+			if err := s.db.Save(&existente).Error; err != nil {
+				return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+			}
+			relatorio.Atualizados++
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if boleto.Numero == "" {
+				boleto.Numero = boleto.CodigoBarras
+			}
+			if boleto.Status == "" {
+				boleto.Status = models.StatusBoletoAberto
+			}
+			if err := s.db.Create(boleto).Error; err != nil {
+				return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+			}
+			relatorio.Novos++
+		default:
+			return fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+		}
+	}
+
+	documentos := make([]string, 0, len(beneficiarios))
+	for documento := range beneficiarios {
+		documentos = append(documentos, documento)
+	}
+	vinculos, err := s.vincularBeneficiarios(documentos)
+	if err != nil {
+		return err
+	}
+	relatorio.Vinculos += vinculos
+	return nil
+}
+
+// completarBoletoDDA preenche no boleto já conhecido os dados que faltavam e informa
+// se houve alteração. A situação e os valores já gravados são mantidos
+func completarBoletoDDA(existente, capturado *models.Boleto) bool {
+	alterado := false
+	completar := func(campo *string, valor string) {
+		if *campo == "" && valor != "" {
+			*campo = valor
+			alterado = true
+		}
+	}
+	completar(&existente.DocumentoBeneficiario, capturado.DocumentoBeneficiario)
+	completar(&existente.NomeBeneficiario, capturado.NomeBeneficiario)
+	completar(&existente.LinhaDigitavel, capturado.LinhaDigitavel)
+	completar(&existente.PixCopiaECola, capturado.PixCopiaECola)
+	completar(&existente.PixTxID, capturado.PixTxID)
+	if existente.Valor == 0 && capturado.Valor > 0 {
+		existente.Valor = capturado.Valor
+		alterado = true
+	}
+	if existente.Vencimento.IsZero() && !capturado.Vencimento.IsZero() {
+		existente.Vencimento = capturado.Vencimento
+		alterado = true
+	}
+	return alterado
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// provedorDDATeste devolve os boletos configurados para qualquer pagador
+type provedorDDATeste struct {
+	nome      string
+	boletos   []models.Boleto
+	err       error
+	consultas []bancos.ConsultaDDA
+}
+
+func (p *provedorDDATeste) Nome() string           { return p.nome }
+func (p *provedorDDATeste) Bancos() []string       { return []string{"341"} }
+func (p *provedorDDATeste) Timeout() time.Duration { return time.Second }
+
+func (p *provedorDDATeste) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	return nil, bancos.ErrBoletoNaoEncontrado
+}
+
+func (p *provedorDDATeste) ListarDDA(ctx context.Context, consulta bancos.ConsultaDDA) ([]models.Boleto, error) {
+	p.consultas = append(p.consultas, consulta)
+	return p.boletos, p.err
+}
+
+// linhaCNAB240 monta uma linha de 240 posições com os campos nas posições do manual
+func linhaCNAB240(campos map[int]string) string {
+	texto := []byte(strings.Repeat(" ", 240))
+	for posicao, valor := range campos {
+		copy(texto[posicao-1:], valor)
+	}
+	return string(texto)
+}
+
+func TestImportarDDA(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	nfe := models.NFe{
+		ChaveAcesso:  "35231198765432000198550010000001231000001230",
+		Numero:       "123",
+		EmitenteCNPJ: "98765432000198",
+		Duplicatas:   []models.Duplicata{{Numero: "001", Vencimento: time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC), Valor: 250.75}},
+	}
+	assert.NoError(t, db.Create(&nfe).Error)
+	conhecido := models.Boleto{Banco: "237", Numero: "BOL-1", CodigoBarras: "23796907800000099903381090000001234500123450", Valor: 99.90, Status: models.StatusBoletoPago}
+	assert.NoError(t, db.Create(&conhecido).Error)
+
+	itau := map[int]string{1: "34100013", 14: "G", 18: "34195955000000250751091234567800057123457000", 62: "2",
+		63: "098765432000198", 78: "INDUSTRIA SAO JOAO SA", 108: "30112023", 116: "000000000025075", 148: "NF123-1"}
+	arquivo := strings.Join([]string{
+		linhaCNAB240(map[int]string{1: "34100000", 18: "2", 19: "12345678000195", 143: "2"}),
+		linhaCNAB240(itau),
+		// O mesmo título repetido no arquivo
+		linhaCNAB240(itau),
+		// Boleto já conhecido recebe o beneficiário, mas continua pago
+		linhaCNAB240(map[int]string{1: "34100013", 14: "G", 18: "23796907800000099903381090000001234500123450", 62: "2",
+			63: "011222333000181", 78: "DISTRIBUIDORA NORTE LTDA", 108: "15082022", 116: "000000000009990"}),
+		linhaCNAB240(map[int]string{1: "34199999"}),
+	}, "\r\n")
+
+	relatorio, err := service.ImportarDDA(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{OrigemArquivoDDA}, relatorio.Origem)
+	assert.Equal(t, 3, relatorio.Titulos)
+	assert.Equal(t, 1, relatorio.Novos)
+	assert.Equal(t, 1, relatorio.Atualizados)
+	assert.Equal(t, 1, relatorio.Ignorados)
+	assert.Equal(t, 1, relatorio.Vinculos)
+
+	// O boleto capturado aparece na consulta da NF-e
+	boletos, err := service.ConsultarBoletosPorNFe(nfe.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, boletos, 1) {
+		assert.Equal(t, "NF123-1", boletos[0].Numero)
+		assert.Equal(t, "98765432000198", boletos[0].DocumentoBeneficiario)
+		assert.Equal(t, models.StatusBoletoAberto, boletos[0].Status)
+		assert.Equal(t, "34191.09123 34567.800056 71234.570001 5 95500000025075", boletos[0].LinhaDigitavel)
+	}
+
+	assert.NoError(t, db.First(&conhecido, conhecido.ID).Error)
+	assert.Equal(t, "DISTRIBUIDORA NORTE LTDA", conhecido.NomeBeneficiario)
+	assert.Equal(t, models.StatusBoletoPago, conhecido.Status)
+
+	// Uma nova importação não duplica os boletos
+	relatorio, err = service.ImportarDDA(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relatorio.Novos)
+	assert.Equal(t, 3, relatorio.Ignorados)
+}
+
+func TestSincronizarDDA(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	service := NewBankService(cfg, db, logrus.New())
+
+	_, err := service.SincronizarDDA(context.Background())
+	assert.ErrorIs(t, err, ErrDDANaoConfigurado)
+
+	cfg.DDA.CNPJs = []string{"12.345.678/0001-95", "12.345.678/0002-76"}
+	cfg.DDA.Janela = 24 * time.Hour
+	provedor := &provedorDDATeste{nome: "itau", boletos: []models.Boleto{
+		{CodigoBarras: "34195955000000250751091234567800057123457000", Valor: 250.75, DocumentoBeneficiario: "98.765.432/0001-98"},
+		// Boleto de arrecadação não é título do DDA
+		{CodigoBarras: "85870000009876500011234567890123456789012345"},
+	}}
+	falho := &provedorDDATeste{nome: "bradesco", err: errors.New("timeout")}
+	service.provedores.Registrar(provedor)
+	service.provedores.Registrar(falho)
+
+	relatorio, err := service.SincronizarDDA(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"itau", "bradesco"}, relatorio.Origem)
+	assert.Len(t, relatorio.Falhas, 2)
+	if assert.Len(t, provedor.consultas, 2) {
+		assert.Equal(t, "12345678000195", provedor.consultas[0].Pagador)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), provedor.consultas[0].Desde, time.Minute)
+	}
+	// O mesmo boleto devolvido para os dois CNPJs é gravado uma vez
+	assert.Equal(t, 4, relatorio.Titulos)
+	assert.Equal(t, 1, relatorio.Novos)
+	assert.Equal(t, 3, relatorio.Ignorados)
+
+	var boleto models.Boleto
+	assert.NoError(t, db.Where("codigo_barras = ?", "34195955000000250751091234567800057123457000").First(&boleto).Error)
+	assert.Equal(t, "98765432000198", boleto.DocumentoBeneficiario)
+	assert.Equal(t, "341", boleto.Banco)
+}
+
+func TestSincronizarDDAArquivos(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	cfg.DDA.CNPJs = []string{"12.345.678/0001-95"}
+	cfg.DDA.Janela = 24 * time.Hour
+	cfg.DDA.Diretorio = t.TempDir()
+	arquivo := strings.Join([]string{
+		linhaCNAB240(map[int]string{1: "34100000", 18: "2", 19: "12345678000195", 143: "2"}),
+		linhaCNAB240(map[int]string{1: "34100013", 14: "G", 18: "34195955000000250751091234567800057123457000", 62: "2",
+			63: "098765432000198", 78: "INDUSTRIA SAO JOAO SA", 108: "30112023", 116: "000000000025075"}),
+		linhaCNAB240(map[int]string{1: "34199999"}),
+	}, "\r\n")
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg.DDA.Diretorio, "DDA341.RET"), []byte(arquivo), 0o644))
+
+	// O provedor de arquivos é criado pela configuração, sem provedor bancário com DDA
+	service := NewBankService(cfg, db, logrus.New())
+	relatorio, err := service.SincronizarDDA(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"arquivo-dda"}, relatorio.Origem)
+	assert.Equal(t, 1, relatorio.Novos)
+
+	// A leitura seguinte do mesmo arquivo não repete o boleto
+	relatorio, err = service.SincronizarDDA(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relatorio.Novos)
+	assert.Equal(t, 1, relatorio.Ignorados)
+}
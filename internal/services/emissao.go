@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Erros da emissão de boletos de cobrança
+var (
+	// ErrCobrancaNaoConfigurada indica a falta do banco de cobrança na configuração
+	ErrCobrancaNaoConfigurada = errors.New("banco de cobrança não configurado")
+	// ErrNFeSemPagador indica uma NF-e sem o documento e o nome do destinatário
+	ErrNFeSemPagador = errors.New("NF-e sem destinatário identificado para o pagador")
+	// ErrBoletoNaoEmitido indica um boleto que não foi registrado pela empresa
+	ErrBoletoNaoEmitido = errors.New("boleto não foi emitido pela empresa")
+	// ErrBoletoNaoAberto indica um boleto já pago ou baixado
+	ErrBoletoNaoAberto = errors.New("boleto não está em aberto")
+)
+
+// EmitirBoletosNFe registra no banco de cobrança um boleto para cada duplicata da
+// NF-e que ainda não tem boleto emitido em aberto ou pago. O pagador é o destinatário
+// da NF-e, com o endereço do XML. A recusa de uma duplicata não interrompe as demais,
+// e a nova tentativa retoma o boleto pendente com o mesmo nosso número
+func (s *BankService) EmitirBoletosNFe(ctx context.Context, nfeID uint) (*models.EmissaoBoletos, error) {
+	cobranca := s.config.Cobranca
+	if cobranca.Banco == "" {
+		return nil, ErrCobrancaNaoConfigurada
+	}
+	provedor, err := s.provedores.ProvedorEmissao(cobranca.Banco)
+	if err != nil {
+		return nil, err
+	}
+
+	var nfe models.NFe
+	if err := s.db.Preload("Duplicatas", func(db *gorm.DB) *gorm.DB {
+		return db.Order("vencimento, id")
+	}).First(&nfe, nfeID).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar NFe no banco: %w", err)
+	}
+	pagador := pagadorNFe(&nfe)
+	if pagador.Documento == "" || pagador.Nome == "" {
+		return nil, ErrNFeSemPagador
+	}
+	s.logger.WithFields(logrus.Fields{
+		"nfe_id":     nfe.ID,
+		"banco":      cobranca.Banco,
+		"duplicatas": len(nfe.Duplicatas),
+	}).Info("Emitindo boletos das duplicatas da NFe")
+
+	emissao := &models.EmissaoBoletos{NFeID: nfe.ID, Banco: cobranca.Banco, Emitidos: []models.Boleto{}}
+	for _, duplicata := range nfe.Duplicatas {
+		var existentes []models.Boleto
+		if err := s.db.Where("duplicata_id = ? AND emitido = ? AND status <> ?", duplicata.ID, true, models.StatusBoletoBaixado).
+			Limit(1).Find(&existentes).Error; err != nil {
+			return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+		}
+		var pendente *models.Boleto
+		if len(existentes) > 0 {
+			if existentes[0].Status != models.StatusBoletoPendente {
+				emissao.JaEmitidos++
+				continue
+			}
+			pendente = &existentes[0]
+		}
+
+		boleto, err := s.emitirDuplicata(ctx, provedor, &nfe, duplicata, pagador, pendente)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.logger.WithError(err).WithField("duplicata_id", duplicata.ID).Warn("Erro ao emitir boleto da duplicata")
+			emissao.Falhas = append(emissao.Falhas, models.FalhaEmissao{
+				DuplicataID: duplicata.ID,
+				Numero:      duplicata.Numero,
+				Erro:        err.Error(),
+			})
+			continue
+		}
+		emissao.Emitidos = append(emissao.Emitidos, *boleto)
+	}
+	return emissao, nil
+}
+
+// emitirDuplicata registra o boleto da duplicata no banco. Antes do registro, o
+// próximo nosso número da carteira é gravado em um boleto pendente vinculado à
+// duplicata; sem a confirmação do banco, a nova tentativa retoma esse boleto
+func (s *BankService) emitirDuplicata(ctx context.Context, provedor bancos.ProvedorEmissao, nfe *models.NFe, duplicata models.Duplicata, pagador bancos.Pagador, pendente *models.Boleto) (*models.Boleto, error) {
+	cobranca := s.config.Cobranca
+	if pendente == nil {
+		nossoNumero, err := s.proximoNossoNumero(cobranca.Banco, cobranca.Carteira)
+		if err != nil {
+			return nil, err
+		}
+		duplicataID := duplicata.ID
+		pendente = &models.Boleto{
+			NFeID:       nfe.ID,
+			DuplicataID: &duplicataID,
+			Emitido:     true,
+			Banco:       cobranca.Banco,
+			Numero:      nossoNumero,
+			NossoNumero: nossoNumero,
+			Carteira:    cobranca.Carteira,
+			Valor:       duplicata.Valor,
+			Vencimento:  duplicata.Vencimento,
+			Status:      models.StatusBoletoPendente,
+		}
+		if err := s.db.Create(pendente).Error; err != nil {
+			return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+		}
+	}
+
+	solicitacao := bancos.SolicitacaoEmissao{
+		SeuNumero:   strings.TrimLeft(nfe.Numero, "0") + "-" + duplicata.Numero,
+		NossoNumero: pendente.NossoNumero,
+		Carteira:    pendente.Carteira,
+		Valor:       duplicata.Valor,
+		Emissao:     time.Now(),
+		Vencimento:  duplicata.Vencimento,
+		Pagador:     pagador,
+		TipoJuros:   cobranca.TipoJuros,
+		TaxaJuros:   cobranca.TaxaJuros,
+		TipoMulta:   cobranca.TipoMulta,
+		TaxaMulta:   cobranca.TaxaMulta,
+		Instrucoes:  cobranca.Instrucoes,
+	}
+	ctxProvedor, cancel := contextoProvedor(ctx, provedor)
+	defer cancel()
+	boleto, err := provedor.EmitirBoleto(ctxProvedor, solicitacao)
+	if err != nil {
+		return nil, err
+	}
+
+	boleto.ID, boleto.CreatedAt = pendente.ID, pendente.CreatedAt
+	boleto.NFeID = nfe.ID
+	boleto.DuplicataID = pendente.DuplicataID
+	boleto.Emitido = true
+	boleto.Banco = pendente.Banco
+	boleto.DocumentoBeneficiario = nfe.EmitenteCNPJ
+	boleto.NomeBeneficiario = nfe.EmitenteNome
+	boleto.TipoJuros, boleto.TaxaJuros = cobranca.TipoJuros, cobranca.TaxaJuros
+	boleto.TipoMulta, boleto.TaxaMulta = cobranca.TipoMulta, cobranca.TaxaMulta
+	if boleto.Status == "" || boleto.Status == models.StatusBoletoPendente {
+		boleto.Status = models.StatusBoletoAberto
+	}
+	if boleto.Numero == "" {
+		boleto.Numero = pendente.Numero
+	}
+	if boleto.NossoNumero == "" {
+		boleto.NossoNumero = pendente.NossoNumero
+	}
+	if boleto.Carteira == "" {
+		boleto.Carteira = pendente.Carteira
+	}
+	if boleto.Valor == 0 {
+		boleto.Valor = duplicata.Valor
+	}
+	if boleto.Vencimento.IsZero() {
+		boleto.Vencimento = duplicata.Vencimento
+	}
+	if err := s.db.Save(boleto).Error; err != nil {
+		// O título já está registrado no banco: o erro precisa chegar ao operador
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"nosso_numero":  boleto.NossoNumero,
+			"codigo_barras": boleto.CodigoBarras,
+		}).Error("Boleto registrado no banco mas não salvo")
+		return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	return boleto, nil
+}
+
+// proximoNossoNumero incrementa a sequência do banco e da carteira
+func (s *BankService) proximoNossoNumero(banco, carteira string) (string, error) {
+	sequencia := models.SequenciaNossoNumero{Banco: banco, Carteira: carteira}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequencia).Error; err != nil {
+			return err
+		}
+		consulta := tx.Model(&sequencia).Where("banco = ? AND carteira = ?", banco, carteira)
+		if err := consulta.UpdateColumn("ultimo", gorm.Expr("ultimo + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Where("banco = ? AND carteira = ?", banco, carteira).First(&sequencia).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao gerar nosso número: %w", err)
+	}
+	return strconv.FormatInt(sequencia.Ultimo, 10), nil
+}
+
+// BaixarBoletoEmitido solicita ao banco a baixa do boleto emitido em aberto
+func (s *BankService) BaixarBoletoEmitido(ctx context.Context, codigo string) (*models.Boleto, error) {
+	boleto, provedor, err := s.boletoEmitido(codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxProvedor, cancel := contextoProvedor(ctx, provedor)
+	defer cancel()
+	if err := provedor.BaixarBoleto(ctxProvedor, boleto); err != nil {
+		return nil, err
+	}
+	boleto.Status = models.StatusBoletoBaixado
+	if err := s.db.Save(boleto).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	s.logger.WithField("boleto_id", boleto.ID).Info("Boleto emitido baixado")
+	return boleto, nil
+}
+
+// AlterarVencimentoBoleto solicita ao banco a alteração do vencimento do boleto
+// emitido em aberto
+func (s *BankService) AlterarVencimentoBoleto(ctx context.Context, codigo string, vencimento time.Time) (*models.Boleto, error) {
+	boleto, provedor, err := s.boletoEmitido(codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	ctxProvedor, cancel := contextoProvedor(ctx, provedor)
+	defer cancel()
+	if err := provedor.AlterarVencimento(ctxProvedor, boleto, vencimento); err != nil {
+		return nil, err
+	}
+	// O código de barras traz o fator de vencimento e muda com a nova data
+	boleto.Vencimento = vencimento
+	if boleto.CodigoBarras != "" {
+		codigoBarras, err := utils.AlterarVencimentoCodigoBarras(boleto.CodigoBarras, vencimento)
+		if err != nil {
+			return nil, err
+		}
+		boleto.CodigoBarras = codigoBarras
+		if boleto.LinhaDigitavel, err = utils.LinhaDigitavel(codigoBarras); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.db.Save(boleto).Error; err != nil {
+		return nil, fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"boleto_id":  boleto.ID,
+		"vencimento": vencimento.Format(time.DateOnly),
+	}).Info("Vencimento do boleto emitido alterado")
+	return boleto, nil
+}
+
+// boletoEmitido consulta o boleto e o provedor do banco para as operações sobre
+// boletos emitidos em aberto
+func (s *BankService) boletoEmitido(codigo string) (*models.Boleto, bancos.ProvedorEmissao, error) {
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !boleto.Emitido {
+		return nil, nil, ErrBoletoNaoEmitido
+	}
+	if boleto.Status != models.StatusBoletoAberto {
+		return nil, nil, ErrBoletoNaoAberto
+	}
+	provedor, err := s.provedores.ProvedorEmissao(boleto.Banco)
+	if err != nil {
+		return nil, nil, err
+	}
+	return boleto, provedor, nil
+}
+
+// contextoProvedor limita a operação ao timeout do provedor
+func contextoProvedor(ctx context.Context, provedor bancos.Provedor) (context.Context, context.CancelFunc) {
+	timeout := provedor.Timeout()
+	if timeout <= 0 {
+		timeout = bancos.TimeoutPadrao
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// pagadorNFe extrai do XML da NF-e o destinatário com o endereço (enderDest); sem
+// XML, usa o documento e o nome gravados
+func pagadorNFe(nfe *models.NFe) bancos.Pagador {
+	pagador := bancos.Pagador{Documento: apenasDigitos(nfe.DestinatarioCNPJ), Nome: nfe.DestinatarioNome}
+	if nfe.XML == "" {
+		return pagador
+	}
+	doc, err := xmlquery.Parse(strings.NewReader(nfe.XML))
+	if err != nil {
+		return pagador
+	}
+	dest := xmlquery.FindOne(doc, "//dest")
+	if dest == nil {
+		return pagador
+	}
+
+	texto := func(no *xmlquery.Node, campo string) string {
+		if no == nil {
+			return ""
+		}
+		if valor := xmlquery.FindOne(no, campo); valor != nil {
+			return strings.TrimSpace(valor.InnerText())
+		}
+		return ""
+	}
+	if cpf := texto(dest, "CPF"); pagador.Documento == "" && cpf != "" {
+		pagador.Documento = cpf
+	}
+	if pagador.Nome == "" {
+		pagador.Nome = texto(dest, "xNome")
+	}
+	endereco := xmlquery.FindOne(dest, "enderDest")
+	pagador.Logradouro = texto(endereco, "xLgr")
+	pagador.Numero = texto(endereco, "nro")
+	pagador.Complemento = texto(endereco, "xCpl")
+	pagador.Bairro = texto(endereco, "xBairro")
+	pagador.Cidade = texto(endereco, "xMun")
+	pagador.UF = texto(endereco, "UF")
+	pagador.CEP = texto(endereco, "CEP")
+	return pagador
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// provedorEmissaoTeste registra os boletos com o código de barras do Itaú e recusa
+// as solicitações com o valor configurado
+type provedorEmissaoTeste struct {
+	recusar      float64
+	solicitacoes []bancos.SolicitacaoEmissao
+	baixados     []string
+	vencimentos  []time.Time
+}
+
+func (p *provedorEmissaoTeste) Nome() string           { return "emissao" }
+func (p *provedorEmissaoTeste) Bancos() []string       { return []string{"341"} }
+func (p *provedorEmissaoTeste) Timeout() time.Duration { return time.Second }
+
+func (p *provedorEmissaoTeste) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	return nil, bancos.ErrBoletoNaoEncontrado
+}
+
+func (p *provedorEmissaoTeste) EmitirBoleto(ctx context.Context, solicitacao bancos.SolicitacaoEmissao) (*models.Boleto, error) {
+	p.solicitacoes = append(p.solicitacoes, solicitacao)
+	if solicitacao.Valor == p.recusar {
+		return nil, &bancos.ErroAPI{Status: 422, Corpo: `{"mensagem":"pagador inválido"}`}
+	}
+	return &models.Boleto{
+		Numero:       solicitacao.NossoNumero,
+		NossoNumero:  solicitacao.NossoNumero,
+		Carteira:     solicitacao.Carteira,
+		CodigoBarras: "34195955000000250751091234567800057123457000",
+		Valor:        solicitacao.Valor,
+		Vencimento:   solicitacao.Vencimento,
+		Status:       models.StatusBoletoAberto,
+	}, nil
+}
+
+func (p *provedorEmissaoTeste) BaixarBoleto(ctx context.Context, boleto *models.Boleto) error {
+	p.baixados = append(p.baixados, boleto.NossoNumero)
+	return nil
+}
+
+func (p *provedorEmissaoTeste) AlterarVencimento(ctx context.Context, boleto *models.Boleto, vencimento time.Time) error {
+	p.vencimentos = append(p.vencimentos, vencimento)
+	return nil
+}
+
+func cobrancaTeste() config.CobrancaConfig {
+	return config.CobrancaConfig{Banco: "341", Carteira: "109", TipoJuros: models.JurosTaxaMensal, TaxaJuros: 1}
+}
+
+const xmlNFeEmitida = `<nfeProc><NFe><infNFe><dest><CNPJ>12345678000195</CNPJ><xNome>CLIENTE EXEMPLO LTDA</xNome>
+<enderDest><xLgr>Rua das Flores</xLgr><nro>100</nro><xBairro>Centro</xBairro><xMun>São Paulo</xMun><UF>SP</UF><CEP>01001000</CEP></enderDest>
+</dest></infNFe></NFe></nfeProc>`
+
+func TestEmitirBoletosNFe(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	service := NewBankService(cfg, db, logrus.New())
+
+	nfe := models.NFe{
+		ChaveAcesso:      "35231198765432000198550010000001231000001230",
+		Numero:           "000123",
+		EmitenteCNPJ:     "98765432000198",
+		EmitenteNome:     "EMPRESA EXEMPLO LTDA",
+		DestinatarioCNPJ: "12345678000195",
+		DestinatarioNome: "CLIENTE EXEMPLO LTDA",
+		XML:              xmlNFeEmitida,
+		Duplicatas: []models.Duplicata{
+			{Numero: "001", Vencimento: time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC), Valor: 250.75},
+			{Numero: "002", Vencimento: time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC), Valor: 99.90},
+		},
+	}
+	assert.NoError(t, db.Create(&nfe).Error)
+
+	_, err := service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.ErrorIs(t, err, ErrCobrancaNaoConfigurada)
+	cfg.Cobranca = cobrancaTeste()
+	_, err = service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.ErrorIs(t, err, bancos.ErrEmissaoNaoSuportada)
+
+	provedor := &provedorEmissaoTeste{recusar: 99.90}
+	service.provedores.Registrar(provedor)
+	emissao, err := service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.NoError(t, err)
+	assert.Len(t, emissao.Emitidos, 1)
+	assert.Len(t, emissao.Falhas, 1)
+	assert.Equal(t, "002", emissao.Falhas[0].Numero)
+
+	emitido := emissao.Emitidos[0]
+	assert.True(t, emitido.Emitido)
+	assert.Equal(t, nfe.Duplicatas[0].ID, *emitido.DuplicataID)
+	assert.Equal(t, "341", emitido.Banco)
+	assert.Equal(t, "98765432000198", emitido.DocumentoBeneficiario)
+	assert.Equal(t, models.JurosTaxaMensal, emitido.TipoJuros)
+
+	solicitacao := provedor.solicitacoes[0]
+	assert.Equal(t, "123-001", solicitacao.SeuNumero)
+	assert.Equal(t, "1", solicitacao.NossoNumero)
+	assert.Equal(t, "109", solicitacao.Carteira)
+	assert.Equal(t, bancos.Pagador{Documento: "12345678000195", Nome: "CLIENTE EXEMPLO LTDA", Logradouro: "Rua das Flores",
+		Numero: "100", Bairro: "Centro", Cidade: "São Paulo", UF: "SP", CEP: "01001000"}, solicitacao.Pagador)
+
+	// O nosso número da duplicata recusada fica reservado no boleto pendente
+	var pendente models.Boleto
+	assert.NoError(t, db.Where("duplicata_id = ?", nfe.Duplicatas[1].ID).First(&pendente).Error)
+	assert.Equal(t, models.StatusBoletoPendente, pendente.Status)
+	assert.Equal(t, "2", pendente.NossoNumero)
+
+	// Na segunda emissão, só a duplicata recusada é enviada, retomando o boleto
+	// pendente com o mesmo nosso número
+	provedor.recusar = 0
+	emissao, err = service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.NoError(t, err)
+	assert.Len(t, emissao.Emitidos, 1)
+	assert.Equal(t, 1, emissao.JaEmitidos)
+	assert.Equal(t, "2", provedor.solicitacoes[2].NossoNumero)
+	assert.Equal(t, pendente.ID, emissao.Emitidos[0].ID)
+	assert.Equal(t, models.StatusBoletoAberto, emissao.Emitidos[0].Status)
+	var boletosDuplicata int64
+	assert.NoError(t, db.Model(&models.Boleto{}).Where("duplicata_id = ?", nfe.Duplicatas[1].ID).Count(&boletosDuplicata).Error)
+	assert.Equal(t, int64(1), boletosDuplicata)
+
+	// Alteração de vencimento recalcula o código de barras
+	novo := time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC)
+	alterado, err := service.AlterarVencimentoBoleto(context.Background(), "1", novo)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{novo}, provedor.vencimentos)
+	assert.Equal(t, "2023-12-15", alterado.Vencimento.Format(time.DateOnly))
+	assert.Equal(t, "9565", alterado.CodigoBarras[5:9])
+
+	baixado, err := service.BaixarBoletoEmitido(context.Background(), alterado.CodigoBarras)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusBoletoBaixado, baixado.Status)
+	assert.Equal(t, []string{"1"}, provedor.baixados)
+
+	_, err = service.BaixarBoletoEmitido(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrBoletoNaoAberto)
+
+	// Com o boleto baixado, a duplicata volta a ser emitida
+	emissao, err = service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.NoError(t, err)
+	assert.Len(t, emissao.Emitidos, 1)
+	assert.Equal(t, 1, emissao.JaEmitidos)
+}
+
+func TestOperacoesBoletoNaoEmitido(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+	service.provedores.Registrar(&provedorEmissaoTeste{})
+
+	fornecedor := models.Boleto{Banco: "341", Numero: "BOL-1", Valor: 10, Status: models.StatusBoletoAberto}
+	assert.NoError(t, db.Create(&fornecedor).Error)
+
+	_, err := service.BaixarBoletoEmitido(context.Background(), "BOL-1")
+	assert.ErrorIs(t, err, ErrBoletoNaoEmitido)
+	_, err = service.AlterarVencimentoBoleto(context.Background(), "BOL-1", time.Now())
+	assert.ErrorIs(t, err, ErrBoletoNaoEmitido)
+
+	// Sem destinatário, a NF-e não tem pagador
+	nfe := models.NFe{ChaveAcesso: "35231198765432000198550010000001241000001240"}
+	assert.NoError(t, db.Create(&nfe).Error)
+	service.config.Cobranca = cobrancaTeste()
+	_, err = service.EmitirBoletosNFe(context.Background(), nfe.ID)
+	assert.ErrorIs(t, err, ErrNFeSemPagador)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// novoCalendario monta o calendário bancário com os feriados locais configurados
+func novoCalendario(cfg *config.Config, logger *logrus.Logger) *calendario.Calendario {
+	c, err := calendario.New(cfg.Calendario.FeriadosLocais)
+	if err != nil {
+		logger.WithError(err).Warn("Ignorando feriados locais inválidos")
+	}
+	return c
+}
+
+// arredondar arredonda o valor para centavos
+func arredondar(valor float64) float64 {
+	return math.Round(valor*100) / 100
+}
+
+// CalcularValorAtualizado calcula o valor devido do boleto na data. O boleto que
+// vence em fim de semana ou feriado do calendário pode ser pago sem encargos no dia
+// útil seguinte; depois disso, juros e multa contam desde o vencimento original. Até
+// o vencimento, vale a faixa de desconto vigente. Juros e multa incidem sobre o valor
+// já abatido
+func CalcularValorAtualizado(cal *calendario.Calendario, boleto *models.Boleto, data time.Time) *models.ValorAtualizado {
+	data = calendario.Dia(data)
+	base := boleto.Valor - boleto.ValorAbatimento
+	resultado := &models.ValorAtualizado{
+		BoletoID:     boleto.ID,
+		CodigoBarras: boleto.CodigoBarras,
+		Data:         data,
+		Vencimento:   calendario.Dia(boleto.Vencimento),
+		Valor:        boleto.Valor,
+		Abatimento:   boleto.ValorAbatimento,
+	}
+	if !resultado.Vencimento.IsZero() {
+		resultado.VencimentoUtil = cal.ProximoDiaUtil(resultado.Vencimento)
+		if data.After(resultado.VencimentoUtil) {
+			resultado.DiasAtraso = int(data.Sub(resultado.Vencimento).Hours() / 24)
+		}
+	}
+
+	if resultado.DiasAtraso > 0 {
+		dias := float64(resultado.DiasAtraso)
+		switch boleto.TipoJuros {
+		case models.JurosValorDia:
+			resultado.Juros = boleto.TaxaJuros * dias
+		case models.JurosTaxaDiaria:
+			resultado.Juros = base * boleto.TaxaJuros / 100 * dias
+		case models.JurosTaxaMensal:
+			resultado.Juros = base * boleto.TaxaJuros / 100 / 30 * dias
+		}
+		switch boleto.TipoMulta {
+		case models.EncargoValor:
+			resultado.Multa = boleto.TaxaMulta
+		case models.EncargoPercentual:
+			resultado.Multa = base * boleto.TaxaMulta / 100
+		}
+	} else if desconto := descontoVigente(cal, boleto.Descontos, data); desconto != nil {
+		resultado.Desconto = desconto.Valor
+		if desconto.Tipo == models.EncargoPercentual {
+			resultado.Desconto = base * desconto.Valor / 100
+		}
+		resultado.Desconto = math.Min(resultado.Desconto, base)
+	}
+
+	resultado.Juros = arredondar(resultado.Juros)
+	resultado.Multa = arredondar(resultado.Multa)
+	resultado.Desconto = arredondar(resultado.Desconto)
+	resultado.Total = arredondar(base - resultado.Desconto + resultado.Juros + resultado.Multa)
+	return resultado
+}
+
+// descontoVigente escolhe a faixa de desconto de menor data limite que ainda vale na
+// data. A data limite em dia sem expediente se estende ao dia útil seguinte
+func descontoVigente(cal *calendario.Calendario, descontos []models.DescontoBoleto, data time.Time) *models.DescontoBoleto {
+	var vigente *models.DescontoBoleto
+	for i := range descontos {
+		limite := calendario.Dia(descontos[i].DataLimite)
+		if data.After(cal.ProximoDiaUtil(limite)) {
+			continue
+		}
+		if vigente == nil || limite.Before(calendario.Dia(vigente.DataLimite)) {
+			vigente = &descontos[i]
+		}
+	}
+	return vigente
+}
+
+// ValorAtualizado consulta o boleto e calcula o valor devido na data de pagamento
+func (s *BankService) ValorAtualizado(codigo string, data time.Time) (*models.ValorAtualizado, error) {
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, err
+	}
+	return CalcularValorAtualizado(s.calendario, boleto, data), nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+func dia(ano int, mes time.Month, d int) time.Time {
+	return time.Date(ano, mes, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalcularValorAtualizado(t *testing.T) {
+	cal := calendario.Nacional()
+	boleto := &models.Boleto{
+		Valor:      1000,
+		Vencimento: dia(2023, 11, 30),
+		TipoJuros:  models.JurosTaxaMensal,
+		TaxaJuros:  3,
+		TipoMulta:  models.EncargoPercentual,
+		TaxaMulta:  2,
+		Descontos: []models.DescontoBoleto{
+			{DataLimite: dia(2023, 11, 25), Tipo: models.EncargoValor, Valor: 20},
+			{DataLimite: dia(2023, 11, 20), Tipo: models.EncargoPercentual, Valor: 5},
+		},
+	}
+
+	// Vale a faixa de desconto de menor data limite ainda não ultrapassada
+	assert.Equal(t, 950.0, CalcularValorAtualizado(cal, boleto, dia(2023, 11, 18)).Total)
+	assert.Equal(t, 980.0, CalcularValorAtualizado(cal, boleto, dia(2023, 11, 22)).Total)
+	// O limite de sábado se estende à segunda-feira
+	assert.Equal(t, 980.0, CalcularValorAtualizado(cal, boleto, dia(2023, 11, 27)).Total)
+	assert.Equal(t, 1000.0, CalcularValorAtualizado(cal, boleto, time.Date(2023, 11, 30, 18, 0, 0, 0, time.UTC)).Total)
+
+	atualizado := CalcularValorAtualizado(cal, boleto, dia(2023, 12, 10))
+	assert.Equal(t, 10, atualizado.DiasAtraso)
+	assert.Equal(t, 0.0, atualizado.Desconto)
+	assert.Equal(t, 10.0, atualizado.Juros)
+	assert.Equal(t, 20.0, atualizado.Multa)
+	assert.Equal(t, 1030.0, atualizado.Total)
+
+	// Juros e multa incidem sobre o valor abatido
+	boleto.ValorAbatimento = 100
+	boleto.TipoJuros, boleto.TaxaJuros = models.JurosTaxaDiaria, 0.033
+	atualizado = CalcularValorAtualizado(cal, boleto, dia(2023, 12, 10))
+	assert.Equal(t, 2.97, atualizado.Juros)
+	assert.Equal(t, 18.0, atualizado.Multa)
+	assert.Equal(t, 920.97, atualizado.Total)
+}
+
+func TestCalcularValorAtualizadoDiaNaoUtil(t *testing.T) {
+	cal, _ := calendario.New([]string{"2023-12-11=Feriado municipal"})
+	boleto := &models.Boleto{
+		Valor:     100,
+		TipoJuros: models.JurosValorDia,
+		TaxaJuros: 0.5,
+		TipoMulta: models.EncargoValor,
+		TaxaMulta: 2,
+	}
+
+	// Vencimento no sábado: pago na segunda sem encargos; depois, os juros contam desde sábado
+	boleto.Vencimento = dia(2023, 12, 2)
+	atualizado := CalcularValorAtualizado(cal, boleto, dia(2023, 12, 4))
+	assert.Equal(t, dia(2023, 12, 4), atualizado.VencimentoUtil)
+	assert.Equal(t, 100.0, atualizado.Total)
+	atualizado = CalcularValorAtualizado(cal, boleto, dia(2023, 12, 5))
+	assert.Equal(t, 3, atualizado.DiasAtraso)
+	assert.Equal(t, 103.5, atualizado.Total)
+
+	// Sábado seguido de feriado municipal na segunda
+	boleto.Vencimento = dia(2023, 12, 9)
+	assert.Equal(t, dia(2023, 12, 12), CalcularValorAtualizado(cal, boleto, dia(2023, 12, 12)).VencimentoUtil)
+	assert.Equal(t, 100.0, CalcularValorAtualizado(cal, boleto, dia(2023, 12, 12)).Total)
+
+	// Carnaval
+	boleto.Vencimento = dia(2024, 2, 12)
+	assert.Equal(t, dia(2024, 2, 14), CalcularValorAtualizado(cal, boleto, dia(2024, 2, 14)).VencimentoUtil)
+
+	// Natal na segunda-feira
+	boleto.Vencimento = dia(2023, 12, 25)
+	assert.Equal(t, dia(2023, 12, 26), CalcularValorAtualizado(cal, boleto, dia(2023, 12, 26)).VencimentoUtil)
+	assert.Equal(t, 100.0, CalcularValorAtualizado(cal, boleto, dia(2023, 12, 26)).Total)
+}
+
+func TestValorAtualizado(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	boleto := models.Boleto{
+		Banco:        "341",
+		CodigoBarras: "34195955000000250751091234567800057123457000",
+		Valor:        250.75,
+		Vencimento:   dia(2023, 11, 30),
+		Status:       models.StatusBoletoAberto,
+		Descontos:    []models.DescontoBoleto{{DataLimite: dia(2023, 11, 20), Tipo: models.EncargoValor, Valor: 10}},
+	}
+	assert.NoError(t, db.Create(&boleto).Error)
+
+	atualizado, err := service.ValorAtualizado("34191.09123 34567.800056 71234.570001 5 95500000025075", dia(2023, 11, 20))
+	assert.NoError(t, err)
+	assert.Equal(t, boleto.ID, atualizado.BoletoID)
+	assert.Equal(t, 10.0, atualizado.Desconto)
+	assert.Equal(t, 240.75, atualizado.Total)
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ErrBoletoNaoImprimivel indica um boleto sem o código de barras bancário de 44
+// dígitos, necessário à ficha de compensação
+var ErrBoletoNaoImprimivel = errors.New("boleto sem código de barras bancário para impressão")
+
+// Dimensões do boleto, em milímetros, conforme o leiaute FEBRABAN da ficha de
+// compensação: código de barras ITF com 103 mm e 13 mm de altura
+const (
+	margemBoleto        = 10.0
+	larguraBoleto       = 190.0
+	colunaDireitaBoleto = 45.0
+	alturaCampoBoleto   = 8.5
+	moduloITF           = 103.0 / 405.0
+	alturaITF           = 13.0
+	ladoPixBoleto       = 28.0
+)
+
+// nomesBancos identifica os bancos na área do logo
+var nomesBancos = map[string]string{
+	"001": "Banco do Brasil",
+	"033": "Santander",
+	"104": "Caixa",
+	"237": "Bradesco",
+	"341": "Itaú",
+	"422": "Safra",
+	"748": "Sicredi",
+	"756": "Sicoob",
+}
+
+// campoBoleto é um campo do boleto: rótulo pequeno no topo da caixa e valor abaixo
+type campoBoleto struct {
+	largura float64
+	rotulo  string
+	valor   string
+	// direita alinha o valor à direita, como nos campos de valor e vencimento
+	direita bool
+}
+
+// boletoPDF desenha o boleto em um documento gofpdf, convertendo os textos para a
+// codificação das fontes padrão
+type boletoPDF struct {
+	pdf *gofpdf.Fpdf
+	tr  func(string) string
+}
+
+// GerarBoleto gera o boleto em PDF no leiaute FEBRABAN: recibo do pagador e ficha
+// de compensação com a linha digitável e o código de barras ITF. A NF-e, quando
+// informada, completa o beneficiário e identifica o pagador; sem ela, o pagador é a
+// empresa configurada para os pagamentos
+func (s *PDFService) GerarBoleto(boleto *models.Boleto, nfe *models.NFe) ([]byte, error) {
+	if boleto.Tipo == utils.TipoCodigoArrecadacao || len(boleto.CodigoBarras) != 44 {
+		return nil, ErrBoletoNaoImprimivel
+	}
+	linhaDigitavel := boleto.LinhaDigitavel
+	if linhaDigitavel == "" {
+		var err error
+		if linhaDigitavel, err = utils.LinhaDigitavel(boleto.CodigoBarras); err != nil {
+			return nil, err
+		}
+	}
+	s.logger.WithField("codigo_barras", boleto.CodigoBarras).Info("Gerando boleto em PDF")
+
+	beneficiario := strings.TrimSpace(boleto.NomeBeneficiario + " - " + formatarDocumento(boleto.DocumentoBeneficiario))
+	pagador := strings.TrimSpace(s.pagador.Empresa + " - " + formatarDocumento(s.pagador.CNPJ))
+	dataDocumento := boleto.CreatedAt
+	numeroDocumento := boleto.Numero
+	if nfe != nil {
+		if boleto.NomeBeneficiario == "" {
+			beneficiario = nfe.EmitenteNome + " - " + formatarDocumento(nfe.EmitenteCNPJ)
+		}
+		if nfe.DestinatarioNome != "" {
+			pagador = nfe.DestinatarioNome + " - " + formatarDocumento(nfe.DestinatarioCNPJ)
+		}
+		dataDocumento = nfe.DataEmissao
+	}
+	beneficiario = strings.Trim(beneficiario, " -")
+	pagador = strings.Trim(pagador, " -")
+	agencia := strings.Trim(boleto.Agencia+" / "+boleto.CodigoBeneficiario, " /")
+	vencimento := formatarData(boleto.Vencimento)
+	valor := valorBRL(boleto.Valor)
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margemBoleto, margemBoleto, margemBoleto)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	b := &boletoPDF{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	// Recibo do pagador
+	y := b.cabecalho(margemBoleto, boleto.Banco, "Recibo do Pagador", 10)
+	y = b.linha(y, campoBoleto{largura: 100, rotulo: "Beneficiário", valor: beneficiario},
+		campoBoleto{largura: 45, rotulo: "Agência / Código do Beneficiário", valor: agencia},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "Vencimento", valor: vencimento, direita: true})
+	y = b.linha(y, campoBoleto{largura: 50, rotulo: "Nosso Número", valor: boleto.NossoNumero},
+		campoBoleto{largura: 50, rotulo: "Nº do Documento", valor: numeroDocumento},
+		campoBoleto{largura: 45, rotulo: "Data do Documento", valor: formatarData(dataDocumento)},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "(=) Valor do Documento", valor: valor, direita: true})
+	y = b.linha(y, campoBoleto{largura: larguraBoleto - colunaDireitaBoleto, rotulo: "Pagador", valor: pagador},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "(=) Valor Cobrado", direita: true})
+	y = b.rodape(y, "Autenticação Mecânica")
+
+	// Boletos híbridos: o QR Code do Pix fica no recibo, com o copia-e-cola ao lado
+	if boleto.PixCopiaECola != "" {
+		qr, err := utils.GerarQRCode(boleto.PixCopiaECola)
+		if err != nil {
+			s.logger.WithError(err).WithField("codigo_barras", boleto.CodigoBarras).Warn("Erro ao desenhar QR Code Pix do boleto")
+		} else {
+			desenharQRCodePDF(pdf, qr, margemBoleto, y, ladoPixBoleto)
+			pdf.SetXY(margemBoleto+ladoPixBoleto+2, y+2)
+			pdf.SetFont("Arial", "B", 8)
+			pdf.Cell(0, 4, b.tr("Pague com Pix"))
+			pdf.SetXY(margemBoleto+ladoPixBoleto+2, y+7)
+			pdf.SetFont("Courier", "", 7)
+			pdf.MultiCell(larguraBoleto-ladoPixBoleto-2, 3, boleto.PixCopiaECola, "", "L", false)
+			y += ladoPixBoleto
+		}
+	}
+
+	// Linha de corte entre o recibo e a ficha
+	y += 4
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(margemBoleto, y, margemBoleto+larguraBoleto, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetFont("Arial", "", 6)
+	pdf.SetXY(margemBoleto, y+0.5)
+	pdf.CellFormat(larguraBoleto, 3, b.tr("Corte na linha pontilhada"), "", 0, "R", false, 0, "")
+	y += 6
+
+	// Ficha de compensação
+	y = b.cabecalho(y, boleto.Banco, linhaDigitavel, 11)
+	y = b.linha(y, campoBoleto{largura: larguraBoleto - colunaDireitaBoleto, rotulo: "Local de Pagamento", valor: "Pagável em qualquer banco até o vencimento"},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "Vencimento", valor: vencimento, direita: true})
+	y = b.linha(y, campoBoleto{largura: larguraBoleto - colunaDireitaBoleto, rotulo: "Beneficiário", valor: beneficiario},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "Agência / Código do Beneficiário", valor: agencia, direita: true})
+	y = b.linha(y, campoBoleto{largura: 30, rotulo: "Data do Documento", valor: formatarData(dataDocumento)},
+		campoBoleto{largura: 40, rotulo: "Nº do Documento", valor: numeroDocumento},
+		campoBoleto{largura: 20, rotulo: "Espécie Doc.", valor: "DM"},
+		campoBoleto{largura: 15, rotulo: "Aceite", valor: "N"},
+		campoBoleto{largura: 40, rotulo: "Data do Processamento", valor: formatarData(time.Now())},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "Nosso Número", valor: boleto.NossoNumero, direita: true})
+	y = b.linha(y, campoBoleto{largura: 30, rotulo: "Uso do Banco"},
+		campoBoleto{largura: 25, rotulo: "Carteira", valor: boleto.Carteira},
+		campoBoleto{largura: 20, rotulo: "Espécie", valor: "R$"},
+		campoBoleto{largura: 35, rotulo: "Quantidade"},
+		campoBoleto{largura: 35, rotulo: "Valor"},
+		campoBoleto{largura: colunaDireitaBoleto, rotulo: "(=) Valor do Documento", valor: valor, direita: true})
+
+	// Instruções à esquerda e os campos de deduções e acréscimos à direita
+	rotulos := []string{"(-) Desconto / Abatimento", "(-) Outras Deduções", "(+) Mora / Multa", "(+) Outros Acréscimos", "(=) Valor Cobrado"}
+	esquerda := larguraBoleto - colunaDireitaBoleto
+	b.caixa(margemBoleto, y, esquerda, alturaCampoBoleto*float64(len(rotulos)), "Instruções (texto de responsabilidade do beneficiário)")
+	pdf.SetFont("Arial", "", 8)
+	pdf.SetXY(margemBoleto+1, y+4)
+	pdf.MultiCell(esquerda-2, 3.8, b.tr(strings.Join(instrucoesBoleto(boleto, nfe), "\n")), "", "L", false)
+	for i, rotulo := range rotulos {
+		b.caixa(margemBoleto+esquerda, y+float64(i)*alturaCampoBoleto, colunaDireitaBoleto, alturaCampoBoleto, rotulo)
+	}
+	y += alturaCampoBoleto * float64(len(rotulos))
+
+	b.caixa(margemBoleto, y, larguraBoleto, 12, "Pagador")
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetXY(margemBoleto+1, y+4)
+	pdf.Cell(larguraBoleto-2, 4, b.tr(pagador))
+	y = b.rodape(y+12, "Autenticação Mecânica - Ficha de Compensação")
+
+	elementos, err := utils.CodificarITF(boleto.CodigoBarras)
+	if err != nil {
+		return nil, err
+	}
+	desenharITF(pdf, elementos, margemBoleto, y+1)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cabecalho desenha a área do logo com o nome do banco, o código do banco com o DV e,
+// à direita, a linha digitável ou o título do recibo. Retorna a posição abaixo dele
+func (b *boletoPDF) cabecalho(y float64, banco, texto string, tamanho float64) float64 {
+	const altura = 9.0
+	nome := nomesBancos[banco]
+	if nome == "" {
+		nome = "Banco " + banco
+	}
+
+	b.pdf.SetFont("Arial", "B", 12)
+	b.pdf.SetXY(margemBoleto, y)
+	b.pdf.CellFormat(45, altura, b.tr(nome), "", 0, "LM", false, 0, "")
+	b.pdf.SetLineWidth(0.6)
+	b.pdf.Line(margemBoleto+45, y+1, margemBoleto+45, y+altura)
+	b.pdf.Line(margemBoleto+65, y+1, margemBoleto+65, y+altura)
+	b.pdf.SetFont("Arial", "B", 14)
+	b.pdf.CellFormat(20, altura, banco+"-"+dvBanco(banco), "", 0, "CM", false, 0, "")
+	b.pdf.SetFont("Arial", "B", tamanho)
+	b.pdf.CellFormat(larguraBoleto-65, altura, b.tr(texto), "", 0, "RM", false, 0, "")
+	b.pdf.Line(margemBoleto, y+altura, margemBoleto+larguraBoleto, y+altura)
+	b.pdf.SetLineWidth(0.2)
+	return y + altura
+}
+
+// linha desenha uma linha de campos lado a lado e retorna a posição abaixo dela
+func (b *boletoPDF) linha(y float64, campos ...campoBoleto) float64 {
+	x := margemBoleto
+	for _, campo := range campos {
+		b.caixa(x, y, campo.largura, alturaCampoBoleto, campo.rotulo)
+		alinhamento := "L"
+		if campo.direita {
+			alinhamento = "R"
+		}
+		b.pdf.SetFont("Arial", "", 9)
+		b.pdf.SetXY(x+1, y+3.8)
+		b.pdf.CellFormat(campo.largura-2, 4, b.tr(campo.valor), "", 0, alinhamento, false, 0, "")
+		x += campo.largura
+	}
+	return y + alturaCampoBoleto
+}
+
+// caixa desenha a borda do campo com o rótulo no topo
+func (b *boletoPDF) caixa(x, y, largura, altura float64, rotulo string) {
+	b.pdf.Rect(x, y, largura, altura, "D")
+	b.pdf.SetFont("Arial", "", 6)
+	b.pdf.SetXY(x+0.8, y+0.6)
+	b.pdf.Cell(largura-1.6, 2.5, b.tr(rotulo))
+}
+
+// rodape escreve o texto de autenticação à direita, abaixo dos campos
+func (b *boletoPDF) rodape(y float64, texto string) float64 {
+	b.pdf.SetFont("Arial", "", 7)
+	b.pdf.SetXY(margemBoleto, y+0.5)
+	b.pdf.CellFormat(larguraBoleto, 3, b.tr(texto), "", 0, "R", false, 0, "")
+	return y + 4
+}
+
+// desenharITF desenha as barras do código ITF; os espaços são apenas deslocamentos
+func desenharITF(pdf *gofpdf.Fpdf, elementos []int, x, y float64) {
+	pdf.SetFillColor(0, 0, 0)
+	for i, largura := range elementos {
+		w := float64(largura) * moduloITF
+		if i%2 == 0 {
+			pdf.Rect(x, y, w, alturaITF, "F")
+		}
+		x += w
+	}
+}
+
+// instrucoesBoleto descreve as regras de encargos e descontos do boleto
+func instrucoesBoleto(boleto *models.Boleto, nfe *models.NFe) []string {
+	var instrucoes []string
+	switch boleto.TipoJuros {
+	case models.JurosValorDia:
+		instrucoes = append(instrucoes, "Após o vencimento, cobrar mora de R$ "+valorBRL(boleto.TaxaJuros)+" por dia de atraso.")
+	case models.JurosTaxaDiaria:
+		instrucoes = append(instrucoes, "Após o vencimento, cobrar juros de "+percentualBRL(boleto.TaxaJuros)+" ao dia.")
+	case models.JurosTaxaMensal:
+		instrucoes = append(instrucoes, "Após o vencimento, cobrar juros de "+percentualBRL(boleto.TaxaJuros)+" ao mês.")
+	}
+	switch boleto.TipoMulta {
+	case models.EncargoValor:
+		instrucoes = append(instrucoes, "Após o vencimento, cobrar multa de R$ "+valorBRL(boleto.TaxaMulta)+".")
+	case models.EncargoPercentual:
+		instrucoes = append(instrucoes, "Após o vencimento, cobrar multa de "+percentualBRL(boleto.TaxaMulta)+".")
+	}
+	for _, desconto := range boleto.Descontos {
+		valor := "R$ " + valorBRL(desconto.Valor)
+		if desconto.Tipo == models.EncargoPercentual {
+			valor = percentualBRL(desconto.Valor)
+		}
+		instrucoes = append(instrucoes, fmt.Sprintf("Conceder desconto de %s até %s.", valor, formatarData(desconto.DataLimite)))
+	}
+	if boleto.ValorAbatimento > 0 {
+		instrucoes = append(instrucoes, "Conceder abatimento de R$ "+valorBRL(boleto.ValorAbatimento)+".")
+	}
+	if nfe != nil && nfe.Numero != "" {
+		instrucoes = append(instrucoes, "Referente à NF-e nº "+nfe.Numero+".")
+	}
+	return instrucoes
+}
+
+// dvsBancoPublicados são os dígitos atribuídos pela compensação que diferem do
+// cálculo em módulo 11
+var dvsBancoPublicados = map[string]string{
+	"104": "0",
+}
+
+// dvBanco calcula o dígito do código do banco, em módulo 11 com pesos de 2 a 4:
+// resto 1 resulta em "X" (como o 748-X do Sicredi) e resto 0, em 0
+func dvBanco(banco string) string {
+	if dv, ok := dvsBancoPublicados[banco]; ok {
+		return dv
+	}
+	soma, peso := 0, 2
+	for i := len(banco) - 1; i >= 0; i-- {
+		soma += int(banco[i]-'0') * peso
+		peso++
+	}
+	switch dv := 11 - soma%11; dv {
+	case 10:
+		return "X"
+	case 11:
+		return "0"
+	default:
+		return strconv.Itoa(dv)
+	}
+}
+
+// valorBRL formata o valor com vírgula decimal e ponto de milhar
+func valorBRL(valor float64) string {
+	inteiro, decimal, _ := strings.Cut(strconv.FormatFloat(valor, 'f', 2, 64), ".")
+	negativo := strings.HasPrefix(inteiro, "-")
+	inteiro = strings.TrimPrefix(inteiro, "-")
+	for i := len(inteiro) - 3; i > 0; i -= 3 {
+		inteiro = inteiro[:i] + "." + inteiro[i:]
+	}
+	if negativo {
+		inteiro = "-" + inteiro
+	}
+	return inteiro + "," + decimal
+}
+
+// percentualBRL formata a taxa percentual com vírgula decimal
+func percentualBRL(taxa float64) string {
+	texto := strconv.FormatFloat(taxa, 'f', -1, 64)
+	if !strings.Contains(texto, ".") {
+		texto += ".00"
+	} else if len(texto)-strings.Index(texto, ".") == 2 {
+		texto += "0"
+	}
+	return strings.Replace(texto, ".", ",", 1) + "%"
+}
+
+// formatarData formata a data no padrão brasileiro, vazia quando não informada
+func formatarData(data time.Time) string {
+	if data.IsZero() {
+		return ""
+	}
+	return data.Format("02/01/2006")
+}
+
+// formatarDocumento aplica a máscara de CNPJ ou CPF
+func formatarDocumento(documento string) string {
+	digitos := apenasDigitos(documento)
+	switch len(digitos) {
+	case 14:
+		return digitos[:2] + "." + digitos[2:5] + "." + digitos[5:8] + "/" + digitos[8:12] + "-" + digitos[12:]
+	case 11:
+		return digitos[:3] + "." + digitos[3:6] + "." + digitos[6:9] + "-" + digitos[9:]
+	}
+	return documento
+}
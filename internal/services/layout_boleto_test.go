@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGerarBoleto(t *testing.T) {
+	service := NewPDFService(setupTestConfig(), logrus.New())
+
+	boleto := &models.Boleto{
+		Banco:            "341",
+		CodigoBarras:     "34195955000000250751091234567800057123457000",
+		Valor:            250.75,
+		Vencimento:       time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC),
+		NossoNumero:      "109/12345678-0",
+		NomeBeneficiario: "Distribuidora São João Ltda",
+		TipoJuros:        models.JurosTaxaMensal,
+		TaxaJuros:        1,
+		TipoMulta:        models.EncargoPercentual,
+		TaxaMulta:        2,
+		PixCopiaECola:    "00020101021226580014br.gov.bcb.pix",
+	}
+	nfe := &models.NFe{Numero: "1234", DestinatarioNome: "Cliente Exemplo", DestinatarioCNPJ: "12345678000195"}
+
+	documento, err := service.GerarBoleto(boleto, nfe)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(documento, []byte("%PDF")))
+
+	// Boletos de arrecadação não têm ficha de compensação
+	_, err = service.GerarBoleto(&models.Boleto{
+		Tipo:         utils.TipoCodigoArrecadacao,
+		CodigoBarras: "84670000001435900240200240500024384221010811",
+	}, nil)
+	assert.ErrorIs(t, err, ErrBoletoNaoImprimivel)
+
+	_, err = service.GerarBoleto(&models.Boleto{Banco: "341", Numero: "123"}, nil)
+	assert.ErrorIs(t, err, ErrBoletoNaoImprimivel)
+}
+
+func TestInstrucoesBoleto(t *testing.T) {
+	boleto := &models.Boleto{
+		TipoJuros:       models.JurosValorDia,
+		TaxaJuros:       0.5,
+		TipoMulta:       models.EncargoPercentual,
+		TaxaMulta:       2,
+		ValorAbatimento: 1234.5,
+		Descontos: []models.DescontoBoleto{
+			{DataLimite: time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC), Tipo: models.EncargoValor, Valor: 10},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"Após o vencimento, cobrar mora de R$ 0,50 por dia de atraso.",
+		"Após o vencimento, cobrar multa de 2,00%.",
+		"Conceder desconto de R$ 10,00 até 20/11/2023.",
+		"Conceder abatimento de R$ 1.234,50.",
+		"Referente à NF-e nº 42.",
+	}, instrucoesBoleto(boleto, &models.NFe{Numero: "42"}))
+}
+
+func TestDVBanco(t *testing.T) {
+	assert.Equal(t, "7", dvBanco("341"))
+	assert.Equal(t, "2", dvBanco("237"))
+	assert.Equal(t, "9", dvBanco("001"))
+	assert.Equal(t, "0", dvBanco("104"))
+	assert.Equal(t, "7", dvBanco("033"))
+	assert.Equal(t, "X", dvBanco("748"))
+	assert.Equal(t, "0", dvBanco("756"))
+}
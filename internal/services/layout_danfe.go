@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// alturaLogo é a altura do logo no cabeçalho do DANFE, em milímetros
+const alturaLogo = 15.0
+
+// superficieDANFE representa a área de desenho de um backend paginado do DANFE.
+// As medidas são em milímetros e seguem o modelo de fluxo do gofpdf: o texto é
+// escrito em células a partir da posição atual e Ln avança para a próxima linha.
+type superficieDANFE interface {
+	// Logo desenha a imagem no canto superior esquerdo e retorna a largura ocupada
+	Logo(caminho string, altura float64) float64
+	// AvancarX desloca a posição horizontal atual
+	AvancarX(mm float64)
+	// Fonte define a família, o estilo ("" ou "B") e o tamanho em pontos
+	Fonte(familia, estilo string, tamanho float64)
+	// CorTexto define a cor do texto em hexadecimal (#RRGGBB)
+	CorTexto(hex string)
+	// Texto escreve uma célula de texto com a altura informada
+	Texto(altura float64, texto string)
+	// Ln quebra a linha, voltando à margem esquerda
+	Ln(altura float64)
+}
+
+// desenharDANFE desenha o layout do DANFE numa superfície, aplicando o template.
+// É compartilhado pelos backends paginados para que todos produzam o mesmo layout.
+func desenharDANFE(sup superficieDANFE, nfe *models.NFe, tpl *DANFETemplate) {
+	// Configura fonte
+	sup.Fonte(tpl.Fontes.Familia, "B", tpl.Fontes.Secao)
+
+	// Cabeçalho
+	desenharCabecalho(sup, nfe, tpl)
+
+	// Dados do emitente
+	if tpl.MostrarBloco(BlocoEmitente) {
+		desenharSecao(sup, tpl, "emitente", []string{
+			tpl.Rotulo("cnpj") + ": " + nfe.EmitenteCNPJ,
+			tpl.Rotulo("nome") + ": " + nfe.EmitenteNome,
+			tpl.Rotulo("ie") + ": " + nfe.EmitenteIE,
+		})
+	}
+
+	// Dados do destinatário
+	if tpl.MostrarBloco(BlocoDestinatario) {
+		desenharSecao(sup, tpl, "destinatario", []string{
+			tpl.Rotulo("cnpj") + ": " + nfe.DestinatarioCNPJ,
+			tpl.Rotulo("nome") + ": " + nfe.DestinatarioNome,
+			tpl.Rotulo("ie") + ": " + nfe.DestinatarioIE,
+		})
+	}
+
+	// Dados da NFe
+	if tpl.MostrarBloco(BlocoDadosNFe) {
+		linhas := []string{
+			tpl.Rotulo("numero") + ": " + nfe.Numero,
+			tpl.Rotulo("serie") + ": " + nfe.Serie,
+			tpl.Rotulo("data_emissao") + ": " + nfe.DataEmissao.Format("02/01/2006 15:04:05"),
+		}
+		if nfe.DataAutorizacao != nil {
+			linhas = append(linhas, tpl.Rotulo("data_autorizacao")+": "+nfe.DataAutorizacao.Format("02/01/2006 15:04:05"))
+		}
+		linhas = append(linhas,
+			tpl.Rotulo("status")+": "+nfe.Status,
+			tpl.Rotulo("ambiente")+": "+nfe.Ambiente,
+			tpl.Rotulo("uf")+": "+nfe.UF,
+		)
+		desenharSecao(sup, tpl, "dados_nfe", linhas)
+	}
+
+	// Valores
+	if tpl.MostrarBloco(BlocoValores) {
+		desenharSecao(sup, tpl, "valores", []string{
+			tpl.Rotulo("valor_total") + ": R$ " + formatarValor(nfe.ValorTotal),
+			tpl.Rotulo("valor_produtos") + ": R$ " + formatarValor(nfe.ValorProdutos),
+			tpl.Rotulo("valor_impostos") + ": R$ " + formatarValor(nfe.ValorImpostos),
+		})
+	}
+
+	// Duplicatas
+	if tpl.MostrarBloco(BlocoDuplicatas) && len(nfe.Duplicatas) > 0 {
+		linhas := make([]string, 0, len(nfe.Duplicatas))
+		for _, dup := range nfe.Duplicatas {
+			linhas = append(linhas, fmt.Sprintf("%s: %s, %s: %s, %s: R$ %s",
+				tpl.Rotulo("numero"), dup.Numero,
+				tpl.Rotulo("vencimento"), dup.Vencimento.Format("02/01/2006"),
+				tpl.Rotulo("valor"), formatarValor(dup.Valor)))
+		}
+		desenharSecao(sup, tpl, "duplicatas", linhas)
+	}
+
+	// Código de barras
+	if tpl.MostrarBloco(BlocoCodigoBarras) {
+		desenharSecao(sup, tpl, "codigo_barras", []string{nfe.ChaveAcesso})
+	}
+}
+
+// desenharCabecalho desenha o logo, o título e a chave de acesso
+func desenharCabecalho(sup superficieDANFE, nfe *models.NFe, tpl *DANFETemplate) {
+	if caminho := tpl.CaminhoLogo(); caminho != "" {
+		if largura := sup.Logo(caminho, alturaLogo); largura > 0 {
+			sup.AvancarX(largura + 5)
+		}
+	}
+
+	sup.Fonte(tpl.Fontes.Familia, "B", tpl.Fontes.Titulo)
+	sup.CorTexto(tpl.Cores.Titulo)
+	sup.Texto(10, tpl.Rotulo("titulo"))
+	sup.Ln(15)
+
+	sup.Fonte(tpl.Fontes.Familia, "B", tpl.Fontes.Secao)
+	sup.CorTexto(tpl.Cores.Texto)
+	sup.Texto(8, tpl.Rotulo("chave_acesso")+": "+nfe.ChaveAcesso)
+	sup.Ln(10)
+}
+
+// desenharSecao desenha o título de um bloco seguido de suas linhas
+func desenharSecao(sup superficieDANFE, tpl *DANFETemplate, rotulo string, linhas []string) {
+	sup.Fonte(tpl.Fontes.Familia, "B", tpl.Fontes.Secao)
+	sup.CorTexto(tpl.Cores.Secao)
+	sup.Texto(8, tpl.Rotulo(rotulo))
+	sup.Ln(8)
+
+	sup.Fonte(tpl.Fontes.Familia, "", tpl.Fontes.Texto)
+	sup.CorTexto(tpl.Cores.Texto)
+	for i, linha := range linhas {
+		sup.Texto(6, linha)
+		if i < len(linhas)-1 {
+			sup.Ln(6)
+		}
+	}
+	sup.Ln(10)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Limites da consulta em lote quando não configurados
+const (
+	concorrenciaLotePadrao = 16
+	maxCodigosLotePadrao   = 5000
+)
+
+// ErrLoteExcedido indica um lote com mais códigos que o permitido
+var ErrLoteExcedido = errors.New("lote excede o número máximo de códigos")
+
+// MaxCodigosLote retorna o número máximo de códigos aceitos na consulta em lote
+func (s *BankService) MaxCodigosLote() int {
+	if s.config.Bank.Lote.MaxCodigos > 0 {
+		return s.config.Bank.Lote.MaxCodigos
+	}
+	return maxCodigosLotePadrao
+}
+
+// ConsultarMultiplosBoletos consulta os códigos concorrentemente, limitados pela
+// concorrência configurada e por um prazo comum ao lote. Cada código recebe o seu
+// resultado, na ordem enviada; o mesmo boleto em formatos diferentes (código de
+// barras e linha digitável) é consultado uma única vez
+func (s *BankService) ConsultarMultiplosBoletos(ctx context.Context, codigos []string) (*models.ConsultaBoletosLote, error) {
+	if len(codigos) > s.MaxCodigosLote() {
+		return nil, ErrLoteExcedido
+	}
+	s.logger.WithField("codigos", len(codigos)).Info("Consultando múltiplos boletos")
+
+	if prazo := s.config.Bank.Lote.Prazo; prazo > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, prazo)
+		defer cancel()
+	}
+
+	resultados := make([]models.ResultadoConsultaBoleto, len(codigos))
+	posicoes := make(map[string][]int)
+	var chaves []string
+	for i, codigo := range codigos {
+		resultados[i].Codigo = codigo
+		chave, err := chaveLote(codigo)
+		if err != nil {
+			resultados[i].Status = models.ConsultaInvalido
+			resultados[i].Motivo = err.Error()
+			continue
+		}
+		if _, ok := posicoes[chave]; !ok {
+			chaves = append(chaves, chave)
+		}
+		posicoes[chave] = append(posicoes[chave], i)
+	}
+
+	concorrencia := s.config.Bank.Lote.Concorrencia
+	if concorrencia <= 0 {
+		concorrencia = concorrenciaLotePadrao
+	}
+	// Cada chave é consultada por um único worker, que grava apenas nas suas posições
+	pendentes := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concorrencia, len(chaves)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chave := range pendentes {
+				resultado := s.consultarItemLote(ctx, chave)
+				for _, i := range posicoes[chave] {
+					resultados[i].Status = resultado.Status
+					resultados[i].Motivo = resultado.Motivo
+					resultados[i].Boleto = resultado.Boleto
+				}
+			}
+		}()
+	}
+distribuir:
+	for _, chave := range chaves {
+		select {
+		case pendentes <- chave:
+		case <-ctx.Done():
+			break distribuir
+		}
+	}
+	close(pendentes)
+	wg.Wait()
+
+	lote := &models.ConsultaBoletosLote{Total: len(codigos), Resultados: resultados}
+	for i := range resultados {
+		if resultados[i].Status == "" {
+			resultados[i].Status = models.ConsultaErro
+			resultados[i].Motivo = "prazo da consulta em lote esgotado"
+		}
+		switch resultados[i].Status {
+		case models.ConsultaEncontrado:
+			lote.Encontrados++
+		case models.ConsultaNaoEncontrado:
+			lote.NaoEncontrados++
+		case models.ConsultaInvalido:
+			lote.Invalidos++
+		default:
+			lote.Erros++
+		}
+	}
+	s.logger.WithFields(logrus.Fields{
+		"encontrados":     lote.Encontrados,
+		"nao_encontrados": lote.NaoEncontrados,
+		"invalidos":       lote.Invalidos,
+		"erros":           lote.Erros,
+	}).Info("Consulta de boletos em lote concluída")
+	return lote, nil
+}
+
+// chaveLote normaliza o código para identificar os repetidos: códigos de barras e
+// linhas digitáveis são validados e reduzidos aos 44 dígitos
+func chaveLote(codigo string) (string, error) {
+	codigo = strings.TrimSpace(codigo)
+	if codigo == "" {
+		return "", errors.New("código vazio")
+	}
+	if !utils.PareceCodigoBoleto(codigo) {
+		return codigo, nil
+	}
+	parsed, err := utils.ParseCodigoBoleto(codigo)
+	if err != nil {
+		return "", err
+	}
+	return parsed.CodigoBarras, nil
+}
+
+// consultarItemLote consulta um código do lote e traduz o erro na situação do resultado
+func (s *BankService) consultarItemLote(ctx context.Context, codigo string) models.ResultadoConsultaBoleto {
+	boleto, err := s.consultarBoleto(ctx, codigo)
+	switch {
+	case err == nil:
+		return models.ResultadoConsultaBoleto{Status: models.ConsultaEncontrado, Boleto: boleto}
+	case errors.Is(err, utils.ErrCodigoBoletoInvalido):
+		return models.ResultadoConsultaBoleto{Status: models.ConsultaInvalido, Motivo: err.Error()}
+	case errors.Is(err, bancos.ErrBoletoNaoEncontrado):
+		return models.ResultadoConsultaBoleto{Status: models.ConsultaNaoEncontrado, Motivo: "boleto não encontrado nos provedores bancários"}
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		return models.ResultadoConsultaBoleto{Status: models.ConsultaErro, Motivo: "prazo da consulta em lote esgotado"}
+	}
+	s.logger.WithError(err).WithField("codigo", codigo).Error("Erro ao consultar boleto")
+	return models.ResultadoConsultaBoleto{Status: models.ConsultaErro, Motivo: err.Error()}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// provedorLoteTeste encontra os boletos do Itaú, falha nos do Bradesco, não responde
+// aos do Banco do Brasil até o fim do prazo e não conhece os demais códigos
+type provedorLoteTeste struct {
+	consultas atomic.Int32
+}
+
+func (p *provedorLoteTeste) Nome() string           { return "teste" }
+func (p *provedorLoteTeste) Bancos() []string       { return []string{"341", "237", "001"} }
+func (p *provedorLoteTeste) Timeout() time.Duration { return time.Minute }
+
+func (p *provedorLoteTeste) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	p.consultas.Add(1)
+	switch consulta.Banco {
+	case "341":
+		return &models.Boleto{Banco: "341", CodigoBarras: consulta.CodigoBarras, Valor: 250.75, DocumentoBeneficiario: "98.765.432/0001-98",
+			Status: models.StatusBoletoAberto}, nil
+	case "237":
+		return nil, errors.New("serviço indisponível")
+	case "":
+		return nil, bancos.ErrBoletoNaoEncontrado
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestConsultarMultiplosBoletos(t *testing.T) {
+	db := setupTestDB()
+	// O SQLite em memória é por conexão
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	cfg := setupTestConfig()
+	cfg.Bank.Lote.Concorrencia = 2
+	cfg.Bank.Lote.Prazo = 300 * time.Millisecond
+	service := NewBankService(cfg, db, logrus.New())
+	provedor := &provedorLoteTeste{}
+	service.provedores.Registrar(provedor)
+
+	codigos := []string{
+		"34195955000000250751091234567800057123457000",
+		// O mesmo boleto pela linha digitável
+		"34191.09123 34567.800056 71234.570001 5 95500000025075",
+		"23796907800000099903381090000001234500123450",
+		"34195955000000250751091234567800057123457001",
+		"BOL-INEXISTENTE",
+		"00193373700000001000500940144816060680935031",
+		"",
+	}
+	lote, err := service.ConsultarMultiplosBoletos(context.Background(), codigos)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, lote.Total)
+	assert.Equal(t, 2, lote.Encontrados)
+	assert.Equal(t, 1, lote.NaoEncontrados)
+	assert.Equal(t, 2, lote.Invalidos)
+	assert.Equal(t, 2, lote.Erros)
+	// Os códigos repetidos e os inválidos não chegam aos provedores
+	assert.Equal(t, int32(4), provedor.consultas.Load())
+
+	if !assert.Len(t, lote.Resultados, 7) {
+		return
+	}
+	for i, codigo := range codigos {
+		assert.Equal(t, codigo, lote.Resultados[i].Codigo)
+	}
+	assert.Equal(t, models.ConsultaEncontrado, lote.Resultados[0].Status)
+	assert.Equal(t, 250.75, lote.Resultados[0].Boleto.Valor)
+	assert.Equal(t, lote.Resultados[0].Boleto, lote.Resultados[1].Boleto)
+	assert.Equal(t, models.ConsultaErro, lote.Resultados[2].Status)
+	assert.Contains(t, lote.Resultados[2].Motivo, "serviço indisponível")
+	assert.Equal(t, models.ConsultaInvalido, lote.Resultados[3].Status)
+	assert.Equal(t, models.ConsultaNaoEncontrado, lote.Resultados[4].Status)
+	assert.Equal(t, models.ConsultaErro, lote.Resultados[5].Status)
+	assert.Equal(t, "prazo da consulta em lote esgotado", lote.Resultados[5].Motivo)
+	assert.Equal(t, models.ConsultaInvalido, lote.Resultados[6].Status)
+
+	cfg.Bank.Lote.MaxCodigos = 3
+	_, err = service.ConsultarMultiplosBoletos(context.Background(), codigos)
+	assert.ErrorIs(t, err, ErrLoteExcedido)
+}
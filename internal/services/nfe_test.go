@@ -3,8 +3,8 @@ package services
 import (
 	"testing"
 
-	"helpdanfe-go/internal/config"
-	"helpdanfe-go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -19,7 +19,7 @@ func setupTestDB() *gorm.DB {
 	}
 
 	// Auto migrate
-	db.AutoMigrate(&models.NFe{}, &models.Duplicata{}, &models.Boleto{})
+	db.AutoMigrate(&models.NFe{}, &models.Duplicata{}, &models.Boleto{}, &models.DescontoBoleto{}, &models.RemessaPagamento{}, &models.VinculoDuplicata{}, &models.LancamentoExtrato{}, &models.SequenciaNossoNumero{}, &models.NotificacaoWebhook{})
 
 	return db
 }
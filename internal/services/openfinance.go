@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/openfinance"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrOpenFinanceNaoConfigurado indica que a integração com o Open Finance não está configurada
+var ErrOpenFinanceNaoConfigurado = errors.New("integração com o Open Finance não configurada")
+
+// OpenFinanceService gerencia os consentimentos do Open Finance usados para
+// confirmar pagamentos de boletos nas nossas contas
+type OpenFinanceService struct {
+	logger         *logrus.Logger
+	consentimentos *openfinance.Consentimentos
+}
+
+// NewOpenFinanceService cria uma nova instância do serviço. Sem OPEN_BANKING_URL, ou
+// com credenciais inválidas, as operações retornam ErrOpenFinanceNaoConfigurado
+func NewOpenFinanceService(cfg *config.Config, db *gorm.DB, logger *logrus.Logger) *OpenFinanceService {
+	s := &OpenFinanceService{logger: logger}
+	if cfg.Bank.OpenBanking.URL == "" {
+		return s
+	}
+	cliente, err := openfinance.NovoCliente(cfg.Bank.OpenBanking, logger)
+	if err != nil {
+		logger.WithError(err).Error("Erro ao configurar cliente do Open Finance")
+		return s
+	}
+	s.consentimentos = openfinance.NovoConsentimentos(cliente, db, logger)
+	return s
+}
+
+// CriarConsentimento cria um consentimento e retorna a URL de autorização do titular
+func (s *OpenFinanceService) CriarConsentimento(req models.CriarConsentimentoRequest) (*models.ConsentimentoOpenFinance, error) {
+	if s.consentimentos == nil {
+		return nil, ErrOpenFinanceNaoConfigurado
+	}
+	return s.consentimentos.Criar(context.Background(), req)
+}
+
+// ListarConsentimentos lista os consentimentos registrados
+func (s *OpenFinanceService) ListarConsentimentos() ([]models.ConsentimentoOpenFinance, error) {
+	if s.consentimentos == nil {
+		return nil, ErrOpenFinanceNaoConfigurado
+	}
+	return s.consentimentos.Listar()
+}
+
+// ObterConsentimento retorna o consentimento com a situação atualizada
+func (s *OpenFinanceService) ObterConsentimento(consentID string) (*models.ConsentimentoOpenFinance, error) {
+	if s.consentimentos == nil {
+		return nil, ErrOpenFinanceNaoConfigurado
+	}
+	return s.consentimentos.Obter(context.Background(), consentID)
+}
+
+// AutorizarConsentimento conclui a autorização com o código recebido no redirect
+func (s *OpenFinanceService) AutorizarConsentimento(consentID string, req models.AutorizarConsentimentoRequest) (*models.ConsentimentoOpenFinance, error) {
+	if s.consentimentos == nil {
+		return nil, ErrOpenFinanceNaoConfigurado
+	}
+	return s.consentimentos.Autorizar(context.Background(), consentID, req.Code)
+}
+
+// RevogarConsentimento revoga o consentimento na instituição e o remove
+func (s *OpenFinanceService) RevogarConsentimento(consentID string) error {
+	if s.consentimentos == nil {
+		return ErrOpenFinanceNaoConfigurado
+	}
+	return s.consentimentos.Revogar(context.Background(), consentID)
+}
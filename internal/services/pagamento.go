@@ -0,0 +1,318 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ErrBancoPagadorNaoConfigurado indica um banco sem conta de pagamento configurada
+var ErrBancoPagadorNaoConfigurado = errors.New("banco pagador não configurado")
+
+// ErrBoletosInvalidosRemessa indica boletos que não podem entrar na remessa
+var ErrBoletosInvalidosRemessa = errors.New("boletos não podem ser pagos")
+
+// ErroBoletosRemessa lista o motivo da recusa de cada boleto da seleção
+type ErroBoletosRemessa struct {
+	Motivos map[uint]string
+}
+
+func (e *ErroBoletosRemessa) Error() string {
+	ids := make([]int, 0, len(e.Motivos))
+	for id := range e.Motivos {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	partes := make([]string, len(ids))
+	for i, id := range ids {
+		partes[i] = fmt.Sprintf("boleto %d: %s", id, e.Motivos[uint(id)])
+	}
+	return fmt.Sprintf("%s: %s", ErrBoletosInvalidosRemessa, strings.Join(partes, "; "))
+}
+
+// Is faz o erro corresponder a ErrBoletosInvalidosRemessa
+func (e *ErroBoletosRemessa) Is(target error) bool {
+	return target == ErrBoletosInvalidosRemessa
+}
+
+// PagamentoService gera as remessas de pagamento dos boletos de fornecedores e
+// importa os retornos do banco pagador
+type PagamentoService struct {
+	config     *config.Config
+	db         *gorm.DB
+	logger     *logrus.Logger
+	agora      func() time.Time
+	calendario *calendario.Calendario
+}
+
+// NewPagamentoService cria uma nova instância do serviço de pagamentos
+func NewPagamentoService(cfg *config.Config, db *gorm.DB, logger *logrus.Logger) *PagamentoService {
+	return &PagamentoService{
+		config:     cfg,
+		db:         db,
+		logger:     logger,
+		agora:      time.Now,
+		calendario: novoCalendario(cfg, logger),
+	}
+}
+
+// GerarRemessa monta o arquivo CNAB 240 de pagamento dos boletos selecionados e
+// registra a remessa, que passa a identificar os boletos até o retorno
+func (s *PagamentoService) GerarRemessa(req models.GerarRemessaPagamentoRequest) (*models.RemessaPagamento, []byte, error) {
+	conta, ok := s.config.Pagamentos.Contas[req.Banco]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrBancoPagadorNaoConfigurado, req.Banco)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"banco":   req.Banco,
+		"boletos": len(req.BoletoIDs),
+	}).Info("Gerando remessa de pagamento")
+
+	var remessa *models.RemessaPagamento
+	var arquivo []byte
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		boletos, err := s.boletosRemessa(tx, req.BoletoIDs)
+		if err != nil {
+			return err
+		}
+		pagamentos, err := s.pagamentos(tx, boletos, req.DataPagamento)
+		if err != nil {
+			return err
+		}
+
+		var ultimo int
+		if err := tx.Model(&models.RemessaPagamento{}).Where("banco = ?", req.Banco).
+			Select("COALESCE(MAX(sequencial), 0)").Scan(&ultimo).Error; err != nil {
+			return fmt.Errorf("erro ao consultar remessas no banco: %w", err)
+		}
+		remessa = &models.RemessaPagamento{Banco: req.Banco, Sequencial: ultimo + 1, Quantidade: len(pagamentos)}
+		for _, pagamento := range pagamentos {
+			remessa.ValorTotal += pagamento.ValorPagamento
+		}
+
+		cnabRemessa := cnab.RemessaPagamento{
+			Pagador: cnab.Pagador{
+				Banco:     req.Banco,
+				CNPJ:      apenasDigitos(s.config.Pagamentos.CNPJ),
+				Nome:      s.config.Pagamentos.Empresa,
+				Convenio:  conta.Convenio,
+				Agencia:   conta.Agencia,
+				DVAgencia: conta.DVAgencia,
+				Conta:     conta.Conta,
+				DVConta:   conta.DVConta,
+			},
+			Sequencial: remessa.Sequencial,
+			Geracao:    s.agora(),
+			Pagamentos: pagamentos,
+		}
+		if arquivo, err = cnabRemessa.Gerar(); err != nil {
+			return err
+		}
+
+		if err := tx.Create(remessa).Error; err != nil {
+			return fmt.Errorf("erro ao salvar remessa no banco: %w", err)
+		}
+		return tx.Model(&models.Boleto{}).Where("id IN ?", req.BoletoIDs).
+			Update("remessa_pagamento_id", remessa.ID).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return remessa, arquivo, nil
+}
+
+// boletosRemessa carrega os boletos e recusa os que não podem ser pagos por
+// código de barras, os emitidos pela empresa e os que já aguardam outra remessa
+func (s *PagamentoService) boletosRemessa(tx *gorm.DB, ids []uint) ([]models.Boleto, error) {
+	var boletos []models.Boleto
+	if err := tx.Preload("Descontos").Where("id IN ?", ids).Order("id").Find(&boletos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+	}
+
+	motivos := make(map[uint]string)
+	encontrados := make(map[uint]bool)
+	for _, boleto := range boletos {
+		encontrados[boleto.ID] = true
+		switch {
+		case boleto.Emitido:
+			motivos[boleto.ID] = "boleto emitido pela empresa é a receber"
+		case boleto.Tipo == utils.TipoCodigoArrecadacao:
+			motivos[boleto.ID] = "boletos de arrecadação não são pagos pelo segmento J"
+		case boleto.Status != models.StatusBoletoAberto:
+			motivos[boleto.ID] = "boleto " + strings.ToLower(boleto.Status)
+		case len(boleto.CodigoBarras) != 44:
+			motivos[boleto.ID] = "boleto sem código de barras"
+		case boleto.RemessaPagamentoID != nil:
+			motivos[boleto.ID] = fmt.Sprintf("boleto já incluído na remessa %d", *boleto.RemessaPagamentoID)
+		}
+	}
+	for _, id := range ids {
+		if !encontrados[id] {
+			motivos[id] = "boleto não encontrado"
+		}
+	}
+	if len(motivos) > 0 {
+		return nil, &ErroBoletosRemessa{Motivos: motivos}
+	}
+	return boletos, nil
+}
+
+// pagamentos monta os títulos da remessa com o beneficiário da NF-e de cada boleto
+func (s *PagamentoService) pagamentos(tx *gorm.DB, boletos []models.Boleto, dataPagamento *time.Time) ([]cnab.Pagamento, error) {
+	emitentes := make(map[uint]models.NFe)
+	var nfeIDs []uint
+	for _, boleto := range boletos {
+		if boleto.NFeID != 0 {
+			nfeIDs = append(nfeIDs, boleto.NFeID)
+		}
+	}
+	if len(nfeIDs) > 0 {
+		var nfes []models.NFe
+		if err := tx.Select("id", "emitente_cnpj", "emitente_nome").Where("id IN ?", nfeIDs).Find(&nfes).Error; err != nil {
+			return nil, fmt.Errorf("erro ao consultar NFes no banco: %w", err)
+		}
+		for _, nfe := range nfes {
+			emitentes[nfe.ID] = nfe
+		}
+	}
+
+	hoje := calendario.Dia(s.agora())
+	pagamentos := make([]cnab.Pagamento, len(boletos))
+	for i, boleto := range boletos {
+		data := hoje
+		switch {
+		case dataPagamento != nil:
+			data = *dataPagamento
+		case boleto.Vencimento.After(hoje):
+			data = boleto.Vencimento
+		}
+		// Sem NF-e vinculada, vale o beneficiário informado pelo banco
+		emitente, ok := emitentes[boleto.NFeID]
+		if !ok {
+			emitente = models.NFe{EmitenteNome: boleto.NomeBeneficiario, EmitenteCNPJ: boleto.DocumentoBeneficiario}
+		}
+		pagamentos[i] = cnab.Pagamento{
+			SeuNumero:             strconv.FormatUint(uint64(boleto.ID), 10),
+			CodigoBarras:          boleto.CodigoBarras,
+			NomeBeneficiario:      emitente.EmitenteNome,
+			DocumentoBeneficiario: apenasDigitos(emitente.EmitenteCNPJ),
+			Vencimento:            boleto.Vencimento,
+			ValorTitulo:           boleto.Valor,
+			Desconto:              boleto.ValorDesconto,
+			Juros:                 boleto.ValorJuros + boleto.ValorMulta,
+			DataPagamento:         data,
+			ValorPagamento:        boleto.Valor - boleto.ValorDesconto + boleto.ValorJuros + boleto.ValorMulta,
+		}
+		// Com as regras de encargos, o valor pago é o atualizado para a data do pagamento
+		if boleto.PossuiRegrasEncargos() {
+			atualizado := CalcularValorAtualizado(s.calendario, &boleto, data)
+			pagamentos[i].Desconto = atualizado.Abatimento + atualizado.Desconto
+			pagamentos[i].Juros = atualizado.Juros + atualizado.Multa
+			pagamentos[i].ValorPagamento = atualizado.Total
+		}
+	}
+	return pagamentos, nil
+}
+
+// ImportarRetorno lê o retorno da remessa de pagamento: pagamentos efetivados marcam
+// o boleto como pago; os rejeitados liberam o boleto para uma nova remessa
+func (s *PagamentoService) ImportarRetorno(arquivo io.Reader) (*models.RelatorioRetorno, error) {
+	retorno, err := cnab.ParseRetornoPagamento(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"banco":     retorno.Banco,
+		"registros": len(retorno.Registros),
+	}).Info("Importando retorno de pagamentos")
+
+	relatorio := &models.RelatorioRetorno{
+		Layout:         cnab.Layout240,
+		Banco:          retorno.Banco,
+		Registros:      len(retorno.Registros),
+		Boletos:        []models.BoletoConciliado{},
+		NaoConciliados: []models.RegistroNaoConciliado{},
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range retorno.Registros {
+			registro := &retorno.Registros[i]
+			ocorrencias := strings.Join(registro.Ocorrencias, ",")
+
+			var boleto models.Boleto
+			consulta := tx.Where("codigo_barras = ?", registro.CodigoBarras)
+			if id, err := strconv.ParseUint(registro.SeuNumero, 10, 64); err == nil {
+				consulta = tx.Where("id = ? AND codigo_barras = ?", id, registro.CodigoBarras)
+			}
+			if err := consulta.First(&boleto).Error; err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+				}
+				relatorio.NaoConciliados = append(relatorio.NaoConciliados, models.RegistroNaoConciliado{
+					Linha:      registro.Linha,
+					SeuNumero:  registro.SeuNumero,
+					Ocorrencia: ocorrencias,
+					ValorPago:  registro.ValorPagamento,
+					Motivo:     "boleto não encontrado",
+				})
+				continue
+			}
+
+			switch {
+			case registro.Efetivado():
+				valorPago := registro.ValorPagamento
+				boleto.Status = models.StatusBoletoPago
+				boleto.ValorPago = &valorPago
+				boleto.DataPagamento = registro.DataPagamento
+				boleto.RemessaPagamentoID = nil
+				relatorio.Atualizados++
+			case registro.Agendado():
+				relatorio.SemAlteracao++
+			default:
+				s.logger.WithFields(logrus.Fields{
+					"boleto_id":   boleto.ID,
+					"ocorrencias": ocorrencias,
+				}).Warn("Pagamento de boleto rejeitado pelo banco")
+				boleto.RemessaPagamentoID = nil
+				relatorio.SemAlteracao++
+			}
+			if err := tx.Save(&boleto).Error; err != nil {
+				return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+			}
+			relatorio.Boletos = append(relatorio.Boletos, models.BoletoConciliado{
+				Linha:       registro.Linha,
+				BoletoID:    boleto.ID,
+				NossoNumero: boleto.NossoNumero,
+				Ocorrencia:  ocorrencias,
+				Status:      boleto.Status,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relatorio, nil
+}
+
+// apenasDigitos remove a formatação de CNPJ e CPF
+func apenasDigitos(documento string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, documento)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+func TestRemessaPagamento(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	cfg.Pagamentos = config.PagamentosConfig{
+		CNPJ:    "12.345.678/0001-95",
+		Empresa: "Comércio de Peças Ltda",
+		Contas:  map[string]config.ContaPagamento{"341": {Agencia: "0057", Conta: "12345", DVConta: "7"}},
+	}
+	service := NewPagamentoService(cfg, db, logrus.New())
+	service.agora = func() time.Time { return time.Date(2023, 11, 20, 14, 30, 0, 0, time.UTC) }
+
+	nfe := models.NFe{ChaveAcesso: "35231112345678000195550010000001231000001230", EmitenteCNPJ: "98.765.432/0001-98", EmitenteNome: "Indústria São João S.A."}
+	assert.NoError(t, db.Create(&nfe).Error)
+	boletos := []models.Boleto{
+		{NFeID: nfe.ID, Banco: "341", CodigoBarras: "34195955000000250751091234567800057123457000", Valor: 250.75, Vencimento: time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC), Status: models.StatusBoletoAberto},
+		{NFeID: nfe.ID, Banco: "237", CodigoBarras: "23796907800000099903381090000001234500123450", Valor: 99.90, Vencimento: time.Date(2022, 8, 15, 0, 0, 0, 0, time.UTC), Status: models.StatusBoletoAberto},
+		{Banco: "237", CodigoBarras: "23796907800000099903381090000001234500123450", Valor: 99.90, Status: models.StatusBoletoPago},
+		{Banco: "341", CodigoBarras: "34195955000000250751091234567800057123457000", Valor: 250.75, Emitido: true, Status: models.StatusBoletoAberto},
+	}
+	assert.NoError(t, db.Create(&boletos).Error)
+
+	_, _, err := service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "237", BoletoIDs: []uint{boletos[0].ID}})
+	assert.ErrorIs(t, err, ErrBancoPagadorNaoConfigurado)
+
+	_, _, err = service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "341", BoletoIDs: []uint{boletos[0].ID, boletos[2].ID, boletos[3].ID, 99}})
+	var erroBoletos *ErroBoletosRemessa
+	if assert.ErrorAs(t, err, &erroBoletos) {
+		assert.Equal(t, map[uint]string{
+			boletos[2].ID: "boleto pago",
+			boletos[3].ID: "boleto emitido pela empresa é a receber",
+			99:            "boleto não encontrado",
+		}, erroBoletos.Motivos)
+	}
+
+	remessa, arquivo, err := service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "341", BoletoIDs: []uint{boletos[0].ID, boletos[1].ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, remessa.Sequencial)
+	assert.Equal(t, 2, remessa.Quantidade)
+	assert.InDelta(t, 350.65, remessa.ValorTotal, 0.001)
+
+	linhas := strings.Split(strings.TrimSuffix(string(arquivo), "\r\n"), "\r\n")
+	if !assert.Len(t, linhas, 10) {
+		return
+	}
+	// Vencido, o boleto do Bradesco é pago na data da geração; o do Itaú, no vencimento
+	assert.Equal(t, "30112023", linhas[2][144:152])
+	assert.Equal(t, "20112023", linhas[6][144:152])
+	assert.Equal(t, "INDUSTRIA SAO JOAO S.A.", strings.TrimSpace(linhas[2][61:91]))
+	assert.Equal(t, "98765432000198", linhas[3][77:91])
+
+	// Boletos aguardando retorno não entram em outra remessa
+	_, _, err = service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "341", BoletoIDs: []uint{boletos[0].ID}})
+	assert.ErrorIs(t, err, ErrBoletosInvalidosRemessa)
+
+	// Retorno: o título do Itaú foi pago e o do Bradesco, rejeitado
+	linhas[0] = linhas[0][:142] + "2" + linhas[0][143:]
+	linhas[2] = linhas[2][:230] + "00        "
+	linhas[6] = linhas[6][:230] + "AM        "
+	relatorio, err := service.ImportarRetorno(strings.NewReader(strings.Join(linhas, "\r\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relatorio.Registros)
+	assert.Equal(t, 1, relatorio.Atualizados)
+	assert.Empty(t, relatorio.NaoConciliados)
+
+	var pago, rejeitado models.Boleto
+	assert.NoError(t, db.First(&pago, boletos[0].ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, pago.Status)
+	assert.Nil(t, pago.RemessaPagamentoID)
+	if assert.NotNil(t, pago.ValorPago) && assert.NotNil(t, pago.DataPagamento) {
+		assert.Equal(t, 250.75, *pago.ValorPago)
+		assert.Equal(t, "2023-11-30", pago.DataPagamento.Format(time.DateOnly))
+	}
+	assert.NoError(t, db.First(&rejeitado, boletos[1].ID).Error)
+	assert.Equal(t, models.StatusBoletoAberto, rejeitado.Status)
+	assert.Nil(t, rejeitado.RemessaPagamentoID)
+
+	// O boleto rejeitado volta na remessa seguinte
+	remessa, _, err = service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "341", BoletoIDs: []uint{boletos[1].ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, remessa.Sequencial)
+}
+
+func TestRemessaPagamentoDataLocal(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	cfg.Pagamentos = config.PagamentosConfig{
+		CNPJ:    "12.345.678/0001-95",
+		Empresa: "Comércio de Peças Ltda",
+		Contas:  map[string]config.ContaPagamento{"341": {Agencia: "0057", Conta: "12345", DVConta: "7"}},
+	}
+	service := NewPagamentoService(cfg, db, logrus.New())
+	// 01:00 em Brasília ainda é o dia 21 em UTC, mas o truncamento do instante caía no dia 20
+	service.agora = func() time.Time { return time.Date(2023, 11, 21, 1, 0, 0, 0, time.FixedZone("BRT", -3*3600)) }
+
+	boleto := models.Boleto{Banco: "237", CodigoBarras: "23796907800000099903381090000001234500123450", Valor: 99.90,
+		Vencimento: time.Date(2022, 8, 15, 0, 0, 0, 0, time.UTC), Status: models.StatusBoletoAberto}
+	assert.NoError(t, db.Create(&boleto).Error)
+
+	_, arquivo, err := service.GerarRemessa(models.GerarRemessaPagamentoRequest{Banco: "341", BoletoIDs: []uint{boleto.ID}})
+	assert.NoError(t, err)
+	linhas := strings.Split(strings.TrimSuffix(string(arquivo), "\r\n"), "\r\n")
+	if assert.Len(t, linhas, 6) {
+		assert.Equal(t, "21112023", linhas[2][144:152])
+	}
+}
@@ -5,178 +5,126 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
 	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/sirupsen/logrus"
 )
 
-// PDFService representa o serviço de geração de PDFs
+// PDFService representa o serviço de geração de documentos (DANFE e relatórios)
 type PDFService struct {
-	logger *logrus.Logger
+	templates *DANFETemplateStore
+	renderers map[string]DANFERenderer
+	cache     *documentoCache
+	// pagador identifica a empresa nos boletos sem NF-e de destinatário
+	pagador config.PagamentosConfig
+	logger  *logrus.Logger
 }
 
-// NewPDFService cria uma nova instância do serviço de PDF
-func NewPDFService(logger *logrus.Logger) *PDFService {
-	return &PDFService{
-		logger: logger,
+// NewPDFService cria uma nova instância do serviço de PDF com os renderizadores padrão
+func NewPDFService(cfg *config.Config, logger *logrus.Logger) *PDFService {
+	s := &PDFService{
+		templates: NewDANFETemplateStore(cfg.DANFE.TemplatesDir, cfg.DANFE.TemplatePadrao, logger),
+		renderers: make(map[string]DANFERenderer),
+		cache:     newDocumentoCache(cfg.Cache.TTL),
+		pagador:   cfg.Pagamentos,
+		logger:    logger,
 	}
+	s.RegistrarRenderer(NewDANFEPDFRenderer(logger))
+	s.RegistrarRenderer(NewDANFEHTMLRenderer(logger))
+	s.RegistrarRenderer(NewDANFERasterRenderer(FormatoPNG, logger))
+	s.RegistrarRenderer(NewDANFERasterRenderer(FormatoJPEG, logger))
+	return s
 }
 
-// GerarDANFE gera o DANFE (Documento Auxiliar da Nota Fiscal Eletrônica) em PDF
-func (s *PDFService) GerarDANFE(nfe *models.NFe) ([]byte, error) {
-	s.logger.WithField("chave_acesso", nfe.ChaveAcesso).Info("Gerando DANFE")
-
-	// Cria novo documento PDF
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-
-	// Configura fonte
-	pdf.SetFont("Arial", "B", 12)
-
-	// Cabeçalho
-	s.adicionarCabecalho(pdf, nfe)
-
-	// Dados do emitente
-	s.adicionarEmitente(pdf, nfe)
-
-	// Dados do destinatário
-	s.adicionarDestinatario(pdf, nfe)
-
-	// Dados da NFe
-	s.adicionarDadosNFe(pdf, nfe)
-
-	// Valores
-	s.adicionarValores(pdf, nfe)
-
-	// Duplicatas
-	s.adicionarDuplicatas(pdf, nfe)
-
-	// Código de barras
-	s.adicionarCodigoBarras(pdf, nfe)
+// RegistrarRenderer registra um backend de renderização, substituindo o do mesmo formato
+func (s *PDFService) RegistrarRenderer(renderer DANFERenderer) {
+	s.renderers[renderer.Formato()] = renderer
+}
 
-	// Gera o PDF
-	var buf bytes.Buffer
-	err := pdf.Output(&buf)
-	if err != nil {
-		return nil, err
+// Renderer retorna o backend registrado para o formato informado
+func (s *PDFService) Renderer(formato string) (DANFERenderer, error) {
+	renderer, ok := s.renderers[formato]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFormatoNaoSuportado, formato)
 	}
-	return buf.Bytes(), nil
+	return renderer, nil
 }
 
-// adicionarCabecalho adiciona o cabeçalho do DANFE
-func (s *PDFService) adicionarCabecalho(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(0, 10, "DANFE - Documento Auxiliar da Nota Fiscal Eletrônica")
-	pdf.Ln(15)
-
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "Chave de Acesso: "+nfe.ChaveAcesso)
-	pdf.Ln(10)
+// GerarDANFE gera o DANFE (Documento Auxiliar da Nota Fiscal Eletrônica) em PDF.
+// O template é carregado a cada renderização; se nomeTemplate for vazio, usa o
+// template associado ao CNPJ do emitente ou o padrão configurado.
+func (s *PDFService) GerarDANFE(nfe *models.NFe, nomeTemplate string) ([]byte, error) {
+	return s.RenderizarDANFE(FormatoPDF, nfe, nomeTemplate)
 }
 
-// adicionarEmitente adiciona os dados do emitente
-func (s *PDFService) adicionarEmitente(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "EMITENTE")
-	pdf.Ln(8)
+// RenderizarDANFE gera o DANFE no formato informado usando o backend registrado.
+// Documentos gerados ficam em cache pelo TTL configurado.
+func (s *PDFService) RenderizarDANFE(formato string, nfe *models.NFe, nomeTemplate string) ([]byte, error) {
+	s.logger.WithFields(logrus.Fields{
+		"chave_acesso": nfe.ChaveAcesso,
+		"formato":      formato,
+		"template":     nomeTemplate,
+	}).Info("Gerando DANFE")
 
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, "CNPJ: "+nfe.EmitenteCNPJ)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Nome: "+nfe.EmitenteNome)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "IE: "+nfe.EmitenteIE)
-	pdf.Ln(10)
-}
+	renderer, err := s.Renderer(formato)
+	if err != nil {
+		return nil, err
+	}
 
-// adicionarDestinatario adiciona os dados do destinatário
-func (s *PDFService) adicionarDestinatario(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "DESTINATÁRIO")
-	pdf.Ln(8)
+	tpl, err := s.templates.Resolver(nomeTemplate, nfe.EmitenteCNPJ)
+	if err != nil {
+		return nil, err
+	}
 
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, "CNPJ: "+nfe.DestinatarioCNPJ)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Nome: "+nfe.DestinatarioNome)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "IE: "+nfe.DestinatarioIE)
-	pdf.Ln(10)
+	return s.renderizarComCache(chaveCacheDANFE(formato, nfe.ChaveAcesso, nfe.UpdatedAt, tpl), func() ([]byte, error) {
+		return renderer.Renderizar(nfe, tpl)
+	})
 }
 
-// adicionarDadosNFe adiciona os dados da NFe
-func (s *PDFService) adicionarDadosNFe(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "DADOS DA NFE")
-	pdf.Ln(8)
-
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, "Número: "+nfe.Numero)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Série: "+nfe.Serie)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Data de Emissão: "+nfe.DataEmissao.Format("02/01/2006 15:04:05"))
-	pdf.Ln(6)
-	if nfe.DataAutorizacao != nil {
-		pdf.Cell(0, 6, "Data de Autorização: "+nfe.DataAutorizacao.Format("02/01/2006 15:04:05"))
-		pdf.Ln(6)
+// GerarPreviewDANFE gera a imagem de uma página do DANFE, com o mesmo layout e cache do PDF
+func (s *PDFService) GerarPreviewDANFE(formato string, nfe *models.NFe, nomeTemplate string, pagina int, dpi float64) ([]byte, error) {
+	s.logger.WithFields(logrus.Fields{
+		"chave_acesso": nfe.ChaveAcesso,
+		"formato":      formato,
+		"template":     nomeTemplate,
+		"pagina":       pagina,
+		"dpi":          dpi,
+	}).Info("Gerando prévia do DANFE")
+
+	renderer, err := s.Renderer(formato)
+	if err != nil {
+		return nil, err
+	}
+	paginado, ok := renderer.(DANFEPaginaRenderer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s não gera prévias", ErrFormatoNaoSuportado, formato)
 	}
-	pdf.Cell(0, 6, "Status: "+nfe.Status)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Ambiente: "+nfe.Ambiente)
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "UF: "+nfe.UF)
-	pdf.Ln(10)
-}
 
-// adicionarValores adiciona os valores da NFe
-func (s *PDFService) adicionarValores(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "VALORES")
-	pdf.Ln(8)
+	tpl, err := s.templates.Resolver(nomeTemplate, nfe.EmitenteCNPJ)
+	if err != nil {
+		return nil, err
+	}
 
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, "Valor Total: R$ "+formatarValor(nfe.ValorTotal))
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Valor dos Produtos: R$ "+formatarValor(nfe.ValorProdutos))
-	pdf.Ln(6)
-	pdf.Cell(0, 6, "Valor dos Impostos: R$ "+formatarValor(nfe.ValorImpostos))
-	pdf.Ln(10)
+	return s.renderizarComCache(chaveCacheDANFE(formato, nfe.ChaveAcesso, nfe.UpdatedAt, tpl, pagina, dpi), func() ([]byte, error) {
+		return paginado.RenderizarPagina(nfe, tpl, pagina, dpi)
+	})
 }
 
-// adicionarDuplicatas adiciona as duplicatas da NFe
-func (s *PDFService) adicionarDuplicatas(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	if len(nfe.Duplicatas) == 0 {
-		return
+// renderizarComCache retorna o documento em cache ou o gera e guarda
+func (s *PDFService) renderizarComCache(chave string, gerar func() ([]byte, error)) ([]byte, error) {
+	if documento, ok := s.cache.Obter(chave); ok {
+		s.logger.Debug("DANFE obtido do cache")
+		return documento, nil
 	}
 
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "DUPLICATAS")
-	pdf.Ln(8)
-
-	pdf.SetFont("Arial", "", 10)
-	for _, dup := range nfe.Duplicatas {
-		pdf.Cell(0, 6, fmt.Sprintf("Número: %s, Vencimento: %s, Valor: R$ %s",
-			dup.Numero,
-			dup.Vencimento.Format("02/01/2006"),
-			formatarValor(dup.Valor)))
-		pdf.Ln(6)
+	documento, err := gerar()
+	if err != nil {
+		return nil, err
 	}
-	pdf.Ln(10)
-}
-
-// adicionarCodigoBarras adiciona o código de barras da NFe
-func (s *PDFService) adicionarCodigoBarras(pdf *gofpdf.Fpdf, nfe *models.NFe) {
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(0, 8, "CÓDIGO DE BARRAS")
-	pdf.Ln(8)
-
-	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(0, 6, nfe.ChaveAcesso)
-	pdf.Ln(10)
-
-	// Aqui você poderia adicionar a geração de código de barras real
-	// usando uma biblioteca como github.com/boombuler/barcode
+	s.cache.Salvar(chave, documento)
+	return documento, nil
 }
 
 // formatarValor formata um valor float64 para string
@@ -219,6 +167,13 @@ func (s *PDFService) GerarRelatorioBoletos(boletos []models.Boleto) ([]byte, err
 		pdf.Cell(30, 6, "R$ "+formatarValor(boleto.Valor))
 		pdf.Cell(30, 6, boleto.Status)
 		pdf.Ln(6)
+
+		// Boletos híbridos: QR Code e Pix copia-e-cola abaixo da linha do boleto
+		if boleto.PixCopiaECola != "" {
+			if err := desenharPixPDF(pdf, boleto.PixCopiaECola); err != nil {
+				s.logger.WithError(err).WithField("numero", boleto.Numero).Warn("Erro ao desenhar QR Code Pix do boleto")
+			}
+		}
 	}
 
 	// Gera o PDF
@@ -229,3 +184,61 @@ func (s *PDFService) GerarRelatorioBoletos(boletos []models.Boleto) ([]byte, err
 	}
 	return buf.Bytes(), nil
 }
+
+// Dimensões do Pix no relatório de boletos, em milímetros
+const (
+	ladoQRCodePix   = 30.0
+	margemQRCodePix = 4.0
+)
+
+// desenharPixPDF desenha o QR Code do Pix e, ao lado, o texto copia-e-cola
+func desenharPixPDF(pdf *gofpdf.Fpdf, pix string) error {
+	qr, err := utils.GerarQRCode(pix)
+	if err != nil {
+		return err
+	}
+
+	_, altura := pdf.GetPageSize()
+	_, _, _, margemInferior := pdf.GetMargins()
+	if pdf.GetY()+ladoQRCodePix+margemQRCodePix > altura-margemInferior {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()+margemQRCodePix/2
+	desenharQRCodePDF(pdf, qr, x, y, ladoQRCodePix)
+
+	pdf.SetXY(x+ladoQRCodePix+margemQRCodePix, y)
+	pdf.SetFont("Arial", "B", 8)
+	pdf.Cell(0, 4, "Pix copia e cola")
+	pdf.Ln(4)
+	pdf.SetX(x + ladoQRCodePix + margemQRCodePix)
+	pdf.SetFont("Courier", "", 7)
+	pdf.MultiCell(0, 3, pix, "", "L", false)
+	pdf.SetFont("Arial", "", 10)
+	pdf.SetXY(x, y+ladoQRCodePix+margemQRCodePix/2)
+	return nil
+}
+
+// desenharQRCodePDF desenha o QR Code como retângulos vetoriais, com a zona de
+// silêncio de quatro módulos incluída no lado informado
+func desenharQRCodePDF(pdf *gofpdf.Fpdf, qr *utils.QRCode, x, y, lado float64) {
+	modulo := lado / float64(qr.Tamanho+8)
+	x += 4 * modulo
+	y += 4 * modulo
+
+	pdf.SetFillColor(0, 0, 0)
+	for linha := 0; linha < qr.Tamanho; linha++ {
+		// Módulos escuros consecutivos viram um único retângulo
+		for coluna := 0; coluna < qr.Tamanho; {
+			if !qr.Modulo(coluna, linha) {
+				coluna++
+				continue
+			}
+			inicio := coluna
+			for coluna < qr.Tamanho && qr.Modulo(coluna, linha) {
+				coluna++
+			}
+			pdf.Rect(x+float64(inicio)*modulo, y+float64(linha)*modulo, float64(coluna-inicio)*modulo, modulo, "F")
+		}
+	}
+}
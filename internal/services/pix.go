@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Erros da consulta das cobranças Pix
+var (
+	// ErrPixNaoConfigurado indica que a integração com a API Pix não está configurada
+	ErrPixNaoConfigurado = errors.New("integração com a API Pix não configurada")
+	// ErrBoletoSemTxID indica um boleto sem o txid da cobrança Pix
+	ErrBoletoSemTxID = errors.New("boleto sem txid da cobrança Pix")
+)
+
+// novoClientePix cria o cliente da API Pix. Sem PIX_API_URL, ou com credenciais
+// inválidas, as consultas retornam ErrPixNaoConfigurado
+func novoClientePix(cfg *config.Config, logger *logrus.Logger) *pix.Cliente {
+	if cfg.Bank.Pix.URL == "" {
+		return nil
+	}
+	cliente, err := pix.NovoCliente(cfg.Bank.Pix, logger)
+	if err != nil {
+		logger.WithError(err).Error("Erro ao configurar cliente da API Pix")
+		return nil
+	}
+	return cliente
+}
+
+// SincronizarPixBoleto consulta no PSP a cobrança Pix do boleto e, quando concluída,
+// registra o pagamento
+func (s *BankService) SincronizarPixBoleto(ctx context.Context, codigo string) (*models.Boleto, *pix.Cobranca, error) {
+	if s.pix == nil {
+		return nil, nil, ErrPixNaoConfigurado
+	}
+	boleto, err := s.ConsultarBoleto(codigo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if boleto.PixTxID == "" {
+		return nil, nil, ErrBoletoSemTxID
+	}
+
+	cobranca, err := s.pix.ConsultarCobranca(ctx, boleto.PixTxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.aplicarCobrancaPix(boleto, cobranca); err != nil {
+		return nil, nil, err
+	}
+	return boleto, cobranca, nil
+}
+
+// ConsultarCobrancaPix consulta a cobrança pelo txid e registra o pagamento no boleto
+// vinculado a ela, retornado quando houver
+func (s *BankService) ConsultarCobrancaPix(ctx context.Context, txid string) (*pix.Cobranca, *models.Boleto, error) {
+	if s.pix == nil {
+		return nil, nil, ErrPixNaoConfigurado
+	}
+	cobranca, err := s.pix.ConsultarCobranca(ctx, txid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var boleto models.Boleto
+	if err := s.db.Where("pix_tx_id = ?", txid).First(&boleto).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return cobranca, nil, nil
+		}
+		return nil, nil, fmt.Errorf("erro ao consultar boleto no banco: %w", err)
+	}
+	if err := s.aplicarCobrancaPix(&boleto, cobranca); err != nil {
+		return nil, nil, err
+	}
+	return cobranca, &boleto, nil
+}
+
+// aplicarCobrancaPix marca o boleto como pago com a soma dos Pix da cobrança
+// concluída e o horário do último. As demais situações não alteram o boleto: a
+// cobrança removida não baixa o título, que continua pagável pelo código de barras
+func (s *BankService) aplicarCobrancaPix(boleto *models.Boleto, cobranca *pix.Cobranca) error {
+	if cobranca.Status != pix.StatusConcluida {
+		return nil
+	}
+	valor, horario, err := cobranca.Pagamento()
+	if err != nil {
+		return err
+	}
+
+	evento := bancos.NotificacaoPagamento{Status: models.StatusBoletoPago, ValorPago: valor, DataPagamento: horario}
+	if !aplicarNotificacao(boleto, evento) {
+		return nil
+	}
+	s.logger.WithFields(logrus.Fields{
+		"codigo_barras": boleto.CodigoBarras,
+		"txid":          cobranca.TxID,
+		"valor_pago":    valor,
+	}).Info("Pagamento Pix registrado no boleto")
+	if err := s.db.Save(boleto).Error; err != nil {
+		return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos/pix/pixtest"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+)
+
+const txidBoleto = "HELPDANFE000000000000012345"
+
+func TestSincronizarPixBoleto(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB()
+
+	_, _, err := NewBankService(setupTestConfig(), db, logrus.New()).SincronizarPixBoleto(ctx, "00012345")
+	assert.ErrorIs(t, err, ErrPixNaoConfigurado)
+
+	servidor := pixtest.NovoServidor(t)
+	servidor.CriarCobranca(pix.TipoCobV, txidBoleto, 250.75)
+	cfg := setupTestConfig()
+	cfg.Bank.Pix = servidor.Config()
+	service := NewBankService(cfg, db, logrus.New())
+
+	boleto := models.Boleto{Banco: "341", Numero: "00012345", NossoNumero: "00012345", Valor: 250.75,
+		Status: models.StatusBoletoAberto, PixTxID: txidBoleto}
+	semTxID := models.Boleto{Banco: "341", Numero: "00012346", NossoNumero: "00012346", Valor: 99.90, Status: models.StatusBoletoAberto}
+	assert.NoError(t, db.Create(&boleto).Error)
+	assert.NoError(t, db.Create(&semTxID).Error)
+
+	// A cobrança ativa não altera o boleto
+	atualizado, cobranca, err := service.SincronizarPixBoleto(ctx, "00012345")
+	assert.NoError(t, err)
+	assert.Equal(t, pix.StatusAtiva, cobranca.Status)
+	assert.Equal(t, models.StatusBoletoAberto, atualizado.Status)
+
+	horario := time.Date(2023, 12, 4, 14, 30, 0, 0, time.UTC)
+	servidor.Pagar(txidBoleto, 250.75, horario)
+	atualizado, cobranca, err = service.SincronizarPixBoleto(ctx, "00012345")
+	assert.NoError(t, err)
+	assert.Equal(t, pix.StatusConcluida, cobranca.Status)
+	assert.Equal(t, models.StatusBoletoPago, atualizado.Status)
+	assert.Equal(t, 250.75, *atualizado.ValorPago)
+	assert.True(t, horario.Equal(*atualizado.DataPagamento))
+
+	assert.NoError(t, db.First(&boleto, boleto.ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, boleto.Status)
+
+	// A consulta pelo txid retorna o boleto vinculado, já conciliado
+	cobranca, vinculado, err := service.ConsultarCobrancaPix(ctx, txidBoleto)
+	assert.NoError(t, err)
+	assert.Equal(t, boleto.ID, vinculado.ID)
+	assert.Equal(t, models.StatusBoletoPago, vinculado.Status)
+	assert.Len(t, cobranca.Pix, 1)
+
+	servidor.CriarCobranca(pix.TipoCob, "HELPDANFE000000000000099999", 10)
+	_, vinculado, err = service.ConsultarCobrancaPix(ctx, "HELPDANFE000000000000099999")
+	assert.NoError(t, err)
+	assert.Nil(t, vinculado)
+
+	_, _, err = service.SincronizarPixBoleto(ctx, "00012346")
+	assert.ErrorIs(t, err, ErrBoletoSemTxID)
+	_, _, err = service.ConsultarCobrancaPix(ctx, "HELPDANFE000000000000088888")
+	assert.ErrorIs(t, err, pix.ErrCobrancaNaoEncontrada)
+}
+
+func TestAssociarPixTxID(t *testing.T) {
+	service := NewBankService(setupTestConfig(), setupTestDB(), logrus.New())
+	codigo := "82600000001-6 23450058202-3 41030000000-8 00012345678-2"
+	copiaECola, err := (&utils.BRCode{URL: "pix.exemplo.com.br/cobv/9f3c5a0e", NomeRecebedor: "SANEAMENTO", CidadeRecebedor: "CAMPINAS"}).Payload()
+	assert.NoError(t, err)
+
+	_, err = service.AssociarPix(codigo, copiaECola, "curto")
+	assert.ErrorIs(t, err, pix.ErrTxIDInvalido)
+
+	boleto, err := service.AssociarPix(codigo, copiaECola, txidBoleto)
+	assert.NoError(t, err)
+	assert.Equal(t, txidBoleto, boleto.PixTxID)
+
+	// Um novo Pix copia-e-cola sem txid mantém o da cobrança já gravado
+	boleto, err = service.AssociarPix(codigo, copiaECola, "")
+	assert.NoError(t, err)
+	assert.Equal(t, txidBoleto, boleto.PixTxID)
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// Formatos de saída do DANFE suportados pelos renderizadores padrão
+const (
+	FormatoPDF  = "pdf"
+	FormatoHTML = "html"
+)
+
+// ErrFormatoNaoSuportado indica que não há renderizador registrado para o formato
+var ErrFormatoNaoSuportado = errors.New("formato de documento não suportado")
+
+// DANFERenderer representa um backend de renderização do DANFE.
+// Novos formatos de saída são adicionados implementando esta interface e
+// registrando o backend com PDFService.RegistrarRenderer.
+type DANFERenderer interface {
+	// Formato retorna o identificador do formato (ex.: "pdf", "html")
+	Formato() string
+	// ContentType retorna o tipo MIME do documento gerado
+	ContentType() string
+	// Inline informa se o documento deve ser exibido no navegador em vez de baixado
+	Inline() bool
+	// Renderizar gera o documento da NFe aplicando o template
+	Renderizar(nfe *models.NFe, tpl *DANFETemplate) ([]byte, error)
+}
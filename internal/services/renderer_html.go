@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed html/danfe.html
+var danfeHTMLTemplate string
+
+// danfeHTML é o template HTML do DANFE, compilado uma única vez
+var danfeHTML = template.Must(template.New("danfe").Funcs(template.FuncMap{
+	"valor": formatarValor,
+}).Parse(danfeHTMLTemplate))
+
+// danfeHTMLRenderer renderiza o DANFE em HTML para exibição no navegador
+type danfeHTMLRenderer struct {
+	logger *logrus.Logger
+}
+
+// danfeHTMLDados representa os dados disponíveis no template HTML do DANFE
+type danfeHTMLDados struct {
+	NFe           *models.NFe
+	Template      *DANFETemplate
+	TamanhoPagina string
+	Logo          template.URL
+}
+
+// Rotulo retorna o texto de um rótulo do template
+func (d danfeHTMLDados) Rotulo(chave string) string {
+	return d.Template.Rotulo(chave)
+}
+
+// Mostrar informa se um bloco opcional deve ser exibido
+func (d danfeHTMLDados) Mostrar(bloco string) bool {
+	return d.Template.MostrarBloco(bloco)
+}
+
+// NewDANFEHTMLRenderer cria o backend de renderização do DANFE em HTML
+func NewDANFEHTMLRenderer(logger *logrus.Logger) DANFERenderer {
+	return &danfeHTMLRenderer{logger: logger}
+}
+
+// Formato retorna o identificador do formato HTML
+func (r *danfeHTMLRenderer) Formato() string {
+	return FormatoHTML
+}
+
+// ContentType retorna o tipo MIME do HTML
+func (r *danfeHTMLRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+// Inline informa que o HTML é exibido diretamente no navegador
+func (r *danfeHTMLRenderer) Inline() bool {
+	return true
+}
+
+// Renderizar gera o DANFE em HTML com o layout do template
+func (r *danfeHTMLRenderer) Renderizar(nfe *models.NFe, tpl *DANFETemplate) ([]byte, error) {
+	dados := danfeHTMLDados{
+		NFe:           nfe,
+		Template:      tpl,
+		TamanhoPagina: tamanhoPaginaCSS(tpl),
+		Logo:          r.logoDataURI(tpl),
+	}
+
+	var buf bytes.Buffer
+	if err := danfeHTML.Execute(&buf, dados); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// logoDataURI embute o logo do template no HTML como data URI
+func (r *danfeHTMLRenderer) logoDataURI(tpl *DANFETemplate) template.URL {
+	caminho := tpl.CaminhoLogo()
+	if caminho == "" {
+		return ""
+	}
+
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		r.logger.WithError(err).WithField("logo", caminho).Warn("Erro ao carregar logo do template")
+		return ""
+	}
+
+	tipo := http.DetectContentType(conteudo)
+	return template.URL("data:" + tipo + ";base64," + base64.StdEncoding.EncodeToString(conteudo))
+}
+
+// tamanhoPaginaCSS converte o tamanho e a orientação do template para a regra CSS @page
+func tamanhoPaginaCSS(tpl *DANFETemplate) string {
+	orientacao := "portrait"
+	if strings.EqualFold(tpl.Orientacao, "L") {
+		orientacao = "landscape"
+	}
+	return tpl.TamanhoPagina + " " + orientacao
+}
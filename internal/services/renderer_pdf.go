@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sirupsen/logrus"
+)
+
+// danfePDFRenderer renderiza o DANFE em PDF usando gofpdf
+type danfePDFRenderer struct {
+	logger *logrus.Logger
+}
+
+// NewDANFEPDFRenderer cria o backend de renderização do DANFE em PDF
+func NewDANFEPDFRenderer(logger *logrus.Logger) DANFERenderer {
+	return &danfePDFRenderer{logger: logger}
+}
+
+// Formato retorna o identificador do formato PDF
+func (r *danfePDFRenderer) Formato() string {
+	return FormatoPDF
+}
+
+// ContentType retorna o tipo MIME do PDF
+func (r *danfePDFRenderer) ContentType() string {
+	return "application/pdf"
+}
+
+// Inline informa que o PDF é servido como anexo para download
+func (r *danfePDFRenderer) Inline() bool {
+	return false
+}
+
+// Renderizar gera o DANFE em PDF com o layout do template
+func (r *danfePDFRenderer) Renderizar(nfe *models.NFe, tpl *DANFETemplate) ([]byte, error) {
+	// Cria novo documento PDF
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: tpl.Orientacao,
+		UnitStr:        "mm",
+		SizeStr:        tpl.TamanhoPagina,
+	})
+	pdf.SetMargins(tpl.Margens.Esquerda, tpl.Margens.Superior, tpl.Margens.Direita)
+	pdf.SetAutoPageBreak(true, tpl.Margens.Inferior)
+	pdf.AddPage()
+
+	desenharDANFE(&superficiePDF{pdf: pdf, logger: r.logger}, nfe, tpl)
+
+	// Gera o PDF
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// superficiePDF adapta um documento gofpdf à superfície de desenho do DANFE
+type superficiePDF struct {
+	pdf    *gofpdf.Fpdf
+	logger *logrus.Logger
+}
+
+// Logo desenha o logo no canto superior esquerdo, ignorando imagens inválidas
+func (s *superficiePDF) Logo(caminho string, altura float64) float64 {
+	info := s.pdf.RegisterImageOptions(caminho, gofpdf.ImageOptions{ReadDpi: true})
+	if s.pdf.Err() {
+		s.logger.WithError(s.pdf.Error()).WithField("logo", caminho).Warn("Erro ao carregar logo do template")
+		s.pdf.ClearError()
+		return 0
+	}
+
+	esquerda, topo, _, _ := s.pdf.GetMargins()
+	largura := info.Width() * altura / info.Height()
+	s.pdf.ImageOptions(caminho, esquerda, topo, largura, altura, false, gofpdf.ImageOptions{}, 0, "")
+	return largura
+}
+
+// AvancarX desloca a posição horizontal atual
+func (s *superficiePDF) AvancarX(mm float64) {
+	s.pdf.SetX(s.pdf.GetX() + mm)
+}
+
+// Fonte define a fonte atual
+func (s *superficiePDF) Fonte(familia, estilo string, tamanho float64) {
+	s.pdf.SetFont(familia, estilo, tamanho)
+}
+
+// CorTexto define a cor do texto
+func (s *superficiePDF) CorTexto(hex string) {
+	s.pdf.SetTextColor(corRGB(hex))
+}
+
+// Texto escreve uma célula de texto
+func (s *superficiePDF) Texto(altura float64, texto string) {
+	s.pdf.Cell(0, altura, texto)
+}
+
+// Ln quebra a linha
+func (s *superficiePDF) Ln(altura float64) {
+	s.pdf.Ln(altura)
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/sirupsen/logrus"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Formatos de imagem suportados pelo backend raster
+const (
+	FormatoPNG  = "png"
+	FormatoJPEG = "jpeg"
+)
+
+// Limites de resolução aceitos para a prévia do DANFE
+const (
+	DPIPadrao = 96.0
+	DPIMinimo = 24.0
+	DPIMaximo = 300.0
+)
+
+// ErrPaginaInexistente indica que a página solicitada não existe no documento
+var ErrPaginaInexistente = errors.New("página inexistente")
+
+// ErrDPIInvalido indica uma resolução fora dos limites aceitos
+var ErrDPIInvalido = fmt.Errorf("dpi deve estar entre %.0f e %.0f", DPIMinimo, DPIMaximo)
+
+// tamanhosPagina contém as dimensões (largura x altura, em mm, retrato) aceitas pelo gofpdf
+var tamanhosPagina = map[string][2]float64{
+	"a3":      {297, 420},
+	"a4":      {210, 297},
+	"a5":      {148, 210},
+	"a6":      {105, 148},
+	"a2":      {420, 594},
+	"a1":      {594, 841},
+	"letter":  {215.9, 279.4},
+	"legal":   {215.9, 355.6},
+	"tabloid": {279.4, 431.8},
+}
+
+// DANFEPaginaRenderer é implementado por backends que renderizam páginas individuais
+type DANFEPaginaRenderer interface {
+	DANFERenderer
+	// RenderizarPagina gera a página informada (a partir de 1) na resolução informada
+	RenderizarPagina(nfe *models.NFe, tpl *DANFETemplate, pagina int, dpi float64) ([]byte, error)
+}
+
+// danfeRasterRenderer desenha o DANFE em imagem (PNG ou JPEG) sem dependências nativas
+type danfeRasterRenderer struct {
+	formato string
+	logger  *logrus.Logger
+}
+
+// NewDANFERasterRenderer cria o backend de prévia do DANFE em imagem no formato informado
+func NewDANFERasterRenderer(formato string, logger *logrus.Logger) DANFEPaginaRenderer {
+	return &danfeRasterRenderer{formato: formato, logger: logger}
+}
+
+// Formato retorna o identificador do formato de imagem
+func (r *danfeRasterRenderer) Formato() string {
+	return r.formato
+}
+
+// ContentType retorna o tipo MIME da imagem
+func (r *danfeRasterRenderer) ContentType() string {
+	return "image/" + r.formato
+}
+
+// Inline informa que a imagem é exibida diretamente no navegador
+func (r *danfeRasterRenderer) Inline() bool {
+	return true
+}
+
+// Renderizar gera a primeira página do DANFE na resolução padrão
+func (r *danfeRasterRenderer) Renderizar(nfe *models.NFe, tpl *DANFETemplate) ([]byte, error) {
+	return r.RenderizarPagina(nfe, tpl, 1, DPIPadrao)
+}
+
+// RenderizarPagina desenha o DANFE com o mesmo layout do PDF e codifica a página solicitada
+func (r *danfeRasterRenderer) RenderizarPagina(nfe *models.NFe, tpl *DANFETemplate, pagina int, dpi float64) ([]byte, error) {
+	// A comparação negada também recusa NaN, que não é menor nem maior que os limites
+	if !(dpi >= DPIMinimo && dpi <= DPIMaximo) {
+		return nil, ErrDPIInvalido
+	}
+
+	sup := novaSuperficieRaster(tpl, dpi, r.logger)
+	desenharDANFE(sup, nfe, tpl)
+
+	if pagina < 1 || pagina > len(sup.paginas) {
+		return nil, fmt.Errorf("%w: %d de %d", ErrPaginaInexistente, pagina, len(sup.paginas))
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if r.formato == FormatoJPEG {
+		err = jpeg.Encode(&buf, sup.paginas[pagina-1], &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&buf, sup.paginas[pagina-1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao codificar imagem: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// superficieRaster desenha o DANFE em imagens, reproduzindo o fluxo e a
+// quebra de página automática do gofpdf
+type superficieRaster struct {
+	logger  *logrus.Logger
+	dpi     float64
+	largura float64
+	altura  float64
+	margens MargensTemplate
+	paginas []*image.RGBA
+	x, y    float64
+	tamanho float64
+	negrito bool
+	cor     color.RGBA
+}
+
+// novaSuperficieRaster cria a superfície com a primeira página em branco
+func novaSuperficieRaster(tpl *DANFETemplate, dpi float64, logger *logrus.Logger) *superficieRaster {
+	dimensoes, ok := tamanhosPagina[strings.ToLower(tpl.TamanhoPagina)]
+	if !ok {
+		dimensoes = tamanhosPagina["a4"]
+	}
+	largura, altura := dimensoes[0], dimensoes[1]
+	if strings.EqualFold(tpl.Orientacao, "L") {
+		largura, altura = altura, largura
+	}
+
+	s := &superficieRaster{
+		logger:  logger,
+		dpi:     dpi,
+		largura: largura,
+		altura:  altura,
+		margens: tpl.Margens,
+		cor:     color.RGBA{A: 0xff},
+	}
+	s.novaPagina()
+	return s
+}
+
+// px converte milímetros em pixels na resolução da superfície
+func (s *superficieRaster) px(mm float64) int {
+	return int(math.Round(mm * s.dpi / 25.4))
+}
+
+// pagina retorna a página em desenho
+func (s *superficieRaster) pagina() *image.RGBA {
+	return s.paginas[len(s.paginas)-1]
+}
+
+// novaPagina adiciona uma página em branco e posiciona o cursor nas margens
+func (s *superficieRaster) novaPagina() {
+	img := image.NewRGBA(image.Rect(0, 0, s.px(s.largura), s.px(s.altura)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	s.paginas = append(s.paginas, img)
+	s.x = s.margens.Esquerda
+	s.y = s.margens.Superior
+}
+
+// Logo desenha o logo no canto superior esquerdo, ignorando imagens inválidas
+func (s *superficieRaster) Logo(caminho string, altura float64) float64 {
+	arquivo, err := os.Open(caminho)
+	if err != nil {
+		s.logger.WithError(err).WithField("logo", caminho).Warn("Erro ao carregar logo do template")
+		return 0
+	}
+	defer arquivo.Close()
+
+	logo, _, err := image.Decode(arquivo)
+	if err != nil {
+		s.logger.WithError(err).WithField("logo", caminho).Warn("Erro ao decodificar logo do template")
+		return 0
+	}
+
+	limites := logo.Bounds()
+	largura := float64(limites.Dx()) * altura / float64(limites.Dy())
+	x, y := s.px(s.margens.Esquerda), s.px(s.margens.Superior)
+	destino := image.Rect(x, y, x+s.px(largura), y+s.px(altura))
+	xdraw.CatmullRom.Scale(s.pagina(), destino, logo, limites, draw.Over, nil)
+	return largura
+}
+
+// AvancarX desloca a posição horizontal atual
+func (s *superficieRaster) AvancarX(mm float64) {
+	s.x += mm
+}
+
+// Fonte define o estilo e o tamanho do texto; a família é ignorada pois a
+// prévia usa sempre a fonte bitmap embutida
+func (s *superficieRaster) Fonte(familia, estilo string, tamanho float64) {
+	s.negrito = strings.Contains(strings.ToUpper(estilo), "B")
+	s.tamanho = tamanho
+}
+
+// CorTexto define a cor do texto
+func (s *superficieRaster) CorTexto(hex string) {
+	r, g, b := corRGB(hex)
+	s.cor = color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+}
+
+// Texto escreve uma célula de texto, quebrando a página como o gofpdf faz
+func (s *superficieRaster) Texto(altura float64, texto string) {
+	if s.y+altura > s.altura-s.margens.Inferior {
+		x := s.x
+		s.novaPagina()
+		s.x = x
+	}
+
+	texto = textoASCII(texto)
+	if texto != "" {
+		s.desenharTexto(texto, altura)
+	}
+	s.x = s.largura - s.margens.Direita
+}
+
+// Ln quebra a linha
+func (s *superficieRaster) Ln(altura float64) {
+	s.x = s.margens.Esquerda
+	s.y += altura
+}
+
+// desenharTexto rasteriza o texto na fonte bitmap e o escala para o tamanho
+// da fonte, com a linha de base na mesma posição usada pelo gofpdf
+func (s *superficieRaster) desenharTexto(texto string, altura float64) {
+	face := basicfont.Face7x13
+	metricas := face.Metrics()
+	ascendente := metricas.Ascent.Ceil()
+	alturaFonte := metricas.Height.Ceil()
+
+	larguraTexto := font.MeasureString(face, texto).Ceil() + 1
+	origem := image.NewRGBA(image.Rect(0, 0, larguraTexto, alturaFonte))
+	drawer := &font.Drawer{Dst: origem, Src: image.NewUniform(s.cor), Face: face}
+	deslocamentos := []int{0}
+	if s.negrito {
+		deslocamentos = append(deslocamentos, 1)
+	}
+	for _, dx := range deslocamentos {
+		drawer.Dot = fixed.P(dx, ascendente)
+		drawer.DrawString(texto)
+	}
+
+	// Tamanho da fonte em mm e escala da fonte bitmap para esse tamanho
+	tamanhoMM := s.tamanho * 25.4 / 72
+	escala := (tamanhoMM * s.dpi / 25.4) / float64(alturaFonte)
+
+	// Margem interna da célula (1 mm) e linha de base como no gofpdf
+	baseline := s.y + altura/2 + 0.3*tamanhoMM
+	x := s.px(s.x + 1)
+	y := s.px(baseline) - int(math.Round(float64(ascendente)*escala))
+	destino := image.Rect(x, y,
+		x+int(math.Round(float64(larguraTexto)*escala)),
+		y+int(math.Round(float64(alturaFonte)*escala)))
+
+	xdraw.ApproxBiLinear.Scale(s.pagina(), destino, origem, origem.Bounds(), draw.Over, nil)
+}
+
+// textoASCII remove acentos e substitui caracteres fora do ASCII, que a fonte bitmap não cobre
+func textoASCII(texto string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(texto) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < 0x20 || r > 0x7e:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGerarPreviewDANFE(t *testing.T) {
+	cfg := setupTestConfig()
+	cfg.Cache.TTL = time.Minute
+	service := NewPDFService(cfg, logrus.New())
+
+	nfe := &models.NFe{
+		ChaveAcesso:  "12345678901234567890123456789012345678901234",
+		EmitenteNome: "EMPRESA EXEMPLO LTDA",
+	}
+	for i := 0; i < 40; i++ {
+		nfe.Duplicatas = append(nfe.Duplicatas, models.Duplicata{Numero: "001", Valor: 100})
+	}
+
+	imagem, err := service.GerarPreviewDANFE(FormatoPNG, nfe, "", 1, 96)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(imagem))
+	assert.NoError(t, err)
+	// A4 em 96 dpi
+	assert.Equal(t, 794, img.Bounds().Dx())
+	assert.Equal(t, 1123, img.Bounds().Dy())
+
+	// A segunda chamada vem do cache
+	emCache, err := service.GerarPreviewDANFE(FormatoPNG, nfe, "", 1, 96)
+	assert.NoError(t, err)
+	assert.Equal(t, &imagem[0], &emCache[0])
+
+	// As duplicatas quebram o documento em duas páginas
+	_, err = service.GerarPreviewDANFE(FormatoPNG, nfe, "", 2, 48)
+	assert.NoError(t, err)
+	_, err = service.GerarPreviewDANFE(FormatoPNG, nfe, "", 3, 48)
+	assert.ErrorIs(t, err, ErrPaginaInexistente)
+
+	_, err = service.GerarPreviewDANFE(FormatoJPEG, nfe, "", 1, 1000)
+	assert.ErrorIs(t, err, ErrDPIInvalido)
+	_, err = service.GerarPreviewDANFE(FormatoPNG, nfe, "", 1, math.NaN())
+	assert.ErrorIs(t, err, ErrDPIInvalido)
+
+	_, err = service.GerarPreviewDANFE(FormatoHTML, nfe, "", 1, 96)
+	assert.ErrorIs(t, err, ErrFormatoNaoSuportado)
+}
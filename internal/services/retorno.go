@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ImportarRetorno lê um arquivo de retorno CNAB 240 ou 400 e atualiza a situação e
+// o pagamento dos boletos encontrados. Os registros sem boleto correspondente voltam
+// no relatório para conciliação manual
+func (s *BankService) ImportarRetorno(arquivo io.Reader) (*models.RelatorioRetorno, error) {
+	retorno, err := cnab.ParseRetorno(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithFields(logrus.Fields{
+		"layout":    retorno.Layout,
+		"banco":     retorno.Banco,
+		"registros": len(retorno.Registros),
+	}).Info("Importando retorno CNAB")
+
+	relatorio := &models.RelatorioRetorno{
+		Layout:         retorno.Layout,
+		Banco:          retorno.Banco,
+		Registros:      len(retorno.Registros),
+		Boletos:        []models.BoletoConciliado{},
+		NaoConciliados: []models.RegistroNaoConciliado{},
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range retorno.Registros {
+			registro := &retorno.Registros[i]
+			boleto, motivo, err := localizarBoletoRetorno(tx, retorno.Banco, registro)
+			if err != nil {
+				return fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+			}
+			if boleto == nil {
+				relatorio.NaoConciliados = append(relatorio.NaoConciliados, models.RegistroNaoConciliado{
+					Linha:       registro.Linha,
+					NossoNumero: registro.NossoNumero,
+					SeuNumero:   registro.SeuNumero,
+					Ocorrencia:  registro.Ocorrencia,
+					ValorPago:   registro.ValorPago,
+					Motivo:      motivo,
+				})
+				continue
+			}
+
+			if aplicarRetorno(boleto, registro) {
+				if err := tx.Save(boleto).Error; err != nil {
+					return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+				}
+				relatorio.Atualizados++
+			} else {
+				relatorio.SemAlteracao++
+			}
+			relatorio.Boletos = append(relatorio.Boletos, models.BoletoConciliado{
+				Linha:       registro.Linha,
+				BoletoID:    boleto.ID,
+				NossoNumero: registro.NossoNumero,
+				Ocorrencia:  registro.Ocorrencia,
+				Status:      boleto.Status,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"atualizados":     relatorio.Atualizados,
+		"nao_conciliados": len(relatorio.NaoConciliados),
+	}).Info("Retorno CNAB importado")
+	return relatorio, nil
+}
+
+// localizarBoletoRetorno procura o boleto do registro pelo nosso número, pelo número
+// do documento e, por fim, pelo nosso número contido no campo livre do código de
+// barras. Sem um boleto único, retorna o motivo para o relatório
+func localizarBoletoRetorno(tx *gorm.DB, banco string, registro *cnab.Registro) (*models.Boleto, string, error) {
+	if registro.NossoNumero == "" && registro.SeuNumero == "" {
+		return nil, "registro sem nosso número", nil
+	}
+
+	var boletos []models.Boleto
+	if registro.NossoNumero != "" {
+		if err := tx.Where("banco = ? AND LTRIM(nosso_numero, '0') IN ?", banco, variantesNossoNumero(banco, registro.NossoNumero)).
+			Find(&boletos).Error; err != nil {
+			return nil, "", err
+		}
+	}
+	if len(boletos) == 0 && registro.SeuNumero != "" {
+		if err := tx.Where("banco = ? AND numero = ?", banco, registro.SeuNumero).Find(&boletos).Error; err != nil {
+			return nil, "", err
+		}
+	}
+	if len(boletos) == 0 && len(registro.NossoNumero) >= 6 {
+		var candidatos []models.Boleto
+		if err := tx.Where("codigo_barras LIKE ?", banco+"%"+registro.NossoNumero+"%").Find(&candidatos).Error; err != nil {
+			return nil, "", err
+		}
+		for _, candidato := range candidatos {
+			// Campo livre: posições 20 a 44 do código de barras
+			if len(candidato.CodigoBarras) == 44 && strings.Contains(candidato.CodigoBarras[19:], registro.NossoNumero) {
+				boletos = append(boletos, candidato)
+			}
+		}
+	}
+
+	switch len(boletos) {
+	case 0:
+		return nil, "boleto não encontrado", nil
+	case 1:
+		return &boletos[0], "", nil
+	default:
+		return nil, fmt.Sprintf("registro corresponde a %d boletos", len(boletos)), nil
+	}
+}
+
+// nossoNumeroRetornoComDV são os bancos cujo retorno traz o nosso número com o DV
+// (Santander nos dois layouts e Sicoob no CNAB 240); nos demais, o leiaute já separa
+// o DV e o número lido é o gravado no boleto
+var nossoNumeroRetornoComDV = map[string]bool{
+	utils.BancoSantander: true,
+	utils.BancoSicoob:    true,
+}
+
+// variantesNossoNumero lista as formas do nosso número gravadas pelo banco, sem
+// zeros à esquerda: sem o DV, quando o retorno do banco o inclui, e, nos 17 dígitos,
+// sem o convênio do Banco do Brasil ou sem a modalidade da Caixa
+func variantesNossoNumero(banco, nossoNumero string) []string {
+	variantes := []string{nossoNumero}
+	adicionar := func(variante string) {
+		if variante = strings.TrimLeft(variante, "0"); variante != "" {
+			variantes = append(variantes, variante)
+		}
+	}
+	switch {
+	case nossoNumeroRetornoComDV[banco] && len(nossoNumero) > 1:
+		adicionar(nossoNumero[:len(nossoNumero)-1])
+	case banco == utils.BancoBrasil && len(nossoNumero) == 17:
+		adicionar(nossoNumero[7:])
+	case banco == utils.BancoCaixa && len(nossoNumero) == 17:
+		adicionar(nossoNumero[2:])
+	}
+	return variantes
+}
+
+// aplicarRetorno atualiza o boleto com a ocorrência e informa se houve alteração.
+// Uma baixa não desfaz um pagamento já registrado
+func aplicarRetorno(boleto *models.Boleto, registro *cnab.Registro) bool {
+	switch {
+	case registro.Liquidado():
+		valorPago := registro.ValorPago
+		if valorPago == 0 {
+			valorPago = registro.ValorTitulo
+		}
+		boleto.Status = models.StatusBoletoPago
+		boleto.ValorPago = &valorPago
+		boleto.DataPagamento = registro.DataPagamento()
+		if registro.Juros > 0 {
+			boleto.ValorJuros = registro.Juros
+		}
+		if registro.Desconto > 0 {
+			boleto.ValorDesconto = registro.Desconto
+		}
+		return true
+	case registro.Baixado() && boleto.Status != models.StatusBoletoPago:
+		boleto.Status = models.StatusBoletoBaixado
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/cnab"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// linhaCNAB400 monta uma linha de 400 posições com os campos nas posições do manual
+func linhaCNAB400(campos map[int]string) string {
+	texto := []byte(strings.Repeat(" ", 400))
+	for posicao, valor := range campos {
+		copy(texto[posicao-1:], valor)
+	}
+	return string(texto)
+}
+
+func TestImportarRetorno(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	pago := 100.0
+	boletos := []models.Boleto{
+		{Banco: "237", Numero: "NF123-1", NossoNumero: "00012345", Valor: 250.75, Status: models.StatusBoletoAberto},
+		{Banco: "237", Numero: "NF124-1", NossoNumero: "543210", Valor: 100, Status: models.StatusBoletoPago, ValorPago: &pago},
+		{Banco: "237", Numero: "NF125-1", NossoNumero: "11111", Valor: 80, Status: models.StatusBoletoAberto},
+		{Banco: "341", Numero: "NF126-1", NossoNumero: "777777", Valor: 90, Status: models.StatusBoletoAberto},
+	}
+	assert.NoError(t, db.Create(&boletos).Error)
+
+	arquivo := strings.Join([]string{
+		linhaCNAB400(map[int]string{1: "02RETORNO", 77: "237BRADESCO", 95: "051223"}),
+		// Liquidação com o nosso número sem zeros e com DV
+		linhaCNAB400(map[int]string{1: "1", 71: "00000012345P", 109: "06", 111: "041223", 153: "0000000025075", 254: "0000000025326", 267: "0000000000251"}),
+		// Baixa de boleto já pago não altera a situação
+		linhaCNAB400(map[int]string{1: "1", 71: "000005432100", 109: "09", 111: "041223", 153: "0000000010000"}),
+		// Nosso número de outro banco
+		linhaCNAB400(map[int]string{1: "1", 71: "000007777770", 109: "06", 111: "041223", 254: "0000000009000"}),
+		// Baixa localizada pelo número do documento
+		linhaCNAB400(map[int]string{1: "1", 71: "000009999990", 109: "10", 111: "041223", 117: "NF125-1"}),
+		linhaCNAB400(map[int]string{1: "9"}),
+	}, "\r\n")
+
+	relatorio, err := service.ImportarRetorno(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Equal(t, cnab.Layout400, relatorio.Layout)
+	assert.Equal(t, 4, relatorio.Registros)
+	assert.Equal(t, 2, relatorio.Atualizados)
+	assert.Equal(t, 1, relatorio.SemAlteracao)
+	assert.Len(t, relatorio.Boletos, 3)
+	if assert.Len(t, relatorio.NaoConciliados, 1) {
+		assert.Equal(t, 4, relatorio.NaoConciliados[0].Linha)
+		assert.Equal(t, "777777", relatorio.NaoConciliados[0].NossoNumero)
+		assert.Equal(t, 90.0, relatorio.NaoConciliados[0].ValorPago)
+	}
+
+	var liquidado models.Boleto
+	assert.NoError(t, db.First(&liquidado, boletos[0].ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, liquidado.Status)
+	assert.Equal(t, 2.51, liquidado.ValorJuros)
+	if assert.NotNil(t, liquidado.ValorPago) && assert.NotNil(t, liquidado.DataPagamento) {
+		assert.Equal(t, 253.26, *liquidado.ValorPago)
+		assert.Equal(t, "2023-12-04", liquidado.DataPagamento.Format(time.DateOnly))
+	}
+
+	var jaPago, baixado models.Boleto
+	assert.NoError(t, db.First(&jaPago, boletos[1].ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, jaPago.Status)
+	assert.NoError(t, db.First(&baixado, boletos[2].ID).Error)
+	assert.Equal(t, models.StatusBoletoBaixado, baixado.Status)
+
+	_, err = service.ImportarRetorno(strings.NewReader("arquivo qualquer"))
+	assert.ErrorIs(t, err, cnab.ErrArquivoInvalido)
+}
+
+func TestVariantesNossoNumero(t *testing.T) {
+	// O DV só é retirado nos bancos cujo retorno o inclui
+	assert.Equal(t, []string{"1250", "125"}, variantesNossoNumero("033", "1250"))
+	assert.Equal(t, []string{"1235", "123"}, variantesNossoNumero("756", "1235"))
+	assert.Equal(t, []string{"1230"}, variantesNossoNumero("237", "1230"))
+	assert.Equal(t, []string{"1230"}, variantesNossoNumero("341", "1230"))
+
+	// Nos 17 dígitos, sem o convênio do Banco do Brasil ou a modalidade da Caixa
+	assert.Equal(t, []string{"12345670000000123", "123"}, variantesNossoNumero("001", "12345670000000123"))
+	assert.Equal(t, []string{"14000000000000123", "123"}, variantesNossoNumero("104", "14000000000000123"))
+	assert.Equal(t, []string{"14000000000000123"}, variantesNossoNumero("237", "14000000000000123"))
+}
+
+func TestImportarRetornoSemPrefixo(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	// O nosso número 1230 do Bradesco não é o boleto 123 sem DV
+	boleto := models.Boleto{Banco: "237", Numero: "NF200-1", NossoNumero: "123", Valor: 50, Status: models.StatusBoletoAberto}
+	assert.NoError(t, db.Create(&boleto).Error)
+
+	arquivo := strings.Join([]string{
+		linhaCNAB400(map[int]string{1: "02RETORNO", 77: "237BRADESCO", 95: "051223"}),
+		linhaCNAB400(map[int]string{1: "1", 71: "000000012300", 109: "06", 111: "041223", 254: "0000000005000"}),
+		linhaCNAB400(map[int]string{1: "9"}),
+	}, "\r\n")
+
+	relatorio, err := service.ImportarRetorno(strings.NewReader(arquivo))
+	assert.NoError(t, err)
+	assert.Zero(t, relatorio.Atualizados)
+	if assert.Len(t, relatorio.NaoConciliados, 1) {
+		assert.Equal(t, "1230", relatorio.NaoConciliados[0].NossoNumero)
+	}
+	assert.NoError(t, db.First(&boleto, boleto.ID).Error)
+	assert.Equal(t, models.StatusBoletoAberto, boleto.Status)
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrTemplateNaoEncontrado indica que o template solicitado não existe
+var ErrTemplateNaoEncontrado = errors.New("template não encontrado")
+
+// nomeTemplateValido restringe os nomes de template a caracteres seguros para uso em caminhos
+var nomeTemplateValido = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// extensoesTemplate lista as extensões aceitas, em ordem de prioridade
+var extensoesTemplate = []string{".json", ".yaml", ".yml"}
+
+// Blocos opcionais do DANFE que podem ser ocultados pelo template
+const (
+	BlocoEmitente     = "emitente"
+	BlocoDestinatario = "destinatario"
+	BlocoDadosNFe     = "dados_nfe"
+	BlocoValores      = "valores"
+	BlocoDuplicatas   = "duplicatas"
+	BlocoCodigoBarras = "codigo_barras"
+)
+
+// rotulosPadrao contém os textos usados no DANFE quando o template não os sobrescreve
+var rotulosPadrao = map[string]string{
+	"titulo":           "DANFE - Documento Auxiliar da Nota Fiscal Eletrônica",
+	"chave_acesso":     "Chave de Acesso",
+	"emitente":         "EMITENTE",
+	"destinatario":     "DESTINATÁRIO",
+	"dados_nfe":        "DADOS DA NFE",
+	"valores":          "VALORES",
+	"duplicatas":       "DUPLICATAS",
+	"codigo_barras":    "CÓDIGO DE BARRAS",
+	"cnpj":             "CNPJ",
+	"nome":             "Nome",
+	"ie":               "IE",
+	"numero":           "Número",
+	"serie":            "Série",
+	"data_emissao":     "Data de Emissão",
+	"data_autorizacao": "Data de Autorização",
+	"status":           "Status",
+	"ambiente":         "Ambiente",
+	"uf":               "UF",
+	"valor_total":      "Valor Total",
+	"valor_produtos":   "Valor dos Produtos",
+	"valor_impostos":   "Valor dos Impostos",
+	"vencimento":       "Vencimento",
+	"valor":            "Valor",
+}
+
+// DANFETemplate representa a definição de layout de um DANFE
+type DANFETemplate struct {
+	Nome          string            `json:"nome" yaml:"nome"`
+	Logo          string            `json:"logo" yaml:"logo"`
+	TamanhoPagina string            `json:"tamanho_pagina" yaml:"tamanho_pagina"`
+	Orientacao    string            `json:"orientacao" yaml:"orientacao"`
+	Margens       MargensTemplate   `json:"margens" yaml:"margens"`
+	Cores         CoresTemplate     `json:"cores" yaml:"cores"`
+	Fontes        FontesTemplate    `json:"fontes" yaml:"fontes"`
+	Blocos        map[string]bool   `json:"blocos" yaml:"blocos"`
+	Rotulos       map[string]string `json:"rotulos" yaml:"rotulos"`
+	CNPJs         []string          `json:"cnpjs" yaml:"cnpjs"`
+
+	// diretorio é o diretório de onde o template foi carregado, usado para resolver o logo
+	diretorio string
+}
+
+// MargensTemplate representa as margens da página em milímetros
+type MargensTemplate struct {
+	Superior float64 `json:"superior" yaml:"superior"`
+	Inferior float64 `json:"inferior" yaml:"inferior"`
+	Esquerda float64 `json:"esquerda" yaml:"esquerda"`
+	Direita  float64 `json:"direita" yaml:"direita"`
+}
+
+// CoresTemplate representa as cores do DANFE em hexadecimal (#RRGGBB)
+type CoresTemplate struct {
+	Titulo string `json:"titulo" yaml:"titulo"`
+	Secao  string `json:"secao" yaml:"secao"`
+	Texto  string `json:"texto" yaml:"texto"`
+}
+
+// FontesTemplate representa a família e os tamanhos de fonte do DANFE
+type FontesTemplate struct {
+	Familia string  `json:"familia" yaml:"familia"`
+	Titulo  float64 `json:"titulo" yaml:"titulo"`
+	Secao   float64 `json:"secao" yaml:"secao"`
+	Texto   float64 `json:"texto" yaml:"texto"`
+}
+
+// TemplatePadrao retorna o template embutido, equivalente ao layout original do DANFE
+func TemplatePadrao() *DANFETemplate {
+	return &DANFETemplate{
+		Nome:          "padrao",
+		TamanhoPagina: "A4",
+		Orientacao:    "P",
+		Margens:       MargensTemplate{Superior: 10, Inferior: 10, Esquerda: 10, Direita: 10},
+		Cores:         CoresTemplate{Titulo: "#000000", Secao: "#000000", Texto: "#000000"},
+		Fontes:        FontesTemplate{Familia: "Arial", Titulo: 16, Secao: 12, Texto: 10},
+	}
+}
+
+// MostrarBloco informa se um bloco opcional deve ser exibido; blocos não listados são exibidos
+func (t *DANFETemplate) MostrarBloco(bloco string) bool {
+	if mostrar, ok := t.Blocos[bloco]; ok {
+		return mostrar
+	}
+	return true
+}
+
+// Rotulo retorna o texto de um rótulo, aplicando a sobrescrita do template quando houver
+func (t *DANFETemplate) Rotulo(chave string) string {
+	if rotulo, ok := t.Rotulos[chave]; ok && rotulo != "" {
+		return rotulo
+	}
+	if rotulo, ok := rotulosPadrao[chave]; ok {
+		return rotulo
+	}
+	return chave
+}
+
+// CaminhoLogo retorna o caminho absoluto do logo, resolvido em relação ao diretório do template
+func (t *DANFETemplate) CaminhoLogo() string {
+	if t.Logo == "" || filepath.IsAbs(t.Logo) || t.diretorio == "" {
+		return t.Logo
+	}
+	return filepath.Join(t.diretorio, t.Logo)
+}
+
+// AtendeCNPJ informa se o template é o padrão do CNPJ informado
+func (t *DANFETemplate) AtendeCNPJ(cnpj string) bool {
+	cnpj = somenteDigitos(cnpj)
+	if cnpj == "" {
+		return false
+	}
+	for _, c := range t.CNPJs {
+		if somenteDigitos(c) == cnpj {
+			return true
+		}
+	}
+	return false
+}
+
+// completar preenche os campos ausentes com os valores do template padrão
+func (t *DANFETemplate) completar() {
+	padrao := TemplatePadrao()
+	if t.TamanhoPagina == "" {
+		t.TamanhoPagina = padrao.TamanhoPagina
+	}
+	if t.Orientacao == "" {
+		t.Orientacao = padrao.Orientacao
+	}
+	if t.Margens == (MargensTemplate{}) {
+		t.Margens = padrao.Margens
+	}
+	if t.Cores.Titulo == "" {
+		t.Cores.Titulo = padrao.Cores.Titulo
+	}
+	if t.Cores.Secao == "" {
+		t.Cores.Secao = padrao.Cores.Secao
+	}
+	if t.Cores.Texto == "" {
+		t.Cores.Texto = padrao.Cores.Texto
+	}
+	if t.Fontes.Familia == "" {
+		t.Fontes.Familia = padrao.Fontes.Familia
+	}
+	if t.Fontes.Titulo == 0 {
+		t.Fontes.Titulo = padrao.Fontes.Titulo
+	}
+	if t.Fontes.Secao == 0 {
+		t.Fontes.Secao = padrao.Fontes.Secao
+	}
+	if t.Fontes.Texto == 0 {
+		t.Fontes.Texto = padrao.Fontes.Texto
+	}
+}
+
+// DANFETemplateStore carrega templates de DANFE a partir de um diretório
+type DANFETemplateStore struct {
+	diretorio  string
+	nomePadrao string
+	logger     *logrus.Logger
+}
+
+// NewDANFETemplateStore cria um novo repositório de templates
+func NewDANFETemplateStore(diretorio, nomePadrao string, logger *logrus.Logger) *DANFETemplateStore {
+	return &DANFETemplateStore{
+		diretorio:  diretorio,
+		nomePadrao: nomePadrao,
+		logger:     logger,
+	}
+}
+
+// Resolver escolhe o template de uma renderização: o nome explícito tem prioridade,
+// depois o template associado ao CNPJ do emitente, depois o padrão configurado
+func (s *DANFETemplateStore) Resolver(nome, cnpjEmitente string) (*DANFETemplate, error) {
+	if nome != "" {
+		return s.Carregar(nome)
+	}
+
+	if template, err := s.buscarPorCNPJ(cnpjEmitente); err != nil {
+		return nil, err
+	} else if template != nil {
+		return template, nil
+	}
+
+	if s.nomePadrao != "" {
+		return s.Carregar(s.nomePadrao)
+	}
+
+	return TemplatePadrao(), nil
+}
+
+// Carregar lê um template pelo nome, aceitando arquivos .json, .yaml ou .yml
+func (s *DANFETemplateStore) Carregar(nome string) (*DANFETemplate, error) {
+	if nome == "padrao" {
+		if template, err := s.carregarArquivo(nome); err == nil {
+			return template, nil
+		}
+		return TemplatePadrao(), nil
+	}
+	return s.carregarArquivo(nome)
+}
+
+// carregarArquivo procura o arquivo do template no diretório configurado
+func (s *DANFETemplateStore) carregarArquivo(nome string) (*DANFETemplate, error) {
+	if !nomeTemplateValido.MatchString(nome) {
+		return nil, fmt.Errorf("%w: nome inválido %q", ErrTemplateNaoEncontrado, nome)
+	}
+	if s.diretorio == "" {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNaoEncontrado, nome)
+	}
+
+	for _, ext := range extensoesTemplate {
+		caminho := filepath.Join(s.diretorio, nome+ext)
+		if _, err := os.Stat(caminho); err != nil {
+			continue
+		}
+		template, err := lerTemplate(caminho)
+		if err != nil {
+			return nil, err
+		}
+		if template.Nome == "" {
+			template.Nome = nome
+		}
+		return template, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNaoEncontrado, nome)
+}
+
+// buscarPorCNPJ procura no diretório o template marcado como padrão do CNPJ. Um
+// arquivo inválido é ignorado: só falha a renderização que pedir o template pelo nome
+func (s *DANFETemplateStore) buscarPorCNPJ(cnpj string) (*DANFETemplate, error) {
+	if s.diretorio == "" || somenteDigitos(cnpj) == "" {
+		return nil, nil
+	}
+
+	entradas, err := os.ReadDir(s.diretorio)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erro ao listar templates: %w", err)
+	}
+
+	for _, entrada := range entradas {
+		if entrada.IsDir() || !extensaoTemplateValida(entrada.Name()) {
+			continue
+		}
+		template, err := lerTemplate(filepath.Join(s.diretorio, entrada.Name()))
+		if err != nil {
+			s.logger.WithError(err).WithField("arquivo", entrada.Name()).Warn("Template de DANFE inválido ignorado")
+			continue
+		}
+		if template.AtendeCNPJ(cnpj) {
+			if template.Nome == "" {
+				template.Nome = strings.TrimSuffix(entrada.Name(), filepath.Ext(entrada.Name()))
+			}
+			return template, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// lerTemplate decodifica um arquivo de template JSON ou YAML
+func lerTemplate(caminho string) (*DANFETemplate, error) {
+	conteudo, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler template %s: %w", caminho, err)
+	}
+
+	var template DANFETemplate
+	if filepath.Ext(caminho) == ".json" {
+		err = json.Unmarshal(conteudo, &template)
+	} else {
+		err = yaml.Unmarshal(conteudo, &template)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar template %s: %w", caminho, err)
+	}
+
+	template.diretorio = filepath.Dir(caminho)
+	template.completar()
+	return &template, nil
+}
+
+// extensaoTemplateValida informa se o arquivo tem uma extensão de template aceita
+func extensaoTemplateValida(nome string) bool {
+	ext := filepath.Ext(nome)
+	for _, e := range extensoesTemplate {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// corRGB converte uma cor hexadecimal (#RRGGBB) em componentes RGB; cores inválidas viram preto
+func corRGB(hex string) (int, int, int) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0
+	}
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff)
+}
+
+// somenteDigitos remove todos os caracteres não numéricos
+func somenteDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolverTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "marca.yaml"), []byte(`
+tamanho_pagina: A5
+blocos:
+  duplicatas: false
+rotulos:
+  titulo: "DANFE MARCA"
+cnpjs: ["12.345.678/0001-23"]
+`), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "simples.json"), []byte(`{"orientacao": "L"}`), 0o644))
+	// Listado antes dos demais, o arquivo inválido não impede a busca pelo CNPJ
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "apagado.yaml"), []byte("blocos: [duplicatas\n"), 0o644))
+
+	store := NewDANFETemplateStore(dir, "", logrus.New())
+
+	// Nome explícito tem prioridade sobre o CNPJ
+	tpl, err := store.Resolver("simples", "12345678000123")
+	assert.NoError(t, err)
+	assert.Equal(t, "simples", tpl.Nome)
+	assert.Equal(t, "L", tpl.Orientacao)
+	assert.Equal(t, "A4", tpl.TamanhoPagina)
+
+	// Template padrão do CNPJ do emitente
+	tpl, err = store.Resolver("", "12345678000123")
+	assert.NoError(t, err)
+	assert.Equal(t, "marca", tpl.Nome)
+	assert.Equal(t, "A5", tpl.TamanhoPagina)
+	assert.False(t, tpl.MostrarBloco(BlocoDuplicatas))
+	assert.True(t, tpl.MostrarBloco(BlocoEmitente))
+	assert.Equal(t, "DANFE MARCA", tpl.Rotulo("titulo"))
+	assert.Equal(t, "EMITENTE", tpl.Rotulo("emitente"))
+
+	// Sem correspondência, usa o layout embutido
+	tpl, err = store.Resolver("", "98765432000198")
+	assert.NoError(t, err)
+	assert.Equal(t, "padrao", tpl.Nome)
+
+	// Nomes inexistentes ou com caminho são rejeitados
+	_, err = store.Resolver("inexistente", "")
+	assert.ErrorIs(t, err, ErrTemplateNaoEncontrado)
+	_, err = store.Resolver("../marca", "")
+	assert.ErrorIs(t, err, ErrTemplateNaoEncontrado)
+
+	// Pedido pelo nome, o template inválido falha
+	_, err = store.Resolver("apagado", "12345678000123")
+	assert.Error(t, err)
+}
+
+func TestGerarDANFEComTemplate(t *testing.T) {
+	cfg := setupTestConfig()
+	cfg.DANFE.TemplatesDir = t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg.DANFE.TemplatesDir, "carta.json"),
+		[]byte(`{"tamanho_pagina": "Letter", "logo": "inexistente.png"}`), 0o644))
+
+	service := NewPDFService(cfg, logrus.New())
+	nfe := &models.NFe{ChaveAcesso: "12345678901234567890123456789012345678901234"}
+
+	pdf, err := service.GerarDANFE(nfe, "carta")
+	assert.NoError(t, err)
+	assert.Equal(t, "%PDF", string(pdf[:4]))
+
+	_, err = service.GerarDANFE(nfe, "outro")
+	assert.ErrorIs(t, err, ErrTemplateNaoEncontrado)
+}
+
+func TestRenderizarDANFEHTML(t *testing.T) {
+	cfg := setupTestConfig()
+	cfg.DANFE.TemplatesDir = t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(cfg.DANFE.TemplatesDir, "intranet.json"),
+		[]byte(`{"cores": {"titulo": "#1F3A93"}, "blocos": {"valores": false}, "rotulos": {"titulo": "Nota <Fiscal>"}}`), 0o644))
+
+	service := NewPDFService(cfg, logrus.New())
+	nfe := &models.NFe{
+		ChaveAcesso:  "12345678901234567890123456789012345678901234",
+		EmitenteNome: "EMPRESA & FILHOS LTDA",
+		Duplicatas:   []models.Duplicata{{Numero: "001", Valor: 1000}},
+	}
+
+	html, err := service.RenderizarDANFE(FormatoHTML, nfe, "intranet")
+	assert.NoError(t, err)
+	conteudo := string(html)
+	assert.Contains(t, conteudo, "Nota &lt;Fiscal&gt;")
+	assert.Contains(t, conteudo, "EMPRESA &amp; FILHOS LTDA")
+	assert.Contains(t, conteudo, "color: #1F3A93")
+	assert.Contains(t, conteudo, "R$ 1000.00")
+	assert.NotContains(t, conteudo, "Valor Total")
+
+	_, err = service.RenderizarDANFE("docx", nfe, "")
+	assert.ErrorIs(t, err, ErrFormatoNaoSuportado)
+}
+
+func TestChaveCacheLogo(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	assert.NoError(t, os.WriteFile(logo, []byte("logo"), 0o644))
+	tpl := &DANFETemplate{Logo: logo}
+	atualizadoEm := time.Date(2023, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	chave := chaveCacheDANFE(FormatoPDF, "35231198765432000198550010000001231000001230", atualizadoEm, tpl)
+	assert.Equal(t, chave, chaveCacheDANFE(FormatoPDF, "35231198765432000198550010000001231000001230", atualizadoEm, tpl))
+
+	// A troca da imagem, mantido o nome do arquivo, gera outra chave
+	assert.NoError(t, os.WriteFile(logo, []byte("logo novo"), 0o644))
+	assert.NoError(t, os.Chtimes(logo, time.Now(), time.Now().Add(time.Hour)))
+	assert.NotEqual(t, chave, chaveCacheDANFE(FormatoPDF, "35231198765432000198550010000001231000001230", atualizadoEm, tpl))
+}
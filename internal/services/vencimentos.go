@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// faixasVencimento são os limites superiores, em dias de atraso, das faixas do
+// relatório de vencimentos; a última faixa não tem limite
+var faixasVencimento = []int{30, 60, 90}
+
+// RelatorioVencimentos agrupa os boletos a pagar em aberto por atraso na data. Os
+// boletos emitidos pela empresa são a receber e ficam fora. O atraso conta
+// do vencimento original, mas o boleto que vence em dia sem expediente só fica
+// vencido depois do dia útil seguinte, como no cálculo do valor atualizado
+func (s *BankService) RelatorioVencimentos(data time.Time) (*models.RelatorioVencimentos, error) {
+	data = calendario.Dia(data)
+	s.logger.WithField("data", data.Format("2006-01-02")).Info("Gerando relatório de vencimentos")
+
+	var boletos []models.Boleto
+	if err := s.db.Preload("Descontos").Where("status = ? AND emitido = ?", models.StatusBoletoAberto, false).
+		Order("vencimento, id").Find(&boletos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+	}
+
+	relatorio := &models.RelatorioVencimentos{Data: data, Faixas: novasFaixasVencimento()}
+	for i := range boletos {
+		atualizado := CalcularValorAtualizado(s.calendario, &boletos[i], data)
+		faixa := &relatorio.Faixas[0]
+		if atualizado.DiasAtraso > 0 {
+			faixa = &relatorio.Faixas[len(relatorio.Faixas)-1]
+			for j, limite := range faixasVencimento {
+				if atualizado.DiasAtraso <= limite {
+					faixa = &relatorio.Faixas[j+1]
+					break
+				}
+			}
+		}
+		faixa.Quantidade++
+		faixa.Valor = arredondar(faixa.Valor + boletos[i].Valor)
+		faixa.ValorAtualizado = arredondar(faixa.ValorAtualizado + atualizado.Total)
+		faixa.BoletoIDs = append(faixa.BoletoIDs, boletos[i].ID)
+
+		relatorio.Quantidade++
+		relatorio.Valor = arredondar(relatorio.Valor + boletos[i].Valor)
+		relatorio.ValorAtualizado = arredondar(relatorio.ValorAtualizado + atualizado.Total)
+	}
+	return relatorio, nil
+}
+
+// novasFaixasVencimento monta as faixas vazias: a vencer, as limitadas e a última
+func novasFaixasVencimento() []models.FaixaVencimento {
+	faixas := []models.FaixaVencimento{{Faixa: "a vencer", BoletoIDs: []uint{}}}
+	minimo := 1
+	for _, limite := range faixasVencimento {
+		faixas = append(faixas, models.FaixaVencimento{
+			Faixa:      fmt.Sprintf("%d a %d dias", minimo, limite),
+			DiasMinimo: minimo,
+			DiasMaximo: limite,
+			BoletoIDs:  []uint{},
+		})
+		minimo = limite + 1
+	}
+	return append(faixas, models.FaixaVencimento{
+		Faixa:      fmt.Sprintf("mais de %d dias", minimo-1),
+		DiasMinimo: minimo,
+		BoletoIDs:  []uint{},
+	})
+}
+
+// Feriados lista os feriados nacionais e locais do ano no calendário bancário
+func (s *BankService) Feriados(ano int) []calendario.Feriado {
+	return s.calendario.Feriados(ano)
+}
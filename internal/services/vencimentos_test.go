@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+func TestRelatorioVencimentos(t *testing.T) {
+	db := setupTestDB()
+	cfg := setupTestConfig()
+	cfg.Calendario.FeriadosLocais = []string{"01-25=Aniversário de São Paulo"}
+	service := NewBankService(cfg, db, logrus.New())
+
+	boletos := []models.Boleto{
+		// Vence no feriado local de quinta: ainda pode ser pago na sexta
+		{Numero: "A", Valor: 100, Vencimento: dia(2024, 1, 25), Status: models.StatusBoletoAberto},
+		{Numero: "B", Valor: 200, Vencimento: dia(2024, 1, 10), Status: models.StatusBoletoAberto,
+			TipoMulta: models.EncargoPercentual, TaxaMulta: 2},
+		{Numero: "C", Valor: 300, Vencimento: dia(2023, 10, 1), Status: models.StatusBoletoAberto},
+		{Numero: "D", Valor: 400, Vencimento: dia(2023, 12, 1), Status: models.StatusBoletoPago},
+		// Emitido pela empresa: é a receber, não entra no relatório de contas a pagar
+		{Numero: "E", Valor: 500, Vencimento: dia(2023, 12, 1), Emitido: true, Status: models.StatusBoletoAberto},
+	}
+	assert.NoError(t, db.Create(&boletos).Error)
+
+	relatorio, err := service.RelatorioVencimentos(dia(2024, 1, 26))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, relatorio.Quantidade)
+	assert.Equal(t, 600.0, relatorio.Valor)
+	assert.Equal(t, 604.0, relatorio.ValorAtualizado)
+	if assert.Len(t, relatorio.Faixas, 5) {
+		assert.Equal(t, []uint{boletos[0].ID}, relatorio.Faixas[0].BoletoIDs)
+		assert.Equal(t, "1 a 30 dias", relatorio.Faixas[1].Faixa)
+		assert.Equal(t, []uint{boletos[1].ID}, relatorio.Faixas[1].BoletoIDs)
+		assert.Equal(t, 204.0, relatorio.Faixas[1].ValorAtualizado)
+		assert.Empty(t, relatorio.Faixas[2].BoletoIDs)
+		assert.Equal(t, "mais de 90 dias", relatorio.Faixas[4].Faixa)
+		assert.Equal(t, []uint{boletos[2].ID}, relatorio.Faixas[4].BoletoIDs)
+	}
+
+	feriados := service.Feriados(2024)
+	assert.Contains(t, feriados[1].Nome, "São Paulo")
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Critérios do vínculo entre duplicatas e boletos. A confiança soma os pesos de
+// valor (0,40), vencimento (0,25), beneficiário (0,25) e número do documento (0,10)
+const (
+	// toleranciaValor é a diferença relativa aceita entre o valor da duplicata e o do
+	// boleto, para arredondamentos e abatimentos
+	toleranciaValor = 0.01
+	// toleranciaVencimento é a diferença em dias aceita entre os vencimentos, já
+	// prorrogados para o dia útil, para boletos emitidos com outra data
+	toleranciaVencimento = 5
+	// confiancaAutomatica grava o vínculo no boleto sem revisão
+	confiancaAutomatica = 0.8
+	// confiancaMinima é o mínimo para o vínculo entrar na fila de revisão
+	confiancaMinima = 0.5
+)
+
+// ErrVinculoNaoEncontrado indica um vínculo inexistente
+var ErrVinculoNaoEncontrado = errors.New("vínculo não encontrado")
+
+// ErrVinculoRevisado indica um vínculo que não está mais pendente de revisão
+var ErrVinculoRevisado = errors.New("vínculo já revisado")
+
+// candidatoVinculo é um par duplicata-boleto avaliado
+type candidatoVinculo struct {
+	duplicata *models.Duplicata
+	boleto    *models.Boleto
+	confianca float64
+	motivos   []string
+}
+
+// vincularBeneficiarios vincula aos boletos conhecidos as duplicatas das NF-e
+// emitidas pelos beneficiários informados, sem formatação, e retorna quantos
+// vínculos foram criados. A falha em uma NF-e não interrompe as demais
+func (s *BankService) vincularBeneficiarios(documentos []string) (int, error) {
+	if len(documentos) == 0 {
+		return 0, nil
+	}
+	var nfeIDs []uint
+	if err := s.db.Model(&models.NFe{}).Where("emitente_cnpj IN ?", documentos).Order("id").Pluck("id", &nfeIDs).Error; err != nil {
+		return 0, fmt.Errorf("erro ao consultar NFes no banco: %w", err)
+	}
+	total := 0
+	for _, nfeID := range nfeIDs {
+		vinculos, err := s.VincularDuplicatas(nfeID)
+		if err != nil {
+			s.logger.WithError(err).WithField("nfe_id", nfeID).Warn("Erro ao vincular duplicatas aos boletos capturados")
+			continue
+		}
+		total += len(vinculos)
+	}
+	return total, nil
+}
+
+// VincularDuplicatas procura, entre os boletos conhecidos e ainda sem duplicata, os
+// que correspondem às duplicatas da NF-e. Cada boleto e cada duplicata recebem no
+// máximo um vínculo, escolhido pela maior confiança
+func (s *BankService) VincularDuplicatas(nfeID uint) ([]models.VinculoDuplicata, error) {
+	s.logger.WithField("nfe_id", nfeID).Info("Vinculando duplicatas a boletos")
+
+	var nfe models.NFe
+	if err := s.db.Preload("Duplicatas").First(&nfe, nfeID).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar NFe no banco: %w", err)
+	}
+	duplicatas, err := s.duplicatasSemVinculo(nfe.Duplicatas)
+	if err != nil || len(duplicatas) == 0 {
+		return nil, err
+	}
+	boletos, err := s.boletosCandidatos(nfe.ID, duplicatas)
+	if err != nil {
+		return nil, err
+	}
+	rejeitados, err := s.paresRejeitados(duplicatas)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidatos []candidatoVinculo
+	for i := range duplicatas {
+		for j := range boletos {
+			if rejeitados[[2]uint{duplicatas[i].ID, boletos[j].ID}] {
+				continue
+			}
+			confianca, motivos, ok := avaliarVinculo(s.calendario, &nfe, &duplicatas[i], &boletos[j])
+			if ok && confianca >= confiancaMinima {
+				candidatos = append(candidatos, candidatoVinculo{&duplicatas[i], &boletos[j], confianca, motivos})
+			}
+		}
+	}
+	sort.SliceStable(candidatos, func(i, j int) bool {
+		return candidatos[i].confianca > candidatos[j].confianca
+	})
+
+	var vinculos []models.VinculoDuplicata
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		usados := make(map[uint]bool)
+		vinculadas := make(map[uint]bool)
+		for _, candidato := range candidatos {
+			if vinculadas[candidato.duplicata.ID] || usados[candidato.boleto.ID] {
+				continue
+			}
+			vinculadas[candidato.duplicata.ID] = true
+			usados[candidato.boleto.ID] = true
+
+			vinculo := models.VinculoDuplicata{
+				NFeID:       nfe.ID,
+				DuplicataID: candidato.duplicata.ID,
+				BoletoID:    candidato.boleto.ID,
+				Confianca:   math.Round(candidato.confianca*100) / 100,
+				Motivos:     strings.Join(candidato.motivos, "; "),
+				Status:      models.VinculoPendente,
+			}
+			if candidato.confianca >= confiancaAutomatica {
+				vinculo.Status = models.VinculoAutomatico
+				if err := gravarVinculo(tx, &vinculo); err != nil {
+					return err
+				}
+			}
+			if err := tx.Omit(clause.Associations).Create(&vinculo).Error; err != nil {
+				return fmt.Errorf("erro ao salvar vínculo no banco: %w", err)
+			}
+			vinculos = append(vinculos, vinculo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"nfe_id":   nfeID,
+		"vinculos": len(vinculos),
+	}).Info("Duplicatas vinculadas")
+	return vinculos, nil
+}
+
+// duplicatasSemVinculo descarta as duplicatas que já têm boleto ou vínculo pendente
+func (s *BankService) duplicatasSemVinculo(duplicatas []models.Duplicata) ([]models.Duplicata, error) {
+	if len(duplicatas) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(duplicatas))
+	for i, duplicata := range duplicatas {
+		ids[i] = duplicata.ID
+	}
+
+	var comBoleto, comPendente []uint
+	if err := s.db.Model(&models.Boleto{}).Where("duplicata_id IN ?", ids).Pluck("duplicata_id", &comBoleto).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+	}
+	if err := s.db.Model(&models.VinculoDuplicata{}).Where("duplicata_id IN ? AND status = ?", ids, models.VinculoPendente).
+		Pluck("duplicata_id", &comPendente).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar vínculos no banco: %w", err)
+	}
+	ignorar := make(map[uint]bool)
+	for _, id := range append(comBoleto, comPendente...) {
+		ignorar[id] = true
+	}
+
+	var resultado []models.Duplicata
+	for _, duplicata := range duplicatas {
+		if !ignorar[duplicata.ID] {
+			resultado = append(resultado, duplicata)
+		}
+	}
+	return resultado, nil
+}
+
+// boletosCandidatos carrega os boletos sem duplicata, da própria NF-e ou avulsos,
+// com valor na faixa das duplicatas e sem vínculo pendente
+func (s *BankService) boletosCandidatos(nfeID uint, duplicatas []models.Duplicata) ([]models.Boleto, error) {
+	minimo, maximo := math.MaxFloat64, 0.0
+	for _, duplicata := range duplicatas {
+		minimo = math.Min(minimo, duplicata.Valor*(1-toleranciaValor))
+		maximo = math.Max(maximo, duplicata.Valor*(1+toleranciaValor))
+	}
+
+	pendentes := s.db.Model(&models.VinculoDuplicata{}).Select("boleto_id").Where("status = ?", models.VinculoPendente)
+	var boletos []models.Boleto
+	if err := s.db.Where("duplicata_id IS NULL AND (n_fe_id = ? OR n_fe_id = 0) AND status <> ? AND tipo <> ?",
+		nfeID, models.StatusBoletoBaixado, utils.TipoCodigoArrecadacao).
+		Where("valor BETWEEN ? AND ?", minimo, maximo).
+		Where("id NOT IN (?)", pendentes).
+		Order("id").Find(&boletos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+	}
+	return boletos, nil
+}
+
+// paresRejeitados lista os pares duplicata-boleto já rejeitados na revisão
+func (s *BankService) paresRejeitados(duplicatas []models.Duplicata) (map[[2]uint]bool, error) {
+	ids := make([]uint, len(duplicatas))
+	for i, duplicata := range duplicatas {
+		ids[i] = duplicata.ID
+	}
+	var vinculos []models.VinculoDuplicata
+	if err := s.db.Where("duplicata_id IN ? AND status = ?", ids, models.VinculoRejeitado).Find(&vinculos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar vínculos no banco: %w", err)
+	}
+	pares := make(map[[2]uint]bool)
+	for _, vinculo := range vinculos {
+		pares[[2]uint{vinculo.DuplicataID, vinculo.BoletoID}] = true
+	}
+	return pares, nil
+}
+
+// avaliarVinculo pontua a correspondência entre a duplicata e o boleto. Valor fora da
+// tolerância ou beneficiário de outra empresa descartam o par
+func avaliarVinculo(cal *calendario.Calendario, nfe *models.NFe, duplicata *models.Duplicata, boleto *models.Boleto) (float64, []string, bool) {
+	var confianca float64
+	var motivos []string
+
+	diferenca := math.Abs(boleto.Valor - duplicata.Valor)
+	switch {
+	case duplicata.Valor <= 0:
+		return 0, nil, false
+	case diferenca < 0.005:
+		confianca += 0.40
+		motivos = append(motivos, "valor igual")
+	case diferenca <= duplicata.Valor*toleranciaValor:
+		confianca += 0.20
+		motivos = append(motivos, fmt.Sprintf("valor com diferença de R$ %s", formatarValor(diferenca)))
+	default:
+		return 0, nil, false
+	}
+
+	if duplicata.Vencimento.IsZero() || boleto.Vencimento.IsZero() {
+		motivos = append(motivos, "vencimento não informado")
+	} else {
+		// Vencimentos em dias sem expediente valem no dia útil seguinte
+		dias := int(math.Abs(math.Round(cal.ProximoDiaUtil(boleto.Vencimento).Sub(cal.ProximoDiaUtil(duplicata.Vencimento)).Hours() / 24)))
+		switch {
+		case dias == 0:
+			confianca += 0.25
+			motivos = append(motivos, "vencimento igual")
+		case dias <= toleranciaVencimento:
+			confianca += 0.15
+			motivos = append(motivos, fmt.Sprintf("vencimento a %d dia(s)", dias))
+		default:
+			motivos = append(motivos, fmt.Sprintf("vencimento a %d dias, fora da tolerância", dias))
+		}
+	}
+
+	beneficiario := apenasDigitos(boleto.DocumentoBeneficiario)
+	emitente := apenasDigitos(nfe.EmitenteCNPJ)
+	switch {
+	case beneficiario == "" || emitente == "":
+		motivos = append(motivos, "beneficiário não informado")
+	case beneficiario == emitente:
+		confianca += 0.25
+		motivos = append(motivos, "beneficiário é o emitente")
+	case len(beneficiario) == 14 && len(emitente) == 14 && beneficiario[:8] == emitente[:8]:
+		confianca += 0.20
+		motivos = append(motivos, "beneficiário é estabelecimento do emitente")
+	default:
+		return 0, nil, false
+	}
+
+	// Número do documento ou nosso número compostos com o número da NF-e
+	if numeroNFe := strings.TrimLeft(apenasDigitos(nfe.Numero), "0"); len(numeroNFe) >= 3 {
+		for _, numero := range []string{boleto.Numero, boleto.NossoNumero} {
+			if strings.Contains(apenasDigitos(numero), numeroNFe) {
+				confianca += 0.10
+				motivos = append(motivos, "número do documento contém o número da NF-e")
+				break
+			}
+		}
+	}
+
+	return confianca, motivos, true
+}
+
+// gravarVinculo liga o boleto à duplicata e à NF-e
+func gravarVinculo(tx *gorm.DB, vinculo *models.VinculoDuplicata) error {
+	resultado := tx.Model(&models.Boleto{}).Where("id = ? AND duplicata_id IS NULL", vinculo.BoletoID).
+		Updates(map[string]interface{}{"duplicata_id": vinculo.DuplicataID, "n_fe_id": vinculo.NFeID})
+	if resultado.Error != nil {
+		return fmt.Errorf("erro ao salvar boleto no banco: %w", resultado.Error)
+	}
+	if resultado.RowsAffected == 0 {
+		return fmt.Errorf("%w: boleto %d já vinculado a outra duplicata", ErrVinculoRevisado, vinculo.BoletoID)
+	}
+	return nil
+}
+
+// ListarVinculos lista os vínculos na situação informada, com a duplicata e o boleto
+func (s *BankService) ListarVinculos(status string) ([]models.VinculoDuplicata, error) {
+	var vinculos []models.VinculoDuplicata
+	if err := s.db.Preload("Duplicata").Preload("Boleto").Where("status = ?", status).
+		Order("confianca DESC, id").Find(&vinculos).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar vínculos no banco: %w", err)
+	}
+	return vinculos, nil
+}
+
+// ConfirmarVinculo aprova um vínculo pendente, gravando a duplicata no boleto. Os
+// demais vínculos pendentes da mesma duplicata ou do mesmo boleto são rejeitados
+func (s *BankService) ConfirmarVinculo(id uint) (*models.VinculoDuplicata, error) {
+	return s.revisarVinculo(id, models.VinculoConfirmado)
+}
+
+// RejeitarVinculo descarta um vínculo pendente; o par não é sugerido novamente
+func (s *BankService) RejeitarVinculo(id uint) (*models.VinculoDuplicata, error) {
+	return s.revisarVinculo(id, models.VinculoRejeitado)
+}
+
+func (s *BankService) revisarVinculo(id uint, status string) (*models.VinculoDuplicata, error) {
+	var vinculo models.VinculoDuplicata
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&vinculo, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVinculoNaoEncontrado
+			}
+			return fmt.Errorf("erro ao consultar vínculo no banco: %w", err)
+		}
+		if vinculo.Status != models.VinculoPendente {
+			return fmt.Errorf("%w: situação %s", ErrVinculoRevisado, vinculo.Status)
+		}
+
+		if status == models.VinculoConfirmado {
+			if err := gravarVinculo(tx, &vinculo); err != nil {
+				return err
+			}
+			if err := tx.Model(&models.VinculoDuplicata{}).
+				Where("id <> ? AND status = ? AND (duplicata_id = ? OR boleto_id = ?)", vinculo.ID, models.VinculoPendente, vinculo.DuplicataID, vinculo.BoletoID).
+				Update("status", models.VinculoRejeitado).Error; err != nil {
+				return fmt.Errorf("erro ao salvar vínculos no banco: %w", err)
+			}
+		}
+
+		vinculo.Status = status
+		if err := tx.Omit(clause.Associations).Save(&vinculo).Error; err != nil {
+			return fmt.Errorf("erro ao salvar vínculo no banco: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"vinculo_id": id,
+		"status":     status,
+	}).Info("Vínculo de duplicata revisado")
+	return &vinculo, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/calendario"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+func TestAvaliarVinculo(t *testing.T) {
+	vencimento := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	nfe := &models.NFe{Numero: "000123", EmitenteCNPJ: "98.765.432/0001-98"}
+	duplicata := &models.Duplicata{Numero: "001", Vencimento: vencimento, Valor: 500}
+	cal := calendario.Nacional()
+
+	confianca, motivos, ok := avaliarVinculo(cal, nfe, duplicata, &models.Boleto{
+		Numero: "123-1", Valor: 500, Vencimento: vencimento, DocumentoBeneficiario: "98765432000198",
+	})
+	assert.True(t, ok)
+	assert.InDelta(t, 1.0, confianca, 0.001)
+	assert.Contains(t, motivos, "beneficiário é o emitente")
+
+	// A duplicata vence no domingo: o boleto de segunda tem o mesmo vencimento
+	confianca, _, ok = avaliarVinculo(cal, nfe, duplicata, &models.Boleto{
+		Valor: 500, Vencimento: vencimento.AddDate(0, 0, 1), DocumentoBeneficiario: "98765432000198",
+	})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.9, confianca, 0.001)
+
+	// Vencimento diferente, sem beneficiário e sem o número da NF-e
+	confianca, motivos, ok = avaliarVinculo(cal, nfe, duplicata, &models.Boleto{
+		Valor: 500, Vencimento: vencimento.AddDate(0, 0, 3),
+	})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.55, confianca, 0.001)
+	assert.Equal(t, []string{"valor igual", "vencimento a 2 dia(s)", "beneficiário não informado"}, motivos)
+
+	// Beneficiário de outra empresa ou valor fora da tolerância descartam o par
+	_, _, ok = avaliarVinculo(cal, nfe, duplicata, &models.Boleto{Valor: 500, Vencimento: vencimento, DocumentoBeneficiario: "11222333000181"})
+	assert.False(t, ok)
+	_, _, ok = avaliarVinculo(cal, nfe, duplicata, &models.Boleto{Valor: 510, Vencimento: vencimento})
+	assert.False(t, ok)
+}
+
+func TestVincularDuplicatas(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	vencimento := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	nfe := models.NFe{
+		ChaveAcesso:  "35231198765432000198550010000001231000001230",
+		Numero:       "123",
+		EmitenteCNPJ: "98765432000198",
+		Duplicatas: []models.Duplicata{
+			{Numero: "001", Vencimento: vencimento, Valor: 500},
+			{Numero: "002", Vencimento: vencimento.AddDate(0, 1, 0), Valor: 500},
+		},
+	}
+	assert.NoError(t, db.Create(&nfe).Error)
+
+	boletos := []models.Boleto{
+		// Mesmo valor das duas parcelas; o vencimento decide a duplicata
+		{Numero: "123/2", Valor: 500, Vencimento: vencimento.AddDate(0, 1, 0), DocumentoBeneficiario: "98765432000198", Status: models.StatusBoletoAberto},
+		{Numero: "123/1", Valor: 500, Vencimento: vencimento, DocumentoBeneficiario: "98765432000198", Status: models.StatusBoletoAberto},
+		// Sem beneficiário: vai para a revisão
+		{Valor: 500, Vencimento: vencimento.AddDate(0, 1, 3), Status: models.StatusBoletoAberto},
+	}
+	assert.NoError(t, db.Create(&boletos).Error)
+
+	// A consulta dos boletos da NF-e não grava vínculos
+	consultados, err := service.ConsultarBoletosPorNFe(nfe.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, consultados)
+	var gravados int64
+	assert.NoError(t, db.Model(&models.VinculoDuplicata{}).Count(&gravados).Error)
+	assert.Zero(t, gravados)
+
+	vinculos, err := service.VincularDuplicatas(nfe.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, vinculos, 2) {
+		for _, vinculo := range vinculos {
+			assert.Equal(t, models.VinculoAutomatico, vinculo.Status)
+		}
+	}
+
+	var boleto models.Boleto
+	assert.NoError(t, db.First(&boleto, boletos[1].ID).Error)
+	if assert.NotNil(t, boleto.DuplicataID) {
+		assert.Equal(t, nfe.Duplicatas[0].ID, *boleto.DuplicataID)
+	}
+	assert.Equal(t, nfe.ID, boleto.NFeID)
+
+	// Uma nova execução não repete vínculos
+	vinculos, err = service.VincularDuplicatas(nfe.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, vinculos)
+}
+
+func TestRevisarVinculo(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+
+	vencimento := time.Date(2023, 12, 10, 0, 0, 0, 0, time.UTC)
+	nfe := models.NFe{
+		ChaveAcesso: "35231198765432000198550010000001231000001230",
+		Numero:      "123",
+		Duplicatas:  []models.Duplicata{{Numero: "001", Vencimento: vencimento, Valor: 500}},
+	}
+	assert.NoError(t, db.Create(&nfe).Error)
+	boletos := []models.Boleto{
+		{Valor: 500, Vencimento: vencimento, Status: models.StatusBoletoAberto},
+		{Valor: 500, Vencimento: vencimento.AddDate(0, 0, 1), Status: models.StatusBoletoAberto},
+	}
+	assert.NoError(t, db.Create(&boletos).Error)
+
+	vinculos, err := service.VincularDuplicatas(nfe.ID)
+	assert.NoError(t, err)
+	if !assert.Len(t, vinculos, 1) {
+		return
+	}
+	assert.Equal(t, models.VinculoPendente, vinculos[0].Status)
+	assert.Equal(t, boletos[0].ID, vinculos[0].BoletoID)
+	assert.InDelta(t, 0.65, vinculos[0].Confianca, 0.001)
+
+	pendentes, err := service.ListarVinculos(models.VinculoPendente)
+	assert.NoError(t, err)
+	if assert.Len(t, pendentes, 1) {
+		assert.Equal(t, boletos[0].ID, pendentes[0].Boleto.ID)
+	}
+
+	// Rejeitado, o par não volta a ser sugerido e o próximo boleto entra na fila
+	_, err = service.RejeitarVinculo(vinculos[0].ID)
+	assert.NoError(t, err)
+	_, err = service.RejeitarVinculo(vinculos[0].ID)
+	assert.ErrorIs(t, err, ErrVinculoRevisado)
+
+	vinculos, err = service.VincularDuplicatas(nfe.ID)
+	assert.NoError(t, err)
+	if !assert.Len(t, vinculos, 1) {
+		return
+	}
+	assert.Equal(t, boletos[1].ID, vinculos[0].BoletoID)
+
+	confirmado, err := service.ConfirmarVinculo(vinculos[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.VinculoConfirmado, confirmado.Status)
+
+	var boleto models.Boleto
+	assert.NoError(t, db.First(&boleto, boletos[1].ID).Error)
+	if assert.NotNil(t, boleto.DuplicataID) {
+		assert.Equal(t, nfe.Duplicatas[0].ID, *boleto.DuplicataID)
+	}
+
+	_, err = service.ConfirmarVinculo(999)
+	assert.ErrorIs(t, err, ErrVinculoNaoEncontrado)
+}
+
+func TestVincularBoletoConsultado(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+	service.provedores.Registrar(&provedorLoteTeste{})
+
+	nfe := models.NFe{
+		ChaveAcesso:  "35231198765432000198550010000001231000001230",
+		Numero:       "123",
+		EmitenteCNPJ: "98765432000198",
+		Duplicatas:   []models.Duplicata{{Numero: "001", Vencimento: time.Date(2023, 11, 30, 0, 0, 0, 0, time.UTC), Valor: 250.75}},
+	}
+	assert.NoError(t, db.Create(&nfe).Error)
+
+	// O boleto novo, encontrado no provedor, é vinculado às duplicatas do beneficiário
+	_, err := service.ConsultarBoleto("34195955000000250751091234567800057123457000")
+	assert.NoError(t, err)
+	var vinculos []models.VinculoDuplicata
+	assert.NoError(t, db.Find(&vinculos).Error)
+	if assert.Len(t, vinculos, 1) {
+		assert.Equal(t, nfe.Duplicatas[0].ID, vinculos[0].DuplicataID)
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tamanhoMaximoWebhook limita o corpo lido das notificações
+const tamanhoMaximoWebhook = 1 << 20
+
+// ErrNotificacaoNaoEncontrada indica uma notificação de webhook inexistente
+var ErrNotificacaoNaoEncontrada = errors.New("notificação de webhook não encontrada")
+
+// ReceberWebhook autentica a notificação enviada pelo banco, guarda o corpo recebido
+// e aplica os eventos aos boletos. A reentrega de um corpo já recebido retorna a
+// notificação gravada, sem reaplicá-la
+func (s *BankService) ReceberWebhook(banco string, req *http.Request) (*models.NotificacaoWebhook, error) {
+	provedor, err := s.provedores.ProvedorWebhook(banco)
+	if err != nil {
+		return nil, err
+	}
+	corpo, err := io.ReadAll(io.LimitReader(req.Body, tamanhoMaximoWebhook+1))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler notificação: %w", err)
+	}
+	if len(corpo) > tamanhoMaximoWebhook {
+		return nil, fmt.Errorf("%w: corpo excede %d bytes", bancos.ErrWebhookInvalido, tamanhoMaximoWebhook)
+	}
+
+	requisicao := bancos.RequisicaoWebhook{
+		Cabecalhos:   req.Header,
+		Corpo:        corpo,
+		Certificados: bancos.CertificadosCliente(req, s.config.Bank.Webhook),
+	}
+	if err := provedor.AutenticarWebhook(requisicao); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"banco":  banco,
+			"origem": req.RemoteAddr,
+		}).Warn("Webhook bancário rejeitado")
+		return nil, err
+	}
+
+	hash := sha256.Sum256(corpo)
+	notificacao := models.NotificacaoWebhook{Banco: banco, Hash: hex.EncodeToString(hash[:]), Payload: string(corpo)}
+	resultado := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&notificacao)
+	if resultado.Error != nil {
+		return nil, fmt.Errorf("erro ao salvar notificação no banco: %w", resultado.Error)
+	}
+	if resultado.RowsAffected == 0 {
+		if err := s.db.Where("banco = ? AND hash = ?", banco, notificacao.Hash).First(&notificacao).Error; err != nil {
+			return nil, fmt.Errorf("erro ao consultar notificação no banco: %w", err)
+		}
+		s.logger.WithField("notificacao_id", notificacao.ID).Info("Reentrega de webhook bancário ignorada")
+		notificacao.Duplicada = true
+		return &notificacao, nil
+	}
+
+	return s.processarWebhook(provedor, &notificacao)
+}
+
+// ReprocessarWebhook aplica novamente uma notificação gravada, por exemplo depois que
+// o boleto não localizado foi cadastrado
+func (s *BankService) ReprocessarWebhook(id uint) (*models.NotificacaoWebhook, error) {
+	var notificacao models.NotificacaoWebhook
+	if err := s.db.First(&notificacao, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificacaoNaoEncontrada
+		}
+		return nil, fmt.Errorf("erro ao consultar notificação no banco: %w", err)
+	}
+	provedor, err := s.provedores.ProvedorWebhook(notificacao.Banco)
+	if err != nil {
+		return nil, err
+	}
+	return s.processarWebhook(provedor, &notificacao)
+}
+
+// ListarNotificacoesWebhook lista as notificações recebidas, das mais recentes para as
+// mais antigas; sem situação, lista todas
+func (s *BankService) ListarNotificacoesWebhook(status string) ([]models.NotificacaoWebhook, error) {
+	consulta := s.db.Order("id DESC")
+	if status != "" {
+		consulta = consulta.Where("status = ?", status)
+	}
+	var notificacoes []models.NotificacaoWebhook
+	if err := consulta.Find(&notificacoes).Error; err != nil {
+		return nil, fmt.Errorf("erro ao consultar notificações no banco: %w", err)
+	}
+	return notificacoes, nil
+}
+
+// processarWebhook lê os eventos do corpo gravado, atualiza os boletos e registra o
+// resultado na notificação. Um corpo não reconhecido fica como INVALIDO
+func (s *BankService) processarWebhook(provedor bancos.ProvedorWebhook, notificacao *models.NotificacaoWebhook) (*models.NotificacaoWebhook, error) {
+	agora := time.Now()
+	notificacao.ProcessadoEm = &agora
+	notificacao.Eventos, notificacao.Atualizados, notificacao.Erro = 0, 0, ""
+
+	eventos, err := provedor.LerWebhook([]byte(notificacao.Payload))
+	if err != nil {
+		s.logger.WithError(err).WithField("notificacao_id", notificacao.ID).Warn("Webhook bancário inválido")
+		notificacao.Status = models.WebhookInvalido
+		notificacao.Erro = err.Error()
+		if err := s.db.Save(notificacao).Error; err != nil {
+			return nil, fmt.Errorf("erro ao salvar notificação no banco: %w", err)
+		}
+		return notificacao, nil
+	}
+
+	notificacao.Eventos = len(eventos)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var pendentes []string
+		for _, evento := range eventos {
+			boleto, motivo, err := localizarBoletoNotificacao(tx, notificacao.Banco, evento)
+			if err != nil {
+				return fmt.Errorf("erro ao consultar boletos no banco: %w", err)
+			}
+			if boleto == nil {
+				pendentes = append(pendentes, identificacaoEvento(evento)+": "+motivo)
+				continue
+			}
+			if aplicarNotificacao(boleto, evento) {
+				if err := tx.Save(boleto).Error; err != nil {
+					return fmt.Errorf("erro ao salvar boleto no banco: %w", err)
+				}
+				notificacao.Atualizados++
+			}
+		}
+
+		notificacao.Status = models.WebhookProcessado
+		if len(pendentes) > 0 {
+			notificacao.Status = models.WebhookNaoConciliado
+			notificacao.Erro = strings.Join(pendentes, "; ")
+		}
+		return tx.Save(notificacao).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"notificacao_id": notificacao.ID,
+		"banco":          notificacao.Banco,
+		"eventos":        notificacao.Eventos,
+		"atualizados":    notificacao.Atualizados,
+	}).Info("Webhook bancário processado")
+	return notificacao, nil
+}
+
+// localizarBoletoNotificacao procura o boleto do evento pelo código de barras, pelo
+// nosso número e, por fim, pelo número do documento
+func localizarBoletoNotificacao(tx *gorm.DB, banco string, evento bancos.NotificacaoPagamento) (*models.Boleto, string, error) {
+	var boletos []models.Boleto
+	if evento.CodigoBarras != "" {
+		if err := tx.Where("codigo_barras = ?", evento.CodigoBarras).Find(&boletos).Error; err != nil {
+			return nil, "", err
+		}
+	}
+	if nossoNumero := strings.TrimLeft(evento.NossoNumero, "0"); len(boletos) == 0 && nossoNumero != "" {
+		if err := tx.Where("banco = ? AND LTRIM(nosso_numero, '0') = ?", banco, nossoNumero).Find(&boletos).Error; err != nil {
+			return nil, "", err
+		}
+	}
+	if len(boletos) == 0 && evento.SeuNumero != "" {
+		if err := tx.Where("banco = ? AND numero = ?", banco, evento.SeuNumero).Find(&boletos).Error; err != nil {
+			return nil, "", err
+		}
+	}
+
+	switch len(boletos) {
+	case 0:
+		return nil, "boleto não encontrado", nil
+	case 1:
+		return &boletos[0], "", nil
+	default:
+		return nil, fmt.Sprintf("evento corresponde a %d boletos", len(boletos)), nil
+	}
+}
+
+// identificacaoEvento descreve o evento nas pendências da notificação
+func identificacaoEvento(evento bancos.NotificacaoPagamento) string {
+	switch {
+	case evento.NossoNumero != "":
+		return "nosso número " + evento.NossoNumero
+	case evento.CodigoBarras != "":
+		return "código de barras " + evento.CodigoBarras
+	default:
+		return "seu número " + evento.SeuNumero
+	}
+}
+
+// aplicarNotificacao atualiza o boleto com o evento e informa se houve alteração. O
+// mesmo pagamento notificado de novo não altera o boleto, e uma baixa não desfaz um
+// pagamento já registrado
+func aplicarNotificacao(boleto *models.Boleto, evento bancos.NotificacaoPagamento) bool {
+	switch evento.Status {
+	case models.StatusBoletoPago:
+		valorPago := evento.ValorPago
+		if valorPago == 0 {
+			valorPago = boleto.Valor
+		}
+		mesmaData := evento.DataPagamento == nil ||
+			(boleto.DataPagamento != nil && boleto.DataPagamento.Equal(*evento.DataPagamento))
+		if boleto.Status == models.StatusBoletoPago && boleto.ValorPago != nil && *boleto.ValorPago == valorPago && mesmaData {
+			return false
+		}
+		boleto.Status = models.StatusBoletoPago
+		boleto.ValorPago = &valorPago
+		if evento.DataPagamento != nil {
+			boleto.DataPagamento = evento.DataPagamento
+		}
+		return true
+	case models.StatusBoletoBaixado:
+		if boleto.Status != models.StatusBoletoAberto {
+			return false
+		}
+		boleto.Status = models.StatusBoletoBaixado
+		return true
+	default:
+		return false
+	}
+}
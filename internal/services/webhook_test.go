@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/bancos"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/config"
+	"github.com/Douglaslessat/HelpDanfe-Go/internal/models"
+)
+
+// provedorWebhookTeste recebe os eventos como uma lista JSON de NotificacaoPagamento,
+// assinada com HMAC no cabeçalho X-Assinatura
+type provedorWebhookTeste struct{}
+
+func (p *provedorWebhookTeste) Nome() string           { return "webhook" }
+func (p *provedorWebhookTeste) Bancos() []string       { return []string{"341"} }
+func (p *provedorWebhookTeste) Timeout() time.Duration { return time.Second }
+
+func (p *provedorWebhookTeste) ConsultarBoleto(ctx context.Context, consulta bancos.Consulta) (*models.Boleto, error) {
+	return nil, bancos.ErrBoletoNaoEncontrado
+}
+
+func (p *provedorWebhookTeste) AutenticarWebhook(requisicao bancos.RequisicaoWebhook) error {
+	return bancos.AutenticarWebhook(requisicao, config.BankAPIConfig{WebhookSecret: "segredo"}, "X-Assinatura")
+}
+
+func (p *provedorWebhookTeste) LerWebhook(corpo []byte) ([]bancos.NotificacaoPagamento, error) {
+	var eventos []bancos.NotificacaoPagamento
+	if err := json.Unmarshal(corpo, &eventos); err != nil {
+		return nil, fmt.Errorf("%w: %v", bancos.ErrWebhookInvalido, err)
+	}
+	return eventos, nil
+}
+
+// requisicaoWebhook monta a notificação com a assinatura do segredo informado
+func requisicaoWebhook(corpo, segredo string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write([]byte(corpo))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/banco/341", strings.NewReader(corpo))
+	req.Header.Set("X-Assinatura", hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestReceberWebhook(t *testing.T) {
+	db := setupTestDB()
+	service := NewBankService(setupTestConfig(), db, logrus.New())
+	service.provedores.Registrar(&provedorWebhookTeste{})
+
+	pago := models.Boleto{Banco: "341", Numero: "00012345", NossoNumero: "00012345", Valor: 250.75,
+		CodigoBarras: "34195955000000250751091234567800057123457000", Status: models.StatusBoletoAberto, Emitido: true}
+	baixado := models.Boleto{Banco: "341", Numero: "00012346", NossoNumero: "00012346", Valor: 99.90, Status: models.StatusBoletoAberto, Emitido: true}
+	assert.NoError(t, db.Create(&pago).Error)
+	assert.NoError(t, db.Create(&baixado).Error)
+
+	corpo := `[
+		{"Evento":"1","CodigoBarras":"34195955000000250751091234567800057123457000","Status":"PAGO","ValorPago":253.26,"DataPagamento":"2023-12-04T00:00:00Z"},
+		{"Evento":"1","NossoNumero":"12346","Status":"BAIXADO"},
+		{"Evento":"1","NossoNumero":"99999","Status":"PAGO","ValorPago":10}
+	]`
+
+	_, err := service.ReceberWebhook("237", requisicaoWebhook(corpo, "segredo"))
+	assert.ErrorIs(t, err, bancos.ErrWebhookNaoSuportado)
+	_, err = service.ReceberWebhook("341", requisicaoWebhook(corpo, "outro"))
+	assert.ErrorIs(t, err, bancos.ErrWebhookNaoAutenticado)
+
+	notificacao, err := service.ReceberWebhook("341", requisicaoWebhook(corpo, "segredo"))
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookNaoConciliado, notificacao.Status)
+	assert.Equal(t, 3, notificacao.Eventos)
+	assert.Equal(t, 2, notificacao.Atualizados)
+	assert.Equal(t, "nosso número 99999: boleto não encontrado", notificacao.Erro)
+	assert.Equal(t, corpo, notificacao.Payload)
+
+	assert.NoError(t, db.First(&pago, pago.ID).Error)
+	assert.Equal(t, models.StatusBoletoPago, pago.Status)
+	assert.Equal(t, 253.26, *pago.ValorPago)
+	assert.Equal(t, "2023-12-04", pago.DataPagamento.Format(time.DateOnly))
+	assert.NoError(t, db.First(&baixado, baixado.ID).Error)
+	assert.Equal(t, models.StatusBoletoBaixado, baixado.Status)
+
+	// A reentrega do mesmo corpo não é reaplicada
+	reentrega, err := service.ReceberWebhook("341", requisicaoWebhook(corpo, "segredo"))
+	assert.NoError(t, err)
+	assert.True(t, reentrega.Duplicada)
+	assert.Equal(t, notificacao.ID, reentrega.ID)
+	var total int64
+	db.Model(&models.NotificacaoWebhook{}).Count(&total)
+	assert.Equal(t, int64(1), total)
+
+	// Com o boleto cadastrado, o reprocessamento concilia só o evento pendente
+	pendente := models.Boleto{Banco: "341", Numero: "00099999", NossoNumero: "00099999", Valor: 10, Status: models.StatusBoletoAberto}
+	assert.NoError(t, db.Create(&pendente).Error)
+	notificacao, err = service.ReprocessarWebhook(notificacao.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookProcessado, notificacao.Status)
+	assert.Equal(t, 1, notificacao.Atualizados)
+	assert.Empty(t, notificacao.Erro)
+
+	_, err = service.ReprocessarWebhook(999)
+	assert.ErrorIs(t, err, ErrNotificacaoNaoEncontrada)
+
+	// O corpo não reconhecido é guardado para auditoria
+	invalida, err := service.ReceberWebhook("341", requisicaoWebhook(`{"evento":`, "segredo"))
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookInvalido, invalida.Status)
+	assert.Contains(t, invalida.Erro, bancos.ErrWebhookInvalido.Error())
+
+	notificacoes, err := service.ListarNotificacoesWebhook(models.WebhookInvalido)
+	assert.NoError(t, err)
+	assert.Len(t, notificacoes, 1)
+	notificacoes, err = service.ListarNotificacoesWebhook("")
+	assert.NoError(t, err)
+	assert.Len(t, notificacoes, 2)
+}
+
+func TestAplicarNotificacao(t *testing.T) {
+	pagamento := time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC)
+	valor := 253.26
+	boleto := &models.Boleto{Valor: 250.75, Status: models.StatusBoletoPago, ValorPago: &valor, DataPagamento: &pagamento}
+
+	// Pagamento repetido e baixa após o pagamento não alteram o boleto
+	assert.False(t, aplicarNotificacao(boleto, bancos.NotificacaoPagamento{Status: models.StatusBoletoPago, ValorPago: 253.26, DataPagamento: &pagamento}))
+	assert.False(t, aplicarNotificacao(boleto, bancos.NotificacaoPagamento{Status: models.StatusBoletoBaixado}))
+	assert.Equal(t, models.StatusBoletoPago, boleto.Status)
+
+	// Pagamento sem valor usa o valor do título
+	aberto := &models.Boleto{Valor: 99.90, Status: models.StatusBoletoAberto}
+	assert.True(t, aplicarNotificacao(aberto, bancos.NotificacaoPagamento{Status: models.StatusBoletoPago}))
+	assert.Equal(t, 99.90, *aberto.ValorPago)
+	assert.False(t, aplicarNotificacao(aberto, bancos.NotificacaoPagamento{Status: models.StatusBoletoAberto}))
+}
@@ -0,0 +1,347 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipos de código de boleto reconhecidos pelo parser
+const (
+	// TipoCodigoBancario é o boleto de cobrança bancária (44 dígitos / linha de 47)
+	TipoCodigoBancario = "BANCARIO"
+	// TipoCodigoArrecadacao é o boleto de arrecadação/convênio (44 dígitos / linha de 48)
+	TipoCodigoArrecadacao = "ARRECADACAO"
+)
+
+// Segmentos de arrecadação (posição 2 do código de barras)
+const (
+	SegmentoPrefeituras    = "1"
+	SegmentoSaneamento     = "2"
+	SegmentoEnergiaGas     = "3"
+	SegmentoTelecom        = "4"
+	SegmentoOrgaosGov      = "5"
+	SegmentoCarnes         = "6"
+	SegmentoMultasTransito = "7"
+	SegmentoUsoBanco       = "9"
+)
+
+var descricaoSegmentos = map[string]string{
+	SegmentoPrefeituras:    "Prefeituras",
+	SegmentoSaneamento:     "Saneamento",
+	SegmentoEnergiaGas:     "Energia elétrica e gás",
+	SegmentoTelecom:        "Telecomunicações",
+	SegmentoOrgaosGov:      "Órgãos governamentais",
+	SegmentoCarnes:         "Carnês e assemelhados",
+	SegmentoMultasTransito: "Multas de trânsito",
+	SegmentoUsoBanco:       "Uso exclusivo do banco",
+}
+
+// ErrCodigoBoletoInvalido é a causa comum de todos os erros de validação de código de boleto
+var ErrCodigoBoletoInvalido = errors.New("código de boleto inválido")
+
+// ErroCodigoBoleto descreve precisamente por que um código de boleto é inválido
+type ErroCodigoBoleto struct {
+	Campo  string
+	Motivo string
+}
+
+// Error implementa a interface error
+func (e *ErroCodigoBoleto) Error() string {
+	if e.Campo == "" {
+		return fmt.Sprintf("%s: %s", ErrCodigoBoletoInvalido, e.Motivo)
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrCodigoBoletoInvalido, e.Campo, e.Motivo)
+}
+
+// Is permite comparar com ErrCodigoBoletoInvalido usando errors.Is
+func (e *ErroCodigoBoleto) Is(target error) bool {
+	return target == ErrCodigoBoletoInvalido
+}
+
+// CodigoBoleto representa um código de boleto decodificado e validado
+type CodigoBoleto struct {
+	Tipo string `json:"tipo"`
+	// CodigoBarras são os 44 dígitos do código de barras
+	CodigoBarras string `json:"codigo_barras"`
+	// LinhaDigitavel é a linha digitável formatada (47 ou 48 dígitos)
+	LinhaDigitavel string `json:"linha_digitavel"`
+
+	// Campos do boleto bancário
+	Banco           string     `json:"banco,omitempty"`
+	Moeda           string     `json:"moeda,omitempty"`
+	FatorVencimento int        `json:"fator_vencimento,omitempty"`
+	Vencimento      *time.Time `json:"vencimento,omitempty"`
+	CampoLivre      string     `json:"campo_livre,omitempty"`
+
+	// Campos do boleto de arrecadação
+	Segmento          string `json:"segmento,omitempty"`
+	SegmentoDescricao string `json:"segmento_descricao,omitempty"`
+	// IdentificadorValor é o dígito 6, 7, 8 ou 9 que define o módulo do DV e o tipo do valor
+	IdentificadorValor string `json:"identificador_valor,omitempty"`
+	// ValorReferencia indica que o campo de valor traz uma referência, não o valor em reais
+	ValorReferencia bool `json:"valor_referencia,omitempty"`
+	// Convenio identifica a empresa/órgão: 4 dígitos (código FEBRABAN) ou, no
+	// segmento 6, os 8 primeiros dígitos do CNPJ
+	Convenio string `json:"convenio,omitempty"`
+
+	// Valor do documento; em arrecadação só é preenchido quando o código traz valor efetivo
+	Valor float64 `json:"valor"`
+}
+
+// DescricaoSegmento retorna o nome do segmento de arrecadação
+func DescricaoSegmento(segmento string) string {
+	return descricaoSegmentos[segmento]
+}
+
+// NormalizarCodigoBoleto remove a pontuação usual (espaços, pontos, hífens e barras)
+func NormalizarCodigoBoleto(codigo string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '/', '\t', '\n', '\r':
+			return -1
+		}
+		return r
+	}, codigo)
+}
+
+// PareceCodigoBoleto informa se o texto tem a forma de um código de barras ou linha
+// digitável (apenas dígitos e pontuação, entre 40 e 50 dígitos), independente dos DVs
+func PareceCodigoBoleto(codigo string) bool {
+	normalizado := NormalizarCodigoBoleto(codigo)
+	return len(normalizado) >= 40 && len(normalizado) <= 50 && apenasDigitos(normalizado) == normalizado
+}
+
+// ParseCodigoBoleto decodifica um código de barras de 44 dígitos, uma linha digitável
+// bancária de 47 dígitos ou uma linha de arrecadação de 48 dígitos, com ou sem
+// pontuação, validando todos os dígitos verificadores. O vencimento é resolvido na
+// janela do fator de vencimento em torno da data atual
+func ParseCodigoBoleto(codigo string) (*CodigoBoleto, error) {
+	return ParseCodigoBoletoEm(codigo, time.Now())
+}
+
+// ParseCodigoBoletoEm decodifica o código como ParseCodigoBoleto, resolvendo o fator
+// de vencimento em torno da data de referência (por exemplo, a data de emissão)
+func ParseCodigoBoletoEm(codigo string, referencia time.Time) (*CodigoBoleto, error) {
+	normalizado := NormalizarCodigoBoleto(codigo)
+	if normalizado == "" {
+		return nil, &ErroCodigoBoleto{Motivo: "código vazio"}
+	}
+	if apenasDigitos(normalizado) != normalizado {
+		return nil, &ErroCodigoBoleto{Motivo: "deve conter apenas dígitos"}
+	}
+
+	switch len(normalizado) {
+	case 44:
+		if normalizado[0] == '8' {
+			return parseArrecadacao(normalizado)
+		}
+		return parseBancario(normalizado, referencia)
+	case 47:
+		codigoBarras, err := linhaBancariaParaCodigoBarras(normalizado)
+		if err != nil {
+			return nil, err
+		}
+		return parseBancario(codigoBarras, referencia)
+	case 48:
+		codigoBarras, err := linhaArrecadacaoParaCodigoBarras(normalizado)
+		if err != nil {
+			return nil, err
+		}
+		return parseArrecadacao(codigoBarras)
+	default:
+		return nil, &ErroCodigoBoleto{Motivo: fmt.Sprintf("tamanho %d inválido; esperado 44 (código de barras), 47 (linha digitável) ou 48 (arrecadação)", len(normalizado))}
+	}
+}
+
+// ParaCodigoBarras converte qualquer formato aceito no código de barras de 44 dígitos
+func ParaCodigoBarras(codigo string) (string, error) {
+	parsed, err := ParseCodigoBoleto(codigo)
+	if err != nil {
+		return "", err
+	}
+	return parsed.CodigoBarras, nil
+}
+
+// ParaLinhaDigitavel converte qualquer formato aceito na linha digitável formatada
+func ParaLinhaDigitavel(codigo string) (string, error) {
+	parsed, err := ParseCodigoBoleto(codigo)
+	if err != nil {
+		return "", err
+	}
+	return parsed.LinhaDigitavel, nil
+}
+
+// parseBancario valida e decodifica um código de barras bancário de 44 dígitos
+func parseBancario(codigoBarras string, referencia time.Time) (*CodigoBoleto, error) {
+	if codigoBarras[3:4] != CodigoMoedaReal {
+		return nil, &ErroCodigoBoleto{Campo: "moeda", Motivo: fmt.Sprintf("código de moeda %s não suportado", codigoBarras[3:4])}
+	}
+
+	dvInformado := int(codigoBarras[4] - '0')
+	dvCalculado := DVCodigoBarras(codigoBarras[:4] + codigoBarras[5:])
+	if dvInformado != dvCalculado {
+		return nil, &ErroCodigoBoleto{Campo: "DV geral", Motivo: fmt.Sprintf("esperado %d, informado %d", dvCalculado, dvInformado)}
+	}
+
+	// Fator zero indica boleto sem vencimento; os demais começam em 1000
+	fator, _ := strconv.Atoi(codigoBarras[5:9])
+	if fator > 0 && fator < fatorMinimo {
+		return nil, &ErroCodigoBoleto{Campo: "fator de vencimento", Motivo: fmt.Sprintf("fator %04d fora do intervalo de %d a %d", fator, fatorMinimo, fatorMaximo)}
+	}
+	centavos, _ := strconv.ParseInt(codigoBarras[9:19], 10, 64)
+
+	linha, err := LinhaDigitavel(codigoBarras)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &CodigoBoleto{
+		Tipo:            TipoCodigoBancario,
+		CodigoBarras:    codigoBarras,
+		LinhaDigitavel:  linha,
+		Banco:           codigoBarras[0:3],
+		Moeda:           codigoBarras[3:4],
+		FatorVencimento: fator,
+		Valor:           float64(centavos) / 100,
+		CampoLivre:      codigoBarras[19:44],
+	}
+	if fator > 0 {
+		vencimento := DataVencimentoEm(fator, referencia)
+		parsed.Vencimento = &vencimento
+	}
+	return parsed, nil
+}
+
+// linhaBancariaParaCodigoBarras valida os DVs dos campos da linha digitável de
+// 47 dígitos e a converte no código de barras
+func linhaBancariaParaCodigoBarras(linha string) (string, error) {
+	campos := []struct {
+		nome   string
+		dados  string
+		digito byte
+	}{
+		{"campo 1", linha[0:9], linha[9]},
+		{"campo 2", linha[10:20], linha[20]},
+		{"campo 3", linha[21:31], linha[31]},
+	}
+	for _, c := range campos {
+		esperado := Modulo10(c.dados)
+		if informado := int(c.digito - '0'); informado != esperado {
+			return "", &ErroCodigoBoleto{Campo: "DV do " + c.nome, Motivo: fmt.Sprintf("esperado %d, informado %d", esperado, informado)}
+		}
+	}
+
+	return linha[0:4] + linha[32:33] + linha[33:47] + linha[4:9] + linha[10:20] + linha[21:31], nil
+}
+
+// parseArrecadacao valida e decodifica um código de barras de arrecadação de 44 dígitos
+func parseArrecadacao(codigoBarras string) (*CodigoBoleto, error) {
+	if codigoBarras[0] != '8' {
+		return nil, &ErroCodigoBoleto{Campo: "produto", Motivo: "código de arrecadação deve iniciar com 8"}
+	}
+
+	segmento := codigoBarras[1:2]
+	if _, ok := descricaoSegmentos[segmento]; !ok {
+		return nil, &ErroCodigoBoleto{Campo: "segmento", Motivo: fmt.Sprintf("segmento %s inválido", segmento)}
+	}
+
+	identificador := codigoBarras[2]
+	dvModulo, err := dvArrecadacao(identificador)
+	if err != nil {
+		return nil, err
+	}
+
+	dvInformado := int(codigoBarras[3] - '0')
+	dvCalculado := dvModulo(codigoBarras[:3] + codigoBarras[4:])
+	if dvInformado != dvCalculado {
+		return nil, &ErroCodigoBoleto{Campo: "DV geral", Motivo: fmt.Sprintf("esperado %d, informado %d", dvCalculado, dvInformado)}
+	}
+
+	parsed := &CodigoBoleto{
+		Tipo:               TipoCodigoArrecadacao,
+		CodigoBarras:       codigoBarras,
+		LinhaDigitavel:     linhaArrecadacao(codigoBarras, dvModulo),
+		Segmento:           segmento,
+		SegmentoDescricao:  descricaoSegmentos[segmento],
+		IdentificadorValor: string(identificador),
+	}
+
+	// O segmento 6 identifica a empresa pelo CNPJ (8 dígitos); os demais, pelo
+	// código de 4 dígitos atribuído pela FEBRABAN
+	if segmento == SegmentoCarnes {
+		parsed.Convenio = codigoBarras[15:23]
+		parsed.CampoLivre = codigoBarras[23:44]
+	} else {
+		parsed.Convenio = codigoBarras[15:19]
+		parsed.CampoLivre = codigoBarras[19:44]
+	}
+
+	// Identificadores 6 e 8 indicam valor efetivo em reais; 7 e 9, valor de referência
+	if identificador == '6' || identificador == '8' {
+		centavos, _ := strconv.ParseInt(codigoBarras[4:15], 10, 64)
+		parsed.Valor = float64(centavos) / 100
+	} else {
+		parsed.ValorReferencia = true
+	}
+	return parsed, nil
+}
+
+// linhaArrecadacaoParaCodigoBarras valida os DVs dos quatro blocos da linha de
+// arrecadação de 48 dígitos e a converte no código de barras
+func linhaArrecadacaoParaCodigoBarras(linha string) (string, error) {
+	if linha[0] != '8' {
+		return "", &ErroCodigoBoleto{Campo: "produto", Motivo: "linha de 48 dígitos deve iniciar com 8 (arrecadação)"}
+	}
+
+	dvModulo, err := dvArrecadacao(linha[2])
+	if err != nil {
+		return "", err
+	}
+
+	var codigoBarras strings.Builder
+	for bloco := 0; bloco < 4; bloco++ {
+		dados := linha[bloco*12 : bloco*12+11]
+		informado := int(linha[bloco*12+11] - '0')
+		if esperado := dvModulo(dados); informado != esperado {
+			return "", &ErroCodigoBoleto{Campo: fmt.Sprintf("DV do bloco %d", bloco+1), Motivo: fmt.Sprintf("esperado %d, informado %d", esperado, informado)}
+		}
+		codigoBarras.WriteString(dados)
+	}
+	return codigoBarras.String(), nil
+}
+
+// linhaArrecadacao formata a linha de arrecadação de 48 dígitos a partir do código de barras
+func linhaArrecadacao(codigoBarras string, dvModulo func(string) int) string {
+	blocos := make([]string, 4)
+	for i := range blocos {
+		dados := codigoBarras[i*11 : i*11+11]
+		blocos[i] = dados + "-" + strconv.Itoa(dvModulo(dados))
+	}
+	return strings.Join(blocos, " ")
+}
+
+// dvArrecadacao retorna o cálculo de DV indicado pelo identificador de valor:
+// 6 e 7 usam módulo 10, 8 e 9 usam módulo 11
+func dvArrecadacao(identificador byte) (func(string) int, error) {
+	switch identificador {
+	case '6', '7':
+		return Modulo10, nil
+	case '8', '9':
+		return DVModulo11Arrecadacao, nil
+	default:
+		return nil, &ErroCodigoBoleto{Campo: "identificador de valor", Motivo: fmt.Sprintf("%c inválido; esperado 6, 7, 8 ou 9", identificador)}
+	}
+}
+
+// DVModulo11Arrecadacao calcula o DV módulo 11 (pesos 2 a 9) da arrecadação:
+// restos 0 e 1 resultam em 0
+func DVModulo11Arrecadacao(numero string) int {
+	resto := RestoModulo11(numero, 9)
+	if resto <= 1 {
+		return 0
+	}
+	return 11 - resto
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodigoBoleto(t *testing.T) {
+	casos := []struct {
+		nome           string
+		codigo         string
+		tipo           string
+		codigoBarras   string
+		linhaDigitavel string
+		banco          string
+		valor          float64
+		vencimento     string
+		// referencia é a data de leitura do código; vazia usa a data atual
+		referencia time.Time
+	}{
+		{
+			nome:           "código de barras bancário",
+			codigo:         "00193373700000001000500940144816060680935031",
+			tipo:           TipoCodigoBancario,
+			codigoBarras:   "00193373700000001000500940144816060680935031",
+			linhaDigitavel: "00190.50095 40144.816069 06809.350314 3 37370000000100",
+			banco:          "001",
+			valor:          1.00,
+			vencimento:     "2007-12-31",
+			referencia:     time.Date(2007, time.December, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			nome:           "linha digitável com pontuação",
+			codigo:         "34191.09123 34567.800056 71234.570001 5 95500000025075",
+			tipo:           TipoCodigoBancario,
+			codigoBarras:   "34195955000000250751091234567800057123457000",
+			linhaDigitavel: "34191.09123 34567.800056 71234.570001 5 95500000025075",
+			banco:          "341",
+			valor:          250.75,
+			vencimento:     "2023-11-30",
+		},
+		{
+			nome:           "linha digitável sem pontuação",
+			codigo:         "23793381029000000123145001234504690780000009990",
+			tipo:           TipoCodigoBancario,
+			codigoBarras:   "23796907800000099903381090000001234500123450",
+			linhaDigitavel: "23793.38102 90000.001231 45001.234504 6 90780000009990",
+			banco:          "237",
+			valor:          99.90,
+			vencimento:     "2022-08-15",
+		},
+		{
+			nome:           "arrecadação módulo 10",
+			codigo:         "82600000001-6 23450058202-3 41030000000-8 00012345678-2",
+			tipo:           TipoCodigoArrecadacao,
+			codigoBarras:   "82600000001234500582024103000000000012345678",
+			linhaDigitavel: "82600000001-6 23450058202-3 41030000000-8 00012345678-2",
+			valor:          123.45,
+		},
+		{
+			nome:           "arrecadação módulo 11 pelo código de barras",
+			codigo:         "85870000009876500011234567890123456789012345",
+			tipo:           TipoCodigoArrecadacao,
+			codigoBarras:   "85870000009876500011234567890123456789012345",
+			linhaDigitavel: "85870000009-0 87650001123-1 45678901234-1 56789012345-7",
+			valor:          987.65,
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			referencia := caso.referencia
+			if referencia.IsZero() {
+				referencia = time.Now()
+			}
+			parsed, err := ParseCodigoBoletoEm(caso.codigo, referencia)
+			assert.NoError(t, err)
+			assert.Equal(t, caso.tipo, parsed.Tipo)
+			assert.Equal(t, caso.codigoBarras, parsed.CodigoBarras)
+			assert.Equal(t, caso.linhaDigitavel, parsed.LinhaDigitavel)
+			assert.Equal(t, caso.banco, parsed.Banco)
+			assert.InDelta(t, caso.valor, parsed.Valor, 0.001)
+			if caso.vencimento != "" {
+				assert.Equal(t, caso.vencimento, parsed.Vencimento.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestParseCodigoBoletoInvalido(t *testing.T) {
+	casos := map[string]string{
+		"00193373700000001000500940144816060680935032":            "DV geral",
+		"00196050000000001000500940144816060680935031":            "fator de vencimento",
+		"00190.50096 40144.816069 06809.350314 3 37370000000100":  "DV do campo 1",
+		"00190.50095 40144.816068 06809.350314 3 37370000000100":  "DV do campo 2",
+		"82600000001-6 23450058202-4 41030000000-8 00012345678-2": "DV do bloco 2",
+		"00193373700000001000500940144816060680935":               "tamanho 41",
+		"0019337370000000100050094014481606068093503A":            "apenas dígitos",
+	}
+
+	for codigo, motivo := range casos {
+		_, err := ParseCodigoBoleto(codigo)
+		assert.ErrorIs(t, err, ErrCodigoBoletoInvalido, codigo)
+		assert.Contains(t, err.Error(), motivo)
+	}
+
+	// Fator zero é o boleto sem vencimento
+	parsed, err := ParseCodigoBoleto("00198000000000001000500940144816060680935031")
+	assert.NoError(t, err)
+	assert.Zero(t, parsed.FatorVencimento)
+	assert.Nil(t, parsed.Vencimento)
+}
+
+func TestParseCodigoArrecadacaoSegmento(t *testing.T) {
+	concessionaria, err := ParseCodigoBoleto("82600000001-6 23450058202-3 41030000000-8 00012345678-2")
+	assert.NoError(t, err)
+	assert.Equal(t, SegmentoSaneamento, concessionaria.Segmento)
+	assert.Equal(t, "Saneamento", concessionaria.SegmentoDescricao)
+	assert.Equal(t, "6", concessionaria.IdentificadorValor)
+	assert.False(t, concessionaria.ValorReferencia)
+	assert.Equal(t, "0058", concessionaria.Convenio)
+	assert.Equal(t, "2024103000000000012345678", concessionaria.CampoLivre)
+
+	// Segmento 6 identifica a empresa pelo CNPJ; identificador 7 traz valor de referência
+	carne, err := ParseCodigoBoleto("86740000000-8 15001234567-7 80000000000-3 09876543210-3")
+	assert.NoError(t, err)
+	assert.Equal(t, SegmentoCarnes, carne.Segmento)
+	assert.Equal(t, "12345678", carne.Convenio)
+	assert.True(t, carne.ValorReferencia)
+	assert.Zero(t, carne.Valor)
+	assert.Equal(t, "86740000000150012345678000000000009876543210", carne.CodigoBarras)
+
+	_, err = ParseCodigoBoleto("80600000001234500582024103000000000012345678")
+	assert.ErrorIs(t, err, ErrCodigoBoletoInvalido)
+	assert.Contains(t, err.Error(), "segmento")
+}
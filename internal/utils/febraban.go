@@ -0,0 +1,546 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Códigos COMPE dos bancos com composição de campo livre suportada
+const (
+	BancoBrasil    = "001"
+	BancoSantander = "033"
+	BancoCaixa     = "104"
+	BancoBradesco  = "237"
+	BancoItau      = "341"
+	BancoSicredi   = "748"
+	BancoSicoob    = "756"
+)
+
+// CodigoMoedaReal é o código de moeda do Real no código de barras
+const CodigoMoedaReal = "9"
+
+// ErrBancoNaoSuportado indica que não há composição de campo livre para o banco
+var ErrBancoNaoSuportado = errors.New("banco não suportado para geração de código de barras")
+
+// dataBaseFator é a data base do fator de vencimento FEBRABAN (fator 0)
+var dataBaseFator = time.Date(1997, time.October, 7, 0, 0, 0, 0, time.UTC)
+
+// Regras do fator de vencimento: ao atingir 9999 (21/02/2025) o fator volta a 1000
+// em 22/02/2025, e o ciclo se repete a cada 9000 dias
+const (
+	fatorMinimo = 1000
+	fatorMaximo = 9999
+	cicloFator  = fatorMaximo - fatorMinimo + 1
+	// janelaFatorPassado é quantos dias antes da data de referência um fator ainda é
+	// interpretado no passado; os demais dias do ciclo ficam à frente da referência
+	janelaFatorPassado = 3000
+)
+
+// DadosBoleto contém os dados necessários para compor o código de barras de um boleto
+type DadosBoleto struct {
+	Banco              string
+	Vencimento         *time.Time
+	Valor              float64
+	Agencia            string
+	Conta              string
+	Carteira           string
+	NossoNumero        string
+	CodigoBeneficiario string
+	// Modalidade de cobrança (Sicoob); padrão "01"
+	Modalidade string
+	// Parcela do título (Sicoob); padrão "001"
+	Parcela string
+	// Posto da cooperativa (Sicredi)
+	Posto string
+}
+
+// composicaoCampoLivre monta os 25 dígitos do campo livre de um banco
+type composicaoCampoLivre func(d DadosBoleto) (string, error)
+
+// camposLivres associa cada banco suportado à sua composição de campo livre
+var camposLivres = map[string]composicaoCampoLivre{
+	BancoBrasil:    campoLivreBancoBrasil,
+	BancoSantander: campoLivreSantander,
+	BancoCaixa:     campoLivreCaixa,
+	BancoBradesco:  campoLivreBradesco,
+	BancoItau:      campoLivreItau,
+	BancoSicredi:   campoLivreSicredi,
+	BancoSicoob:    campoLivreSicoob,
+}
+
+// BancoSuportado informa se há composição de campo livre para o banco
+func BancoSuportado(banco string) bool {
+	_, ok := camposLivres[banco]
+	return ok
+}
+
+// Modulo10 calcula o dígito verificador módulo 10 (pesos 2 e 1 a partir da direita)
+func Modulo10(numero string) int {
+	soma := 0
+	peso := 2
+	for i := len(numero) - 1; i >= 0; i-- {
+		produto := int(numero[i]-'0') * peso
+		soma += produto/10 + produto%10
+		if peso == 2 {
+			peso = 1
+		} else {
+			peso = 2
+		}
+	}
+	return (10 - soma%10) % 10
+}
+
+// RestoModulo11 calcula o resto da divisão por 11 da soma ponderada com pesos
+// de 2 até pesoMaximo, aplicados ciclicamente a partir da direita
+func RestoModulo11(numero string, pesoMaximo int) int {
+	soma := 0
+	peso := 2
+	for i := len(numero) - 1; i >= 0; i-- {
+		soma += int(numero[i]-'0') * peso
+		peso++
+		if peso > pesoMaximo {
+			peso = 2
+		}
+	}
+	return soma % 11
+}
+
+// DVCodigoBarras calcula o dígito verificador geral do código de barras (módulo 11,
+// pesos 2 a 9) sobre os 43 dígitos sem o DV; resultados 0, 10 e 11 viram 1
+func DVCodigoBarras(codigoSemDV string) int {
+	dv := 11 - RestoModulo11(codigoSemDV, 9)
+	if dv == 0 || dv == 10 || dv == 11 {
+		return 1
+	}
+	return dv
+}
+
+// FatorVencimento calcula o fator de vencimento FEBRABAN de uma data, considerando
+// o reinício do fator em 1000 a cada ciclo de 9000 dias (o primeiro em 22/02/2025)
+func FatorVencimento(data time.Time) (int, error) {
+	dias := diasDesdeBaseFator(data)
+	if dias < fatorMinimo {
+		return 0, fmt.Errorf("data %s fora do intervalo do fator de vencimento", data.Format("02/01/2006"))
+	}
+	return fatorMinimo + (dias-fatorMinimo)%cicloFator, nil
+}
+
+// DataVencimento converte um fator de vencimento FEBRABAN na data correspondente,
+// usando a data atual como referência da janela deslizante
+func DataVencimento(fator int) time.Time {
+	return DataVencimentoEm(fator, time.Now())
+}
+
+// DataVencimentoEm converte um fator de vencimento na data correspondente dentro da
+// janela deslizante em torno da referência: de 3000 dias antes a 5999 dias depois.
+// Assim o mesmo fator é resolvido para o ciclo anterior ou para o atual conforme a
+// data em que o código é lido
+func DataVencimentoEm(fator int, referencia time.Time) time.Time {
+	inicioJanela := diasDesdeBaseFator(referencia) - janelaFatorPassado
+	dias := fator
+	if dias < inicioJanela {
+		ciclos := (inicioJanela - dias + cicloFator - 1) / cicloFator
+		dias += ciclos * cicloFator
+	}
+	return dataBaseFator.AddDate(0, 0, dias)
+}
+
+// diasDesdeBaseFator conta os dias corridos entre a data base do fator e a data
+func diasDesdeBaseFator(data time.Time) int {
+	dia := time.Date(data.Year(), data.Month(), data.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dia.Sub(dataBaseFator).Hours() / 24)
+}
+
+// MontarCodigoBarras compõe o código de barras de 44 dígitos: banco (3), moeda (1),
+// DV geral (1), fator de vencimento (4), valor (10) e campo livre do banco (25)
+func MontarCodigoBarras(d DadosBoleto) (string, error) {
+	composicao, ok := camposLivres[d.Banco]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrBancoNaoSuportado, d.Banco)
+	}
+
+	fator := 0
+	if d.Vencimento != nil {
+		var err error
+		if fator, err = FatorVencimento(*d.Vencimento); err != nil {
+			return "", err
+		}
+	}
+
+	valor, err := formatarValorCodigo(d.Valor)
+	if err != nil {
+		return "", err
+	}
+
+	campoLivre, err := composicao(d)
+	if err != nil {
+		return "", fmt.Errorf("erro ao montar campo livre do banco %s: %w", d.Banco, err)
+	}
+	if len(campoLivre) != 25 {
+		return "", fmt.Errorf("campo livre do banco %s com %d dígitos", d.Banco, len(campoLivre))
+	}
+
+	semDV := d.Banco + CodigoMoedaReal + fmt.Sprintf("%04d", fator) + valor + campoLivre
+	dv := DVCodigoBarras(semDV)
+	return semDV[:4] + strconv.Itoa(dv) + semDV[4:], nil
+}
+
+// AlterarVencimentoCodigoBarras troca o fator de vencimento do código de barras
+// bancário e recalcula o DV geral; valor e campo livre são mantidos
+func AlterarVencimentoCodigoBarras(codigoBarras string, vencimento time.Time) (string, error) {
+	if !somenteDigitos(codigoBarras, 44) {
+		return "", errors.New("código de barras deve ter 44 dígitos")
+	}
+	fator, err := FatorVencimento(vencimento)
+	if err != nil {
+		return "", err
+	}
+
+	semDV := codigoBarras[0:4] + fmt.Sprintf("%04d", fator) + codigoBarras[9:44]
+	return semDV[:4] + strconv.Itoa(DVCodigoBarras(semDV)) + semDV[4:], nil
+}
+
+// LinhaDigitavel converte um código de barras de 44 dígitos na linha digitável de
+// 47 dígitos, formatada em cinco campos com os DVs módulo 10 dos três primeiros
+func LinhaDigitavel(codigoBarras string) (string, error) {
+	if !somenteDigitos(codigoBarras, 44) {
+		return "", errors.New("código de barras deve ter 44 dígitos")
+	}
+
+	campo1 := codigoBarras[0:4] + codigoBarras[19:24]
+	campo2 := codigoBarras[24:34]
+	campo3 := codigoBarras[34:44]
+	campo1 += strconv.Itoa(Modulo10(campo1))
+	campo2 += strconv.Itoa(Modulo10(campo2))
+	campo3 += strconv.Itoa(Modulo10(campo3))
+
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		campo1[:5], campo1[5:],
+		campo2[:5], campo2[5:],
+		campo3[:5], campo3[5:],
+		codigoBarras[4:5],
+		codigoBarras[5:19],
+	), nil
+}
+
+// formatarValorCodigo converte o valor em reais para os 10 dígitos em centavos
+func formatarValorCodigo(valor float64) (string, error) {
+	centavos := int64(math.Round(valor * 100))
+	if centavos < 0 || centavos > 9999999999 {
+		return "", fmt.Errorf("valor %.2f fora do intervalo do código de barras", valor)
+	}
+	return fmt.Sprintf("%010d", centavos), nil
+}
+
+// campoLivreBancoBrasil compõe o campo livre do Banco do Brasil conforme o tamanho do convênio
+func campoLivreBancoBrasil(d DadosBoleto) (string, error) {
+	convenio := apenasDigitos(d.CodigoBeneficiario)
+	nossoNumero := apenasDigitos(d.NossoNumero)
+
+	switch len(convenio) {
+	case 7:
+		// Convênio de 7 dígitos: nosso número de 17 dígitos (convênio + complemento de 10)
+		nossoNumero = strings.TrimPrefix(nossoNumero, convenio)
+		return (&montadorCampoLivre{}).
+			fixo("000000").
+			campo(convenio, 7).
+			campo(nossoNumero, 10).
+			campo(d.Carteira, 2).
+			resultado()
+	case 6:
+		// Nosso número livre de 17 dígitos (serviço 21)
+		if len(nossoNumero) == 17 {
+			return (&montadorCampoLivre{}).
+				campo(convenio, 6).
+				campo(nossoNumero, 17).
+				fixo("21").
+				resultado()
+		}
+		// Nosso número de 11 dígitos: convênio + sequencial de 5
+		nossoNumero = strings.TrimPrefix(nossoNumero, convenio)
+		if len(nossoNumero) > 5 {
+			return "", fmt.Errorf("nosso número do Banco do Brasil com convênio de 6 dígitos deve ter o convênio seguido de 5 dígitos ou 17 dígitos")
+		}
+		return (&montadorCampoLivre{}).
+			campo(convenio, 6).
+			campo(nossoNumero, 5).
+			campo(d.Agencia, 4).
+			campo(d.Conta, 8).
+			campo(d.Carteira, 2).
+			resultado()
+	case 4:
+		return (&montadorCampoLivre{}).
+			campo(convenio, 4).
+			campo(nossoNumero, 7).
+			campo(d.Agencia, 4).
+			campo(d.Conta, 8).
+			campo(d.Carteira, 2).
+			resultado()
+	default:
+		return "", fmt.Errorf("convênio do Banco do Brasil deve ter 4, 6 ou 7 dígitos")
+	}
+}
+
+// campoLivreItau compõe o campo livre do Itaú: carteira, nosso número, DAC,
+// agência, conta, DAC de agência/conta e zeros
+func campoLivreItau(d DadosBoleto) (string, error) {
+	carteira, err := campo(d.Carteira, 3)
+	if err != nil {
+		return "", err
+	}
+	nossoNumero, err := campo(d.NossoNumero, 8)
+	if err != nil {
+		return "", err
+	}
+	agencia, err := campo(d.Agencia, 4)
+	if err != nil {
+		return "", err
+	}
+	conta, err := campo(d.Conta, 5)
+	if err != nil {
+		return "", err
+	}
+
+	// Carteiras escriturais e de moeda estrangeira calculam o DAC apenas com carteira e nosso número
+	dac := Modulo10(agencia + conta + carteira + nossoNumero)
+	switch carteira {
+	case "126", "131", "146", "150", "168":
+		dac = Modulo10(carteira + nossoNumero)
+	}
+
+	return carteira + nossoNumero + strconv.Itoa(dac) + agencia + conta + strconv.Itoa(Modulo10(agencia+conta)) + "000", nil
+}
+
+// campoLivreBradesco compõe o campo livre do Bradesco: agência, carteira,
+// nosso número, conta e zero
+func campoLivreBradesco(d DadosBoleto) (string, error) {
+	return (&montadorCampoLivre{}).
+		campo(d.Agencia, 4).
+		campo(d.Carteira, 2).
+		campo(d.NossoNumero, 11).
+		campo(d.Conta, 7).
+		fixo("0").
+		resultado()
+}
+
+// campoLivreSantander compõe o campo livre do Santander: fixo 9, código do
+// beneficiário, nosso número com DV, IOF e carteira
+func campoLivreSantander(d DadosBoleto) (string, error) {
+	nossoNumero := apenasDigitos(d.NossoNumero)
+	if len(nossoNumero) <= 12 {
+		nossoNumero = strings.Repeat("0", 12-len(nossoNumero)) + nossoNumero
+		nossoNumero += strconv.Itoa(dvNossoNumeroSantander(nossoNumero))
+	}
+	return (&montadorCampoLivre{}).
+		fixo("9").
+		campo(d.CodigoBeneficiario, 7).
+		campo(nossoNumero, 13).
+		fixo("0").
+		campo(d.Carteira, 3).
+		resultado()
+}
+
+// dvNossoNumeroSantander calcula o DV do nosso número do Santander (módulo 11, pesos 2 a 9)
+func dvNossoNumeroSantander(nossoNumero string) int {
+	resto := RestoModulo11(nossoNumero, 9)
+	switch resto {
+	case 0, 1:
+		return 0
+	case 10:
+		return 1
+	default:
+		return 11 - resto
+	}
+}
+
+// campoLivreCaixa compõe o campo livre da Caixa (SIGCB): código do beneficiário
+// com DV, nosso número intercalado com as constantes de modalidade e emissão e DV
+func campoLivreCaixa(d DadosBoleto) (string, error) {
+	beneficiario, err := campo(d.CodigoBeneficiario, 6)
+	if err != nil {
+		return "", err
+	}
+
+	// Nosso número SIGCB: modalidade (1 registrada), emissão (4 beneficiário) e 15 dígitos
+	nossoNumero := apenasDigitos(d.NossoNumero)
+	if len(nossoNumero) <= 15 {
+		nossoNumero = "14" + strings.Repeat("0", 15-len(nossoNumero)) + nossoNumero
+	}
+	if len(nossoNumero) != 17 {
+		return "", fmt.Errorf("nosso número da Caixa deve ter 15 ou 17 dígitos")
+	}
+
+	semDV := beneficiario + strconv.Itoa(dvModulo11Caixa(beneficiario)) +
+		nossoNumero[2:5] + nossoNumero[0:1] +
+		nossoNumero[5:8] + nossoNumero[1:2] +
+		nossoNumero[8:17]
+	return semDV + strconv.Itoa(dvModulo11Caixa(semDV)), nil
+}
+
+// dvModulo11Caixa calcula o DV módulo 11 da Caixa: 11 menos o resto, com resultados acima de 9 valendo 0
+func dvModulo11Caixa(numero string) int {
+	dv := 11 - RestoModulo11(numero, 9)
+	if dv > 9 {
+		return 0
+	}
+	return dv
+}
+
+// campoLivreSicoob compõe o campo livre do Sicoob: carteira, cooperativa,
+// modalidade, código do cliente, nosso número com DV e parcela
+func campoLivreSicoob(d DadosBoleto) (string, error) {
+	cooperativa, err := campo(d.Agencia, 4)
+	if err != nil {
+		return "", err
+	}
+	cliente, err := campo(d.CodigoBeneficiario, 7)
+	if err != nil {
+		return "", err
+	}
+
+	nossoNumero := apenasDigitos(d.NossoNumero)
+	if len(nossoNumero) <= 7 {
+		nossoNumero = strings.Repeat("0", 7-len(nossoNumero)) + nossoNumero
+		nossoNumero += strconv.Itoa(dvNossoNumeroSicoob(cooperativa, cliente, nossoNumero))
+	}
+
+	return (&montadorCampoLivre{}).
+		campo(valorPadrao(d.Carteira, "1"), 1).
+		fixo(cooperativa).
+		campo(valorPadrao(d.Modalidade, "01"), 2).
+		fixo(cliente).
+		campo(nossoNumero, 8).
+		campo(valorPadrao(d.Parcela, "001"), 3).
+		resultado()
+}
+
+// dvNossoNumeroSicoob calcula o DV do nosso número do Sicoob com a constante 3197
+// aplicada sobre cooperativa (4), cliente (10) e nosso número (7)
+func dvNossoNumeroSicoob(cooperativa, cliente, nossoNumero string) int {
+	sequencia := cooperativa + strings.Repeat("0", 10-len(cliente)) + cliente + nossoNumero
+	pesos := []int{3, 1, 9, 7}
+	soma := 0
+	for i := 0; i < len(sequencia); i++ {
+		soma += int(sequencia[i]-'0') * pesos[i%len(pesos)]
+	}
+	resto := soma % 11
+	if resto <= 1 {
+		return 0
+	}
+	return 11 - resto
+}
+
+// campoLivreSicredi compõe o campo livre do Sicredi: tipo de cobrança, carteira,
+// nosso número com DV, cooperativa, posto, beneficiário, indicador de valor, filler e DV
+func campoLivreSicredi(d DadosBoleto) (string, error) {
+	cooperativa, err := campo(d.Agencia, 4)
+	if err != nil {
+		return "", err
+	}
+	posto, err := campo(d.Posto, 2)
+	if err != nil {
+		return "", err
+	}
+	beneficiario, err := campo(d.CodigoBeneficiario, 5)
+	if err != nil {
+		return "", err
+	}
+
+	nossoNumero := apenasDigitos(d.NossoNumero)
+	if len(nossoNumero) <= 8 {
+		nossoNumero = strings.Repeat("0", 8-len(nossoNumero)) + nossoNumero
+		dv := 11 - RestoModulo11(cooperativa+posto+beneficiario+nossoNumero, 9)
+		if dv > 9 {
+			dv = 0
+		}
+		nossoNumero += strconv.Itoa(dv)
+	}
+	nossoNumero, err = campo(nossoNumero, 9)
+	if err != nil {
+		return "", err
+	}
+
+	indicadorValor := "0"
+	if d.Valor > 0 {
+		indicadorValor = "1"
+	}
+
+	// Tipo de cobrança 1 (com registro) e carteira 1 (simples)
+	semDV := "1" + valorPadrao(d.Carteira, "1") + nossoNumero + cooperativa + posto + beneficiario + indicadorValor + "0"
+	dv := 11 - RestoModulo11(semDV, 9)
+	if dv > 9 {
+		dv = 0
+	}
+	return semDV + strconv.Itoa(dv), nil
+}
+
+// campo normaliza um campo numérico para o tamanho exato, completando com zeros à esquerda
+func campo(valor string, tamanho int) (string, error) {
+	digitos := apenasDigitos(valor)
+	if len(digitos) > tamanho {
+		return "", fmt.Errorf("campo %q excede %d dígitos", valor, tamanho)
+	}
+	return strings.Repeat("0", tamanho-len(digitos)) + digitos, nil
+}
+
+// montadorCampoLivre concatena campos numéricos de tamanho fixo, guardando o primeiro erro
+type montadorCampoLivre struct {
+	b   strings.Builder
+	err error
+}
+
+// fixo acrescenta um trecho literal
+func (m *montadorCampoLivre) fixo(valor string) *montadorCampoLivre {
+	m.b.WriteString(valor)
+	return m
+}
+
+// campo acrescenta um campo numérico completado com zeros à esquerda
+func (m *montadorCampoLivre) campo(valor string, tamanho int) *montadorCampoLivre {
+	if m.err != nil {
+		return m
+	}
+	normalizado, err := campo(valor, tamanho)
+	if err != nil {
+		m.err = err
+		return m
+	}
+	m.b.WriteString(normalizado)
+	return m
+}
+
+// resultado retorna o campo livre montado ou o primeiro erro
+func (m *montadorCampoLivre) resultado() (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.b.String(), nil
+}
+
+// valorPadrao retorna o valor ou o padrão quando vazio
+func valorPadrao(valor, padrao string) string {
+	if strings.TrimSpace(valor) == "" {
+		return padrao
+	}
+	return valor
+}
+
+// apenasDigitos remove os caracteres não numéricos
+func apenasDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// somenteDigitos informa se s tem exatamente o tamanho informado e só contém dígitos
+func somenteDigitos(s string, tamanho int) bool {
+	return len(s) == tamanho && apenasDigitos(s) == s
+}
@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func data(ano int, mes time.Month, dia int) *time.Time {
+	d := time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+func TestMontarCodigoBarras(t *testing.T) {
+	casos := []struct {
+		nome           string
+		dados          DadosBoleto
+		codigoBarras   string
+		linhaDigitavel string
+	}{
+		{
+			// Exemplo do manual do Banco do Brasil (convênio de 6 dígitos, fator 3737)
+			nome: "Banco do Brasil convênio 6",
+			dados: DadosBoleto{
+				Banco: BancoBrasil, Vencimento: data(2007, time.December, 31), Valor: 1.00,
+				CodigoBeneficiario: "050094", NossoNumero: "01448", Agencia: "1606", Conta: "06809350", Carteira: "31",
+			},
+			codigoBarras:   "00193373700000001000500940144816060680935031",
+			linhaDigitavel: "00190.50095 40144.816069 06809.350314 3 37370000000100",
+		},
+		{
+			// Mesmo exemplo com o nosso número completo de 11 dígitos (convênio + sequencial)
+			nome: "Banco do Brasil convênio 6 com nosso número de 11 dígitos",
+			dados: DadosBoleto{
+				Banco: BancoBrasil, Vencimento: data(2007, time.December, 31), Valor: 1.00,
+				CodigoBeneficiario: "050094", NossoNumero: "05009401448", Agencia: "1606", Conta: "06809350", Carteira: "31",
+			},
+			codigoBarras:   "00193373700000001000500940144816060680935031",
+			linhaDigitavel: "00190.50095 40144.816069 06809.350314 3 37370000000100",
+		},
+		{
+			// Exemplo do manual do Itaú: carteira 157, agência 0167, conta 45145-9
+			nome: "Itaú",
+			dados: DadosBoleto{
+				Banco: BancoItau, Vencimento: data(2013, time.April, 1), Valor: 2680.16,
+				Agencia: "0167", Conta: "45145", Carteira: "157", NossoNumero: "21897666",
+			},
+			codigoBarras:   "34196565500002680161572189766660167451459000",
+			linhaDigitavel: "34191.57213 89766.660164 74514.590004 6 56550000268016",
+		},
+		{
+			// Exemplo do manual de cobrança do Bradesco: carteira 06, agência 0123
+			nome: "Bradesco",
+			dados: DadosBoleto{
+				Banco: BancoBradesco, Vencimento: data(2007, time.February, 19), Valor: 100.00,
+				Agencia: "0123", Carteira: "06", NossoNumero: "525", Conta: "4567",
+			},
+			codigoBarras:   "23798342200000100000123060000000052500045670",
+			linhaDigitavel: "23790.12301 60000.000053 25000.456704 8 34220000010000",
+		},
+		{
+			// Exemplo do manual do Santander: código do beneficiário 6329640, carteira 102
+			nome: "Santander",
+			dados: DadosBoleto{
+				Banco: BancoSantander, Vencimento: data(2013, time.February, 19), Valor: 178.32,
+				CodigoBeneficiario: "6329640", NossoNumero: "125", Carteira: "102",
+			},
+			codigoBarras:   "03394561400000178329632964000000000012520102",
+			linhaDigitavel: "03399.63290 64000.000006 00125.201020 4 56140000017832",
+		},
+		{
+			// Exemplo do leiaute SIGCB da Caixa: beneficiário 200656, cobrança registrada
+			nome: "Caixa SIGCB",
+			dados: DadosBoleto{
+				Banco: BancoCaixa, Vencimento: data(2017, time.August, 30), Valor: 10.00,
+				CodigoBeneficiario: "200656", NossoNumero: "099222698",
+			},
+			codigoBarras:   "10493726700000010002006561000100040992226984",
+			linhaDigitavel: "10492.00650 61000.100042 09922.269841 3 72670000001000",
+		},
+		{
+			// Leiaute do Sicoob: carteira 1, cooperativa 3069, modalidade 01, cliente 1234567,
+			// nosso número 0000123-5 (constante 3197) e parcela 001. Vetor calculado à mão
+			// campo a campo sobre o manual, que não traz código de barras completo
+			nome: "Sicoob",
+			dados: DadosBoleto{
+				Banco: BancoSicoob, Vencimento: data(2024, time.December, 20), Valor: 75.00,
+				Agencia: "3069", CodigoBeneficiario: "1234567", NossoNumero: "123",
+			},
+			codigoBarras:   "75695993600000075001306901123456700001235001",
+			linhaDigitavel: "75691.30698 01123.456707 00012.350013 5 99360000007500",
+		},
+		{
+			// Leiaute do Sicredi: cobrança 1, carteira 1, nosso número 24/200123-0, cooperativa
+			// 0710, posto 05, beneficiário 12345, com valor e DV do campo livre 0. Vetor
+			// calculado à mão campo a campo sobre o manual, que não traz código de barras completo
+			nome: "Sicredi",
+			dados: DadosBoleto{
+				Banco: BancoSicredi, Vencimento: data(2024, time.October, 10), Valor: 500.00,
+				Agencia: "0710", Posto: "05", CodigoBeneficiario: "12345", NossoNumero: "24200123",
+			},
+			codigoBarras:   "74899986500000500001124200123007100512345100",
+			linhaDigitavel: "74891.12420 00123.007106 05123.451006 9 98650000050000",
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			codigo, err := MontarCodigoBarras(caso.dados)
+			assert.NoError(t, err)
+			assert.Equal(t, caso.codigoBarras, codigo)
+
+			linha, err := LinhaDigitavel(codigo)
+			assert.NoError(t, err)
+			assert.Equal(t, caso.linhaDigitavel, linha)
+		})
+	}
+}
+
+// TestMontarCodigoBarrasSemExemplo cobre os leiautes sem exemplo publicado: o código
+// montado é conferido pelo decodificador, que valida os DVs e extrai os campos
+func TestMontarCodigoBarrasSemExemplo(t *testing.T) {
+	casos := []struct {
+		nome       string
+		dados      DadosBoleto
+		campoLivre string
+	}{
+		{
+			nome: "Banco do Brasil convênio 7",
+			dados: DadosBoleto{
+				Banco: BancoBrasil, Vencimento: data(2024, time.May, 10), Valor: 1500.00,
+				CodigoBeneficiario: "1234567", NossoNumero: "12345670000000123", Carteira: "17",
+			},
+			campoLivre: "000000" + "1234567" + "0000000123" + "17",
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			codigo, err := MontarCodigoBarras(caso.dados)
+			assert.NoError(t, err)
+
+			parsed, err := ParseCodigoBoleto(codigo)
+			assert.NoError(t, err)
+			assert.Equal(t, caso.dados.Banco, parsed.Banco)
+			assert.Equal(t, caso.dados.Valor, parsed.Valor)
+			assert.Equal(t, caso.dados.Vencimento.Format(time.DateOnly), parsed.Vencimento.Format(time.DateOnly))
+			if caso.campoLivre != "" {
+				assert.Equal(t, caso.campoLivre, parsed.CampoLivre)
+			}
+		})
+	}
+}
+
+// TestExemplosManuais confere os DVs com os exemplos dos manuais dos bancos
+func TestExemplosManuais(t *testing.T) {
+	// Itaú: DAC do nosso número (agência, conta, carteira e nosso número) e da agência/conta
+	assert.Equal(t, 8, Modulo10("0057"+"12345"+"110"+"12345678"))
+	assert.Equal(t, 7, Modulo10("0057"+"12345"))
+
+	// Caixa SIGCB: beneficiário 005507, nosso número 24 000000000000019 (sem registro)
+	campoLivre, err := campoLivreCaixa(DadosBoleto{CodigoBeneficiario: "005507", NossoNumero: "24000000000000019"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0055077000200040000000194", campoLivre)
+}
+
+func TestMontarCodigoBarrasErros(t *testing.T) {
+	_, err := MontarCodigoBarras(DadosBoleto{Banco: "999"})
+	assert.ErrorIs(t, err, ErrBancoNaoSuportado)
+
+	_, err = MontarCodigoBarras(DadosBoleto{Banco: BancoBradesco, Agencia: "12345"})
+	assert.Error(t, err)
+
+	_, err = MontarCodigoBarras(DadosBoleto{Banco: BancoBrasil, CodigoBeneficiario: "12345"})
+	assert.Error(t, err)
+
+	// Convênio de 6 dígitos: nem convênio + 5 dígitos nem nosso número livre de 17
+	for _, nossoNumero := range []string{"12345678", "99999901448", "1234567890123456"} {
+		_, err = MontarCodigoBarras(DadosBoleto{Banco: BancoBrasil, CodigoBeneficiario: "050094", NossoNumero: nossoNumero, Carteira: "31"})
+		assert.Error(t, err, nossoNumero)
+	}
+}
+
+func TestModulos(t *testing.T) {
+	assert.Equal(t, 5, Modulo10("001905009"))
+	assert.Equal(t, 3, DVCodigoBarras("0019373700000001000500940144816060680935031"))
+}
+
+func TestFatorVencimentoRollover(t *testing.T) {
+	casos := []struct {
+		data  time.Time
+		fator int
+	}{
+		{time.Date(2000, time.July, 3, 0, 0, 0, 0, time.UTC), 1000},
+		{time.Date(2023, time.November, 30, 0, 0, 0, 0, time.UTC), 9550},
+		{time.Date(2025, time.February, 21, 0, 0, 0, 0, time.UTC), 9999},
+		{time.Date(2025, time.February, 22, 0, 0, 0, 0, time.UTC), 1000},
+		{time.Date(2025, time.March, 1, 15, 30, 0, 0, time.UTC), 1007},
+	}
+	for _, caso := range casos {
+		fator, err := FatorVencimento(caso.data)
+		assert.NoError(t, err)
+		assert.Equal(t, caso.fator, fator, caso.data.Format(time.DateOnly))
+	}
+
+	_, err := FatorVencimento(time.Date(2000, time.July, 2, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestDataVencimentoJanelaDeslizante(t *testing.T) {
+	casos := []struct {
+		fator      int
+		referencia time.Time
+		vencimento string
+	}{
+		// Antes do reinício, o fator 1000 é o primeiro ciclo
+		{1000, time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC), "2000-07-03"},
+		{9999, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), "2025-02-21"},
+		// Após o reinício, fatores baixos pertencem ao novo ciclo
+		{1000, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC), "2025-02-22"},
+		{9999, time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC), "2025-02-21"},
+		{9550, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), "2023-11-30"},
+		// Fatores mais de 3000 dias no passado são resolvidos no ciclo seguinte
+		{5000, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), "2036-02-05"},
+	}
+	for _, caso := range casos {
+		vencimento := DataVencimentoEm(caso.fator, caso.referencia)
+		assert.Equal(t, caso.vencimento, vencimento.Format(time.DateOnly), "fator %d", caso.fator)
+	}
+
+	// Geração e leitura são inversas dentro da janela
+	vencimento := time.Date(2027, time.January, 15, 0, 0, 0, 0, time.UTC)
+	fator, err := FatorVencimento(vencimento)
+	assert.NoError(t, err)
+	assert.Equal(t, vencimento, DataVencimentoEm(fator, time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestAlterarVencimentoCodigoBarras(t *testing.T) {
+	const codigo = "34195955000000250751091234567800057123457000"
+
+	mesmo, err := AlterarVencimentoCodigoBarras(codigo, time.Date(2023, time.November, 30, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, codigo, mesmo)
+
+	alterado, err := AlterarVencimentoCodigoBarras(codigo, time.Date(2023, time.December, 15, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	parsed, err := ParseCodigoBoletoEm(alterado, time.Date(2023, time.November, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 9565, parsed.FatorVencimento)
+	assert.Equal(t, "2023-12-15", parsed.Vencimento.Format(time.DateOnly))
+	assert.Equal(t, codigo[9:], alterado[9:])
+
+	_, err = AlterarVencimentoCodigoBarras("123", time.Now())
+	assert.Error(t, err)
+}
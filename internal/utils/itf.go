@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// padroesITF são as larguras dos cinco elementos de cada dígito no ITF (interleaved
+// 2 of 5): 1 para elemento estreito e 3 para largo
+var padroesITF = [10][5]int{
+	{1, 1, 3, 3, 1},
+	{3, 1, 1, 1, 3},
+	{1, 3, 1, 1, 3},
+	{3, 3, 1, 1, 1},
+	{1, 1, 3, 1, 3},
+	{3, 1, 3, 1, 1},
+	{1, 3, 3, 1, 1},
+	{1, 1, 1, 3, 3},
+	{3, 1, 1, 3, 1},
+	{1, 3, 1, 3, 1},
+}
+
+// ErrCodigoITFInvalido indica um conteúdo que não pode ser codificado em ITF
+var ErrCodigoITFInvalido = errors.New("código ITF deve ter uma quantidade par de dígitos")
+
+// CodificarITF codifica os dígitos no código de barras ITF usado nos boletos, com a
+// razão 1:3 entre elementos estreitos e largos. O resultado são as larguras dos
+// elementos, em módulos estreitos, alternando barra e espaço a partir de uma barra,
+// incluindo os padrões de início (estreito-estreito-estreito-estreito) e de parada
+// (largo-estreito-estreito)
+func CodificarITF(digitos string) ([]int, error) {
+	if digitos == "" || len(digitos)%2 != 0 || strings.Trim(digitos, "0123456789") != "" {
+		return nil, ErrCodigoITFInvalido
+	}
+
+	elementos := []int{1, 1, 1, 1}
+	for i := 0; i < len(digitos); i += 2 {
+		// O primeiro dígito do par forma as barras e o segundo, os espaços
+		barras := padroesITF[digitos[i]-'0']
+		espacos := padroesITF[digitos[i+1]-'0']
+		for j := 0; j < 5; j++ {
+			elementos = append(elementos, barras[j], espacos[j])
+		}
+	}
+	return append(elementos, 3, 1, 1), nil
+}
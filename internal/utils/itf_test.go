@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodificarITF(t *testing.T) {
+	elementos, err := CodificarITF("12")
+	assert.NoError(t, err)
+	// Início, par 1-2 intercalado (barras de 1, espaços de 2) e parada
+	assert.Equal(t, []int{1, 1, 1, 1, 3, 1, 1, 3, 1, 1, 1, 1, 3, 3, 3, 1, 1}, elementos)
+
+	// 44 dígitos do boleto: 4 módulos de início, 18 por par e 5 de parada
+	elementos, err = CodificarITF("34195955000000250751091234567800057123457000")
+	assert.NoError(t, err)
+	soma := 0
+	for _, largura := range elementos {
+		soma += largura
+	}
+	assert.Equal(t, 405, soma)
+	assert.Len(t, elementos, 4+22*10+3)
+
+	for _, invalido := range []string{"", "123", "12a4"} {
+		_, err = CodificarITF(invalido)
+		assert.ErrorIs(t, err, ErrCodigoITFInvalido)
+	}
+}
@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Identificadores dos campos do BR Code (padrão EMV QRCPS-MPM adotado pelo Pix)
+const (
+	idFormatoPayload      = "00"
+	idPontoIniciacao      = "01"
+	idContaPix            = "26"
+	idCategoriaComercio   = "52"
+	idMoeda               = "53"
+	idValor               = "54"
+	idPais                = "58"
+	idNomeRecebedor       = "59"
+	idCidadeRecebedor     = "60"
+	idCEP                 = "61"
+	idDadosAdicionais     = "62"
+	idCRC                 = "63"
+	idGUIPix              = "00"
+	idChavePix            = "01"
+	idInfoAdicionalPix    = "02"
+	idURLPix              = "25"
+	idTxID                = "05"
+	guiPix                = "br.gov.bcb.pix"
+	moedaReal             = "986"
+	pontoIniciacaoUnico   = "12"
+	txIDAusente           = "***"
+	tamanhoMaximoNome     = 25
+	tamanhoMaximoCidade   = 15
+	tamanhoMaximoTxID     = 25
+	tamanhoMaximoValorPix = 13
+)
+
+// ErrBRCodeInvalido é a causa comum de todos os erros de validação de BR Code
+var ErrBRCodeInvalido = errors.New("BR Code Pix inválido")
+
+// ErroBRCode descreve o campo do BR Code que invalida o payload
+type ErroBRCode struct {
+	Campo  string
+	Motivo string
+}
+
+// Error implementa a interface error
+func (e *ErroBRCode) Error() string {
+	if e.Campo == "" {
+		return fmt.Sprintf("%s: %s", ErrBRCodeInvalido, e.Motivo)
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrBRCodeInvalido, e.Campo, e.Motivo)
+}
+
+// Is permite comparar com ErrBRCodeInvalido usando errors.Is
+func (e *ErroBRCode) Is(target error) bool {
+	return target == ErrBRCodeInvalido
+}
+
+// BRCode é o conteúdo do QR Code Pix (o "Pix copia e cola"). O BR Code estático traz
+// a chave do recebedor; o dinâmico traz a URL da cobrança no PSP, consultada no
+// momento do pagamento
+type BRCode struct {
+	// Chave Pix e informação adicional do BR Code estático
+	Chave         string `json:"chave,omitempty"`
+	InfoAdicional string `json:"info_adicional,omitempty"`
+	// URL da cobrança do BR Code dinâmico, sem o esquema https://
+	URL string `json:"url,omitempty"`
+	// UsoUnico indica que o QR Code não pode ser pago mais de uma vez
+	UsoUnico bool `json:"uso_unico"`
+
+	// Valor é zero quando o pagador informa o valor
+	Valor           float64 `json:"valor,omitempty"`
+	NomeRecebedor   string  `json:"nome_recebedor"`
+	CidadeRecebedor string  `json:"cidade_recebedor"`
+	CEP             string  `json:"cep,omitempty"`
+	// TxID identifica a cobrança; vazio no BR Code estático sem identificador ("***")
+	TxID string `json:"txid,omitempty"`
+}
+
+// Dinamico informa se o BR Code aponta para uma cobrança no PSP
+func (b *BRCode) Dinamico() bool {
+	return b.URL != ""
+}
+
+// campoEMV é um campo ID-tamanho-valor do BR Code
+type campoEMV struct {
+	id    string
+	valor string
+}
+
+// ParseBRCode valida o CRC e os campos obrigatórios do BR Code e o decodifica
+func ParseBRCode(payload string) (*BRCode, error) {
+	payload = strings.TrimSpace(payload)
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != idCRC+"04" {
+		return nil, &ErroBRCode{Campo: "CRC", Motivo: "o payload deve terminar com o campo 63 (CRC16)"}
+	}
+	crc := strings.ToUpper(payload[len(payload)-4:])
+	if esperado := crc16BRCode(payload[:len(payload)-4]); crc != esperado {
+		return nil, &ErroBRCode{Campo: "CRC", Motivo: fmt.Sprintf("CRC16 %s não confere, esperado %s", crc, esperado)}
+	}
+
+	campos, err := lerCamposEMV(payload[:len(payload)-8])
+	if err != nil {
+		return nil, err
+	}
+
+	brcode := &BRCode{}
+	obrigatorios := map[string]bool{}
+	for i, campo := range campos {
+		obrigatorios[campo.id] = true
+		switch campo.id {
+		case idFormatoPayload:
+			if i != 0 || campo.valor != "01" {
+				return nil, &ErroBRCode{Campo: "formato do payload", Motivo: "o campo 00 deve ser o primeiro, com valor 01"}
+			}
+		case idPontoIniciacao:
+			brcode.UsoUnico = campo.valor == pontoIniciacaoUnico
+		case idMoeda:
+			if campo.valor != moedaReal {
+				return nil, &ErroBRCode{Campo: "moeda", Motivo: fmt.Sprintf("moeda %s não é o real (986)", campo.valor)}
+			}
+		case idValor:
+			valor, err := strconv.ParseFloat(campo.valor, 64)
+			if err != nil || valor <= 0 {
+				return nil, &ErroBRCode{Campo: "valor", Motivo: fmt.Sprintf("valor %q inválido", campo.valor)}
+			}
+			brcode.Valor = valor
+		case idPais:
+			if campo.valor != "BR" {
+				return nil, &ErroBRCode{Campo: "país", Motivo: fmt.Sprintf("país %s não é BR", campo.valor)}
+			}
+		case idNomeRecebedor:
+			brcode.NomeRecebedor = campo.valor
+		case idCidadeRecebedor:
+			brcode.CidadeRecebedor = campo.valor
+		case idCEP:
+			brcode.CEP = campo.valor
+		case idDadosAdicionais:
+			subcampos, err := lerCamposEMV(campo.valor)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range subcampos {
+				if sub.id == idTxID && sub.valor != txIDAusente {
+					brcode.TxID = sub.valor
+				}
+			}
+		default:
+			// Informações de conta (26 a 51): apenas o modelo do Pix é reconhecido
+			if campo.id >= idContaPix && campo.id <= "51" {
+				if err := lerContaPix(campo.valor, brcode); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, id := range []string{idFormatoPayload, idCategoriaComercio, idMoeda, idPais, idNomeRecebedor, idCidadeRecebedor, idDadosAdicionais} {
+		if !obrigatorios[id] {
+			return nil, &ErroBRCode{Campo: "campo " + id, Motivo: "campo obrigatório ausente"}
+		}
+	}
+	if brcode.Chave == "" && brcode.URL == "" {
+		return nil, &ErroBRCode{Campo: "conta Pix", Motivo: "o BR Code não traz chave nem URL de cobrança Pix"}
+	}
+	return brcode, nil
+}
+
+// lerContaPix interpreta o modelo de informações de conta do Pix; modelos de outros
+// arranjos são ignorados
+func lerContaPix(valor string, brcode *BRCode) error {
+	subcampos, err := lerCamposEMV(valor)
+	if err != nil {
+		return err
+	}
+	if len(subcampos) == 0 || subcampos[0].id != idGUIPix || !strings.EqualFold(subcampos[0].valor, guiPix) {
+		return nil
+	}
+	for _, sub := range subcampos[1:] {
+		switch sub.id {
+		case idChavePix:
+			brcode.Chave = sub.valor
+		case idInfoAdicionalPix:
+			brcode.InfoAdicional = sub.valor
+		case idURLPix:
+			brcode.URL = sub.valor
+		}
+	}
+	if brcode.Chave != "" && brcode.URL != "" {
+		return &ErroBRCode{Campo: "conta Pix", Motivo: "chave e URL de cobrança não podem vir juntas"}
+	}
+	return nil
+}
+
+// lerCamposEMV divide o texto em campos ID (2 dígitos), tamanho (2 dígitos) e valor
+func lerCamposEMV(texto string) ([]campoEMV, error) {
+	var campos []campoEMV
+	for len(texto) > 0 {
+		if len(texto) < 4 || !somenteDigitos(texto[:4], 4) {
+			return nil, &ErroBRCode{Motivo: fmt.Sprintf("campo malformado em %q", texto)}
+		}
+		tamanho, _ := strconv.Atoi(texto[2:4])
+		if len(texto) < 4+tamanho {
+			return nil, &ErroBRCode{Campo: "campo " + texto[:2], Motivo: fmt.Sprintf("tamanho %d excede o payload", tamanho)}
+		}
+		campos = append(campos, campoEMV{id: texto[:2], valor: texto[4 : 4+tamanho]})
+		texto = texto[4+tamanho:]
+	}
+	return campos, nil
+}
+
+// Payload gera o BR Code (Pix copia e cola) com o CRC16. Nome e cidade são
+// convertidos para ASCII e truncados aos limites do padrão
+func (b *BRCode) Payload() (string, error) {
+	if (b.Chave == "") == (b.URL == "") {
+		return "", &ErroBRCode{Campo: "conta Pix", Motivo: "informe a chave (estático) ou a URL da cobrança (dinâmico)"}
+	}
+	nome := truncar(textoBRCode(b.NomeRecebedor), tamanhoMaximoNome)
+	cidade := truncar(textoBRCode(b.CidadeRecebedor), tamanhoMaximoCidade)
+	if nome == "" || cidade == "" {
+		return "", &ErroBRCode{Campo: "recebedor", Motivo: "nome e cidade do recebedor são obrigatórios"}
+	}
+	txid := b.TxID
+	if txid == "" {
+		txid = txIDAusente
+	} else if len(txid) > tamanhoMaximoTxID || strings.IndexFunc(txid, func(r rune) bool { return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) }) >= 0 {
+		return "", &ErroBRCode{Campo: "txid", Motivo: "o txid deve ter até 25 letras e dígitos"}
+	}
+
+	conta := campoBRCode(idGUIPix, guiPix)
+	if b.Dinamico() {
+		conta += campoBRCode(idURLPix, strings.TrimPrefix(b.URL, "https://"))
+	} else {
+		conta += campoBRCode(idChavePix, b.Chave)
+		if b.InfoAdicional != "" {
+			conta += campoBRCode(idInfoAdicionalPix, textoBRCode(b.InfoAdicional))
+		}
+	}
+	if len(conta) > 99 {
+		return "", &ErroBRCode{Campo: "conta Pix", Motivo: "chave, informação adicional ou URL longas demais"}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(campoBRCode(idFormatoPayload, "01"))
+	if b.UsoUnico {
+		sb.WriteString(campoBRCode(idPontoIniciacao, pontoIniciacaoUnico))
+	}
+	sb.WriteString(campoBRCode(idContaPix, conta))
+	sb.WriteString(campoBRCode(idCategoriaComercio, "0000"))
+	sb.WriteString(campoBRCode(idMoeda, moedaReal))
+	if b.Valor > 0 {
+		valor := strconv.FormatFloat(b.Valor, 'f', 2, 64)
+		if len(valor) > tamanhoMaximoValorPix {
+			return "", &ErroBRCode{Campo: "valor", Motivo: "valor excede 13 caracteres"}
+		}
+		sb.WriteString(campoBRCode(idValor, valor))
+	} else if b.Valor < 0 {
+		return "", &ErroBRCode{Campo: "valor", Motivo: "valor negativo"}
+	}
+	sb.WriteString(campoBRCode(idPais, "BR"))
+	sb.WriteString(campoBRCode(idNomeRecebedor, nome))
+	sb.WriteString(campoBRCode(idCidadeRecebedor, cidade))
+	if cep := apenasDigitos(b.CEP); cep != "" {
+		sb.WriteString(campoBRCode(idCEP, cep))
+	}
+	sb.WriteString(campoBRCode(idDadosAdicionais, campoBRCode(idTxID, txid)))
+	sb.WriteString(idCRC + "04")
+
+	payload := sb.String()
+	return payload + crc16BRCode(payload), nil
+}
+
+// campoBRCode formata um campo ID-tamanho-valor
+func campoBRCode(id, valor string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(valor), valor)
+}
+
+// crc16BRCode calcula o CRC16-CCITT (polinômio 0x1021, valor inicial 0xFFFF) exigido
+// no campo 63, em quatro dígitos hexadecimais maiúsculos
+func crc16BRCode(dados string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(dados); i++ {
+		crc ^= uint16(dados[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+// textoBRCode remove acentos e caracteres fora do ASCII imprimível, que nem todos os
+// aplicativos de pagamento aceitam
+func textoBRCode(texto string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(strings.TrimSpace(texto)) {
+		if r >= 0x20 && r < 0x7F {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// truncar limita o texto ao tamanho informado
+func truncar(texto string, tamanho int) string {
+	if len(texto) > tamanho {
+		return strings.TrimSpace(texto[:tamanho])
+	}
+	return texto
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Exemplo de BR Code estático do manual do Pix
+const brCodeEstatico = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestGerarBRCodeEstatico(t *testing.T) {
+	brcode := BRCode{
+		Chave:           "123e4567-e12b-12d1-a456-426655440000",
+		NomeRecebedor:   "Fulano de Tal",
+		CidadeRecebedor: "BRASILIA",
+	}
+	payload, err := brcode.Payload()
+	assert.NoError(t, err)
+	assert.Equal(t, brCodeEstatico, payload)
+}
+
+func TestParseBRCode(t *testing.T) {
+	estatico, err := ParseBRCode(brCodeEstatico)
+	assert.NoError(t, err)
+	assert.Equal(t, "123e4567-e12b-12d1-a456-426655440000", estatico.Chave)
+	assert.Equal(t, "Fulano de Tal", estatico.NomeRecebedor)
+	assert.Equal(t, "BRASILIA", estatico.CidadeRecebedor)
+	assert.Empty(t, estatico.TxID)
+	assert.False(t, estatico.Dinamico())
+	assert.Zero(t, estatico.Valor)
+
+	// Dinâmico com valor, uso único, CEP e nome com acento truncado a 25 caracteres
+	dinamico := BRCode{
+		URL:             "https://pix.exemplo.com.br/qr/v2/cobv/9d36b84fc70b478fb95c12729b90ca25",
+		UsoUnico:        true,
+		Valor:           1234.5,
+		NomeRecebedor:   "Distribuidora São João de Alimentos Ltda",
+		CidadeRecebedor: "São José dos Campos",
+		CEP:             "12.245-000",
+		TxID:            "BOL00000012345",
+	}
+	payload, err := dinamico.Payload()
+	assert.NoError(t, err)
+
+	lido, err := ParseBRCode(payload)
+	assert.NoError(t, err)
+	assert.True(t, lido.Dinamico())
+	assert.True(t, lido.UsoUnico)
+	assert.Equal(t, "pix.exemplo.com.br/qr/v2/cobv/9d36b84fc70b478fb95c12729b90ca25", lido.URL)
+	assert.Equal(t, 1234.50, lido.Valor)
+	assert.Equal(t, "Distribuidora Sao Joao de", lido.NomeRecebedor)
+	assert.Equal(t, "Sao Jose dos Ca", lido.CidadeRecebedor)
+	assert.Equal(t, "12245000", lido.CEP)
+	assert.Equal(t, "BOL00000012345", lido.TxID)
+
+	// O CRC pode vir em minúsculas
+	_, err = ParseBRCode(brCodeEstatico[:len(brCodeEstatico)-4] + "1d3d")
+	assert.NoError(t, err)
+}
+
+func TestParseBRCodeInvalido(t *testing.T) {
+	casos := []struct {
+		nome    string
+		payload string
+		campo   string
+	}{
+		{"sem CRC", brCodeEstatico[:len(brCodeEstatico)-8], "CRC"},
+		{"CRC incorreto", brCodeEstatico[:len(brCodeEstatico)-4] + "1D3E", "CRC"},
+		{"tamanho excede o payload", comCRC("000201269900"), "campo 26"},
+		{"moeda diferente do real", comCRC("00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053038405802BR5913Fulano de Tal6008BRASILIA62070503***"), "moeda"},
+		{"sem cidade", comCRC("00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal62070503***"), "campo 60"},
+		{"sem conta Pix", comCRC("000201520400005303986" + "5802BR5913Fulano de Tal6008BRASILIA62070503***"), "conta Pix"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			_, err := ParseBRCode(caso.payload)
+			assert.ErrorIs(t, err, ErrBRCodeInvalido)
+			var erro *ErroBRCode
+			if assert.ErrorAs(t, err, &erro) {
+				assert.Equal(t, caso.campo, erro.Campo)
+			}
+		})
+	}
+}
+
+func TestGerarBRCodeInvalido(t *testing.T) {
+	_, err := (&BRCode{NomeRecebedor: "Fulano", CidadeRecebedor: "BRASILIA"}).Payload()
+	assert.ErrorIs(t, err, ErrBRCodeInvalido)
+
+	_, err = (&BRCode{Chave: "chave", URL: "pix.exemplo.com.br/qr", NomeRecebedor: "Fulano", CidadeRecebedor: "BRASILIA"}).Payload()
+	assert.ErrorIs(t, err, ErrBRCodeInvalido)
+
+	_, err = (&BRCode{Chave: "chave", NomeRecebedor: "Fulano", CidadeRecebedor: "BRASILIA", TxID: "BOL-001"}).Payload()
+	assert.ErrorIs(t, err, ErrBRCodeInvalido)
+}
+
+// comCRC completa o payload com o campo 63
+func comCRC(payload string) string {
+	payload += "6304"
+	return payload + crc16BRCode(payload)
+}
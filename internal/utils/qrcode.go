@@ -0,0 +1,495 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrConteudoQRCodeGrande indica que o conteúdo não cabe no maior QR Code (versão 40)
+var ErrConteudoQRCodeGrande = errors.New("conteúdo grande demais para um QR Code")
+
+// Correção de erros nível M (recupera ~15% do símbolo), o recomendado para o BR Code:
+// codewords de correção por bloco e número de blocos, indexados pela versão
+var (
+	eccPorBlocoNivelM = [41]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+	blocosNivelM      = [41]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+)
+
+// QRCode é a matriz de módulos de um QR Code (ISO/IEC 18004) codificado em modo byte
+type QRCode struct {
+	// Versao vai de 1 (21x21 módulos) a 40 (177x177)
+	Versao  int
+	Tamanho int
+
+	modulos [][]bool
+	funcao  [][]bool
+}
+
+// GerarQRCode codifica o conteúdo na menor versão que o comporta, com correção de
+// erros nível M e a máscara de menor penalidade
+func GerarQRCode(conteudo string) (*QRCode, error) {
+	dados := []byte(conteudo)
+
+	versao := 1
+	for ; versao <= 40; versao++ {
+		bitsContagem := 8
+		if versao >= 10 {
+			bitsContagem = 16
+		}
+		if 4+bitsContagem+8*len(dados) <= capacidadeDados(versao)*8 {
+			break
+		}
+	}
+	if versao > 40 {
+		return nil, ErrConteudoQRCodeGrande
+	}
+
+	qr := &QRCode{Versao: versao, Tamanho: versao*4 + 17}
+	qr.modulos = matrizBooleana(qr.Tamanho)
+	qr.funcao = matrizBooleana(qr.Tamanho)
+	qr.desenharPadroes()
+	qr.posicionarDados(qr.adicionarCorrecao(qr.codificar(dados)))
+
+	melhor, menorPenalidade := 0, -1
+	for mascara := 0; mascara < 8; mascara++ {
+		qr.aplicarMascara(mascara)
+		qr.desenharFormato(mascara)
+		if penalidade := qr.penalidade(); menorPenalidade < 0 || penalidade < menorPenalidade {
+			melhor, menorPenalidade = mascara, penalidade
+		}
+		qr.aplicarMascara(mascara)
+	}
+	qr.aplicarMascara(melhor)
+	qr.desenharFormato(melhor)
+	return qr, nil
+}
+
+// Modulo informa se o módulo na coluna x e linha y é escuro
+func (qr *QRCode) Modulo(x, y int) bool {
+	return qr.modulos[y][x]
+}
+
+// Imagem desenha o QR Code com escala pixels por módulo e a zona de silêncio
+// obrigatória de quatro módulos
+func (qr *QRCode) Imagem(escala int) image.Image {
+	const margem = 4
+	lado := (qr.Tamanho + 2*margem) * escala
+	img := image.NewGray(image.Rect(0, 0, lado, lado))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+	for y := 0; y < qr.Tamanho; y++ {
+		for x := 0; x < qr.Tamanho; x++ {
+			if !qr.modulos[y][x] {
+				continue
+			}
+			for dy := 0; dy < escala; dy++ {
+				for dx := 0; dx < escala; dx++ {
+					img.SetGray((x+margem)*escala+dx, (y+margem)*escala+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// PNG codifica a imagem do QR Code em PNG
+func (qr *QRCode) PNG(escala int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, qr.Imagem(escala)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// capacidadeDados retorna quantos codewords de dados cabem na versão
+func capacidadeDados(versao int) int {
+	return modulosDados(versao)/8 - eccPorBlocoNivelM[versao]*blocosNivelM[versao]
+}
+
+// modulosDados conta os módulos disponíveis para dados e correção, descontados os
+// padrões de função da versão
+func modulosDados(versao int) int {
+	resultado := (16*versao+128)*versao + 64
+	if versao >= 2 {
+		alinhamentos := versao/7 + 2
+		resultado -= (25*alinhamentos-10)*alinhamentos - 55
+		if versao >= 7 {
+			resultado -= 36
+		}
+	}
+	return resultado
+}
+
+// codificar monta os codewords de dados: modo byte, contagem, dados, terminador e
+// bytes de preenchimento
+func (qr *QRCode) codificar(dados []byte) []byte {
+	var bits []bool
+	adicionar := func(valor, tamanho int) {
+		for i := tamanho - 1; i >= 0; i-- {
+			bits = append(bits, (valor>>i)&1 == 1)
+		}
+	}
+
+	bitsContagem := 8
+	if qr.Versao >= 10 {
+		bitsContagem = 16
+	}
+	adicionar(0x4, 4)
+	adicionar(len(dados), bitsContagem)
+	for _, b := range dados {
+		adicionar(int(b), 8)
+	}
+
+	capacidade := capacidadeDados(qr.Versao) * 8
+	terminador := capacidade - len(bits)
+	if terminador > 4 {
+		terminador = 4
+	}
+	adicionar(0, terminador)
+	adicionar(0, (8-len(bits)%8)%8)
+	for preenchimento := 0xEC; len(bits) < capacidade; preenchimento ^= 0xEC ^ 0x11 {
+		adicionar(preenchimento, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return codewords
+}
+
+// adicionarCorrecao divide os dados em blocos, calcula a correção Reed-Solomon de
+// cada um e intercala os blocos
+func (qr *QRCode) adicionarCorrecao(dados []byte) []byte {
+	numBlocos := blocosNivelM[qr.Versao]
+	eccPorBloco := eccPorBlocoNivelM[qr.Versao]
+	totalCodewords := modulosDados(qr.Versao) / 8
+	blocosCurtos := numBlocos - totalCodewords%numBlocos
+	tamanhoCurto := totalCodewords / numBlocos
+
+	divisor := divisorReedSolomon(eccPorBloco)
+	blocos := make([][]byte, numBlocos)
+	for i, k := 0, 0; i < numBlocos; i++ {
+		tamanho := tamanhoCurto - eccPorBloco
+		if i >= blocosCurtos {
+			tamanho++
+		}
+		bloco := append([]byte{}, dados[k:k+tamanho]...)
+		k += tamanho
+		blocos[i] = append(bloco, restoReedSolomon(bloco, divisor)...)
+	}
+
+	// Os blocos curtos ganham uma posição vazia para que todos tenham o mesmo tamanho
+	resultado := make([]byte, 0, totalCodewords)
+	for i := 0; i <= tamanhoCurto; i++ {
+		for j, bloco := range blocos {
+			indice := i
+			if j < blocosCurtos {
+				if i == tamanhoCurto-eccPorBloco {
+					continue
+				}
+				if i > tamanhoCurto-eccPorBloco {
+					indice--
+				}
+			}
+			if indice < len(bloco) {
+				resultado = append(resultado, bloco[indice])
+			}
+		}
+	}
+	return resultado
+}
+
+// divisorReedSolomon calcula o polinômio gerador de grau informado sobre GF(256)
+func divisorReedSolomon(grau int) []byte {
+	resultado := make([]byte, grau)
+	resultado[grau-1] = 1
+	raiz := byte(1)
+	for i := 0; i < grau; i++ {
+		for j := range resultado {
+			resultado[j] = multiplicarGF(resultado[j], raiz)
+			if j+1 < len(resultado) {
+				resultado[j] ^= resultado[j+1]
+			}
+		}
+		raiz = multiplicarGF(raiz, 0x02)
+	}
+	return resultado
+}
+
+// restoReedSolomon calcula os codewords de correção dos dados
+func restoReedSolomon(dados, divisor []byte) []byte {
+	resultado := make([]byte, len(divisor))
+	for _, b := range dados {
+		fator := b ^ resultado[0]
+		copy(resultado, resultado[1:])
+		resultado[len(resultado)-1] = 0
+		for i := range resultado {
+			resultado[i] ^= multiplicarGF(divisor[i], fator)
+		}
+	}
+	return resultado
+}
+
+// multiplicarGF multiplica em GF(256) com o polinômio 0x11D
+func multiplicarGF(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// desenharPadroes desenha localizadores, temporização, alinhamento e versão, e
+// reserva a área do formato
+func (qr *QRCode) desenharPadroes() {
+	for i := 0; i < qr.Tamanho; i++ {
+		qr.definirFuncao(6, i, i%2 == 0)
+		qr.definirFuncao(i, 6, i%2 == 0)
+	}
+
+	for _, centro := range [][2]int{{3, 3}, {qr.Tamanho - 4, 3}, {3, qr.Tamanho - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := centro[0]+dx, centro[1]+dy
+				if x < 0 || x >= qr.Tamanho || y < 0 || y >= qr.Tamanho {
+					continue
+				}
+				distancia := max(abs(dx), abs(dy))
+				qr.definirFuncao(x, y, distancia != 2 && distancia != 4)
+			}
+		}
+	}
+
+	posicoes := posicoesAlinhamento(qr.Versao)
+	for i, py := range posicoes {
+		for j, px := range posicoes {
+			// Os cantos ocupados pelos localizadores não recebem alinhamento
+			if (i == 0 && j == 0) || (i == 0 && j == len(posicoes)-1) || (i == len(posicoes)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.definirFuncao(px+dx, py+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	qr.desenharFormato(0)
+	qr.desenharVersao()
+}
+
+// posicoesAlinhamento retorna as coordenadas dos centros dos padrões de alinhamento
+func posicoesAlinhamento(versao int) []int {
+	if versao == 1 {
+		return nil
+	}
+	quantidade := versao/7 + 2
+	passo := (versao*4 + quantidade*2 + 1) / (quantidade*2 - 2) * 2
+	if versao == 32 {
+		passo = 26
+	}
+	posicoes := make([]int, quantidade)
+	posicoes[0] = 6
+	for i, pos := quantidade-1, versao*4+10; i >= 1; i, pos = i-1, pos-passo {
+		posicoes[i] = pos
+	}
+	return posicoes
+}
+
+// desenharFormato grava as duas cópias dos bits de formato (nível M e máscara)
+func (qr *QRCode) desenharFormato(mascara int) {
+	// Nível M é codificado como 00
+	dados := mascara
+	resto := dados
+	for i := 0; i < 10; i++ {
+		resto = (resto << 1) ^ ((resto >> 9) * 0x537)
+	}
+	bits := (dados<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		qr.definirFuncao(8, i, bit(i))
+	}
+	qr.definirFuncao(8, 7, bit(6))
+	qr.definirFuncao(8, 8, bit(7))
+	qr.definirFuncao(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.definirFuncao(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.definirFuncao(qr.Tamanho-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.definirFuncao(8, qr.Tamanho-15+i, bit(i))
+	}
+	qr.definirFuncao(8, qr.Tamanho-8, true)
+}
+
+// desenharVersao grava os bits de versão, presentes a partir da versão 7
+func (qr *QRCode) desenharVersao() {
+	if qr.Versao < 7 {
+		return
+	}
+	resto := qr.Versao
+	for i := 0; i < 12; i++ {
+		resto = (resto << 1) ^ ((resto >> 11) * 0x1F25)
+	}
+	bits := qr.Versao<<12 | resto
+	for i := 0; i < 18; i++ {
+		escuro := (bits>>i)&1 == 1
+		a, b := qr.Tamanho-11+i%3, i/3
+		qr.definirFuncao(a, b, escuro)
+		qr.definirFuncao(b, a, escuro)
+	}
+}
+
+// posicionarDados distribui os codewords em zigue-zague, em colunas duplas da
+// direita para a esquerda
+func (qr *QRCode) posicionarDados(codewords []byte) {
+	i := 0
+	for direita := qr.Tamanho - 1; direita >= 1; direita -= 2 {
+		if direita == 6 {
+			direita = 5
+		}
+		for vertical := 0; vertical < qr.Tamanho; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := direita - j
+				subindo := (direita+1)&2 == 0
+				y := vertical
+				if subindo {
+					y = qr.Tamanho - 1 - vertical
+				}
+				if qr.funcao[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				qr.modulos[y][x] = (codewords[i>>3]>>(7-(i&7)))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// aplicarMascara inverte os módulos de dados selecionados pela máscara; aplicá-la
+// duas vezes desfaz a operação
+func (qr *QRCode) aplicarMascara(mascara int) {
+	for y := 0; y < qr.Tamanho; y++ {
+		for x := 0; x < qr.Tamanho; x++ {
+			var inverter bool
+			switch mascara {
+			case 0:
+				inverter = (x+y)%2 == 0
+			case 1:
+				inverter = y%2 == 0
+			case 2:
+				inverter = x%3 == 0
+			case 3:
+				inverter = (x+y)%3 == 0
+			case 4:
+				inverter = (x/3+y/2)%2 == 0
+			case 5:
+				inverter = x*y%2+x*y%3 == 0
+			case 6:
+				inverter = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				inverter = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if inverter && !qr.funcao[y][x] {
+				qr.modulos[y][x] = !qr.modulos[y][x]
+			}
+		}
+	}
+}
+
+// penalidade pontua o símbolo pelas regras da norma: sequências de mesma cor, blocos
+// 2x2, padrões parecidos com localizadores e desequilíbrio entre claros e escuros
+func (qr *QRCode) penalidade() int {
+	n := qr.Tamanho
+	pontos := 0
+	linha := func(i, j int, horizontal bool) bool {
+		if horizontal {
+			return qr.modulos[i][j]
+		}
+		return qr.modulos[j][i]
+	}
+
+	for _, horizontal := range []bool{true, false} {
+		for i := 0; i < n; i++ {
+			sequencia := 1
+			for j := 1; j < n; j++ {
+				if linha(i, j, horizontal) == linha(i, j-1, horizontal) {
+					sequencia++
+					continue
+				}
+				if sequencia >= 5 {
+					pontos += sequencia - 2
+				}
+				sequencia = 1
+			}
+			if sequencia >= 5 {
+				pontos += sequencia - 2
+			}
+
+			// 1:1:3:1:1 com quatro módulos claros de um dos lados
+			for j := 0; j+11 <= n; j++ {
+				padrao := [11]bool{}
+				for k := range padrao {
+					padrao[k] = linha(i, j+k, horizontal)
+				}
+				if padrao == [11]bool{true, false, true, true, true, false, true, false, false, false, false} ||
+					padrao == [11]bool{false, false, false, false, true, false, true, true, true, false, true} {
+					pontos += 40
+				}
+			}
+		}
+	}
+
+	escuros := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if qr.modulos[y][x] {
+				escuros++
+			}
+			if x+1 < n && y+1 < n {
+				cor := qr.modulos[y][x]
+				if cor == qr.modulos[y][x+1] && cor == qr.modulos[y+1][x] && cor == qr.modulos[y+1][x+1] {
+					pontos += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	desvio := abs(escuros*20-total*10) / total
+	pontos += desvio * 10
+	return pontos
+}
+
+// definirFuncao marca um módulo de padrão de função
+func (qr *QRCode) definirFuncao(x, y int, escuro bool) {
+	qr.modulos[y][x] = escuro
+	qr.funcao[y][x] = true
+}
+
+func matrizBooleana(tamanho int) [][]bool {
+	matriz := make([][]bool, tamanho)
+	for i := range matriz {
+		matriz[i] = make([]bool, tamanho)
+	}
+	return matriz
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
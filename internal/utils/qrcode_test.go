@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoReedSolomon(t *testing.T) {
+	// "HELLO WORLD" na versão 1-M, exemplo clássico da norma
+	dados := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, restoReedSolomon(dados, divisorReedSolomon(10)))
+}
+
+func TestGerarQRCode(t *testing.T) {
+	casos := []struct {
+		conteudo string
+		versao   int
+	}{
+		{"HELLO", 1},
+		{brCodeEstatico, 8},
+		{string(bytes.Repeat([]byte("A"), 400)), 15},
+	}
+
+	for _, caso := range casos {
+		qr, err := GerarQRCode(caso.conteudo)
+		assert.NoError(t, err)
+		assert.Equal(t, caso.versao, qr.Versao)
+		assert.Equal(t, caso.versao*4+17, qr.Tamanho)
+		assert.Equal(t, caso.conteudo, lerQRCode(t, qr))
+	}
+
+	_, err := GerarQRCode(string(bytes.Repeat([]byte("A"), 3000)))
+	assert.ErrorIs(t, err, ErrConteudoQRCodeGrande)
+}
+
+func TestQRCodePNG(t *testing.T) {
+	qr, err := GerarQRCode(brCodeEstatico)
+	assert.NoError(t, err)
+
+	conteudo, err := qr.PNG(4)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(conteudo))
+	assert.NoError(t, err)
+	assert.Equal(t, (qr.Tamanho+8)*4, img.Bounds().Dx())
+
+	// Canto superior esquerdo do localizador é escuro; a zona de silêncio é clara
+	r, _, _, _ := img.At(16, 16).RGBA()
+	assert.Zero(t, r)
+	r, _, _, _ = img.At(0, 0).RGBA()
+	assert.NotZero(t, r)
+}
+
+// lerQRCode decodifica o símbolo: lê a máscara nos bits de formato, desfaz a máscara,
+// recolhe os codewords, separa os blocos, confere a correção e extrai os bytes
+func lerQRCode(t *testing.T, qr *QRCode) string {
+	formato := 0
+	for i := 14; i >= 9; i-- {
+		formato = formato<<1 | bit(qr.Modulo(14-i, 8))
+	}
+	formato = formato<<1 | bit(qr.Modulo(7, 8))
+	formato = formato<<1 | bit(qr.Modulo(8, 8))
+	formato = formato<<1 | bit(qr.Modulo(8, 7))
+	for i := 5; i >= 0; i-- {
+		formato = formato<<1 | bit(qr.Modulo(8, i))
+	}
+	formato ^= 0x5412
+	assert.Zero(t, formato>>13, "nível de correção deve ser M")
+	mascara := (formato >> 10) & 7
+
+	copia := *qr
+	copia.modulos = matrizBooleana(qr.Tamanho)
+	for y := range qr.modulos {
+		copy(copia.modulos[y], qr.modulos[y])
+	}
+	copia.aplicarMascara(mascara)
+
+	total := modulosDados(qr.Versao) / 8
+	codewords := make([]byte, total)
+	i := 0
+	for direita := qr.Tamanho - 1; direita >= 1; direita -= 2 {
+		if direita == 6 {
+			direita = 5
+		}
+		for vertical := 0; vertical < qr.Tamanho; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := direita-j, vertical
+				if (direita+1)&2 == 0 {
+					y = qr.Tamanho - 1 - vertical
+				}
+				if copia.funcao[y][x] || i >= total*8 {
+					continue
+				}
+				if copia.modulos[y][x] {
+					codewords[i/8] |= 1 << (7 - i%8)
+				}
+				i++
+			}
+		}
+	}
+
+	// Separa os blocos intercalados e confere a correção de cada um
+	numBlocos, ecc := blocosNivelM[qr.Versao], eccPorBlocoNivelM[qr.Versao]
+	curtos := numBlocos - total%numBlocos
+	dadosCurto := total/numBlocos - ecc
+	blocos := make([][]byte, numBlocos)
+	k := 0
+	for c := 0; c < dadosCurto+1; c++ {
+		for b := range blocos {
+			if c == dadosCurto && b < curtos {
+				continue
+			}
+			blocos[b] = append(blocos[b], codewords[k])
+			k++
+		}
+	}
+	for c := 0; c < ecc; c++ {
+		for b := range blocos {
+			blocos[b] = append(blocos[b], codewords[k])
+			k++
+		}
+	}
+	var dados []byte
+	for _, bloco := range blocos {
+		n := len(bloco) - ecc
+		assert.Equal(t, bloco[n:], restoReedSolomon(bloco[:n], divisorReedSolomon(ecc)))
+		dados = append(dados, bloco[:n]...)
+	}
+
+	// Modo byte (0100) e contagem de 8 ou 16 bits
+	leitor := func(inicio, tamanho int) int {
+		valor := 0
+		for b := inicio; b < inicio+tamanho; b++ {
+			valor = valor<<1 | int(dados[b/8]>>(7-b%8)&1)
+		}
+		return valor
+	}
+	assert.Equal(t, 4, leitor(0, 4))
+	bitsContagem := 8
+	if qr.Versao >= 10 {
+		bitsContagem = 16
+	}
+	tamanho := leitor(4, bitsContagem)
+	conteudo := make([]byte, tamanho)
+	for b := range conteudo {
+		conteudo[b] = byte(leitor(4+bitsContagem+8*b, 8))
+	}
+	return string(conteudo)
+}
+
+func bit(escuro bool) int {
+	if escuro {
+		return 1
+	}
+	return 0
+}
@@ -116,7 +116,8 @@ func ValidarCPF(cpf string) bool {
 	return digit1 == expectedDigit1 && digit2 == expectedDigit2
 }
 
-// ValidarCodigoBarras valida um código de barras
+// ValidarCodigoBarras valida um código de barras (44 dígitos) ou linha digitável
+// (47 dígitos) no padrão FEBRABAN, incluindo os dígitos verificadores
 func ValidarCodigoBarras(codigo string) bool {
 	// Remove espaços
 	codigo = regexp.MustCompile(`\s`).ReplaceAllString(codigo, "")
@@ -126,24 +127,21 @@ func ValidarCodigoBarras(codigo string) bool {
 		return false
 	}
 
-	// Verifica se contém apenas números
-	matched, _ := regexp.MatchString(`^\d+$`, codigo)
-	return matched
+	_, err := ParseCodigoBoleto(codigo)
+	return err == nil
 }
 
-// ValidarLinhaDigitavel valida uma linha digitável
+// ValidarLinhaDigitavel valida uma linha digitável bancária (47 dígitos) ou de
+// arrecadação (48 dígitos), incluindo os dígitos verificadores
 func ValidarLinhaDigitavel(linha string) bool {
-	// Remove espaços e pontos
-	linha = regexp.MustCompile(`[\s\.]`).ReplaceAllString(linha, "")
+	normalizada := NormalizarCodigoBoleto(linha)
 
-	// Verifica se tem 47 dígitos
-	if len(linha) != 47 {
+	if len(normalizada) != 47 && len(normalizada) != 48 {
 		return false
 	}
 
-	// Verifica se contém apenas números
-	matched, _ := regexp.MatchString(`^\d{47}$`, linha)
-	return matched
+	_, err := ParseCodigoBoleto(normalizada)
+	return err == nil
 }
 
 // ValidarEmail valida um email